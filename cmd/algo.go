@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+var algoCmd = &cobra.Command{
+	Use:   "algo",
+	Short: "Execute large bitFlyer orders in child orders, so that they move the book less",
+	Long: `Execute large bitFlyer orders in child orders sent like 'bitflyer orders', through the risk
+checks of the profile and the journal. Use --paper to trade with the paper broker instead.
+The parent order and its first child order are shown to confirm before it starts, unless --yes is
+given. --dry-run runs the validation and the risk checks of the first child order without sending it.
+The progress is printed as child orders fill. Stop it with Ctrl+C: open child orders are cancelled,
+and the fills until then are reported.`,
+}
+
+// algoCommand returns the command executing an order with algorithm. flags adds the flags of the
+// algorithm to arg.
+func algoCommand(algorithm execution.Algorithm, short string, long string, example string, flags func(cmd *cobra.Command, arg *cli.ExecutionArgument)) *cobra.Command {
+	arg := cli.ExecutionArgument{}
+
+	cmd := &cobra.Command{
+		Use:     string(algorithm) + " [buy|sell] [size]",
+		Short:   short,
+		Long:    long,
+		Example: example,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch strings.ToLower(args[0]) {
+			case "buy", "sell":
+				arg.Buy = strings.ToLower(args[0]) == "buy"
+			default:
+				return fmt.Errorf("side must be buy or sell: %q", args[0])
+			}
+			size, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return fmt.Errorf("size must be a number: %q", args[1])
+			}
+			arg.Size = size
+
+			c := cli.NewExecutionCli(usecases.NewExecutionUseCase(bfClient))
+
+			if dryRun {
+				ctx, cancel := apiContext(cmd)
+				defer cancel()
+
+				res, err := c.DryRun(ctx, string(algorithm), arg)
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				fmt.Print(res)
+				return nil
+			}
+
+			if !assumeYes {
+				// The timeout of the preview does not include waiting for the answer.
+				ctx, cancel := apiContext(cmd)
+				summary, err := c.Preview(ctx, string(algorithm), arg)
+				cancel()
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				if !confirm(summary, "Start this order?") {
+					fmt.Println("the order was not sent.")
+					return nil
+				}
+			}
+
+			output, err := c.Run(cmd.Context(), string(algorithm), arg, os.Stdout)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "BTC_JPY", "product code to trade")
+	cmd.Flags().DurationVar(&arg.PollInterval, "poll", 2*time.Second, "interval of polling order fills and the board")
+	flags(cmd, &arg)
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+var twapCmd = algoCommand(execution.TWAP,
+	"Send equal market slices of an order over a duration",
+	`Split the size into --slices equal market orders, sent at regular intervals over --duration.
+The last slice takes the remainder of the rounding. With --price, a slice is carried over to the
+next one while the best quote is worse than it, and the order may end partly filled.`,
+	`  capital-go algo twap buy 0.5 --duration 1h --slices 12
+  capital-go algo twap sell 0.3 --duration 30m --slices 10 --price 4000000`,
+	func(cmd *cobra.Command, arg *cli.ExecutionArgument) {
+		cmd.Flags().DurationVar(&arg.Duration, "duration", time.Hour, "period over which the slices are sent")
+		cmd.Flags().IntVar(&arg.Slices, "slices", 10, "number of slices")
+		cmd.Flags().Float64Var(&arg.Price, "price", 0, "worst price of the best quote at which a slice is sent, 0 for no limit")
+	})
+
+var icebergCmd = algoCommand(execution.Iceberg,
+	"Rest a limit order showing only part of its size at a time",
+	`Rest a limit order of --display at --price, and the next one once it is filled, until the size is
+filled. Only --display of the size is shown on the board at a time.`,
+	`  capital-go algo iceberg buy 1 --price 3900000 --display 0.05`,
+	func(cmd *cobra.Command, arg *cli.ExecutionArgument) {
+		cmd.Flags().Float64Var(&arg.Price, "price", 0, "limit price of the child orders")
+		cmd.Flags().Float64Var(&arg.Display, "display", 0, "size of each child order")
+	})
+
+var chaseCmd = algoCommand(execution.Chase,
+	"Rest a limit order at the best price of its side and follow the market",
+	`Rest a limit order at the best bid for buys and the best ask for sells, so that it never takes
+liquidity, and re-price it when the best price moves, until it is filled. It stops when the best
+price moves more than --max-slippage percent away from the best price at the start.`,
+	`  capital-go algo chase buy 0.2 --max-slippage 0.5
+  capital-go algo chase sell 0.1 -c ETH_JPY --max-slippage 1 --poll 1s`,
+	func(cmd *cobra.Command, arg *cli.ExecutionArgument) {
+		cmd.Flags().Float64Var(&arg.MaxSlippage, "max-slippage", 0.5, "how far to follow the market, in percent of the best price at the start")
+	})
+
+func init() {
+	algoCmd.AddCommand(twapCmd)
+	algoCmd.AddCommand(icebergCmd)
+	algoCmd.AddCommand(chaseCmd)
+	rootCmd.AddCommand(algoCmd)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/backtest"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/strategy"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// strategiesHelp describes the built-in strategies and their default parameters.
+func strategiesHelp() string {
+	help := "Strategies:\n"
+	for _, name := range strategy.Names() {
+		description, defaults, _ := strategy.Describe(name)
+		keys := make([]string, 0, len(defaults))
+		for k := range defaults {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		params := []string{}
+		for _, k := range keys {
+			params = append(params, fmt.Sprintf("%s=%v", k, defaults[k]))
+		}
+		help += fmt.Sprintf("  %s: %s (%s)\n", name, description, strings.Join(params, ", "))
+	}
+	return help
+}
+
+var backtestCmd = func() *cobra.Command {
+	arg := cli.BacktestArgument{}
+	config := backtest.DefaultConfig()
+	var name string
+	var from string
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "backtest",
+		Short: "Replay downloaded executions through a strategy",
+		Long: `Replay executions downloaded by 'data fetch' through a strategy, and report its PnL,
+drawdown, Sharpe ratio, win rate and trade log.
+Market orders fill on the next trade with --slippage, and limit orders fill once a trade goes
+through their price.
+
+` + strategiesHelp(),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			arg.Strategy = name
+			arg.To = time.Now()
+			if to != "" {
+				t, err := parseTime(to)
+				if err != nil {
+					return err
+				}
+				arg.To = t
+			}
+			arg.From = arg.To.AddDate(0, 0, -7)
+			if from != "" {
+				t, err := parseTime(from)
+				if err != nil {
+					return err
+				}
+				arg.From = t
+			}
+
+			c := cli.NewBacktestCli(usecases.NewBacktestUseCase(dataStore()))
+			output, err := c.Run(cmd.Context(), arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "strategy", "", "strategy to run")
+	cmd.MarkFlagRequired("strategy")
+	cmd.Flags().StringArrayVar(&arg.Params, "param", nil, "strategy parameter as name=value, repeatable")
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "BTC_JPY", "product code of the downloaded executions")
+	cmd.Flags().StringVarP(&arg.Interval, "interval", "i", "1m", "candle interval passed to the strategy (1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d)")
+	cmd.Flags().StringVar(&from, "from", "", "start time, inclusive (default 7 days before --to)")
+	cmd.Flags().StringVar(&to, "to", "", "end time, exclusive (default now)")
+	cmd.Flags().Float64Var(&arg.Cash, "cash", config.Cash, "initial cash in the quote currency")
+	cmd.Flags().Float64Var(&arg.FeeRate, "fee-rate", config.FeeRate, "fee charged on the notional of each fill")
+	cmd.Flags().Float64Var(&arg.Slippage, "slippage", config.Slippage, "fraction market orders pay beyond the trade price")
+	cmd.Flags().StringVarP(&arg.Format, "format", "f", "text", "output format (text or json)")
+	cmd.Flags().StringVar(&dataDir, "dir", "", "data directory (default $CAPITAL_GO_DATA_DIR or ~/.capital-go/data)")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(backtestCmd())
+}
@@ -1,17 +1,14 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
-	"github.com/sn1w/capital-go/config"
-	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
 	"github.com/sn1w/capital-go/entities/interface/cli"
 	"github.com/sn1w/capital-go/entities/usecases"
-	cerror "github.com/sn1w/capital-go/error"
 	"github.com/spf13/cobra"
 )
 
@@ -20,18 +17,29 @@ var bitflyerCmd = &cobra.Command{
 	Short: "Actions related to bitflyer",
 }
 
-var bf = cli.NewBitFlyerCli(
-	usecases.NewBitFlyerUseCase(bitflyer.NewBitFlyer(config.NewConfig())),
-)
+// bf is built by newBitFlyerCli once global flags are parsed.
+var bf cli.BitFlyerCLI
+
+// bfClient is the client behind bf, a paper broker with --paper.
+var bfClient usecases.BitFlyerClient
+
+func newBitFlyerCli(client usecases.BitFlyerClient) cli.BitFlyerCLI {
+	return cli.NewBitFlyerCli(
+		usecases.NewBitFlyerUseCase(client),
+	)
+}
 
 var showMarkets = func() *cobra.Command {
 	return &cobra.Command{
 		Use:   "markets",
 		Short: "Show avaiable markets",
 		Run: func(cmd *cobra.Command, args []string) {
-			markets, err := bf.GetAvaiableMarkets()
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			markets, err := bf.GetAvaiableMarkets(ctx)
 			if err != nil {
-				fmt.Println(err.Error())
+				printError(err)
 				return
 			}
 			fmt.Println(markets)
@@ -40,6 +48,8 @@ var showMarkets = func() *cobra.Command {
 }
 
 var showBoards = func() *cobra.Command {
+	arg := cli.BoardArgument{}
+
 	cmd := cobra.Command{
 		Use:   "board [product_code]",
 		Short: "Show current board",
@@ -47,31 +57,45 @@ var showBoards = func() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			daemon, _ := cmd.Flags().GetBool("daemon")
 
-			boards, err := bf.GetBoard(args[0])
-			if err != nil {
-				fmt.Println(err.Error())
+			show := func() error {
+				ctx, cancel := apiContext(cmd)
+				defer cancel()
+
+				boards, err := bf.GetBoard(ctx, args[0], arg)
+				if err != nil {
+					return err
+				}
+				fmt.Println(boards)
+				return nil
+			}
+
+			if err := show(); err != nil {
+				printError(err)
 				return
 			}
-			fmt.Println(boards)
 
 			if daemon {
 				for {
-					time.Sleep(time.Second * 5)
+					select {
+					case <-cmd.Context().Done():
+						return
+					case <-time.After(time.Second * 5):
+					}
 					c := exec.Command("clear")
 					c.Stdout = os.Stdout
 					c.Run()
-					boards, err := bf.GetBoard(args[0])
-					if err != nil {
-						fmt.Println(err.Error())
+					if err := show(); err != nil {
+						if cmd.Context().Err() == nil {
+							printError(err)
+						}
 						return
 					}
-					fmt.Println(boards)
-
 				}
 			}
 		},
 	}
 	cmd.Flags().BoolP("daemon", "d", false, "Using auto reloading")
+	addBoardFlags(&cmd, &arg)
 	return &cmd
 }
 
@@ -80,14 +104,12 @@ var getBalance = func() *cobra.Command {
 		Use:   "balance",
 		Short: "Show current balance (required authorization)",
 		Run: func(cmd *cobra.Command, args []string) {
-			balance, err := bf.GetBalance()
-			if err != nil {
-				if errors.Is(err, cerror.ErrUnAuthorized) {
-					fmt.Println("authorization key is missing or invalid. please check your configuration.")
-					return
-				}
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
 
-				fmt.Println(err.Error())
+			balance, err := bf.GetBalance(ctx)
+			if err != nil {
+				printError(err)
 				return
 			}
 			fmt.Println(balance)
@@ -96,6 +118,7 @@ var getBalance = func() *cobra.Command {
 }
 var sendOrder = func() *cobra.Command {
 	var productCode string
+	var orderType string
 	var price float64
 	var size float64
 
@@ -105,20 +128,58 @@ var sendOrder = func() *cobra.Command {
 	}
 
 	runner := func(buy bool) func(*cobra.Command, []string) {
-		return func(*cobra.Command, []string) {
-			res, err := bf.CreateOrder(cli.CreateOrderArgument{
+		return func(cmd *cobra.Command, _ []string) {
+			var market bool
+			switch strings.ToLower(orderType) {
+			case "limit":
+			case "market":
+				market = true
+			default:
+				printError(fmt.Errorf("order type must be limit or market: %q", orderType))
+				return
+			}
+			arg := cli.CreateOrderArgument{
 				ProductCode: productCode,
 				Price:       price,
 				Size:        size,
 				Buy:         buy,
-			})
-			if err != nil {
-				if errors.Is(err, cerror.ErrUnAuthorized) {
-					fmt.Println("authorization key is missing or invalid. please check your configuration.")
+				Market:      market,
+			}
+
+			if dryRun {
+				ctx, cancel := apiContext(cmd)
+				defer cancel()
+
+				res, err := bf.DryRunOrder(ctx, arg)
+				if err != nil {
+					printError(err)
 					return
 				}
+				fmt.Print(res)
+				return
+			}
 
-				fmt.Println(err.Error())
+			if !assumeYes {
+				// The timeout of the order does not include waiting for the answer.
+				ctx, cancel := apiContext(cmd)
+				summary, err := bf.PreviewOrder(ctx, arg)
+				cancel()
+				if err != nil {
+					printError(err)
+					return
+				}
+				if !confirm(summary, "Send this order?") {
+					fmt.Println("the order was not sent.")
+					return
+				}
+			}
+
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			res, err := bf.CreateOrder(ctx, arg)
+			if err != nil {
+				printError(err)
 				return
 			}
 			fmt.Println(res)
@@ -141,11 +202,13 @@ var sendOrder = func() *cobra.Command {
 	cmd.AddCommand(&sell)
 
 	cmd.PersistentFlags().StringVarP(&productCode, "code", "c", "PRODUCT_CODE", "product code you want to order (required)")
-	cmd.PersistentFlags().Float64VarP(&price, "price", "p", 0, "order price (required)")
+	cmd.PersistentFlags().StringVar(&orderType, "type", "limit", "order type (limit or market)")
+	cmd.PersistentFlags().Float64VarP(&price, "price", "p", 0, "order price (required for limit orders)")
 	cmd.PersistentFlags().Float64VarP(&size, "size", "s", 0, "order size (required)")
 
+	addConfirmFlags(&cmd)
+
 	cmd.MarkPersistentFlagRequired("product_code")
-	cmd.MarkPersistentFlagRequired("price")
 	cmd.MarkPersistentFlagRequired("size")
 
 	cmd.Flags().SortFlags = false
@@ -153,9 +216,66 @@ var sendOrder = func() *cobra.Command {
 	return &cmd
 }
 
+var showCandles = func() *cobra.Command {
+	var interval string
+	var from string
+	var to string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "candles [product_code]",
+		Short: "Export OHLCV candles aggregated from executions",
+		Long: `Export OHLCV candles aggregated from the execution history.
+Candles are aligned to JST, and intervals without executions repeat the previous close.
+Long ranges need many requests; raise --timeout if needed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			arg := cli.CandlesArgument{
+				ProductCode: args[0],
+				Interval:    interval,
+				To:          time.Now(),
+				Format:      format,
+			}
+			if to != "" {
+				t, err := parseTime(to)
+				if err != nil {
+					return err
+				}
+				arg.To = t
+			}
+			arg.From = arg.To.Add(-24 * time.Hour)
+			if from != "" {
+				t, err := parseTime(from)
+				if err != nil {
+					return err
+				}
+				arg.From = t
+			}
+
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			output, err := bf.GetCandles(ctx, arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&interval, "interval", "i", "1m", "candle interval (1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d)")
+	cmd.Flags().StringVar(&from, "from", "", "start time, inclusive (default 24 hours before --to)")
+	cmd.Flags().StringVar(&to, "to", "", "end time, exclusive (default now)")
+	cmd.Flags().StringVarP(&format, "format", "f", "csv", "output format (csv or json)")
+
+	return cmd
+}
+
 func init() {
 	commands := []*cobra.Command{
-		showMarkets(), showBoards(), getBalance(), sendOrder(),
+		showMarkets(), showBoards(), getBalance(), sendOrder(), showCandles(),
 	}
 	for _, v := range commands {
 		bitflyerCmd.AddCommand(v)
@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/spf13/cobra"
+)
+
+// addBoardFlags adds the flags shaping the output of the board commands.
+func addBoardFlags(cmd *cobra.Command, arg *cli.BoardArgument) {
+	cmd.Flags().IntVar(&arg.Depth, "depth", 10, "number of price levels per side (0 shows all)")
+	cmd.Flags().Float64Var(&arg.Tick, "tick", 0, "group prices into buckets of this width, such as 1000")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sn1w/capital-go/internal/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// assumeYes skips the confirmation of commands sending or cancelling orders.
+	assumeYes bool
+	// dryRun checks orders and prints their requests instead of sending them.
+	dryRun bool
+)
+
+// addConfirmFlags adds --yes and --dry-run to a command sending or cancelling orders.
+func addConfirmFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "send without confirmation (for scripts)")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "run validation and risk checks and print the request instead of sending it")
+}
+
+// confirm prints summary and asks whether to go on, unless --yes is given.
+// A closed stdin answers no.
+func confirm(summary string, question string) bool {
+	if assumeYes {
+		return true
+	}
+	fmt.Print(summary)
+	return print.Question(question)
+}
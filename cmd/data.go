@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/history"
+	"github.com/sn1w/capital-go/internal/print"
+	"github.com/spf13/cobra"
+)
+
+var dataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "Download and check historical market data",
+}
+
+// dataDir is the directory of downloaded market data.
+var dataDir string
+
+// dataStore returns the store of downloaded market data.
+func dataStore() *history.Store {
+	if dataDir != "" {
+		return history.NewStore(dataDir)
+	}
+	if dir := config.NewConfig().DataDir; dir != "" {
+		return history.NewStore(dir)
+	}
+	return history.NewStore(history.DefaultDir())
+}
+
+var fetchData = func() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "fetch [product_code]",
+		Short: "Download the bitFlyer execution history",
+		Long: `Download the bitFlyer execution history back to --from.
+Executions are stored compressed per JST date. Interrupted downloads resume on the next run,
+which also downloads executions newer than the stored ones.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			since := time.Now().AddDate(0, 0, -7)
+			if from != "" {
+				t, err := parseTime(from)
+				if err != nil {
+					return err
+				}
+				since = t
+			}
+
+			store := dataStore()
+			fetcher := history.NewFetcher(bitflyer.NewBitFlyer(config.NewConfig()), store)
+			fetched := 0
+			fetcher.OnPage = func(p *history.Progress, n int) {
+				fetched += n
+				if !p.Oldest.IsZero() {
+					fmt.Fprintf(print.Stderr, "\rfetched %d executions, oldest %s", fetched, p.Oldest.In(candle.JST).Format(time.RFC3339))
+				}
+			}
+
+			p, err := fetcher.Fetch(cmd.Context(), args[0], since)
+			fmt.Fprintln(print.Stderr)
+			if err != nil {
+				if errors.Is(err, cmd.Context().Err()) {
+					print.Info("interrupted. run the command again to resume.")
+					return nil
+				}
+				printError(err)
+				return nil
+			}
+
+			problems, err := store.Verify(args[0])
+			if err != nil {
+				return err
+			}
+			if len(problems) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("stored history has problems:\n%s", strings.Join(problems, "\n"))
+			}
+			print.Info(fmt.Sprintf("%s is stored without gaps since %s", args[0], p.Oldest.In(candle.JST).Format(time.RFC3339)))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "download executions back to this time (default 7 days ago)")
+
+	return cmd
+}
+
+var verifyData = func() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify [product_code]",
+		Short: "Check the downloaded execution history for gaps",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store := dataStore()
+			problems, err := store.Verify(args[0])
+			if err != nil {
+				return err
+			}
+			if len(problems) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("stored history has problems:\n%s", strings.Join(problems, "\n"))
+			}
+
+			p, err := store.Progress(args[0])
+			if err != nil {
+				return err
+			}
+			print.Info(fmt.Sprintf("%s has no gaps from id %d to %d (since %s)",
+				args[0], p.Ranges[0].From, p.Newest(), p.Oldest.In(candle.JST).Format(time.RFC3339)))
+			return nil
+		},
+	}
+}
+
+func init() {
+	dataCmd.PersistentFlags().StringVar(&dataDir, "dir", "", "data directory (default $CAPITAL_GO_DATA_DIR or ~/.capital-go/data)")
+	dataCmd.AddCommand(fetchData(), verifyData())
+	rootCmd.AddCommand(dataCmd)
+}
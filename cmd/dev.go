@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer/bitflyertest"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/kabucomtest"
+	"github.com/sn1w/capital-go/internal/print"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Tools for development and testing",
+}
+
+var fakeBitFlyer = func() *cobra.Command {
+	var addr string
+	var scenarioPath string
+	cfg := bitflyertest.DefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:   "fake-bitflyer",
+		Short: "Serve a local fake bitFlyer API for offline testing",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if scenarioPath != "" {
+				scenario, err := bitflyertest.LoadScenario(scenarioPath)
+				if err != nil {
+					return err
+				}
+				scenario.Apply(&cfg)
+			}
+
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("can not listen %s: %w", addr, err)
+			}
+
+			print.Info("fake bitFlyer is listening. use it with these variables:")
+			fmt.Printf("BITFLYER_API_ENDPOINT=http://%s\n", listener.Addr())
+			fmt.Printf("BITFLYER_REALTIME_ENDPOINT=ws://%s%s\n", listener.Addr(), bitflyertest.RealtimePath)
+			fmt.Printf("BITFLYER_API_KEY=%s\n", cfg.APIKey)
+			fmt.Printf("BITFLYER_API_SECRET=%s\n", cfg.APISecret)
+
+			return serve(cmd.Context(), listener, bitflyertest.NewServer(cfg))
+		},
+	}
+
+	cmd.Flags().StringVarP(&addr, "addr", "a", "127.0.0.1:8080", "address to listen")
+	cmd.Flags().StringVar(&scenarioPath, "scenario", "", "scenario file (JSON) with boards, balances and rules")
+	cmd.Flags().StringVar(&cfg.APIKey, "api-key", cfg.APIKey, "accepted ACCESS-KEY")
+	cmd.Flags().StringVar(&cfg.APISecret, "api-secret", cfg.APISecret, "secret used to verify ACCESS-SIGN")
+	cmd.Flags().Float64Var(&cfg.FeeRate, "fee-rate", 0, "fee rate charged on each fill")
+
+	return cmd
+}
+
+var fakeKabucom = func() *cobra.Command {
+	var addr string
+	cfg := kabucomtest.DefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:   "fake-kabucom",
+		Short: "Serve a local fake kabu STATION API for offline testing",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("can not listen %s: %w", addr, err)
+			}
+
+			print.Info("fake kabu STATION is listening. use it with these variables:")
+			fmt.Printf("KABUCOM_API_HOST=http://%s%s\n", listener.Addr(), kabucomtest.BasePath)
+			print.Info(fmt.Sprintf("API password: %s, order password: %s", cfg.APIPassword, cfg.OrderPassword))
+
+			return serve(cmd.Context(), listener, kabucomtest.NewServer(cfg))
+		},
+	}
+
+	cmd.Flags().StringVarP(&addr, "addr", "a", "127.0.0.1:18080", "address to listen")
+	cmd.Flags().StringVar(&cfg.APIPassword, "api-password", cfg.APIPassword, "accepted API password of POST /token")
+	cmd.Flags().StringVar(&cfg.OrderPassword, "order-password", cfg.OrderPassword, "accepted trading password")
+	cmd.Flags().Float64Var(&cfg.Cash, "cash", cfg.Cash, "initial stock account wallet in JPY")
+
+	return cmd
+}
+
+// serve runs handler on listener until ctx is cancelled, then shuts it down gracefully.
+func serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{Handler: handler}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func init() {
+	devCmd.AddCommand(fakeBitFlyer())
+	devCmd.AddCommand(fakeKabucom())
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// jn is built by newJournalCli once global flags are parsed.
+var jn cli.JournalCLI
+
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Record orders, fills and balances in a local journal",
+	Long: `Record orders, fills, transfers and balances in a local journal, kept after the brokers drop them.
+Every order sent by capital-go is recorded when it is accepted. 'journal sync' pulls the fills
+and snapshots the balances. With --paper, the paper broker is journaled as "paper".`,
+}
+
+// journalStore returns the store of the journal.
+func journalStore(cfg config.Config) *journal.FileStore {
+	path := cfg.JournalFile
+	if path == "" {
+		path = journal.DefaultPath()
+	}
+	return journal.NewFileStore(path)
+}
+
+func newJournalCli(cfg config.Config, broker string, kabucom usecases.JournalKabucom, kabucomBroker string) cli.JournalCLI {
+	return cli.NewJournalCli(usecases.NewJournalUseCase(journalStore(cfg), bfClient, broker, kabucom, kabucomBroker))
+}
+
+var syncJournal = func() *cobra.Command {
+	arg := cli.JournalSyncArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Pull fills and transfers, and snapshot balances into the journal",
+		Long: `Pull the latest fills of the bitFlyer products of --code and of the journaled orders, the completed
+crypto deposits and withdrawals of bitFlyer, and the fills of the day of kabu STATION with --token,
+then snapshot the balances of the accounts. Fills and transfers already in the journal are skipped.
+bitFlyer returns the latest 100 fills per product and transfers: sync regularly to keep every one.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if arg.KabucomToken == "" {
+				arg.KabucomToken = config.NewConfig().KabucomAPIToken
+			}
+
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			output, err := jn.Sync(ctx, arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&arg.ProductCodes, "code", "c", []string{"BTC_JPY"}, "bitFlyer product code to pull fills of, repeatable")
+	cmd.Flags().StringVarP(&arg.KabucomToken, "token", "t", "", "kabu STATION token issued by 'kabucom authorize'; the account is skipped without it (default $KABUCOM_API_TOKEN)")
+
+	return cmd
+}
+
+// addJournalFilterFlags adds the flags selecting records.
+func addJournalFilterFlags(cmd *cobra.Command, arg *cli.JournalListArgument, from *string, to *string) {
+	cmd.Flags().StringVarP(&arg.Kind, "kind", "k", arg.Kind, "records to show ("+strings.Join(cli.JournalKinds, ", ")+")")
+	cmd.Flags().StringVarP(&arg.Broker, "broker", "b", "", "broker of the records (bitflyer, kabucom or paper)")
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "", "product code of the records")
+	cmd.Flags().StringVar(from, "from", "", "start time, inclusive")
+	cmd.Flags().StringVar(to, "to", "", "end time, exclusive")
+}
+
+// parsePeriod parses --from and --to into arg.
+func parsePeriod(arg *cli.JournalListArgument, from string, to string) error {
+	if from != "" {
+		t, err := parseTime(from)
+		if err != nil {
+			return err
+		}
+		arg.From = t
+	}
+	if to != "" {
+		t, err := parseTime(to)
+		if err != nil {
+			return err
+		}
+		arg.To = t
+	}
+	return nil
+}
+
+var listJournal = func() *cobra.Command {
+	arg := cli.JournalListArgument{Kind: "orders"}
+	var from, to string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List orders, fills, transfers or balances of the journal",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := parsePeriod(&arg, from, to); err != nil {
+				return err
+			}
+
+			output, err := jn.List(arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	addJournalFilterFlags(cmd, &arg, &from, &to)
+
+	return cmd
+}
+
+var showJournal = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show a journaled order and its fills",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("id must be a number: %q", args[0])
+		}
+
+		output, err := jn.Show(id)
+		if err != nil {
+			printError(err)
+			return nil
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+var exportJournal = func() *cobra.Command {
+	arg := cli.JournalExportArgument{JournalListArgument: cli.JournalListArgument{Kind: "fills"}}
+	var from, to, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export orders, fills, transfers or balances of the journal as CSV or JSON",
+		Long: `Export orders, fills, transfers or balances of the journal as CSV or JSON. The CSV of fills and
+transfers can be imported again with 'journal import --format generic'.
+--format cryptact and gtax write the fills and transfers of the live bitFlyer account in the
+formats of the tax tools Cryptact and Gtax, whatever --kind is.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := parsePeriod(&arg.JournalListArgument, from, to); err != nil {
+				return err
+			}
+
+			res, err := jn.Export(arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			if output == "" {
+				fmt.Print(res)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(res), 0664); err != nil {
+				return fmt.Errorf("can not write %s: %w", output, err)
+			}
+			return nil
+		},
+	}
+
+	addJournalFilterFlags(cmd, &arg.JournalListArgument, &from, &to)
+	cmd.Flags().StringVarP(&arg.Format, "format", "f", "csv", "output format ("+strings.Join(cli.JournalExportFormats, ", ")+")")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default stdout)")
+
+	return cmd
+}
+
+var importJournal = func() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "import [files...]",
+		Short: "Import fills and transfers from CSV files into the journal",
+		Long: `Import fills and transfers from CSV files, such as the history of the years before the journal.
+  generic:  the CSV of 'journal export --kind fills' or '--kind transfers'
+  bitflyer: the trade history (取引履歴) downloaded from bitFlyer
+  kabucom:  the execution history (約定履歴) downloaded from au kabucom securities
+Files must be UTF-8: convert Shift_JIS downloads first, for example with 'iconv -f SHIFT_JIS -t UTF-8'.
+Records already in the journal, imported or pulled by 'journal sync', are skipped.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range args {
+				f, err := os.Open(name)
+				if err != nil {
+					return fmt.Errorf("can not read %s: %w", name, err)
+				}
+				output, err := jn.Import(format, name, f)
+				f.Close()
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				fmt.Print(output)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "generic", "format of the files ("+strings.Join(cli.JournalImportFormats, ", ")+")")
+
+	return cmd
+}
+
+func init() {
+	journalCmd.AddCommand(syncJournal())
+	journalCmd.AddCommand(listJournal())
+	journalCmd.AddCommand(showJournal)
+	journalCmd.AddCommand(exportJournal())
+	journalCmd.AddCommand(importJournal())
+	rootCmd.AddCommand(journalCmd)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/sn1w/capital-go/config"
-	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
 	"github.com/sn1w/capital-go/entities/interface/cli"
 	"github.com/sn1w/capital-go/entities/usecases"
 	"github.com/spf13/cobra"
@@ -15,11 +14,14 @@ var kabucomCmd = &cobra.Command{
 	Short: "Actions related to kabucom",
 }
 
-var kb = cli.NewKabucomCli(
-	usecases.NewKabucomUseCase(
-		kabucom.NewKabucomClient(config.NewConfig()),
-	),
-)
+// kb is built by newKabucomCli once global flags are parsed.
+var kb cli.KabucomCLI
+
+func newKabucomCli(client usecases.KabucomClient) cli.KabucomCLI {
+	return cli.NewKabucomCli(
+		usecases.NewKabucomUseCase(client),
+	)
+}
 
 var doAuth = func() *cobra.Command {
 	var pwd string
@@ -27,9 +29,12 @@ var doAuth = func() *cobra.Command {
 		Use:   "authorize",
 		Short: "Auth kabucom service",
 		Run: func(cmd *cobra.Command, args []string) {
-			output, err := kb.Authorization(pwd)
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			output, err := kb.Authorization(ctx, pwd)
 			if err != nil {
-				fmt.Println(err.Error())
+				printError(err)
 				return
 			}
 			fmt.Println(output)
@@ -44,9 +49,43 @@ var doAuth = func() *cobra.Command {
 	return cmd
 }
 
+var showKabucomBoard = func() *cobra.Command {
+	var token string
+	arg := cli.BoardArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "board [symbol@exchange]",
+		Short: "Show current board (required token)",
+		Long: `Show the board of a symbol such as 9433@1 (exchange 1 is the Tokyo Stock Exchange).
+The token is the one printed by 'kabucom authorize', given by --token or KABUCOM_API_TOKEN.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if token == "" {
+				token = config.NewConfig().KabucomAPIToken
+			}
+
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			output, err := kb.GetBoard(ctx, token, args[0], arg)
+			if err != nil {
+				printError(err)
+				return
+			}
+			fmt.Println(output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&token, "token", "t", "", "API token issued by 'kabucom authorize' (default $KABUCOM_API_TOKEN)")
+	addBoardFlags(cmd, &arg)
+
+	return cmd
+}
+
 func init() {
 	subCommands := []*cobra.Command{
 		doAuth(),
+		showKabucomBoard(),
 	}
 
 	for _, v := range subCommands {
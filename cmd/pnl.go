@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/pnl"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// pl is built by newPnLCli once global flags are parsed.
+var pl cli.PnLCLI
+
+func newPnLCli(cfg config.Config, broker string, kabucom usecases.PnLKabucom, kabucomBroker string) cli.PnLCLI {
+	return cli.NewPnLCli(usecases.NewPnLUseCase(journalStore(cfg), bfClient, broker, kabucom, kabucomBroker))
+}
+
+var pnlCmd = func() *cobra.Command {
+	arg := cli.PnLArgument{}
+	var from, to string
+
+	methods := []string{}
+	for _, v := range pnl.Methods {
+		methods = append(methods, string(v))
+	}
+
+	cmd := &cobra.Command{
+		Use:   "pnl",
+		Short: "Show realized and unrealized profit and loss of the journaled fills",
+		Long: `Show the realized profit and loss of the journaled fills by product and by day, and the unrealized
+profit and loss of the open positions at current quotes. Run 'journal sync' first to pull the fills.
+Fills before --from still make the cost of the positions, and positions are marked at current quotes
+even when --to is in the past. kabu STATION positions are marked only with --token.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if from == "" {
+				now := time.Now().In(candle.JST)
+				arg.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, candle.JST)
+			} else {
+				t, err := parseTime(from)
+				if err != nil {
+					return err
+				}
+				arg.From = t
+			}
+			if to != "" {
+				t, err := parseTime(to)
+				if err != nil {
+					return err
+				}
+				arg.To = t
+			}
+			if arg.KabucomToken == "" {
+				arg.KabucomToken = config.NewConfig().KabucomAPIToken
+			}
+
+			ctx, cancel := apiContext(cmd)
+			defer cancel()
+
+			output, err := pl.Report(ctx, arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "start time, inclusive (default the first day of this month)")
+	cmd.Flags().StringVar(&to, "to", "", "end time, exclusive (default now)")
+	cmd.Flags().StringVarP(&arg.Method, "method", "m", string(pnl.FIFO), "cost method ("+strings.Join(methods, ", ")+")")
+	cmd.Flags().StringVarP(&arg.Broker, "broker", "b", "", "broker of the fills (bitflyer, kabucom or paper)")
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "", "product code of the fills")
+	cmd.Flags().StringVarP(&arg.KabucomToken, "token", "t", "", "kabu STATION token issued by 'kabucom authorize' (default $KABUCOM_API_TOKEN)")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(pnlCmd())
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// rb is built by newRebalanceCli once global flags are parsed.
+var rb cli.RebalanceCLI
+
+func newRebalanceCli(kabucom usecases.RebalanceKabucom) cli.RebalanceCLI {
+	return cli.NewRebalanceCli(usecases.NewRebalanceUseCase(bfClient, kabucom))
+}
+
+var rebalanceCmd = func() *cobra.Command {
+	arg := cli.RebalanceArgument{}
+	var execute bool
+
+	cmd := &cobra.Command{
+		Use:   "rebalance",
+		Short: "Plan the orders bringing the holdings to a target allocation",
+		Long: `Read the holdings of the assets of --target from bitFlyer and kabu STATION, and plan the smallest
+orders bringing each of them within the tolerance of its target weight, in lots of the brokers.
+The target is a YAML file of weights by asset (a symbol such as 1306@1, a currency such as BTC,
+or JPY for cash), with an optional tolerance (default 0.05) and lots overriding the lot sizes.
+With --execute, the orders are sent as market orders after confirmation, sells first, through
+the risk checks of the profile and the journal. kabu STATION orders need KABUCOM_ORDER_PASSWORD.
+They are not sent when a broker lacks the cash of its buys after its sells.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.NewConfig()
+			if arg.KabucomToken == "" {
+				arg.KabucomToken = cfg.KabucomAPIToken
+			}
+			arg.KabucomOrderPassword = cfg.KabucomOrderPassword
+
+			ctx, cancel := apiContext(cmd)
+			output, plan, err := rb.Plan(ctx, arg)
+			cancel()
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			orders := plan.Orders
+			if !execute || len(orders) == 0 {
+				return nil
+			}
+			// The buys would fail once the cash runs out, after the sells are done. The plan shows
+			// the cash needed.
+			if plan.Unfunded != nil {
+				fmt.Println("the orders can not be sent.")
+				return nil
+			}
+
+			if dryRun {
+				fmt.Println()
+				for _, v := range orders {
+					ctx, cancel := apiContext(cmd)
+					res, err := rb.DryRunOrder(ctx, arg, v)
+					cancel()
+					if err != nil {
+						printError(err)
+						return nil
+					}
+					fmt.Print(res)
+				}
+				return nil
+			}
+			if !confirm("", fmt.Sprintf("Send the %d orders at market?", len(orders))) {
+				fmt.Println("the orders were not sent.")
+				return nil
+			}
+			for _, v := range orders {
+				ctx, cancel := apiContext(cmd)
+				res, err := rb.SendOrder(ctx, arg, v)
+				cancel()
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				fmt.Print(res)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&arg.TargetFile, "target", "", "YAML file of the target allocation (required)")
+	cmd.Flags().StringVarP(&arg.KabucomToken, "token", "t", "", "kabu STATION token issued by 'kabucom authorize'; required for stocks (default $KABUCOM_API_TOKEN)")
+	cmd.Flags().BoolVar(&execute, "execute", false, "send the orders of the plan")
+	cmd.MarkFlagRequired("target")
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(rebalanceCmd())
+}
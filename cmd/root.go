@@ -2,32 +2,263 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
-
+	"syscall"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/risk"
+	"github.com/sn1w/capital-go/entities/usecases"
+	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/cassette"
+	"github.com/sn1w/capital-go/internal/logging"
 	"github.com/sn1w/capital-go/internal/print"
+	"github.com/sn1w/capital-go/internal/transport"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use: "capital-go",
+	Use:               "capital-go",
+	PersistentPreRunE: setup,
+}
+
+var (
+	// timeout is the deadline applied to each API call. Zero disables it.
+	timeout time.Duration
+	// verbose enables debug logs such as HTTP requests and responses.
+	verbose bool
+	// logFormat is the format of logs written to stderr.
+	logFormat string
+	// paperTrading replaces brokers with simulated ones.
+	paperTrading bool
+	// paperBoards is a file of recorded boards used by paper trading instead of live boards.
+	paperBoards string
+	// recordDir is a directory to record API traffic into.
+	recordDir string
+	// replayDir is a directory of recorded API traffic served instead of the APIs.
+	replayDir string
+	// riskProfile is the profile of risk limits checked before orders are sent.
+	riskProfile string
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each API call (0 disables it)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "print debug logs including HTTP requests and responses")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format (text or json)")
+	rootCmd.PersistentFlags().BoolVar(&paperTrading, "paper", false, "simulate orders and balances without sending them to brokers")
+	rootCmd.PersistentFlags().StringVar(&paperBoards, "paper-boards", "", "recorded boards file used by --paper instead of live boards")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "record API requests and responses (secrets redacted) into the directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "serve API responses recorded by --record from the directory")
+	rootCmd.PersistentFlags().StringVar(&riskProfile, "profile", "", "risk profile checked before orders are sent (default \"default\", or $CAPITAL_GO_PROFILE)")
+}
+
+// setup applies global flags and builds API clients. It runs before every command.
+func setup(cmd *cobra.Command, args []string) error {
+	format, err := logging.ParseFormat(logFormat)
+	if err != nil {
+		return err
+	}
+
+	level := logging.LevelWarn
+	if verbose {
+		level = logging.LevelDebug
+	}
+	logging.SetDefault(logging.New(print.Stderr, level, format))
+	transport.DefaultUserAgent = Name + "/" + version()
+	if err := setupCassette(); err != nil {
+		return err
+	}
+
+	cfg := config.NewConfig()
+	if paperTrading {
+		return setupPaperClients(cfg)
+	}
+
+	guard, err := newRiskGuard(cfg, bitflyer.NewBitFlyer(cfg), journal.BitFlyer)
+	if err != nil {
+		return err
+	}
+	kbClient := kabucom.NewKabucomClient(cfg)
+	kbGuard, err := newKabucomRiskGuard(cfg, kbClient, journal.Kabucom)
+	if err != nil {
+		return err
+	}
+	kbOrders := usecases.NewKabucomOrderJournal(kbGuard, journalStore(cfg), journal.Kabucom)
+	bfClient = usecases.NewOrderJournal(guard, journalStore(cfg), journal.BitFlyer)
+	bf = newBitFlyerCli(bfClient)
+	kb = newKabucomCli(kbClient)
+	jn = newJournalCli(cfg, journal.BitFlyer, kbClient, journal.Kabucom)
+	pl = newPnLCli(cfg, journal.BitFlyer, kbClient, journal.Kabucom)
+	tx = newTaxCli(cfg)
+	rb = newRebalanceCli(kbOrders)
+	sc = newScheduleCli(cfg, kbOrders)
+
+	return nil
+}
+
+// setupCassette replaces the transport of API clients to record or replay traffic.
+func setupCassette() error {
+	switch {
+	case recordDir != "" && replayDir != "":
+		return errors.New("--record and --replay can not be used together")
+	case recordDir != "":
+		transport.DefaultTransport = cassette.NewRecorder(filepath.Join(recordDir, cassette.FileName), http.DefaultTransport)
+	case replayDir != "":
+		c, err := cassette.Load(filepath.Join(replayDir, cassette.FileName))
+		if err != nil {
+			return err
+		}
+		transport.DefaultTransport = cassette.NewReplayer(c)
+	}
+	return nil
+}
+
+// setupPaperClients builds simulated brokers keeping their accounts in local state files.
+func setupPaperClients(cfg config.Config) error {
+	var market paper.MarketData = bitflyer.NewBitFlyer(cfg)
+	if paperBoards != "" {
+		boards, err := paper.LoadRecordedBoards(paperBoards)
+		if err != nil {
+			return err
+		}
+		market = boards
+	}
+
+	path := cfg.PaperStatePath
+	if path == "" {
+		path = paper.DefaultStatePath()
+	}
+
+	guard, err := newRiskGuard(cfg, paper.NewBitFlyer(market, paper.NewFileStore(path)), journal.Paper)
+	if err != nil {
+		return err
+	}
+	kbPath := cfg.PaperKabucomStatePath
+	if kbPath == "" {
+		kbPath = paper.DefaultKabucomStatePath()
+	}
+	kbClient := paper.NewKabucom(kabucom.NewKabucomClient(cfg), paper.NewFileStore(kbPath))
+	kbGuard, err := newKabucomRiskGuard(cfg, kbClient, journal.PaperKabucom)
+	if err != nil {
+		return err
+	}
+	kbOrders := usecases.NewKabucomOrderJournal(kbGuard, journalStore(cfg), journal.PaperKabucom)
+	bfClient = usecases.NewOrderJournal(guard, journalStore(cfg), journal.Paper)
+	bf = newBitFlyerCli(bfClient)
+	kb = newKabucomCli(kbClient)
+	jn = newJournalCli(cfg, journal.Paper, kbClient, journal.PaperKabucom)
+	pl = newPnLCli(cfg, journal.Paper, kbClient, journal.PaperKabucom)
+	tx = newTaxCli(cfg)
+	rb = newRebalanceCli(kbOrders)
+	sc = newScheduleCli(cfg, kbOrders)
+
+	return nil
+}
+
+// riskLimits returns the limits of the selected risk profile.
+func riskLimits(cfg config.Config) (risk.Limits, error) {
+	path := cfg.RiskFile
+	if path == "" {
+		path = risk.DefaultPath()
+	}
+	if riskProfile == "" {
+		riskProfile = cfg.RiskProfile
+	}
+	if riskProfile == "" {
+		riskProfile = risk.DefaultProfile
+	}
+
+	profiles, err := risk.LoadProfiles(path)
+	if err != nil {
+		return risk.Limits{}, err
+	}
+	return profiles.Profile(riskProfile)
+}
+
+// newRiskGuard wraps client so that its orders are checked against the selected risk profile.
+func newRiskGuard(cfg config.Config, client usecases.BitFlyerClient, account string) (*usecases.RiskGuard, error) {
+	limits, err := riskLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return usecases.NewRiskGuard(client, limits, risk.NewFileState(risk.DefaultStatePath()), account), nil
+}
+
+// newKabucomRiskGuard wraps client so that its orders are checked against the selected risk profile.
+func newKabucomRiskGuard(cfg config.Config, client usecases.KabucomOrderClient, account string) (*usecases.KabucomRiskGuard, error) {
+	limits, err := riskLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return usecases.NewKabucomRiskGuard(client, limits, risk.NewFileState(risk.DefaultStatePath()), account), nil
 }
 
 // Execute start command.
+// The command context is cancelled on SIGINT/SIGTERM so that running requests and watchers stop.
 func Execute() {
 	cobra.EnableCommandSorting = false
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.SilenceErrors = true
 	deployShellCompletionFileIfNeeded(rootCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		print.Err(err)
+		stop()
 		os.Exit(1)
 	}
 }
 
+// apiContext returns a context for a single API call, bounded by the --timeout flag.
+func apiContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(cmd.Context())
+	}
+	return context.WithTimeout(cmd.Context(), timeout)
+}
+
+// printError prints err with a hint for well-known causes.
+func printError(err error) {
+	fmt.Println(errorMessage(err))
+}
+
+// errorMessage returns err with a hint for well-known causes, on one or two lines.
+func errorMessage(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted."
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("request timed out (--timeout %s).", timeout)
+	case errors.Is(err, cerror.ErrUnAuthorized):
+		return "authorization key is missing or invalid. please check your configuration."
+	case errors.Is(err, cerror.ErrInsufficientFunds):
+		return "insufficient funds for this order. please check your balance.\n" + err.Error()
+	case errors.Is(err, cerror.ErrMarketHalted):
+		return "the market is not accepting orders now. please try again later.\n" + err.Error()
+	case errors.Is(err, cerror.ErrOrderNotFound):
+		return "the order was not found. it may already be filled or cancelled."
+	case errors.Is(err, cerror.ErrRiskRejected):
+		return fmt.Sprintf("the order was rejected by the risk profile %q. it was not sent.\n", riskProfile) + err.Error()
+	case errors.Is(err, cerror.ErrRateLimited):
+		return "API request limit exceeded. please wait a while and try again."
+	}
+	return err.Error()
+}
+
 func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
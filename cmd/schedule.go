@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/schedule"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// sc is built by newScheduleCli once global flags are parsed.
+var sc cli.ScheduleCLI
+
+// newScheduleCli returns the schedule CLI sending bitFlyer orders through the risk checks and the
+// journal of bfClient, and kabu STATION orders through those of kabucom.
+func newScheduleCli(cfg config.Config, kabucom usecases.ScheduleKabucom) cli.ScheduleCLI {
+	path := cfg.ScheduleFile
+	if path == "" {
+		path = schedule.DefaultPath()
+	}
+	return cli.NewScheduleCli(usecases.NewScheduleUseCase(bfClient, kabucom, schedule.NewFileStore(path)))
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Send recurring orders, such as buying a fixed JPY amount every week",
+}
+
+var addSchedule = func() *cobra.Command {
+	arg := cli.ScheduleAddArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "add [buy|sell] [cron]",
+		Short: "Add a recurring order",
+		Long: `Add a recurring order sent by 'schedule run' at each time of the cron expression, in JST.
+The expression has 5 fields (minute hour day month weekday) taking *, values, ranges, lists and
+steps, or a shorthand such as @daily. Each order is --amount JPY, sized at the price of the order
+and rounded down to the lot size, or a fixed --size.
+
+Order types:
+  market         market orders
+  limit-at-best  limit orders at the best bid for buys and the best ask for sells
+
+Products are bitFlyer products such as BTC_JPY, or kabu STATION symbols such as 1306@1 (cash
+orders of the specific account). Orders of symbols wait for the next session of the Tokyo Stock
+Exchange when it is closed, such as on holidays.
+
+The order the schedule would send at the current prices is shown and checked against the risk
+profile before it is added. kabu STATION symbols need KABUCOM_API_TOKEN (or -t) and
+KABUCOM_ORDER_PASSWORD for it.`,
+		Example: `  capital-go schedule add buy "0 9 * * 1" -c BTC_JPY --amount 10000
+  capital-go schedule add buy "0 10 1 * *" -c 1306@1 --amount 50000 --type limit-at-best
+  capital-go schedule add sell @daily -c ETH_JPY --size 0.01 --note "take profit"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch strings.ToLower(args[0]) {
+			case "buy", "sell":
+				arg.Side = strings.ToUpper(args[0])
+			default:
+				return fmt.Errorf("side must be buy or sell: %q", args[0])
+			}
+			arg.Cron = args[1]
+			cfg := config.NewConfig()
+			if arg.KabucomToken == "" {
+				arg.KabucomToken = cfg.KabucomAPIToken
+			}
+			arg.KabucomOrderPassword = cfg.KabucomOrderPassword
+
+			if dryRun {
+				ctx, cancel := apiContext(cmd)
+				defer cancel()
+
+				res, err := sc.DryRunAdd(ctx, arg)
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				fmt.Print(res)
+				return nil
+			}
+
+			if !assumeYes {
+				ctx, cancel := apiContext(cmd)
+				summary, err := sc.Preview(ctx, arg)
+				cancel()
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				if !confirm(summary, "Add this recurring order?") {
+					fmt.Println("the schedule was not added.")
+					return nil
+				}
+			}
+
+			output, err := sc.Add(arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "BTC_JPY", "bitFlyer product code, or kabu STATION symbol such as 1306@1")
+	cmd.Flags().Float64Var(&arg.Amount, "amount", 0, "JPY of each order")
+	cmd.Flags().Float64Var(&arg.Size, "size", 0, "fixed size of each order, instead of --amount")
+	cmd.Flags().StringVar(&arg.OrderType, "type", string(schedule.Market), "order type (market or limit-at-best)")
+	cmd.Flags().StringVar(&arg.Note, "note", "", "note shown in the list")
+	cmd.Flags().StringVarP(&arg.KabucomToken, "token", "t", "", "kabu STATION API token (default $KABUCOM_API_TOKEN)")
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+var listSchedules = &cobra.Command{
+	Use:   "list",
+	Short: "List recurring orders with their last and next runs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := sc.List()
+		if err != nil {
+			printError(err)
+			return nil
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+var removeSchedule = &cobra.Command{
+	Use:   "remove [id]",
+	Short: "Remove a recurring order",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("id must be a number: %q", args[0])
+		}
+
+		output, err := sc.Remove(id)
+		if err != nil {
+			printError(err)
+			return nil
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+var scheduleHistory = &cobra.Command{
+	Use:   "history [id]",
+	Short: "Show the recorded runs of a recurring order, or of all of them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := 0
+		if len(args) == 1 {
+			var err error
+			if id, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("id must be a number: %q", args[0])
+			}
+		}
+
+		output, err := sc.History(id)
+		if err != nil {
+			printError(err)
+			return nil
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+var runSchedules = func() *cobra.Command {
+	arg := cli.ScheduleRunArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Send the recurring orders until interrupted",
+		Long: `Check the recurring orders every --interval until interrupted, and send the orders of the due ones.
+Orders added or removed meanwhile take effect on the next check.
+
+Each run is recorded before its order is sent, so that a restarted 'schedule run' never sends the
+order of a time twice. After a downtime, only the latest due time of each order is sent, and only
+within --grace of it; older times are recorded as missed. Orders go through the risk checks of
+the profile and are journaled. kabu STATION orders need KABUCOM_API_TOKEN (or -t) and
+KABUCOM_ORDER_PASSWORD.`,
+		Example: `  capital-go schedule run
+  capital-go schedule run --once   # from cron or a systemd timer`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.NewConfig()
+			if arg.KabucomToken == "" {
+				arg.KabucomToken = cfg.KabucomAPIToken
+			}
+			arg.KabucomOrderPassword = cfg.KabucomOrderPassword
+
+			output, err := sc.Run(cmd.Context(), arg, os.Stdout)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(&arg.Interval, "interval", "i", 30*time.Second, "interval of checking the recurring orders")
+	cmd.Flags().DurationVar(&arg.Grace, "grace", usecases.DefaultScheduleGrace, "how late a due order is still sent")
+	cmd.Flags().BoolVar(&arg.Once, "once", false, "send the due orders once and exit")
+	cmd.Flags().StringVarP(&arg.KabucomToken, "token", "t", "", "kabu STATION API token (default $KABUCOM_API_TOKEN)")
+
+	return cmd
+}
+
+func init() {
+	scheduleCmd.AddCommand(addSchedule())
+	scheduleCmd.AddCommand(listSchedules)
+	scheduleCmd.AddCommand(removeSchedule)
+	scheduleCmd.AddCommand(scheduleHistory)
+	scheduleCmd.AddCommand(runSchedules())
+	rootCmd.AddCommand(scheduleCmd)
+}
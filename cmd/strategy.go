@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+var strategyCmd = &cobra.Command{
+	Use:   "strategy",
+	Short: "Run trading strategies",
+}
+
+var runStrategy = func() *cobra.Command {
+	arg := cli.StrategyRunArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "run [strategy]",
+		Short: "Run a strategy live on bitFlyer until interrupted",
+		Long: `Run a strategy live on bitFlyer, feeding it the executions after the start from the
+realtime API and sending its orders like 'bitflyer orders'. Use --paper to trade with the paper broker instead.
+The strategy only counts fills of its own orders as its position.
+Stop it with Ctrl+C: resting orders are cancelled, and with --flatten the position is sold.
+The strategy, its product and its order size are shown to confirm before it starts, unless --yes
+is given. --dry-run builds the strategy and shows them without starting it.
+
+` + strategiesHelp(),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			arg.Strategy = args[0]
+
+			c := cli.NewStrategyCli(usecases.NewStrategyUseCase(bfClient, bitflyer.NewRealtime(config.NewConfig())))
+
+			if dryRun {
+				ctx, cancel := apiContext(cmd)
+				defer cancel()
+
+				res, err := c.DryRun(ctx, arg)
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				fmt.Print(res)
+				return nil
+			}
+
+			if !assumeYes {
+				// The timeout of the preview does not include waiting for the answer.
+				ctx, cancel := apiContext(cmd)
+				summary, err := c.Preview(ctx, arg)
+				cancel()
+				if err != nil {
+					printError(err)
+					return nil
+				}
+				if !confirm(summary, "Start this strategy?") {
+					fmt.Println("the strategy was not started.")
+					return nil
+				}
+			}
+
+			output, err := c.Run(cmd.Context(), arg, os.Stdout)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&arg.ProductCode, "code", "c", "BTC_JPY", "product code to trade")
+	cmd.Flags().StringArrayVar(&arg.Params, "param", nil, "strategy parameter as name=value, repeatable")
+	cmd.Flags().StringVarP(&arg.Interval, "interval", "i", "1m", "candle interval passed to the strategy (1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d)")
+	cmd.Flags().DurationVar(&arg.PollInterval, "poll", 2*time.Second, "interval of polling order fills and closing candles")
+	cmd.Flags().BoolVar(&arg.Flatten, "flatten", false, "sell the position of the strategy when it stops")
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+func init() {
+	strategyCmd.AddCommand(runStrategy())
+	rootCmd.AddCommand(strategyCmd)
+}
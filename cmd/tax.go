@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/interface/cli"
+	"github.com/sn1w/capital-go/entities/pnl"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/spf13/cobra"
+)
+
+// tx is built by newTaxCli once global flags are parsed.
+var tx cli.TaxCLI
+
+func newTaxCli(cfg config.Config) cli.TaxCLI {
+	return cli.NewTaxCli(usecases.NewTaxUseCase(journalStore(cfg)))
+}
+
+var taxCmd = &cobra.Command{
+	Use:   "tax",
+	Short: "Compute the figures of Japanese tax returns from the journal",
+	Long: `Compute the miscellaneous income of crypto assets from the bitFlyer fills and transfers of the journal,
+as the calculation sheets of the National Tax Agency (暗号資産の計算書) do, and the capital gains of
+the stocks sold on kabu STATION. Run 'journal sync' first to pull the fills and transfers.
+The figures help filing 確定申告; check them against the reports of the brokers.`,
+}
+
+// addTaxFlags adds the flags selecting the year and the method.
+func addTaxFlags(cmd *cobra.Command, arg *cli.TaxArgument) {
+	cmd.Flags().IntVarP(&arg.Year, "year", "y", time.Now().In(candle.JST).Year()-1, "calendar year to compute")
+	cmd.Flags().StringVarP(&arg.Method, "method", "m", string(pnl.TotalAverage),
+		"cost method of crypto assets ("+strings.Join([]string{string(pnl.TotalAverage), string(pnl.MovingAverage)}, " or ")+")")
+}
+
+var reportTax = func() *cobra.Command {
+	arg := cli.TaxArgument{}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Show the crypto income and the capital gains of stocks of a year",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := tx.Report(arg)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	addTaxFlags(cmd, &arg)
+
+	return cmd
+}
+
+var exportTax = func() *cobra.Command {
+	arg := cli.TaxArgument{}
+	var kind, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the calculation sheet of crypto assets or the capital gains of stocks as CSV",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := tx.Export(arg, kind)
+			if err != nil {
+				printError(err)
+				return nil
+			}
+			if output == "" {
+				fmt.Print(res)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(res), 0664); err != nil {
+				return fmt.Errorf("can not write %s: %w", output, err)
+			}
+			return nil
+		},
+	}
+
+	addTaxFlags(cmd, &arg)
+	cmd.Flags().StringVarP(&kind, "kind", "k", "crypto", "sheet to export ("+strings.Join(cli.TaxKinds, ", ")+")")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default stdout)")
+
+	return cmd
+}
+
+func init() {
+	taxCmd.AddCommand(reportTax())
+	taxCmd.AddCommand(exportTax())
+	rootCmd.AddCommand(taxCmd)
+}
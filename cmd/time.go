@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+// timeLayouts are the accepted layouts of time flags. Times without a zone are in JST.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime parses the value of a time flag such as --from.
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, candle.JST); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse time %q (use 2006-01-02, 2006-01-02 15:04 or RFC3339)", value)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/interface/tui"
+	"github.com/sn1w/capital-go/entities/usecases"
+	"github.com/sn1w/capital-go/internal/terminal"
+	"github.com/spf13/cobra"
+)
+
+// renderInterval bounds how often the screen is redrawn, however fast the market moves.
+const renderInterval = 100 * time.Millisecond
+
+var tuiCmd = func() *cobra.Command {
+	var productCode string
+
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Watch a bitFlyer market full-screen and trade from the keyboard",
+		Long: `Watch the depth ladder, recent trades, ticker, open orders and balances of a bitFlyer product,
+streamed from the Realtime API.
+
+Keys:
+  b / s     buy / sell: type "<price> <size>" (price m for market), Enter, then confirm with y
+  j / k     select an open order (or the arrow keys)
+  c         cancel the selected order, after confirmation
+  q         quit (or Ctrl+C)
+
+Orders need BITFLYER_API_KEY and BITFLYER_API_SECRET, or --paper, and pass the risk checks of --profile.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runTUI(cmd, productCode); err != nil {
+				printError(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&productCode, "code", "c", "BTC_JPY", "product code to watch")
+
+	return cmd
+}
+
+func runTUI(cmd *cobra.Command, productCode string) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !terminal.IsTerminal(in) || !terminal.IsTerminal(out) {
+		return errors.New("tui needs a terminal. use 'bitflyer board' for scripts.")
+	}
+	restore, err := terminal.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	// Switch to the alternate screen without the cursor, and back on exit.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	cfg := config.NewConfig()
+	trading := paperTrading || (cfg.BitFlyerApiKey != "" && cfg.BitFlyerApiSecret != "")
+	query := usecases.MarketWatchQuery{ProductCode: productCode, Account: trading, OrderEvents: !paperTrading}
+	if paperTrading {
+		// The paper broker has no order events: fills are seen by polling.
+		query.RefreshInterval = 5 * time.Second
+	}
+
+	watch := usecases.NewMarketWatchUseCase(bfClient, bitflyer.NewRealtime(cfg))
+	orders := usecases.NewBitFlyerUseCase(bfClient)
+	app := tui.NewApp(productCode, trading)
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	// states keeps the latest state only: the screen never lags behind the market.
+	states := make(chan usecases.MarketState, 1)
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		watch.Watch(ctx, query, func(s usecases.MarketState) {
+			select {
+			case <-states:
+			default:
+			}
+			states <- s
+		})
+	}()
+	defer func() {
+		cancel()
+		<-watchDone
+	}()
+
+	// The reader is left blocked on stdin when the TUI exits; the process ends soon after.
+	keys := make(chan []tui.Key)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- tui.ParseKeys(buf[:n])
+		}
+	}()
+
+	resize := make(chan os.Signal, 1)
+	terminal.NotifyResize(resize)
+	defer signal.Stop(resize)
+	setSize := func() {
+		if width, height, err := terminal.Size(out); err == nil {
+			app.SetSize(width, height)
+		}
+	}
+	setSize()
+
+	// Orders are sent in the background so that the screen keeps updating.
+	results := make(chan string, 1)
+	run := func(action *tui.Action) {
+		apiCtx, cancel := apiContext(cmd)
+		defer cancel()
+
+		var status string
+		switch action.Kind {
+		case tui.ActionSend:
+			res, err := orders.CreateOrder(apiCtx, action.Order)
+			if err != nil {
+				status = errorMessage(err)
+				break
+			}
+			status = fmt.Sprintf("order accepted: %s", res.OrderAcceeptanceId)
+		case tui.ActionCancel:
+			if err := orders.CancelOrder(apiCtx, productCode, action.OrderAcceptanceId); err != nil {
+				status = errorMessage(err)
+				break
+			}
+			status = fmt.Sprintf("cancel requested: %s", action.OrderAcceptanceId)
+		}
+		watch.Refresh()
+		results <- strings.ReplaceAll(status, "\n", " ")
+	}
+
+	ticker := time.NewTicker(renderInterval)
+	defer ticker.Stop()
+	dirty := true
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case s := <-states:
+			app.SetState(s)
+		case <-resize:
+			setSize()
+		case status := <-results:
+			app.SetStatus(status)
+		case ks, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range ks {
+				action := app.HandleKey(k)
+				if action == nil {
+					continue
+				}
+				if action.Kind == tui.ActionQuit {
+					return nil
+				}
+				go run(action)
+			}
+		case <-ticker.C:
+			if dirty {
+				fmt.Print(app.Render())
+				dirty = false
+			}
+			continue
+		}
+		dirty = true
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd())
+}
@@ -28,11 +28,15 @@ const Name = "capital-go"
 // getVersion return gup command version.
 // Version global variable is set by s.
 func getVersion() string {
-	version := "unknown"
+	return fmt.Sprintf("%s version %s", Name, version())
+}
+
+// version returns the version of this binary.
+func version() string {
 	if Version != "" {
-		version = Version
+		return Version
 	} else if buildInfo, ok := debug.ReadBuildInfo(); ok {
-		version = buildInfo.Main.Version
+		return buildInfo.Main.Version
 	}
-	return fmt.Sprintf("%s version %s", Name, version)
+	return "unknown"
 }
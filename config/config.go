@@ -3,17 +3,47 @@ package config
 import "os"
 
 type Config struct {
-	BitFlyerApiKey    string
-	BitFlyerApiSecret string
-	KabucomAPIHost    string
+	BitFlyerApiKey           string
+	BitFlyerApiSecret        string
+	BitFlyerApiEndpoint      string
+	BitFlyerRealtimeEndpoint string
+	KabucomAPIHost           string
+	KabucomAPIToken          string
+	KabucomOrderPassword     string
+	PaperStatePath           string
+	PaperKabucomStatePath    string
+	DataDir                  string
+	RiskFile                 string
+	RiskProfile              string
+	AlertFile                string
+	JournalFile              string
+	ScheduleFile             string
 }
 
 func NewConfig() Config {
 	return Config{
 		/* BitFlyer */
-		BitFlyerApiKey:    os.Getenv("BITFLYER_API_KEY"),
-		BitFlyerApiSecret: os.Getenv("BITFLYER_API_SECRET"),
+		BitFlyerApiKey:           os.Getenv("BITFLYER_API_KEY"),
+		BitFlyerApiSecret:        os.Getenv("BITFLYER_API_SECRET"),
+		BitFlyerApiEndpoint:      os.Getenv("BITFLYER_API_ENDPOINT"),
+		BitFlyerRealtimeEndpoint: os.Getenv("BITFLYER_REALTIME_ENDPOINT"),
 		/* Kabucom */
-		KabucomAPIHost: os.Getenv("KABUCOM_API_HOST"),
+		KabucomAPIHost:       os.Getenv("KABUCOM_API_HOST"),
+		KabucomAPIToken:      os.Getenv("KABUCOM_API_TOKEN"),
+		KabucomOrderPassword: os.Getenv("KABUCOM_ORDER_PASSWORD"),
+		/* Paper trading */
+		PaperStatePath:        os.Getenv("CAPITAL_GO_PAPER_STATE"),
+		PaperKabucomStatePath: os.Getenv("CAPITAL_GO_PAPER_KABUCOM_STATE"),
+		/* Market data */
+		DataDir: os.Getenv("CAPITAL_GO_DATA_DIR"),
+		/* Risk checks */
+		RiskFile:    os.Getenv("CAPITAL_GO_RISK_FILE"),
+		RiskProfile: os.Getenv("CAPITAL_GO_PROFILE"),
+		/* Alerts */
+		AlertFile: os.Getenv("CAPITAL_GO_ALERT_FILE"),
+		/* Journal */
+		JournalFile: os.Getenv("CAPITAL_GO_JOURNAL_FILE"),
+		/* Schedules */
+		ScheduleFile: os.Getenv("CAPITAL_GO_SCHEDULE_FILE"),
 	}
 }
@@ -0,0 +1,240 @@
+// Package alert evaluates conditions on market data, such as a price crossing a threshold.
+//
+// An alert fires when its condition becomes true, including on the first sample, and fires
+// again only after the condition has been false. A move alert measures the next move from
+// the price it fired at.
+package alert
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Condition string
+
+const (
+	// PriceAbove fires when the last traded price rises to Threshold or above.
+	PriceAbove Condition = "price-above"
+	// PriceBelow fires when the last traded price falls to Threshold or below.
+	PriceBelow Condition = "price-below"
+	// SpreadAbove fires when the spread between the best ask and bid widens to Threshold or above.
+	SpreadAbove Condition = "spread-above"
+	// Move fires when the price moves by Threshold percent or more, up or down, within Window.
+	Move Condition = "move"
+	// KeepRateBelow fires when the collateral keep rate of the account falls below Threshold percent.
+	KeepRateBelow Condition = "keep-rate-below"
+)
+
+// Conditions are the supported conditions.
+var Conditions = []Condition{PriceAbove, PriceBelow, SpreadAbove, Move, KeepRateBelow}
+
+// Alert is a condition watched by `alert run`.
+type Alert struct {
+	ID int `json:"id"`
+	// ProductCode is the product watched. Keep rate alerts watch the account instead.
+	ProductCode string    `json:"product_code,omitempty"`
+	Condition   Condition `json:"condition"`
+	Threshold   float64   `json:"threshold"`
+	// Window is the period of Move alerts, such as "15m".
+	Window    string    `json:"window,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate reports whether the alert can be evaluated.
+func (a Alert) Validate() error {
+	switch a.Condition {
+	case PriceAbove, PriceBelow, SpreadAbove, Move:
+		if a.ProductCode == "" {
+			return fmt.Errorf("%s alerts need a product code", a.Condition)
+		}
+	case KeepRateBelow:
+	default:
+		return fmt.Errorf("unsupported condition %q (supported: %s)", a.Condition, conditionNames())
+	}
+	if a.Threshold <= 0 || math.IsInf(a.Threshold, 0) || math.IsNaN(a.Threshold) {
+		return fmt.Errorf("threshold must be positive: %v", a.Threshold)
+	}
+	if a.Condition == Move {
+		if _, err := a.window(); err != nil {
+			return err
+		}
+	} else if a.Window != "" {
+		return fmt.Errorf("window is only used by %s alerts", Move)
+	}
+	return nil
+}
+
+func (a Alert) window() (time.Duration, error) {
+	window, err := time.ParseDuration(a.Window)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("move alerts need a positive window such as 15m: %q", a.Window)
+	}
+	return window, nil
+}
+
+// String describes the alert, such as "BTC_JPY price-above 5100000".
+func (a Alert) String() string {
+	s := fmt.Sprintf("%s %s", a.Condition, formatFloat(a.Threshold))
+	if a.ProductCode != "" {
+		s = a.ProductCode + " " + s
+	}
+	switch a.Condition {
+	case Move:
+		s += "% in " + a.Window
+	case KeepRateBelow:
+		s += "%"
+	}
+	return s
+}
+
+func conditionNames() string {
+	s := ""
+	for i, v := range Conditions {
+		if i > 0 {
+			s += ", "
+		}
+		s += string(v)
+	}
+	return s
+}
+
+// Sample is the market data of a product at a time.
+type Sample struct {
+	Time        time.Time
+	ProductCode string
+	// Price is the last traded price.
+	Price   float64
+	BestBid float64
+	BestAsk float64
+	// KeepRate is the keep rate of the account in percent, or zero when it has no positions.
+	KeepRate float64
+}
+
+// Event is a fired alert.
+type Event struct {
+	Alert   Alert
+	Time    time.Time
+	Value   float64
+	Message string
+}
+
+// point is a price in the window of a Move alert.
+type point struct {
+	time  time.Time
+	price float64
+}
+
+// state is what an Evaluator remembers of an alert.
+type state struct {
+	active bool
+	points []point
+}
+
+// Evaluator checks samples against alerts, remembering which alerts are active between samples.
+type Evaluator struct {
+	states map[int]*state
+}
+
+func NewEvaluator() *Evaluator {
+	return &Evaluator{states: map[int]*state{}}
+}
+
+// Forget drops the state of alerts other than ids, such as removed ones.
+func (e *Evaluator) Forget(ids map[int]bool) {
+	for id := range e.states {
+		if !ids[id] {
+			delete(e.states, id)
+		}
+	}
+}
+
+// Check evaluates a on s, and returns the event when the alert fires.
+// Samples without the value of the condition, such as a zero keep rate, are skipped.
+func (e *Evaluator) Check(a Alert, s Sample) (Event, bool) {
+	st, ok := e.states[a.ID]
+	if !ok {
+		st = &state{}
+		e.states[a.ID] = st
+	}
+
+	switch a.Condition {
+	case PriceAbove:
+		return st.cross(a, s, s.Price, s.Price > 0 && s.Price >= a.Threshold,
+			fmt.Sprintf("%s price %s rose to %s or above", a.ProductCode, formatFloat(s.Price), formatFloat(a.Threshold)))
+	case PriceBelow:
+		return st.cross(a, s, s.Price, s.Price > 0 && s.Price <= a.Threshold,
+			fmt.Sprintf("%s price %s fell to %s or below", a.ProductCode, formatFloat(s.Price), formatFloat(a.Threshold)))
+	case SpreadAbove:
+		if s.BestBid <= 0 || s.BestAsk <= 0 {
+			return Event{}, false
+		}
+		spread := s.BestAsk - s.BestBid
+		return st.cross(a, s, spread, spread >= a.Threshold,
+			fmt.Sprintf("%s spread %s widened to %s or above (bid %s, ask %s)", a.ProductCode,
+				formatFloat(spread), formatFloat(a.Threshold), formatFloat(s.BestBid), formatFloat(s.BestAsk)))
+	case KeepRateBelow:
+		if s.KeepRate <= 0 {
+			return Event{}, false
+		}
+		return st.cross(a, s, s.KeepRate, s.KeepRate < a.Threshold,
+			fmt.Sprintf("keep rate %s%% fell below %s%%", formatFloat(s.KeepRate), formatFloat(a.Threshold)))
+	case Move:
+		return st.move(a, s)
+	}
+	return Event{}, false
+}
+
+// cross fires when the condition becomes true.
+func (st *state) cross(a Alert, s Sample, value float64, active bool, message string) (Event, bool) {
+	fire := active && !st.active
+	st.active = active
+	if !fire {
+		return Event{}, false
+	}
+	return Event{Alert: a, Time: s.Time, Value: value, Message: message}, true
+}
+
+// move fires when the price is Threshold percent away from a price in the window,
+// then measures the next move from the current price.
+func (st *state) move(a Alert, s Sample) (Event, bool) {
+	window, err := a.window()
+	if err != nil || s.Price <= 0 {
+		return Event{}, false
+	}
+
+	st.points = append(st.points, point{time: s.Time, price: s.Price})
+	i := sort.Search(len(st.points), func(i int) bool { return !st.points[i].time.Before(s.Time.Add(-window)) })
+	st.points = st.points[i:]
+
+	var from point
+	change := 0.0
+	for _, v := range st.points {
+		if c := (s.Price - v.price) / v.price * 100; math.Abs(c) > math.Abs(change) {
+			from, change = v, c
+		}
+	}
+	if math.Abs(change) < a.Threshold {
+		return Event{}, false
+	}
+
+	st.points = []point{{time: s.Time, price: s.Price}}
+	direction := "rose"
+	if change < 0 {
+		direction = "fell"
+	}
+	return Event{
+		Alert: a,
+		Time:  s.Time,
+		Value: change,
+		Message: fmt.Sprintf("%s price %s %.2f%% to %s in %s", a.ProductCode, direction, math.Abs(change),
+			formatFloat(s.Price), s.Time.Sub(from.time).Round(time.Second)),
+	}, true
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlert_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		alert   Alert
+		wantErr bool
+	}{
+		{name: "price", alert: Alert{ProductCode: "BTC_JPY", Condition: PriceAbove, Threshold: 5000000}},
+		{name: "move", alert: Alert{ProductCode: "BTC_JPY", Condition: Move, Threshold: 3, Window: "15m"}},
+		{name: "keep rate without product", alert: Alert{Condition: KeepRateBelow, Threshold: 150}},
+		{name: "missing product", alert: Alert{Condition: PriceBelow, Threshold: 1}, wantErr: true},
+		{name: "unknown condition", alert: Alert{ProductCode: "BTC_JPY", Condition: "volume", Threshold: 1}, wantErr: true},
+		{name: "zero threshold", alert: Alert{ProductCode: "BTC_JPY", Condition: SpreadAbove}, wantErr: true},
+		{name: "move without window", alert: Alert{ProductCode: "BTC_JPY", Condition: Move, Threshold: 3}, wantErr: true},
+		{name: "window of price", alert: Alert{ProductCode: "BTC_JPY", Condition: PriceAbove, Threshold: 3, Window: "1m"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.alert.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Alert.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluator_Check(t *testing.T) {
+	start := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(minute int, price float64, bid float64, ask float64, keepRate float64) Sample {
+		return Sample{Time: start.Add(time.Duration(minute) * time.Minute), ProductCode: "BTC_JPY", Price: price, BestBid: bid, BestAsk: ask, KeepRate: keepRate}
+	}
+
+	tests := []struct {
+		name    string
+		alert   Alert
+		samples []Sample
+		// fired are the indexes of the samples firing the alert.
+		fired   []int
+		message string
+	}{
+		{
+			name:    "price crosses above and re-arms",
+			alert:   Alert{ProductCode: "BTC_JPY", Condition: PriceAbove, Threshold: 100},
+			samples: []Sample{sample(0, 99, 0, 0, 0), sample(1, 100, 0, 0, 0), sample(2, 101, 0, 0, 0), sample(3, 98, 0, 0, 0), sample(4, 102, 0, 0, 0)},
+			fired:   []int{1, 4},
+			message: "BTC_JPY price 102 rose to 100 or above",
+		},
+		{
+			name:    "already below at the start",
+			alert:   Alert{ProductCode: "BTC_JPY", Condition: PriceBelow, Threshold: 100},
+			samples: []Sample{sample(0, 90, 0, 0, 0), sample(1, 80, 0, 0, 0)},
+			fired:   []int{0},
+			message: "BTC_JPY price 90 fell to 100 or below",
+		},
+		{
+			name:    "spread widens",
+			alert:   Alert{ProductCode: "BTC_JPY", Condition: SpreadAbove, Threshold: 10},
+			samples: []Sample{sample(0, 100, 99, 101, 0), sample(1, 100, 0, 101, 0), sample(2, 100, 95, 105, 0)},
+			fired:   []int{2},
+			message: "BTC_JPY spread 10 widened to 10 or above (bid 95, ask 105)",
+		},
+		{
+			name:    "keep rate drops, skipping no positions",
+			alert:   Alert{Condition: KeepRateBelow, Threshold: 150},
+			samples: []Sample{sample(0, 0, 0, 0, 0), sample(1, 0, 0, 0, 200), sample(2, 0, 0, 0, 149.5)},
+			fired:   []int{2},
+			message: "keep rate 149.5% fell below 150%",
+		},
+		{
+			name:    "move within the window",
+			alert:   Alert{ProductCode: "BTC_JPY", Condition: Move, Threshold: 3, Window: "10m"},
+			samples: []Sample{sample(0, 100, 0, 0, 0), sample(5, 102, 0, 0, 0), sample(9, 97, 0, 0, 0), sample(12, 96, 0, 0, 0), sample(20, 99, 0, 0, 0)},
+			fired:   []int{2, 4},
+			message: "BTC_JPY price rose 3.12% to 99 in 8m0s",
+		},
+		{
+			name:    "slow move outside the window",
+			alert:   Alert{ProductCode: "BTC_JPY", Condition: Move, Threshold: 3, Window: "10m"},
+			samples: []Sample{sample(0, 100, 0, 0, 0), sample(8, 102, 0, 0, 0), sample(16, 104, 0, 0, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEvaluator()
+			fired := []int{}
+			message := ""
+			for i, s := range tt.samples {
+				if event, ok := e.Check(tt.alert, s); ok {
+					fired = append(fired, i)
+					message = event.Message
+				}
+			}
+			if len(fired) != len(tt.fired) {
+				t.Fatalf("Evaluator.Check() fired on %v, want %v", fired, tt.fired)
+			}
+			for i := range fired {
+				if fired[i] != tt.fired[i] {
+					t.Fatalf("Evaluator.Check() fired on %v, want %v", fired, tt.fired)
+				}
+			}
+			if message != tt.message {
+				t.Errorf("Evaluator.Check() message = %q, want %q", message, tt.message)
+			}
+		})
+	}
+}
+
+func TestEvaluator_Forget(t *testing.T) {
+	e := NewEvaluator()
+	a := Alert{ID: 1, ProductCode: "BTC_JPY", Condition: PriceAbove, Threshold: 100}
+	if _, ok := e.Check(a, Sample{Price: 100}); !ok {
+		t.Fatal("Evaluator.Check() did not fire")
+	}
+	e.Forget(map[int]bool{2: true})
+	if _, ok := e.Check(a, Sample{Price: 100}); !ok {
+		t.Errorf("Evaluator.Check() did not fire again after Forget")
+	}
+}
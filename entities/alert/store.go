@@ -0,0 +1,108 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// FileStore keeps alerts in a JSON file, so that `alert add` and `alert remove` change
+// the alerts of a running `alert run`.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore backed by the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultPath returns the default path of the alerts file.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "alerts.json")
+}
+
+// Load returns the alerts ordered by id. A missing file has no alerts.
+func (f *FileStore) Load() ([]Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// Add stores a with the next id, and returns it.
+func (f *FileStore) Add(a Alert) (Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	alerts, err := f.load()
+	if err != nil {
+		return Alert{}, err
+	}
+	a.ID = 1
+	if len(alerts) > 0 {
+		a.ID = alerts[len(alerts)-1].ID + 1
+	}
+	if err := f.save(append(alerts, a)); err != nil {
+		return Alert{}, err
+	}
+	return a, nil
+}
+
+// Remove deletes the alert id. It fails with ErrResourceNotFound when there is none.
+func (f *FileStore) Remove(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	alerts, err := f.load()
+	if err != nil {
+		return err
+	}
+	for i, v := range alerts {
+		if v.ID == id {
+			return f.save(append(alerts[:i], alerts[i+1:]...))
+		}
+	}
+	return fmt.Errorf("%w: alert %d", cerror.ErrResourceNotFound, id)
+}
+
+func (f *FileStore) load() ([]Alert, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Alert{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read alerts %s: %w", f.path, err)
+	}
+	alerts := []Alert{}
+	if err := json.Unmarshal(raw, &alerts); err != nil {
+		return nil, fmt.Errorf("can not parse alerts %s: %w", f.path, err)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+	return alerts, nil
+}
+
+func (f *FileStore) save(alerts []Alert) error {
+	raw, err := json.MarshalIndent(alerts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		return fmt.Errorf("can not create alerts directory: %w", err)
+	}
+	// Replace the file at once, so that a running `alert run` never reads it half written.
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return fmt.Errorf("can not write alerts %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("can not write alerts %s: %w", f.path, err)
+	}
+	return nil
+}
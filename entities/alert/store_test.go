@@ -0,0 +1,52 @@
+package alert
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func TestFileStore(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "dir", "alerts.json"))
+
+	alerts, err := f.Load()
+	if err != nil || len(alerts) != 0 {
+		t.Fatalf("FileStore.Load() = %v, %v, want no alerts", alerts, err)
+	}
+
+	for _, v := range []Alert{
+		{ProductCode: "BTC_JPY", Condition: PriceAbove, Threshold: 1},
+		{ProductCode: "BTC_JPY", Condition: PriceBelow, Threshold: 2},
+		{Condition: KeepRateBelow, Threshold: 150},
+	} {
+		if _, err := f.Add(v); err != nil {
+			t.Fatalf("FileStore.Add() error = %v", err)
+		}
+	}
+	if err := f.Remove(2); err != nil {
+		t.Fatalf("FileStore.Remove() error = %v", err)
+	}
+	if err := f.Remove(2); !errors.Is(err, cerror.ErrResourceNotFound) {
+		t.Errorf("FileStore.Remove() error = %v, expectedError %v", err, cerror.ErrResourceNotFound)
+	}
+
+	// New ids follow the largest one.
+	added, err := f.Add(Alert{ProductCode: "ETH_JPY", Condition: PriceAbove, Threshold: 3})
+	if err != nil || added.ID != 4 {
+		t.Errorf("FileStore.Add() = %v, %v, want id 4", added, err)
+	}
+
+	alerts, err = f.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, v := range alerts {
+		ids = append(ids, v.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 4 {
+		t.Errorf("FileStore.Load() ids = %v, want [1 3 4]", ids)
+	}
+}
@@ -0,0 +1,238 @@
+// Package backtest replays executions through a strategy and reports its performance.
+//
+// Orders are simulated against the replayed trades rather than a recorded board:
+//   - Market orders fill entirely on the next trade, at its price worsened by the slippage.
+//   - Limit orders rest until a trade goes through their price, and fill at the limit
+//     price up to the size of that trade.
+//
+// Orders never fill on the trade or candle they were placed on, so strategies can not
+// look ahead. Fees are charged in the quote currency on every fill, as the paper broker does.
+package backtest
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/strategy"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// Config is the simulated account and market of a backtest.
+type Config struct {
+	// Interval is the interval of candles passed to Strategy.OnBar.
+	Interval time.Duration
+	// Cash is the initial balance in the quote currency.
+	Cash float64
+	// FeeRate is charged on the notional of each fill.
+	FeeRate float64
+	// Slippage is the fraction market orders pay beyond the trade price, such as 0.0005 for 5 bps.
+	Slippage float64
+}
+
+// DefaultConfig returns 1m candles, 1,000,000 of cash, the paper broker's fee and 5 bps of slippage.
+func DefaultConfig() Config {
+	return Config{
+		Interval: time.Minute,
+		Cash:     1000000,
+		FeeRate:  paper.DefaultFeeRate,
+		Slippage: 0.0005,
+	}
+}
+
+// Fill is an entry of the trade log.
+type Fill struct {
+	OrderID string                  `json:"order_id"`
+	Time    time.Time               `json:"time"`
+	Side    bitflyer.ChildOrderSide `json:"side"`
+	Price   float64                 `json:"price"`
+	Size    float64                 `json:"size"`
+	Fee     float64                 `json:"fee"`
+	// PnL is the profit realized by a sell against the average cost of the position, net of fees.
+	PnL float64 `json:"pnl"`
+}
+
+// order is a placed order waiting for trades.
+type order struct {
+	id string
+	strategy.Order
+	remaining float64
+}
+
+// Engine runs a strategy over trades. It is the strategy.Broker of the strategy.
+type Engine struct {
+	config   Config
+	strategy strategy.Strategy
+
+	cash     float64
+	position float64
+	// cost is the cost basis of the position, including fees.
+	cost float64
+
+	orders   []*order
+	nextID   int
+	rejected int
+
+	candles *candle.Stream
+	first   candle.Trade
+	last    candle.Trade
+	fills   []Fill
+	// equity is the marked value of the account at each candle close.
+	equity []float64
+}
+
+var _ strategy.Broker = &Engine{}
+
+// New returns an Engine running s.
+func New(config Config, s strategy.Strategy) *Engine {
+	return &Engine{
+		config:   config,
+		strategy: s,
+		cash:     config.Cash,
+		candles:  candle.NewStream(config.Interval),
+		equity:   []float64{config.Cash},
+	}
+}
+
+// Add replays a trade. Trades must be added oldest first.
+func (e *Engine) Add(trade candle.Trade) {
+	if e.candles.Current() == nil {
+		e.first = trade
+	}
+	// Close the candle of the previous trade and the empty ones after it.
+	for _, v := range e.candles.Add(trade) {
+		e.closeBar(v)
+	}
+
+	e.match(trade)
+	e.last = trade
+
+	e.strategy.OnTrade(e, trade)
+}
+
+func (e *Engine) closeBar(bar candle.Candle) {
+	e.strategy.OnBar(e, bar)
+	e.equity = append(e.equity, e.cash+e.position*bar.Close)
+}
+
+// match fills resting orders against a trade, in the order they were placed.
+func (e *Engine) match(trade candle.Trade) {
+	resting := e.orders[:0]
+	for _, v := range e.orders {
+		e.fill(v, trade)
+		if v.remaining > execution.SizeEpsilon {
+			resting = append(resting, v)
+		}
+	}
+	e.orders = resting
+}
+
+func (e *Engine) fill(o *order, trade candle.Trade) {
+	price, size := o.Price, o.remaining
+	switch {
+	case o.Price == 0 && o.Side == bitflyer.SideBuy:
+		price = trade.Price * (1 + e.config.Slippage)
+	case o.Price == 0:
+		price = trade.Price * (1 - e.config.Slippage)
+	case o.Side == bitflyer.SideBuy && trade.Price < o.Price,
+		o.Side == bitflyer.SideSell && trade.Price > o.Price:
+		if trade.Size < size {
+			size = trade.Size
+		}
+	default:
+		return
+	}
+
+	if o.Side == bitflyer.SideSell && size > e.position {
+		size = e.position
+	}
+	notional := price * size
+	fee := notional * e.config.FeeRate
+	if o.Side == bitflyer.SideBuy && notional+fee > e.cash {
+		// The price moved beyond the cash checked when the order was placed.
+		o.remaining = 0
+		e.rejected++
+		return
+	}
+	if size <= execution.SizeEpsilon {
+		o.remaining = 0
+		return
+	}
+
+	f := Fill{OrderID: o.id, Time: trade.Time, Side: o.Side, Price: price, Size: size, Fee: fee}
+	if o.Side == bitflyer.SideBuy {
+		e.cash -= notional + fee
+		e.position += size
+		e.cost += notional + fee
+	} else {
+		basis := e.cost * size / e.position
+		f.PnL = notional - fee - basis
+		e.cash += notional - fee
+		e.position -= size
+		e.cost -= basis
+		if e.position <= execution.SizeEpsilon {
+			e.position, e.cost = 0, 0
+		}
+	}
+	o.remaining -= size
+	e.fills = append(e.fills, f)
+}
+
+// Send places an order, filled from the next trade. It is rejected when the cash or the
+// position not reserved by other orders does not cover it.
+func (e *Engine) Send(o strategy.Order) (string, error) {
+	if o.Size <= 0 || o.Price < 0 {
+		e.rejected++
+		return "", fmt.Errorf("%w: size must be positive and price must not be negative", cerror.ErrBadRequest)
+	}
+
+	cash, position := e.cash, e.position
+	for _, v := range e.orders {
+		if v.Side == bitflyer.SideBuy {
+			cash -= e.estimate(v.Order) * v.remaining * (1 + e.config.FeeRate)
+		} else {
+			position -= v.remaining
+		}
+	}
+	if o.Side == bitflyer.SideBuy && e.estimate(o)*o.Size*(1+e.config.FeeRate) > cash {
+		e.rejected++
+		return "", fmt.Errorf("%w: buying %v needs more than the available cash %.0f", cerror.ErrInsufficientFunds, o.Size, cash)
+	}
+	if o.Side == bitflyer.SideSell && o.Size > position+execution.SizeEpsilon {
+		e.rejected++
+		return "", fmt.Errorf("%w: selling %v needs more than the available position %v", cerror.ErrInsufficientFunds, o.Size, position)
+	}
+
+	e.nextID++
+	id := strconv.Itoa(e.nextID)
+	e.orders = append(e.orders, &order{id: id, Order: o, remaining: o.Size})
+	return id, nil
+}
+
+// estimate returns the expected fill price of an order.
+func (e *Engine) estimate(o strategy.Order) float64 {
+	if o.Price > 0 {
+		return o.Price
+	}
+	return e.last.Price * (1 + e.config.Slippage)
+}
+
+// Cancel cancels a resting order.
+func (e *Engine) Cancel(id string) error {
+	for i, v := range e.orders {
+		if v.id == id {
+			e.orders = append(e.orders[:i], e.orders[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", cerror.ErrOrderNotFound, id)
+}
+
+// Position returns the base currency held.
+func (e *Engine) Position() float64 {
+	return e.position
+}
@@ -0,0 +1,221 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/strategy"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// scripted sends orders on the trade or the candle of given indexes and records candles.
+type scripted struct {
+	onTrade map[int]strategy.Order
+	onBar   map[int]strategy.Order
+	trades  int
+	bars    []candle.Candle
+	errs    []error
+}
+
+func (s *scripted) OnTrade(broker strategy.Broker, trade candle.Trade) {
+	if o, ok := s.onTrade[s.trades]; ok {
+		_, err := broker.Send(o)
+		s.errs = append(s.errs, err)
+	}
+	s.trades++
+}
+
+func (s *scripted) OnBar(broker strategy.Broker, bar candle.Candle) {
+	if o, ok := s.onBar[len(s.bars)]; ok {
+		_, err := broker.Send(o)
+		s.errs = append(s.errs, err)
+	}
+	s.bars = append(s.bars, bar)
+}
+
+var start = time.Date(2023, 4, 1, 10, 0, 0, 0, candle.JST)
+
+func trade(id int64, min int, price float64, size float64) candle.Trade {
+	return candle.Trade{ID: id, Time: start.Add(time.Duration(min) * time.Minute), Price: price, Size: size}
+}
+
+func config() Config {
+	return Config{Interval: time.Minute, Cash: 10000, FeeRate: 0.001, Slippage: 0.01}
+}
+
+func approx(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestEngine_marketOrders(t *testing.T) {
+	s := &scripted{
+		onTrade: map[int]strategy.Order{
+			0: {Side: bitflyer.SideBuy, Size: 10},
+			2: {Side: bitflyer.SideSell, Size: 10},
+		},
+	}
+	e := New(config(), s)
+	for _, v := range []candle.Trade{
+		trade(1, 0, 100, 1),
+		trade(2, 0, 200, 1),
+		trade(3, 1, 300, 1),
+		trade(4, 2, 400, 1),
+	} {
+		e.Add(v)
+	}
+	r := e.Report()
+
+	// The buy fills on the next trade at 200 + 1%, the sell at 400 - 1%.
+	want := []Fill{
+		{OrderID: "1", Time: start, Side: bitflyer.SideBuy, Price: 202, Size: 10, Fee: 2.02},
+		{OrderID: "2", Time: start.Add(2 * time.Minute), Side: bitflyer.SideSell, Price: 396, Size: 10, Fee: 3.96, PnL: 3960 - 3.96 - 2022.02},
+	}
+	if len(r.Fills) != len(want) {
+		t.Fatalf("Report().Fills = %v, want %v", r.Fills, want)
+	}
+	for i := range want {
+		got := r.Fills[i]
+		if got.OrderID != want[i].OrderID || !got.Time.Equal(want[i].Time) || got.Side != want[i].Side ||
+			!approx(got.Price, want[i].Price) || !approx(got.Size, want[i].Size) || !approx(got.Fee, want[i].Fee) || !approx(got.PnL, want[i].PnL) {
+			t.Errorf("Report().Fills[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if !approx(r.PnL, 3960-3.96-2022.02) || r.Position != 0 {
+		t.Errorf("Report() PnL = %v, position %v", r.PnL, r.Position)
+	}
+	if r.Trades != 1 || r.Wins != 1 || r.WinRate != 1 {
+		t.Errorf("Report() trades = %v, wins = %v, win rate = %v", r.Trades, r.Wins, r.WinRate)
+	}
+	if !approx(r.Fees, 2.02+3.96) {
+		t.Errorf("Report().Fees = %v", r.Fees)
+	}
+	if !r.From.Equal(start) || !r.To.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Report() From = %v, To = %v", r.From, r.To)
+	}
+}
+
+func TestEngine_limitOrders(t *testing.T) {
+	s := &scripted{
+		onTrade: map[int]strategy.Order{
+			0: {Side: bitflyer.SideBuy, Price: 95, Size: 3},
+		},
+	}
+	e := New(config(), s)
+	for _, v := range []candle.Trade{
+		trade(1, 0, 100, 1),
+		// Trading at the limit price does not prove the order would be filled.
+		trade(2, 0, 95, 5),
+		trade(3, 0, 94, 2),
+		trade(4, 0, 90, 5),
+	} {
+		e.Add(v)
+	}
+	r := e.Report()
+
+	sizes := []float64{}
+	for _, v := range r.Fills {
+		if v.Price != 95 {
+			t.Errorf("limit order filled at %v", v.Price)
+		}
+		sizes = append(sizes, v.Size)
+	}
+	if want := []float64{2, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("fill sizes = %v, want %v", sizes, want)
+	}
+	if r.Position != 3 {
+		t.Errorf("Report().Position = %v, want 3", r.Position)
+	}
+}
+
+func TestEngine_rejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		order strategy.Order
+		want  error
+	}{
+		{name: "Sell Without Position", order: strategy.Order{Side: bitflyer.SideSell, Size: 1}, want: cerror.ErrInsufficientFunds},
+		{name: "Buy Beyond Cash", order: strategy.Order{Side: bitflyer.SideBuy, Size: 100}, want: cerror.ErrInsufficientFunds},
+		{name: "Zero Size", order: strategy.Order{Side: bitflyer.SideBuy}, want: cerror.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scripted{onTrade: map[int]strategy.Order{0: tt.order}}
+			e := New(config(), s)
+			e.Add(trade(1, 0, 100, 1))
+			e.Add(trade(2, 0, 100, 1))
+			r := e.Report()
+
+			if !errors.Is(s.errs[0], tt.want) {
+				t.Errorf("Engine.Send() error = %v, expectedError %v", s.errs[0], tt.want)
+			}
+			if r.Rejected != 1 || len(r.Fills) != 0 {
+				t.Errorf("Report() rejected = %v, fills = %v", r.Rejected, r.Fills)
+			}
+		})
+	}
+}
+
+func TestEngine_bars(t *testing.T) {
+	s := &scripted{
+		// Ordered on the close of the first candle, filled by the first trade after it.
+		onBar: map[int]strategy.Order{0: {Side: bitflyer.SideBuy, Size: 1}},
+	}
+	e := New(config(), s)
+	for _, v := range []candle.Trade{
+		trade(1, 0, 100, 1),
+		trade(2, 0, 110, 2),
+		trade(3, 3, 90, 1),
+	} {
+		e.Add(v)
+	}
+	r := e.Report()
+
+	want := []candle.Candle{
+		{Time: start, Open: 100, High: 110, Low: 100, Close: 110, Volume: 3, Trades: 2},
+		{Time: start.Add(time.Minute), Open: 110, High: 110, Low: 110, Close: 110},
+		{Time: start.Add(2 * time.Minute), Open: 110, High: 110, Low: 110, Close: 110},
+		{Time: start.Add(3 * time.Minute), Open: 90, High: 90, Low: 90, Close: 90, Volume: 1, Trades: 1},
+	}
+	if !reflect.DeepEqual(s.bars, want) {
+		t.Errorf("OnBar() received %v, want %v", s.bars, want)
+	}
+	if len(r.Fills) != 1 || !approx(r.Fills[0].Price, 90.9) {
+		t.Errorf("Report().Fills = %v, want a buy at 90.9", r.Fills)
+	}
+
+	// Marked at 90 after buying at 90.9 plus the fee.
+	if wantDrawdown := 0.9 + 0.0909; !approx(r.MaxDrawdown, wantDrawdown) || !approx(r.PnL, -wantDrawdown) {
+		t.Errorf("Report() MaxDrawdown = %v, PnL = %v, want %v", r.MaxDrawdown, r.PnL, wantDrawdown)
+	}
+}
+
+func TestSharpe(t *testing.T) {
+	tests := []struct {
+		name   string
+		equity []float64
+		want   float64
+	}{
+		{name: "Flat", equity: []float64{100, 100, 100}, want: 0},
+		{name: "Too Short", equity: []float64{100, 110}, want: 0},
+		// Returns of 10% and -10%: the mean is zero.
+		{name: "Zero Mean", equity: []float64{100, 110, 99}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sharpe(tt.equity, 24*time.Hour); !approx(got, tt.want) {
+				t.Errorf("sharpe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Returns of 1% and 3% have a mean of 2% and a deviation of sqrt(2)%, annualized over 365 days.
+	if got, want := sharpe([]float64{100, 101, 104.03}, 24*time.Hour), 2/math.Sqrt(2)*math.Sqrt(365); !approx(got, want) {
+		t.Errorf("sharpe() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// Report is the performance of a backtest.
+type Report struct {
+	// From and To are the times of the first and the last replayed trades.
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	InitialCash float64   `json:"initial_cash"`
+	// FinalEquity is the cash plus the position marked at the last trade.
+	FinalEquity float64 `json:"final_equity"`
+	PnL         float64 `json:"pnl"`
+	Return      float64 `json:"return"`
+	// MaxDrawdown is the largest fall of the equity from a previous peak, at candle closes.
+	MaxDrawdown     float64 `json:"max_drawdown"`
+	MaxDrawdownRate float64 `json:"max_drawdown_rate"`
+	// Sharpe is the annualized Sharpe ratio of candle returns, with a zero risk-free rate.
+	Sharpe float64 `json:"sharpe"`
+	// Trades is the number of sell orders closing (a part of) the position.
+	Trades   int     `json:"trades"`
+	Wins     int     `json:"wins"`
+	WinRate  float64 `json:"win_rate"`
+	Fees     float64 `json:"fees"`
+	Position float64 `json:"position"`
+	// Rejected is the number of orders rejected for invalid sizes or missing funds.
+	Rejected int    `json:"rejected"`
+	Fills    []Fill `json:"fills"`
+}
+
+// Report closes the last candle and returns the performance so far.
+func (e *Engine) Report() *Report {
+	if bar := e.candles.Current(); bar != nil {
+		e.closeBar(*bar)
+	}
+
+	r := &Report{
+		From:        e.first.Time,
+		To:          e.last.Time,
+		InitialCash: e.config.Cash,
+		FinalEquity: e.cash + e.position*e.last.Price,
+		Position:    e.position,
+		Rejected:    e.rejected,
+		Fills:       append([]Fill{}, e.fills...),
+	}
+	r.PnL = r.FinalEquity - r.InitialCash
+	if r.InitialCash > 0 {
+		r.Return = r.PnL / r.InitialCash
+	}
+	r.MaxDrawdown, r.MaxDrawdownRate = drawdown(e.equity)
+	r.Sharpe = sharpe(e.equity, e.config.Interval)
+
+	// A sell order filled in parts is one trade.
+	pnl := map[string]float64{}
+	orders := []string{}
+	for _, v := range e.fills {
+		r.Fees += v.Fee
+		if v.Side != bitflyer.SideSell {
+			continue
+		}
+		if _, ok := pnl[v.OrderID]; !ok {
+			orders = append(orders, v.OrderID)
+		}
+		pnl[v.OrderID] += v.PnL
+	}
+	r.Trades = len(orders)
+	for _, v := range orders {
+		if pnl[v] > 0 {
+			r.Wins++
+		}
+	}
+	if r.Trades > 0 {
+		r.WinRate = float64(r.Wins) / float64(r.Trades)
+	}
+	return r
+}
+
+// drawdown returns the largest fall of equity from a previous peak, and its rate to the peak.
+func drawdown(equity []float64) (float64, float64) {
+	peak, max, rate := 0.0, 0.0, 0.0
+	for i, v := range equity {
+		if i == 0 || v > peak {
+			peak = v
+		}
+		if peak-v > max {
+			max = peak - v
+			if peak > 0 {
+				rate = max / peak
+			}
+		}
+	}
+	return max, rate
+}
+
+// sharpe returns the annualized Sharpe ratio of the returns between equity points an interval apart.
+func sharpe(equity []float64, interval time.Duration) float64 {
+	returns := []float64{}
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1] != 0 {
+			returns = append(returns, equity[i]/equity[i-1]-1)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, v := range returns {
+		mean += v
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, v := range returns {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	// Crypto markets trade all year round.
+	periods := float64(365*24*time.Hour) / float64(interval)
+	return mean / std * math.Sqrt(periods)
+}
@@ -0,0 +1,163 @@
+// Package board shapes an order book for display: price buckets, depth, cumulative sizes,
+// and a summary of the spread and the imbalance between bids and asks.
+//
+// Books of any length are accepted, including one-sided and empty ones.
+package board
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sn1w/capital-go/entities/execution"
+)
+
+// Level is a price level of a book.
+type Level struct {
+	Price float64
+	Size  float64
+	// Cumulative is the size from the best price down to this level, inclusive.
+	Cumulative float64
+}
+
+// Options selects how a book is shaped.
+type Options struct {
+	// Depth is the number of levels kept per side. Zero keeps them all.
+	Depth int
+	// Tick groups prices into buckets of this width. Bids are rounded down and asks up,
+	// so a bucket is never better than the orders in it. Zero disables grouping.
+	Tick float64
+}
+
+// Validate reports whether the options are usable.
+func (o Options) Validate() error {
+	if o.Depth < 0 {
+		return fmt.Errorf("depth must not be negative: %d", o.Depth)
+	}
+	if o.Tick < 0 || math.IsNaN(o.Tick) || math.IsInf(o.Tick, 0) {
+		return fmt.Errorf("tick must be a positive price step: %v", o.Tick)
+	}
+	return nil
+}
+
+// Summary describes the top of a book.
+type Summary struct {
+	// BestBid and BestAsk are zero when their side is empty.
+	BestBid float64
+	BestAsk float64
+	// MidPrice is the mid price given with the book, or the middle of the best quotes.
+	MidPrice float64
+	// Spread is zero unless both sides have orders.
+	Spread float64
+	// SpreadRatio is Spread relative to MidPrice.
+	SpreadRatio float64
+	// BidSize and AskSize are the sizes of the kept levels.
+	BidSize float64
+	AskSize float64
+	// Imbalance is (BidSize - AskSize) / (BidSize + AskSize), from -1 (asks only) to 1 (bids only).
+	Imbalance float64
+}
+
+// Book is a shaped order book.
+type Book struct {
+	// Bids and Asks are ordered from the best price.
+	Bids    []Level
+	Asks    []Level
+	Summary Summary
+}
+
+// Build shapes the levels of a book with opts. The levels may be in any order; their
+// Cumulative is ignored. midPrice is the mid price reported by the exchange, or zero.
+func Build(midPrice float64, bids []Level, asks []Level, opts Options) (Book, error) {
+	if err := opts.Validate(); err != nil {
+		return Book{}, err
+	}
+
+	bids = sorted(bids, true)
+	asks = sorted(asks, false)
+	summary := Summary{MidPrice: midPrice}
+	if len(bids) > 0 {
+		summary.BestBid = bids[0].Price
+	}
+	if len(asks) > 0 {
+		summary.BestAsk = asks[0].Price
+	}
+	if len(bids) > 0 && len(asks) > 0 {
+		summary.Spread = summary.BestAsk - summary.BestBid
+		if summary.MidPrice == 0 {
+			summary.MidPrice = (summary.BestBid + summary.BestAsk) / 2
+		}
+		summary.SpreadRatio = summary.Spread / summary.MidPrice
+	}
+
+	book := Book{
+		Bids: shape(bids, opts, true),
+		Asks: shape(asks, opts, false),
+	}
+	if len(book.Bids) > 0 {
+		summary.BidSize = book.Bids[len(book.Bids)-1].Cumulative
+	}
+	if len(book.Asks) > 0 {
+		summary.AskSize = book.Asks[len(book.Asks)-1].Cumulative
+	}
+	if total := summary.BidSize + summary.AskSize; total > 0 {
+		summary.Imbalance = (summary.BidSize - summary.AskSize) / total
+	}
+	book.Summary = summary
+	return book, nil
+}
+
+// sorted returns a copy of levels from the best price, without empty levels.
+func sorted(levels []Level, bid bool) []Level {
+	res := make([]Level, 0, len(levels))
+	for _, v := range levels {
+		if v.Size > 0 {
+			res = append(res, Level{Price: v.Price, Size: v.Size})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if bid {
+			return res[i].Price > res[j].Price
+		}
+		return res[i].Price < res[j].Price
+	})
+	return res
+}
+
+// shape groups sorted levels into buckets, cuts them to the depth and sums the sizes.
+func shape(levels []Level, opts Options, bid bool) []Level {
+	res := []Level{}
+	for _, v := range levels {
+		price := v.Price
+		if opts.Tick > 0 {
+			price = bucket(price, opts.Tick, bid)
+		}
+		if n := len(res); n > 0 && res[n-1].Price == price {
+			res[n-1].Size += v.Size
+			continue
+		}
+		if opts.Depth > 0 && len(res) == opts.Depth {
+			break
+		}
+		res = append(res, Level{Price: price, Size: v.Size})
+	}
+
+	cumulative := 0.0
+	for i := range res {
+		cumulative += res[i].Size
+		res[i].Cumulative = cumulative
+	}
+	return res
+}
+
+// bucket rounds price down (bids) or up (asks) to a multiple of tick.
+func bucket(price float64, tick float64, bid bool) float64 {
+	// The epsilon keeps prices already on the grid, such as 0.3 with a tick of 0.1, in their bucket.
+	const epsilon = 1e-9
+	n := math.Floor(price/tick + epsilon)
+	if !bid {
+		n = math.Ceil(price/tick - epsilon)
+	}
+	// Round off the float error of the multiplication, such as 0.30000000000000004.
+	return execution.Round(n * tick)
+}
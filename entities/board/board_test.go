@@ -0,0 +1,133 @@
+package board
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	bids := []Level{{Price: 4999, Size: 1}, {Price: 5001, Size: 0.5}, {Price: 4500, Size: 2}, {Price: 4998, Size: 0}}
+	asks := []Level{{Price: 5010, Size: 1}, {Price: 5002, Size: 0.5}, {Price: 5999, Size: 3}}
+
+	tests := []struct {
+		name     string
+		midPrice float64
+		bids     []Level
+		asks     []Level
+		opts     Options
+		want     Book
+		wantErr  bool
+	}{
+		{
+			name: "all levels",
+			bids: bids,
+			asks: asks,
+			want: Book{
+				Bids: []Level{{Price: 5001, Size: 0.5, Cumulative: 0.5}, {Price: 4999, Size: 1, Cumulative: 1.5}, {Price: 4500, Size: 2, Cumulative: 3.5}},
+				Asks: []Level{{Price: 5002, Size: 0.5, Cumulative: 0.5}, {Price: 5010, Size: 1, Cumulative: 1.5}, {Price: 5999, Size: 3, Cumulative: 4.5}},
+				Summary: Summary{
+					BestBid: 5001, BestAsk: 5002, MidPrice: 5001.5, Spread: 1, SpreadRatio: 1 / 5001.5,
+					BidSize: 3.5, AskSize: 4.5, Imbalance: -1.0 / 8,
+				},
+			},
+		},
+		{
+			name:     "depth",
+			midPrice: 5000,
+			bids:     bids,
+			asks:     asks,
+			opts:     Options{Depth: 1},
+			want: Book{
+				Bids: []Level{{Price: 5001, Size: 0.5, Cumulative: 0.5}},
+				Asks: []Level{{Price: 5002, Size: 0.5, Cumulative: 0.5}},
+				Summary: Summary{
+					BestBid: 5001, BestAsk: 5002, MidPrice: 5000, Spread: 1, SpreadRatio: 1.0 / 5000,
+					BidSize: 0.5, AskSize: 0.5,
+				},
+			},
+		},
+		{
+			name: "tick",
+			bids: bids,
+			asks: asks,
+			opts: Options{Depth: 2, Tick: 1000},
+			want: Book{
+				Bids: []Level{{Price: 5000, Size: 0.5, Cumulative: 0.5}, {Price: 4000, Size: 3, Cumulative: 3.5}},
+				Asks: []Level{{Price: 6000, Size: 4.5, Cumulative: 4.5}},
+				Summary: Summary{
+					BestBid: 5001, BestAsk: 5002, MidPrice: 5001.5, Spread: 1, SpreadRatio: 1 / 5001.5,
+					BidSize: 3.5, AskSize: 4.5, Imbalance: -1.0 / 8,
+				},
+			},
+		},
+		{
+			name: "short book",
+			bids: []Level{{Price: 100, Size: 1}},
+			opts: Options{Depth: 10},
+			want: Book{
+				Bids:    []Level{{Price: 100, Size: 1, Cumulative: 1}},
+				Asks:    []Level{},
+				Summary: Summary{BestBid: 100, BidSize: 1, Imbalance: 1},
+			},
+		},
+		{
+			name: "empty book",
+			opts: Options{Depth: 10, Tick: 1},
+			want: Book{Bids: []Level{}, Asks: []Level{}},
+		},
+		{
+			name:    "negative depth",
+			opts:    Options{Depth: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative tick",
+			opts:    Options{Tick: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(tt.midPrice, tt.bids, tt.asks, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild_keepsInput(t *testing.T) {
+	bids := []Level{{Price: 1, Size: 1}, {Price: 2, Size: 1}}
+	if _, err := Build(0, bids, nil, Options{Tick: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if bids[0].Price != 1 || bids[0].Cumulative != 0 {
+		t.Errorf("Build() changed its input: %v", bids)
+	}
+}
+
+func Test_bucket(t *testing.T) {
+	tests := []struct {
+		price float64
+		tick  float64
+		bid   bool
+		want  float64
+	}{
+		{price: 5001234, tick: 1000, bid: true, want: 5001000},
+		{price: 5001234, tick: 1000, bid: false, want: 5002000},
+		{price: 5001000, tick: 1000, bid: false, want: 5001000},
+		{price: 0.3, tick: 0.1, bid: true, want: 0.3},
+		{price: 0.3, tick: 0.1, bid: false, want: 0.3},
+		{price: 2500.25, tick: 0.5, bid: false, want: 2500.5},
+	}
+	for _, tt := range tests {
+		if got := bucket(tt.price, tt.tick, tt.bid); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("bucket(%v, %v, %v) = %v, want %v", tt.price, tt.tick, tt.bid, got, tt.want)
+		}
+	}
+}
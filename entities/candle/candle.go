@@ -0,0 +1,233 @@
+// Package candle aggregates trades into OHLCV candles.
+//
+// Buckets are aligned to midnight in JST, so a 4h candle starts at 0:00, 4:00, 8:00 ... JST
+// and a 1d candle covers a JST calendar day. Trades can be added in any order, from a
+// history or a realtime feed.
+package candle
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// JST is the time zone candles are aligned to.
+var JST = time.FixedZone("JST", 9*60*60)
+
+// Intervals are the supported candle intervals by name.
+var Intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// ParseInterval returns the duration of an interval name such as "5m".
+func ParseInterval(name string) (time.Duration, error) {
+	interval, ok := Intervals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported interval %q (supported: 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d)", name)
+	}
+	return interval, nil
+}
+
+// Truncate returns the start of the bucket of interval containing t, in JST.
+// interval must divide a day.
+func Truncate(t time.Time, interval time.Duration) time.Time {
+	t = t.In(JST)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, JST)
+	return day.Add(t.Sub(day) / interval * interval)
+}
+
+// Trade is an execution aggregated into a candle.
+type Trade struct {
+	// ID orders trades of the same time.
+	ID    int64
+	Time  time.Time
+	Price float64
+	Size  float64
+}
+
+// Candle is an OHLCV bar. Time is the start of the bucket in JST.
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+	// Trades is the number of trades. Zero means the candle was filled from the previous close.
+	Trades int `json:"trades"`
+}
+
+// bucket is a candle being built.
+type bucket struct {
+	Candle
+	first Trade
+	last  Trade
+}
+
+// Builder aggregates trades into candles of an interval.
+type Builder struct {
+	interval time.Duration
+	buckets  map[int64]*bucket
+}
+
+// NewBuilder returns a Builder of interval, which must be one of Intervals.
+func NewBuilder(interval time.Duration) *Builder {
+	return &Builder{interval: interval, buckets: map[int64]*bucket{}}
+}
+
+// Add aggregates a trade.
+func (b *Builder) Add(trade Trade) {
+	start := Truncate(trade.Time, b.interval)
+	v, ok := b.buckets[start.Unix()]
+	if !ok {
+		b.buckets[start.Unix()] = &bucket{
+			Candle: Candle{
+				Time:   start,
+				Open:   trade.Price,
+				High:   trade.Price,
+				Low:    trade.Price,
+				Close:  trade.Price,
+				Volume: trade.Size,
+				Trades: 1,
+			},
+			first: trade,
+			last:  trade,
+		}
+		return
+	}
+
+	if trade.Price > v.High {
+		v.High = trade.Price
+	}
+	if trade.Price < v.Low {
+		v.Low = trade.Price
+	}
+	v.Volume += trade.Size
+	v.Trades++
+	if before(trade, v.first) {
+		v.first = trade
+		v.Open = trade.Price
+	}
+	if before(v.last, trade) {
+		v.last = trade
+		v.Close = trade.Price
+	}
+}
+
+func before(a Trade, b Trade) bool {
+	if a.Time.Equal(b.Time) {
+		return a.ID < b.ID
+	}
+	return a.Time.Before(b.Time)
+}
+
+// Candles returns the candles of buckets starting in [from, to), oldest first.
+// Buckets without trades are filled with the previous close and zero volume.
+// Buckets before the first trade are omitted.
+func (b *Builder) Candles(from time.Time, to time.Time) []Candle {
+	keys := make([]int64, 0, len(b.buckets))
+	for k := range b.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	res := []Candle{}
+	var previous *Candle
+	next := 0
+	for t := Truncate(from, b.interval); t.Before(to); t = t.Add(b.interval) {
+		// Carry the close of buckets before t.
+		for next < len(keys) && keys[next] < t.Unix() {
+			c := b.buckets[keys[next]].Candle
+			previous = &c
+			next++
+		}
+
+		if next < len(keys) && keys[next] == t.Unix() {
+			c := b.buckets[keys[next]].Candle
+			res = append(res, c)
+			previous = &c
+			next++
+			continue
+		}
+		if previous != nil {
+			res = append(res, Candle{
+				Time:  t,
+				Open:  previous.Close,
+				High:  previous.Close,
+				Low:   previous.Close,
+				Close: previous.Close,
+			})
+		}
+	}
+	return res
+}
+
+// Stream aggregates trades arriving oldest first into candles, and closes them as time passes.
+// Unlike Builder, it suits realtime feeds: closed candles are returned as soon as they are known.
+type Stream struct {
+	interval time.Duration
+	// current is the candle being built, nil before the first trade.
+	current *Candle
+}
+
+// NewStream returns a Stream of interval, which must be one of Intervals.
+func NewStream(interval time.Duration) *Stream {
+	return &Stream{interval: interval}
+}
+
+// Add aggregates a trade and returns the candles it closed, including ones without trades
+// which repeat the previous close. A trade older than the current candle counts in it.
+func (s *Stream) Add(trade Trade) []Candle {
+	start := Truncate(trade.Time, s.interval)
+	closed := s.CloseUntil(start)
+	if s.current != nil && start.Before(s.current.Time) {
+		start = s.current.Time
+	}
+
+	if s.current == nil || s.current.Trades == 0 {
+		s.current = &Candle{Time: start, Open: trade.Price, High: trade.Price, Low: trade.Price}
+	}
+	if trade.Price > s.current.High {
+		s.current.High = trade.Price
+	}
+	if trade.Price < s.current.Low {
+		s.current.Low = trade.Price
+	}
+	s.current.Close = trade.Price
+	s.current.Volume += trade.Size
+	s.current.Trades++
+	return closed
+}
+
+// CloseUntil returns the candles ending at or before t, oldest first.
+func (s *Stream) CloseUntil(t time.Time) []Candle {
+	closed := []Candle{}
+	if s.current == nil {
+		return closed
+	}
+	for !s.current.Time.Add(s.interval).After(t) {
+		closed = append(closed, *s.current)
+		previous := s.current.Close
+		s.current = &Candle{Time: s.current.Time.Add(s.interval), Open: previous, High: previous, Low: previous, Close: previous}
+	}
+	return closed
+}
+
+// Current returns the candle being built, or nil before the first trade.
+func (s *Stream) Current() *Candle {
+	if s.current == nil {
+		return nil
+	}
+	c := *s.current
+	return &c
+}
@@ -0,0 +1,151 @@
+package candle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func jst(hour int, min int, sec int) time.Time {
+	return time.Date(2023, 4, 1, hour, min, sec, 0, JST)
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "5m", want: 5 * time.Minute},
+		{name: "1d", want: 24 * time.Hour},
+		{name: "7m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInterval(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseInterval() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{name: "5m", t: jst(10, 7, 59), interval: 5 * time.Minute, want: jst(10, 5, 0)},
+		{name: "4h", t: jst(10, 7, 59), interval: 4 * time.Hour, want: jst(8, 0, 0)},
+		// 2023-03-31T16:30Z is 1:30 on 2023-04-01 in JST.
+		{name: "1d From UTC", t: time.Date(2023, 3, 31, 16, 30, 0, 0, time.UTC), interval: 24 * time.Hour, want: jst(0, 0, 0)},
+		{name: "Boundary", t: jst(0, 0, 0), interval: time.Hour, want: jst(0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.t, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("Truncate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Candles(t *testing.T) {
+	b := NewBuilder(time.Minute)
+	// Added newest first, as /v1/executions returns them.
+	trades := []Trade{
+		{ID: 6, Time: jst(10, 3, 10), Price: 106, Size: 1},
+		{ID: 5, Time: jst(10, 1, 30), Price: 99, Size: 0.5},
+		{ID: 4, Time: jst(10, 1, 30), Price: 101, Size: 0.5},
+		{ID: 3, Time: jst(10, 1, 0), Price: 104, Size: 1},
+		{ID: 2, Time: jst(10, 0, 50), Price: 102, Size: 2},
+		{ID: 1, Time: jst(10, 0, 10), Price: 100, Size: 1},
+	}
+	for _, v := range trades {
+		b.Add(v)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want []Candle
+	}{
+		{
+			name: "Gap Filled",
+			from: jst(9, 59, 0),
+			to:   jst(10, 4, 0),
+			want: []Candle{
+				{Time: jst(10, 0, 0), Open: 100, High: 102, Low: 100, Close: 102, Volume: 3, Trades: 2},
+				{Time: jst(10, 1, 0), Open: 104, High: 104, Low: 99, Close: 99, Volume: 2, Trades: 3},
+				{Time: jst(10, 2, 0), Open: 99, High: 99, Low: 99, Close: 99},
+				{Time: jst(10, 3, 0), Open: 106, High: 106, Low: 106, Close: 106, Volume: 1, Trades: 1},
+			},
+		},
+		{
+			name: "Starts In A Gap",
+			from: jst(10, 2, 30),
+			to:   jst(10, 5, 0),
+			want: []Candle{
+				{Time: jst(10, 2, 0), Open: 99, High: 99, Low: 99, Close: 99},
+				{Time: jst(10, 3, 0), Open: 106, High: 106, Low: 106, Close: 106, Volume: 1, Trades: 1},
+				{Time: jst(10, 4, 0), Open: 106, High: 106, Low: 106, Close: 106},
+			},
+		},
+		{
+			name: "No Trades Yet",
+			from: jst(9, 0, 0),
+			to:   jst(10, 0, 0),
+			want: []Candle{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Candles(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Builder.Candles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := NewStream(time.Minute)
+
+	if got := s.Add(Trade{ID: 1, Time: jst(10, 0, 10), Price: 100, Size: 1}); len(got) != 0 {
+		t.Errorf("Stream.Add() closed %v on the first trade", got)
+	}
+	s.Add(Trade{ID: 2, Time: jst(10, 0, 50), Price: 102, Size: 2})
+
+	got := s.Add(Trade{ID: 3, Time: jst(10, 2, 5), Price: 99, Size: 1})
+	want := []Candle{
+		{Time: jst(10, 0, 0), Open: 100, High: 102, Low: 100, Close: 102, Volume: 3, Trades: 2},
+		{Time: jst(10, 1, 0), Open: 102, High: 102, Low: 102, Close: 102},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stream.Add() = %v, want %v", got, want)
+	}
+
+	if got := s.CloseUntil(jst(10, 2, 59)); len(got) != 0 {
+		t.Errorf("Stream.CloseUntil() = %v before the end of the candle", got)
+	}
+	got = s.CloseUntil(jst(10, 4, 0))
+	want = []Candle{
+		{Time: jst(10, 2, 0), Open: 99, High: 99, Low: 99, Close: 99, Volume: 1, Trades: 1},
+		{Time: jst(10, 3, 0), Open: 99, High: 99, Low: 99, Close: 99},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stream.CloseUntil() = %v, want %v", got, want)
+	}
+
+	// A late trade of a closed candle counts in the current one.
+	s.Add(Trade{ID: 4, Time: jst(10, 3, 59), Price: 98, Size: 1})
+	if c := s.Current(); c == nil || !c.Time.Equal(jst(10, 4, 0)) || c.Close != 98 || c.Trades != 1 {
+		t.Errorf("Stream.Current() = %v", c)
+	}
+}
@@ -0,0 +1,167 @@
+// Package execution splits a large order into child orders, so that it moves the book less.
+//
+// TWAP sends equal slices of the order at market over a duration. Iceberg rests a limit order
+// showing only part of the size at a time, and shows the next part once it fills. Chase rests a
+// limit order at the best price of its own side, never crossing the spread, and re-prices it
+// while the market moves away, until it is filled or the price is beyond the maximum slippage.
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type Algorithm string
+
+const (
+	TWAP    Algorithm = "twap"
+	Iceberg Algorithm = "iceberg"
+	Chase   Algorithm = "chase"
+)
+
+// Algorithms are the supported algorithms.
+var Algorithms = []Algorithm{TWAP, Iceberg, Chase}
+
+// Step is the increment of order sizes of bitFlyer.
+const Step = 0.00000001
+
+// stepsPerUnit is the number of Steps in a unit. Unlike Step, it is exact in floating point.
+const stepsPerUnit = 1e8
+
+// SizeEpsilon absorbs floating point residue when comparing sizes and quantities.
+const SizeEpsilon = 1e-9
+
+// Order is a parent order executed by an algorithm.
+type Order struct {
+	Algorithm   Algorithm
+	ProductCode string
+	Buy         bool
+	Size        float64
+	// Duration and Slices are the period of TWAP and the number of its slices.
+	Duration time.Duration
+	Slices   int
+	// Display is the size of each child order of Iceberg.
+	Display float64
+	// Price is the limit price of Iceberg, and the worst price at which TWAP sends a slice: slices
+	// are carried over to the next one while the best quote is beyond it. Zero is no limit for TWAP.
+	Price float64
+	// MaxSlippage is how far Chase follows the market, in percent of the best price of its side when
+	// it starts.
+	MaxSlippage float64
+}
+
+// Validate reports whether the order can be executed with child orders of at least minSize.
+func (o Order) Validate(minSize float64) error {
+	if o.ProductCode == "" {
+		return errors.New("product code is empty")
+	}
+	if o.Size <= 0 || math.IsInf(o.Size, 0) || math.IsNaN(o.Size) {
+		return fmt.Errorf("size must be positive, got %v", o.Size)
+	}
+	if o.Price < 0 {
+		return fmt.Errorf("price must not be negative, got %v", o.Price)
+	}
+
+	switch o.Algorithm {
+	case TWAP:
+		if o.Duration <= 0 {
+			return fmt.Errorf("duration must be positive, got %s", o.Duration)
+		}
+		if o.Slices <= 0 {
+			return fmt.Errorf("slices must be positive, got %d", o.Slices)
+		}
+		if slice := Slices(o.Size, o.Slices)[0]; slice < minSize {
+			return fmt.Errorf("slices of %v are smaller than the minimum order size %v: use fewer slices", slice, minSize)
+		}
+	case Iceberg:
+		if o.Price <= 0 {
+			return errors.New("iceberg orders need a limit price")
+		}
+		if o.Display < minSize || o.Display > o.Size {
+			return fmt.Errorf("display size must be between the minimum order size %v and the size %v, got %v", minSize, o.Size, o.Display)
+		}
+	case Chase:
+		if o.MaxSlippage < 0 {
+			return fmt.Errorf("max slippage must not be negative, got %v", o.MaxSlippage)
+		}
+		if o.Size < minSize {
+			return fmt.Errorf("size %v is smaller than the minimum order size %v", o.Size, minSize)
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q (supported: %s)", o.Algorithm, algorithmNames())
+	}
+	return nil
+}
+
+func algorithmNames() string {
+	names := make([]string, len(Algorithms))
+	for i, v := range Algorithms {
+		names[i] = string(v)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Slices splits size into n slices rounded down to Step, the last one taking the remainder.
+func Slices(size float64, n int) []float64 {
+	slices := make([]float64, n)
+	slice := RoundSize(size / float64(n))
+	for i := 0; i < n-1; i++ {
+		slices[i] = slice
+	}
+	slices[n-1] = RoundSize(size - slice*float64(n-1))
+	return slices
+}
+
+// RoundSize rounds size down to Step.
+func RoundSize(size float64) float64 {
+	return math.Floor(size*stepsPerUnit+1e-6) / stepsPerUnit
+}
+
+// RoundLot rounds size down to a multiple of lot, and then to Step. A lot of 0 is no lot.
+func RoundLot(size float64, lot float64) float64 {
+	if lot > 0 {
+		size = math.Floor(size/lot+SizeEpsilon) * lot
+	}
+	return RoundSize(size)
+}
+
+// Round rounds v to the nearest Step, dropping float error such as 0.30000000000000004.
+func Round(v float64) float64 {
+	return math.Round(v*stepsPerUnit) / stepsPerUnit
+}
+
+// Within reports whether price is no worse than limit for the side. A zero limit is no limit.
+func Within(buy bool, price float64, limit float64) bool {
+	if limit == 0 {
+		return true
+	}
+	if buy {
+		return price <= limit
+	}
+	return price >= limit
+}
+
+// SlippageLimit returns the worst price Chase follows the market to, from the best price of its
+// side when it starts.
+func SlippageLimit(buy bool, arrival float64, maxSlippage float64) float64 {
+	if buy {
+		return arrival * (1 + maxSlippage/100)
+	}
+	return arrival * (1 - maxSlippage/100)
+}
+
+// ChasePrice returns the price Chase rests at: the best bid for buys and the best ask for sells,
+// so that the order adds liquidity. It is false when there is no such quote, or when the book is
+// crossed and the price would take liquidity.
+func ChasePrice(buy bool, bid float64, ask float64) (float64, bool) {
+	if bid <= 0 || ask <= 0 || bid >= ask {
+		return 0, false
+	}
+	if buy {
+		return bid, true
+	}
+	return ask, true
+}
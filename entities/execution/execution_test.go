@@ -0,0 +1,139 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrder_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   Order
+		wantErr bool
+	}{
+		{name: "TWAP", order: Order{Algorithm: TWAP, ProductCode: "BTC_JPY", Size: 0.1, Duration: time.Hour, Slices: 10}},
+		{name: "TWAP Slices Too Small", order: Order{Algorithm: TWAP, ProductCode: "BTC_JPY", Size: 0.01, Duration: time.Hour, Slices: 20}, wantErr: true},
+		{name: "TWAP Without Duration", order: Order{Algorithm: TWAP, ProductCode: "BTC_JPY", Size: 0.1, Slices: 10}, wantErr: true},
+		{name: "Iceberg", order: Order{Algorithm: Iceberg, ProductCode: "BTC_JPY", Size: 0.1, Display: 0.01, Price: 5000000}},
+		{name: "Iceberg Without Price", order: Order{Algorithm: Iceberg, ProductCode: "BTC_JPY", Size: 0.1, Display: 0.01}, wantErr: true},
+		{name: "Iceberg Display Too Large", order: Order{Algorithm: Iceberg, ProductCode: "BTC_JPY", Size: 0.1, Display: 0.2, Price: 5000000}, wantErr: true},
+		{name: "Chase", order: Order{Algorithm: Chase, ProductCode: "BTC_JPY", Size: 0.1, MaxSlippage: 0.5}},
+		{name: "Chase Too Small", order: Order{Algorithm: Chase, ProductCode: "BTC_JPY", Size: 0.0001}, wantErr: true},
+		{name: "Unknown Algorithm", order: Order{Algorithm: "vwap", ProductCode: "BTC_JPY", Size: 0.1}, wantErr: true},
+		{name: "No Size", order: Order{Algorithm: Chase, ProductCode: "BTC_JPY"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.order.Validate(0.001); (err != nil) != tt.wantErr {
+				t.Errorf("Order.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSlices(t *testing.T) {
+	tests := []struct {
+		name string
+		size float64
+		n    int
+		want []float64
+	}{
+		{name: "Even", size: 0.1, n: 4, want: []float64{0.025, 0.025, 0.025, 0.025}},
+		{name: "Remainder In The Last", size: 0.1, n: 3, want: []float64{0.03333333, 0.03333333, 0.03333334}},
+		{name: "One", size: 0.1, n: 1, want: []float64{0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slices(tt.size, tt.n)
+			for i := range got {
+				// Compare at the step, below the residue of floating point.
+				got[i] = float64(int64(got[i]/Step+0.5)) * Step
+			}
+			want := append([]float64{}, tt.want...)
+			for i := range want {
+				want[i] = float64(int64(want[i]/Step+0.5)) * Step
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Slices() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRoundLot(t *testing.T) {
+	tests := []struct {
+		name string
+		size float64
+		lot  float64
+		want float64
+	}{
+		{name: "No Lot", size: 0.123456789, want: 0.12345678},
+		{name: "Residue Below The Step", size: 0.29999999999999993, want: 0.3},
+		{name: "Lot", size: 1234, lot: 100, want: 1200},
+		{name: "Residue Below The Lot", size: 299.99999999999994, lot: 100, want: 300},
+		{name: "Crypto Lot", size: 0.0567, lot: 0.01, want: 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundLot(tt.size, tt.lot); got != tt.want {
+				t.Errorf("RoundLot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name string
+		v    float64
+		want float64
+	}{
+		{name: "Float Error", v: 0.1 + 0.2, want: 0.3},
+		{name: "Nearest", v: 0.123456789, want: 0.12345679},
+		{name: "Negative", v: -0.000000004, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Round(tt.v); got != tt.want {
+				t.Errorf("Round() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChasePrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		buy    bool
+		bid    float64
+		ask    float64
+		want   float64
+		wantOK bool
+	}{
+		{name: "Buy At The Bid", buy: true, bid: 100, ask: 101, want: 100, wantOK: true},
+		{name: "Sell At The Ask", buy: false, bid: 100, ask: 101, want: 101, wantOK: true},
+		{name: "Crossed Book", buy: true, bid: 101, ask: 101},
+		{name: "No Quote", buy: true, bid: 0, ask: 101},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ChasePrice(tt.buy, tt.bid, tt.ask)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ChasePrice() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSlippageLimit(t *testing.T) {
+	if got := SlippageLimit(true, 1000, 1); got != 1010 {
+		t.Errorf("SlippageLimit(buy) = %v, want 1010", got)
+	}
+	if got := SlippageLimit(false, 1000, 1); got != 990 {
+		t.Errorf("SlippageLimit(sell) = %v, want 990", got)
+	}
+	if !Within(true, 1010, 1010) || Within(true, 1011, 1010) || !Within(false, 990, 990) || Within(false, 989, 990) || !Within(true, 1e9, 0) {
+		t.Errorf("Within() is inconsistent with the limits")
+	}
+}
@@ -2,6 +2,7 @@ package bitflyer
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -9,31 +10,103 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sn1w/capital-go/config"
-	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/transport"
 )
 
+// DefaultEndpoint is the bitFlyer Lightning API endpoint.
+const DefaultEndpoint = "https://api.bitflyer.com"
+
+// HttpRequestDoer performs HTTP requests. *http.Client satisfies it.
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 type BitFlyer struct {
-	hc        *http.Client
+	hc        HttpRequestDoer
 	endPoint  string
 	apiKey    string
 	apiSecret string
+	limiter   *rateLimiter
+	retry     RetryPolicy
+	now       func() time.Time
+}
+
+// Option configures a BitFlyer client.
+type Option func(*BitFlyer)
+
+// WithEndpoint sets the API endpoint such as a proxy, a sandbox or a local stand-in.
+func WithEndpoint(endPoint string) Option {
+	return func(b *BitFlyer) {
+		b.endPoint = strings.TrimSuffix(endPoint, "/")
+	}
+}
+
+// WithHTTPClient sets the client used to send HTTP requests.
+func WithHTTPClient(doer HttpRequestDoer) Option {
+	return func(b *BitFlyer) {
+		b.hc = doer
+	}
+}
+
+// WithClock sets the clock used for signatures. Rate limiting keeps the real clock, as it sleeps
+// for real: a frozen clock would never refill its tokens.
+func WithClock(now func() time.Time) Option {
+	return func(b *BitFlyer) {
+		b.now = now
+	}
+}
+
+// clock returns the clock set by WithClock, or time.Now.
+func (b *BitFlyer) clock() func() time.Time {
+	if b.now == nil {
+		return time.Now
+	}
+	return b.now
 }
 
 func (b *BitFlyer) currentTimestamp() uint64 {
-	return uint64(time.Now().Unix())
+	return uint64(b.clock()().Unix())
 }
 
-// NewBitFlyer returns a Default BitFlyer client.
-func NewBitFlyer(cfg config.Config) *BitFlyer {
-	return &BitFlyer{
-		hc:        http.DefaultClient,
-		endPoint:  "https://api.bitflyer.com",
+// NewBitFlyer returns a BitFlyer client configured by cfg and opts.
+func NewBitFlyer(cfg config.Config, opts ...Option) *BitFlyer {
+	b := &BitFlyer{
+		hc:        transport.NewClient(),
+		endPoint:  DefaultEndpoint,
 		apiKey:    cfg.BitFlyerApiKey,
 		apiSecret: cfg.BitFlyerApiSecret,
+		retry:     DefaultRetryPolicy(),
+		now:       time.Now,
+	}
+	if cfg.BitFlyerApiEndpoint != "" {
+		WithEndpoint(cfg.BitFlyerApiEndpoint)(b)
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	b.limiter = newRateLimiter(DefaultRateLimits(), time.Now)
+
+	return b
+}
+
+// SetRateLimits replaces the client-side request limits. Classes missing from limits are not throttled.
+func (b *BitFlyer) SetRateLimits(limits map[EndpointClass]RateLimit) {
+	b.limiter = newRateLimiter(limits, time.Now)
+}
+
+// SetRetryPolicy replaces the retry policy for idempotent requests.
+func (b *BitFlyer) SetRetryPolicy(policy RetryPolicy) {
+	b.retry = policy
+}
+
+// RateLimitStatus returns the last X-RateLimit-* state the server reported for class,
+// so callers can slow down before hitting the limit.
+func (b *BitFlyer) RateLimitStatus(class EndpointClass) (RateLimitStatus, bool) {
+	return b.limiter.Status(class)
 }
 
 // ChildOrderType represents Order Type used in SendChildOrder.
@@ -55,6 +128,16 @@ const (
 )
 const MiniuteToExpireDefault = 43200
 
+// MinimumOrderSizes are the smallest sizes of orders by product code, as documented by bitFlyer.
+// Sizes above them may have up to 8 decimals.
+var MinimumOrderSizes = map[string]float64{
+	"BTC_JPY":    0.001,
+	"FX_BTC_JPY": 0.01,
+	"ETH_JPY":    0.01,
+	"ETH_BTC":    0.01,
+	"BCH_BTC":    0.01,
+}
+
 // TimeInForceType represents TimeInForce param used in SendChildOrder.
 // https://lightning.bitflyer.com/docs?lang=ja&_gl=1*1rx0t7g*_ga*MjI5Nzg4NDM1LjE2NjQ1OTA4MTU.*_ga_3VYMQNCVSM*MTY2NzQ0MzUyMy4xNi4wLjE2Njc0NDM1MjMuNjAuMC4w#%E6%96%B0%E8%A6%8F%E6%B3%A8%E6%96%87%E3%82%92%E5%87%BA%E3%81%99
 type TimeInForceType string
@@ -108,9 +191,173 @@ type BalanceResponse struct {
 
 type GetBalancesResponse = []BalanceResponse
 
-func request[REQ any, RES any](b *BitFlyer, method string, url string, body *REQ, useSecret bool) (*RES, error) {
-	path := b.endPoint + url
+type CollateralResponse struct {
+	Collateral        float64 `json:"collateral"`
+	OpenPositionPnl   float64 `json:"open_position_pnl"`
+	RequireCollateral float64 `json:"require_collateral"`
+	// KeepRate is the collateral maintenance rate, 1.5 for 150%. It is zero without positions.
+	KeepRate         float64 `json:"keep_rate"`
+	MarginCallAmount float64 `json:"margin_call_amount"`
+}
+
+type TickerResponse struct {
+	ProductCode     string  `json:"product_code"`
+	State           string  `json:"state"`
+	Timestamp       string  `json:"timestamp"`
+	TickId          int64   `json:"tick_id"`
+	BestBid         float64 `json:"best_bid"`
+	BestAsk         float64 `json:"best_ask"`
+	BestBidSize     float64 `json:"best_bid_size"`
+	BestAskSize     float64 `json:"best_ask_size"`
+	TotalBidDepth   float64 `json:"total_bid_depth"`
+	TotalAskDepth   float64 `json:"total_ask_depth"`
+	Ltp             float64 `json:"ltp"`
+	Volume          float64 `json:"volume"`
+	VolumeByProduct float64 `json:"volume_by_product"`
+}
+
+type ExecutionResponse struct {
+	Id                         int64          `json:"id"`
+	Side                       ChildOrderSide `json:"side"`
+	Price                      float64        `json:"price"`
+	Size                       float64        `json:"size"`
+	ExecDate                   string         `json:"exec_date"`
+	BuyChildOrderAcceptanceId  string         `json:"buy_child_order_acceptance_id"`
+	SellChildOrderAcceptanceId string         `json:"sell_child_order_acceptance_id"`
+}
+
+type GetExecutionsResponse = []ExecutionResponse
+
+// Pagination selects a page of list endpoints, which return the newest entries first.
+// Zero fields are omitted.
+type Pagination struct {
+	Count  int
+	Before int64
+	After  int64
+}
+
+func (p Pagination) query() string {
+	q := ""
+	if p.Count > 0 {
+		q += fmt.Sprintf("&count=%d", p.Count)
+	}
+	if p.Before > 0 {
+		q += fmt.Sprintf("&before=%d", p.Before)
+	}
+	if p.After > 0 {
+		q += fmt.Sprintf("&after=%d", p.After)
+	}
+	return q
+}
+
+// dateLayout is the layout of dates such as exec_date, which are in UTC without a zone.
+const dateLayout = "2006-01-02T15:04:05.999999999"
+
+// ParseTime parses a date of bitFlyer responses such as exec_date.
+func ParseTime(value string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, strings.TrimSuffix(value, "Z"), time.UTC)
+}
+
+// ChildOrderState represents child_order_state of GetChildOrders.
+type ChildOrderState string
+
+const (
+	ChildOrderStateActive    ChildOrderState = "ACTIVE"
+	ChildOrderStateCompleted ChildOrderState = "COMPLETED"
+	ChildOrderStateCanceled  ChildOrderState = "CANCELED"
+	ChildOrderStateExpired   ChildOrderState = "EXPIRED"
+	ChildOrderStateRejected  ChildOrderState = "REJECTED"
+)
+
+type ChildOrderResponse struct {
+	Id                     int64           `json:"id"`
+	ChildOrderId           string          `json:"child_order_id"`
+	ProductCode            string          `json:"product_code"`
+	Side                   ChildOrderSide  `json:"side"`
+	ChildOrderType         ChildOrderType  `json:"child_order_type"`
+	Price                  float64         `json:"price"`
+	AveragePrice           float64         `json:"average_price"`
+	Size                   float64         `json:"size"`
+	ChildOrderState        ChildOrderState `json:"child_order_state"`
+	ExpireDate             string          `json:"expire_date"`
+	ChildOrderDate         string          `json:"child_order_date"`
+	ChildOrderAcceptanceId string          `json:"child_order_acceptance_id"`
+	OutstandingSize        float64         `json:"outstanding_size"`
+	CancelSize             float64         `json:"cancel_size"`
+	ExecutedSize           float64         `json:"executed_size"`
+	TotalCommission        float64         `json:"total_commission"`
+}
+
+type GetChildOrdersResponse = []ChildOrderResponse
+
+type CancelOrderRequest struct {
+	ProductCode            string `json:"product_code"`
+	ChildOrderId           string `json:"child_order_id,omitempty"`
+	ChildOrderAcceptanceId string `json:"child_order_acceptance_id,omitempty"`
+}
+
+type PrivateExecutionResponse struct {
+	Id                     int64          `json:"id"`
+	ChildOrderId           string         `json:"child_order_id"`
+	Side                   ChildOrderSide `json:"side"`
+	Price                  float64        `json:"price"`
+	Size                   float64        `json:"size"`
+	Commission             float64        `json:"commission"`
+	ExecDate               string         `json:"exec_date"`
+	ChildOrderAcceptanceId string         `json:"child_order_acceptance_id"`
+}
+
+type GetPrivateExecutionsResponse = []PrivateExecutionResponse
+
+// CoinInResponse is a deposit of a crypto asset. Status is "PENDING" or "COMPLETED".
+type CoinInResponse struct {
+	Id           int64   `json:"id"`
+	OrderId      string  `json:"order_id"`
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Address      string  `json:"address"`
+	TxHash       string  `json:"tx_hash"`
+	Status       string  `json:"status"`
+	EventDate    string  `json:"event_date"`
+}
+
+type GetCoinInsResponse = []CoinInResponse
+
+// CoinOutResponse is a withdrawal of a crypto asset. Fee and AdditionalFee are charged in the asset,
+// on top of Amount.
+type CoinOutResponse struct {
+	Id            int64   `json:"id"`
+	OrderId       string  `json:"order_id"`
+	CurrencyCode  string  `json:"currency_code"`
+	Amount        float64 `json:"amount"`
+	Address       string  `json:"address"`
+	TxHash        string  `json:"tx_hash"`
+	Fee           float64 `json:"fee"`
+	AdditionalFee float64 `json:"additional_fee"`
+	Status        string  `json:"status"`
+	EventDate     string  `json:"event_date"`
+}
+
+type GetCoinOutsResponse = []CoinOutResponse
+
+// PositionResponse is an open position of a margin product such as FX_BTC_JPY.
+type PositionResponse struct {
+	ProductCode         string         `json:"product_code"`
+	Side                ChildOrderSide `json:"side"`
+	Price               float64        `json:"price"`
+	Size                float64        `json:"size"`
+	Commission          float64        `json:"commission"`
+	SwapPointAccumulate float64        `json:"swap_point_accumulate"`
+	RequireCollateral   float64        `json:"require_collateral"`
+	OpenDate            string         `json:"open_date"`
+	Leverage            float64        `json:"leverage"`
+	Pnl                 float64        `json:"pnl"`
+	Sfd                 float64        `json:"sfd"`
+}
+
+type GetPositionsResponse = []PositionResponse
 
+func request[REQ any, RES any](ctx context.Context, b *BitFlyer, method string, url string, body *REQ, useSecret bool) (*RES, error) {
 	var requestBody []byte
 	var err error
 
@@ -121,7 +368,54 @@ func request[REQ any, RES any](b *BitFlyer, method string, url string, body *REQ
 		}
 	}
 
-	req, err := http.NewRequest(method, path, bytes.NewBuffer(requestBody))
+	resp, res, err := b.send(ctx, method, url, requestBody, useSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp.StatusCode, res)
+	}
+
+	var result RES
+
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshall %s's response: %w", url, err)
+	}
+
+	return &result, nil
+}
+
+// send performs an API call with client-side rate limiting and retries, and returns the read response body.
+func (b *BitFlyer) send(ctx context.Context, method string, url string, requestBody []byte, useSecret bool) (*http.Response, []byte, error) {
+	classes := endpointClasses(method, url)
+
+	for attempt := 1; ; attempt++ {
+		if err := b.limiter.Wait(ctx, classes); err != nil {
+			return nil, nil, fmt.Errorf("got error from url %s: %w", url, err)
+		}
+
+		resp, res, err := b.sendOnce(ctx, method, url, requestBody, useSecret)
+		if resp != nil {
+			b.limiter.observe(classes[len(classes)-1], resp.Header)
+		}
+
+		if attempt >= b.retry.MaxAttempts || ctx.Err() != nil || !retryable(method, resp, err) {
+			return resp, res, err
+		}
+
+		if err := sleepContext(ctx, b.retry.backoff(attempt, resp)); err != nil {
+			return nil, nil, fmt.Errorf("got error from url %s: %w", url, err)
+		}
+	}
+}
+
+func (b *BitFlyer) sendOnce(ctx context.Context, method string, url string, requestBody []byte, useSecret bool) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.endPoint+url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to make request to %s, %w", url, err)
+	}
 
 	if useSecret {
 		timestamp := b.currentTimestamp()
@@ -134,47 +428,22 @@ func request[REQ any, RES any](b *BitFlyer, method string, url string, body *REQ
 		req.Header.Add("Content-Type", "application/json")
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request to %s, %w", url, err)
-	}
-
 	resp, err := b.hc.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("got error from url %s: %w", url, err)
+		return nil, nil, fmt.Errorf("got error from url %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	res, err := io.ReadAll(resp.Body)
-
-	if err != nil {
-		return nil, fmt.Errorf("error from read %s's  response: %w", url, err)
-	}
-
-	if resp.StatusCode >= 400 {
-		rootError := cerror.ErrUnknown
-		switch resp.StatusCode {
-		case 400:
-			rootError = cerror.ErrBadRequest
-		case 401:
-			rootError = cerror.ErrUnAuthorized
-		case 404:
-			rootError = cerror.ErrResourceNotFound
-		}
-		return nil, fmt.Errorf("%w: unexpected response %d. reason = %s", rootError, resp.StatusCode, string(res))
-	}
-
-	var result RES
-
-	err = json.Unmarshal(res, &result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshall %s's response: %w", url, err)
+		return resp, nil, fmt.Errorf("error from read %s's  response: %w", url, err)
 	}
 
-	return &result, nil
+	return resp, res, nil
 }
 
-func getRequest[T any](b *BitFlyer, url string, useSecret bool) (*T, error) {
-	return request[any, T](b, "GET", url, nil, useSecret)
+func getRequest[T any](ctx context.Context, b *BitFlyer, url string, useSecret bool) (*T, error) {
+	return request[any, T](ctx, b, "GET", url, nil, useSecret)
 }
 
 // generateSign makes a token used to call HTTP Private API.
@@ -190,8 +459,8 @@ func (b *BitFlyer) generateSign(method string, url string, requestBody string, u
 // GetAvaiableMarkets represents an API call to `GET /v1/markets`.
 //
 // https://lightning.bitflyer.com/docs?lang=ja#%E3%83%9E%E3%83%BC%E3%82%B1%E3%83%83%E3%83%88%E3%81%AE%E4%B8%80%E8%A6%A7
-func (b *BitFlyer) GetAvaiableMarkets() (GetMarketsResponse, error) {
-	response, err := getRequest[GetMarketsResponse](b, "/v1/markets", false)
+func (b *BitFlyer) GetAvaiableMarkets(ctx context.Context) (GetMarketsResponse, error) {
+	response, err := getRequest[GetMarketsResponse](ctx, b, "/v1/markets", false)
 	if err != nil {
 		return nil, err
 	}
@@ -202,9 +471,22 @@ func (b *BitFlyer) GetAvaiableMarkets() (GetMarketsResponse, error) {
 // GetBoard represents an API call to `GET /v1/board`.
 //
 // https://lightning.bitflyer.com/docs?lang=ja#%E6%9D%BF%E6%83%85%E5%A0%B1
-func (b *BitFlyer) GetBoard(productCode string) (*BoardResponse, error) {
+func (b *BitFlyer) GetBoard(ctx context.Context, productCode string) (*BoardResponse, error) {
 	url := fmt.Sprintf("/v1/board?product_code=%s", productCode)
-	response, err := getRequest[BoardResponse](b, url, false)
+	response, err := getRequest[BoardResponse](ctx, b, url, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetTicker represents an API call to `GET /v1/ticker`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#ticker
+func (b *BitFlyer) GetTicker(ctx context.Context, productCode string) (*TickerResponse, error) {
+	url := fmt.Sprintf("/v1/ticker?product_code=%s", productCode)
+	response, err := getRequest[TickerResponse](ctx, b, url, false)
 	if err != nil {
 		return nil, err
 	}
@@ -212,11 +494,24 @@ func (b *BitFlyer) GetBoard(productCode string) (*BoardResponse, error) {
 	return response, nil
 }
 
+// GetExecutions represents an API call to `GET /v1/executions`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E7%B4%84%E5%AE%9A%E5%B1%A5%E6%AD%B4
+func (b *BitFlyer) GetExecutions(ctx context.Context, productCode string, page Pagination) (GetExecutionsResponse, error) {
+	url := fmt.Sprintf("/v1/executions?product_code=%s%s", productCode, page.query())
+	response, err := getRequest[GetExecutionsResponse](ctx, b, url, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
+
 // GetBalance represents an API call to `GET /v1/me/balance`.
 //
 // https://lightning.bitflyer.com/docs?lang=ja#%E8%B3%87%E7%94%A3%E6%AE%8B%E9%AB%98%E3%82%92%E5%8F%96%E5%BE%97
-func (b *BitFlyer) GetBalance() (GetBalancesResponse, error) {
-	response, err := getRequest[GetBalancesResponse](b, "/v1/me/getbalance", true)
+func (b *BitFlyer) GetBalance(ctx context.Context) (GetBalancesResponse, error) {
+	response, err := getRequest[GetBalancesResponse](ctx, b, "/v1/me/getbalance", true)
 	if err != nil {
 		return nil, err
 	}
@@ -224,14 +519,123 @@ func (b *BitFlyer) GetBalance() (GetBalancesResponse, error) {
 	return *response, nil
 }
 
+// GetCollateral represents an API call to `GET /v1/me/getcollateral`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E8%A8%BC%E6%8B%A0%E9%87%91%E3%81%AE%E7%8A%B6%E6%85%8B%E3%82%92%E5%8F%96%E5%BE%97
+func (b *BitFlyer) GetCollateral(ctx context.Context) (*CollateralResponse, error) {
+	response, err := getRequest[CollateralResponse](ctx, b, "/v1/me/getcollateral", true)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 // SendOrder represents an API call to `POST /v1/me/sendchildorder`.
 //
 // https://lightning.bitflyer.com/docs?lang=ja#%E6%96%B0%E8%A6%8F%E6%B3%A8%E6%96%87%E3%82%92%E5%87%BA%E3%81%99
-func (b *BitFlyer) SendOrder(req SendOrderRequest) (*OrderResponse, error) {
-	response, err := request[SendOrderRequest, OrderResponse](b, "POST", "/v1/me/sendchildorder", &req, true)
+func (b *BitFlyer) SendOrder(ctx context.Context, req SendOrderRequest) (*OrderResponse, error) {
+	response, err := request[SendOrderRequest, OrderResponse](ctx, b, "POST", "/v1/me/sendchildorder", &req, true)
 	if err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
+
+// CancelOrder represents an API call to `POST /v1/me/cancelchildorder`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E6%B3%A8%E6%96%87%E3%82%92%E3%82%AD%E3%83%A3%E3%83%B3%E3%82%BB%E3%83%AB%E3%81%99%E3%82%8B
+func (b *BitFlyer) CancelOrder(ctx context.Context, req CancelOrderRequest) error {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshall request body: %w", err)
+	}
+
+	// A successful cancel has an empty body.
+	resp, res, err := b.send(ctx, "POST", "/v1/me/cancelchildorder", requestBody, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp.StatusCode, res)
+	}
+
+	return nil
+}
+
+// GetMyExecutions represents an API call to `GET /v1/me/getexecutions` for the executions of an order,
+// or of every order when childOrderAcceptanceId is empty. page selects the executions, newest first.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E7%B4%84%E5%AE%9A%E4%B8%80%E8%A6%A7%E3%82%92%E5%8F%96%E5%BE%97
+func (b *BitFlyer) GetMyExecutions(ctx context.Context, productCode string, childOrderAcceptanceId string, page Pagination) (GetPrivateExecutionsResponse, error) {
+	url := fmt.Sprintf("/v1/me/getexecutions?product_code=%s&child_order_acceptance_id=%s", productCode, childOrderAcceptanceId) + page.query()
+	response, err := getRequest[GetPrivateExecutionsResponse](ctx, b, url, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
+
+// GetChildOrders represents an API call to `GET /v1/me/getchildorders`. An empty state returns orders of every state.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E6%B3%A8%E6%96%87%E3%81%AE%E4%B8%80%E8%A6%A7%E3%82%92%E5%8F%96%E5%BE%97
+func (b *BitFlyer) GetChildOrders(ctx context.Context, productCode string, state ChildOrderState) (GetChildOrdersResponse, error) {
+	url := fmt.Sprintf("/v1/me/getchildorders?product_code=%s", productCode)
+	if state != "" {
+		url += fmt.Sprintf("&child_order_state=%s", state)
+	}
+	response, err := getRequest[GetChildOrdersResponse](ctx, b, url, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
+
+// GetCoinIns represents an API call to `GET /v1/me/getcoinins`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E4%BB%AE%E6%83%B3%E9%80%9A%E8%B2%A8%E9%A0%90%E5%85%A5%E5%B1%A5%E6%AD%B4
+func (b *BitFlyer) GetCoinIns(ctx context.Context, page Pagination) (GetCoinInsResponse, error) {
+	url := "/v1/me/getcoinins"
+	if q := page.query(); q != "" {
+		url += "?" + q[1:]
+	}
+	response, err := getRequest[GetCoinInsResponse](ctx, b, url, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
+
+// GetCoinOuts represents an API call to `GET /v1/me/getcoinouts`.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E4%BB%AE%E6%83%B3%E9%80%9A%E8%B2%A8%E9%80%81%E4%BB%98%E5%B1%A5%E6%AD%B4
+func (b *BitFlyer) GetCoinOuts(ctx context.Context, page Pagination) (GetCoinOutsResponse, error) {
+	url := "/v1/me/getcoinouts"
+	if q := page.query(); q != "" {
+		url += "?" + q[1:]
+	}
+	response, err := getRequest[GetCoinOutsResponse](ctx, b, url, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
+
+// GetPositions represents an API call to `GET /v1/me/getpositions`. Only margin products such as
+// FX_BTC_JPY have positions.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E5%BB%BA%E7%8E%89%E3%81%AE%E4%B8%80%E8%A6%A7%E3%82%92%E5%8F%96%E5%BE%97
+func (b *BitFlyer) GetPositions(ctx context.Context, productCode string) (GetPositionsResponse, error) {
+	url := fmt.Sprintf("/v1/me/getpositions?product_code=%s", productCode)
+	response, err := getRequest[GetPositionsResponse](ctx, b, url, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
@@ -1,13 +1,17 @@
 package bitflyer
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/sn1w/capital-go/config"
 	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/transport"
 )
 
 func TestBitFlyer_generateSign(t *testing.T) {
@@ -100,12 +104,22 @@ func TestBitFlyer_GetAvaiableMarkets(t *testing.T) {
 			name: "Failed By Unknown Status Code",
 			fields: fields{
 				endPoint:        "http://localhost",
-				apiResponseCode: 429,
+				apiResponseCode: 418,
 				apiResponse:     "I'm a teapot",
 			},
 			wantErr:       true,
 			expectedError: cerror.ErrUnknown,
 		},
+		{
+			name: "Failed By Rate Limit",
+			fields: fields{
+				endPoint:        "http://localhost",
+				apiResponseCode: 429,
+				apiResponse:     "Too Many Requests",
+			},
+			wantErr:       true,
+			expectedError: cerror.ErrRateLimited,
+		},
 	}
 	for _, tt := range tests {
 		httpmock.Activate()
@@ -122,7 +136,7 @@ func TestBitFlyer_GetAvaiableMarkets(t *testing.T) {
 				apiKey:    tt.fields.apiKey,
 				apiSecret: tt.fields.apiSecret,
 			}
-			got, err := b.GetAvaiableMarkets()
+			got, err := b.GetAvaiableMarkets(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyer.GetAvaiableMarkets() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -232,7 +246,7 @@ func TestBitFlyer_GetBoard(t *testing.T) {
 				apiKey:    tt.fields.apiKey,
 				apiSecret: tt.fields.apiSecret,
 			}
-			got, err := b.GetBoard(tt.args.productCode)
+			got, err := b.GetBoard(context.Background(), tt.args.productCode)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyer.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -308,7 +322,7 @@ func TestBitFlyer_GetBalance(t *testing.T) {
 				apiKey:    tt.fields.apiKey,
 				apiSecret: tt.fields.apiSecret,
 			}
-			got, err := b.GetBalance()
+			got, err := b.GetBalance(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyer.GetBalance() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -324,6 +338,110 @@ func TestBitFlyer_GetBalance(t *testing.T) {
 	}
 }
 
+func TestBitFlyer_GetTicker(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            *TickerResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse:     `{"product_code": "BTC_JPY", "best_bid": 4999000, "best_ask": 5001000, "ltp": 5000000, "volume": 12.5}`,
+			want:            &TickerResponse{ProductCode: "BTC_JPY", BestBid: 4999000, BestAsk: 5001000, Ltp: 5000000, Volume: 12.5},
+		},
+		{
+			name:            "Not Found",
+			apiResponseCode: 404,
+			apiResponse:     `{"status": -1, "error_message": "Not found"}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrResourceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/ticker?product_code=BTC_JPY",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetTicker(context.Background(), "BTC_JPY")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetTicker() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetTicker() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetTicker() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetCollateral(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            *CollateralResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse:     `{"collateral": 100000, "open_position_pnl": -715, "require_collateral": 19857, "keep_rate": 5.000, "margin_call_amount": 0}`,
+			want:            &CollateralResponse{Collateral: 100000, OpenPositionPnl: -715, RequireCollateral: 19857, KeepRate: 5},
+		},
+		{
+			name:            "UnAuthorized",
+			apiResponseCode: 401,
+			apiResponse:     "UnAuthorized",
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getcollateral",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetCollateral(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetCollateral() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetCollateral() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetCollateral() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBitFlyer_SendOrder(t *testing.T) {
 	type fields struct {
 		endPoint        string
@@ -384,7 +502,7 @@ func TestBitFlyer_SendOrder(t *testing.T) {
 				apiKey:    tt.fields.apiKey,
 				apiSecret: tt.fields.apiSecret,
 			}
-			got, err := b.SendOrder(tt.args.req)
+			got, err := b.SendOrder(context.Background(), tt.args.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyer.SendOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -399,3 +517,678 @@ func TestBitFlyer_SendOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestBitFlyer_requestTimeout(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost/v1/markets",
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	)
+
+	b := BitFlyer{
+		hc:       http.DefaultClient,
+		endPoint: "http://localhost",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := b.GetAvaiableMarkets(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("BitFlyer.GetAvaiableMarkets() error = %v, expectedError %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNewBitFlyer(t *testing.T) {
+	type args struct {
+		cfg  config.Config
+		opts []Option
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantEndPoint string
+	}{
+		{
+			name:         "default endpoint",
+			args:         args{cfg: config.Config{}},
+			wantEndPoint: DefaultEndpoint,
+		},
+		{
+			name:         "endpoint from config",
+			args:         args{cfg: config.Config{BitFlyerApiEndpoint: "http://localhost:8080/"}},
+			wantEndPoint: "http://localhost:8080",
+		},
+		{
+			name: "option overrides config",
+			args: args{
+				cfg:  config.Config{BitFlyerApiEndpoint: "http://localhost:8080"},
+				opts: []Option{WithEndpoint("http://proxy")},
+			},
+			wantEndPoint: "http://proxy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewBitFlyer(tt.args.cfg, tt.args.opts...); got.endPoint != tt.wantEndPoint {
+				t.Errorf("NewBitFlyer().endPoint = %v, want %v", got.endPoint, tt.wantEndPoint)
+			}
+		})
+	}
+}
+
+func TestNewBitFlyer_deterministicSign(t *testing.T) {
+	var header http.Header
+	hc := &http.Client{Transport: transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header
+		return httpmock.NewStringResponse(200, "[]"), nil
+	})}
+
+	b := NewBitFlyer(
+		config.Config{BitFlyerApiKey: "key", BitFlyerApiSecret: "hello"},
+		WithEndpoint("http://localhost"),
+		WithHTTPClient(hc),
+		WithClock(func() time.Time { return time.Unix(10000, 0) }),
+	)
+	if _, err := b.GetBalance(context.Background()); err != nil {
+		t.Fatalf("BitFlyer.GetBalance() error = %v", err)
+	}
+
+	if got := header.Get("ACCESS-TIMESTAMP"); got != "10000" {
+		t.Errorf("ACCESS-TIMESTAMP = %v, want 10000", got)
+	}
+	if got, want := header.Get("ACCESS-SIGN"), b.generateSign("GET", "/v1/me/getbalance", "", 10000); got != want {
+		t.Errorf("ACCESS-SIGN = %v, want %v", got, want)
+	}
+}
+
+func TestNewBitFlyer_frozenClockRateLimit(t *testing.T) {
+	hc := &http.Client{Transport: transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(200, "[]"), nil
+	})}
+
+	b := NewBitFlyer(
+		config.Config{BitFlyerApiKey: "key", BitFlyerApiSecret: "hello"},
+		WithEndpoint("http://localhost"),
+		WithHTTPClient(hc),
+		WithClock(func() time.Time { return time.Unix(10000, 0) }),
+	)
+	b.SetRateLimits(map[EndpointClass]RateLimit{EndpointPrivate: {Requests: 1, Period: 50 * time.Millisecond}})
+
+	// The limiter refills on the real clock, so the second request waits only for the period.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if _, err := b.GetBalance(ctx); err != nil {
+			t.Fatalf("BitFlyer.GetBalance() error = %v", err)
+		}
+	}
+}
+
+func TestBitFlyer_GetExecutions(t *testing.T) {
+	type args struct {
+		productCode string
+		page        Pagination
+	}
+	tests := []struct {
+		name            string
+		args            args
+		url             string
+		apiResponseCode int
+		apiResponse     string
+		want            GetExecutionsResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			args:            args{productCode: "BTC_JPY"},
+			url:             "http://localhost/v1/executions?product_code=BTC_JPY",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"id": 39287,
+						"side": "BUY",
+						"price": 31690,
+						"size": 27.04,
+						"exec_date": "2015-07-08T02:43:34.823",
+						"buy_child_order_acceptance_id": "JRF20150707-200203-452209",
+						"sell_child_order_acceptance_id": "JRF20150708-024334-060234"
+					}
+				]
+			`,
+			want: GetExecutionsResponse{
+				{
+					Id:                         39287,
+					Side:                       SideBuy,
+					Price:                      31690,
+					Size:                       27.04,
+					ExecDate:                   "2015-07-08T02:43:34.823",
+					BuyChildOrderAcceptanceId:  "JRF20150707-200203-452209",
+					SellChildOrderAcceptanceId: "JRF20150708-024334-060234",
+				},
+			},
+		},
+		{
+			name:            "Paging",
+			args:            args{productCode: "BTC_JPY", page: Pagination{Count: 500, Before: 39287}},
+			url:             "http://localhost/v1/executions?product_code=BTC_JPY&count=500&before=39287",
+			apiResponseCode: 200,
+			apiResponse:     `[]`,
+			want:            GetExecutionsResponse{},
+		},
+		{
+			name:            "Unexpected Product Code",
+			args:            args{productCode: "TST"},
+			url:             "http://localhost/v1/executions?product_code=TST",
+			apiResponseCode: 400,
+			apiResponse:     `{"status": -1, "error_message": "Invalid product", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", tt.url,
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetExecutions(context.Background(), tt.args.productCode, tt.args.page)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetExecutions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetExecutions() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetExecutions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Milliseconds", value: "2015-07-08T02:43:34.823", want: time.Date(2015, 7, 8, 2, 43, 34, 823000000, time.UTC)},
+		{name: "Seconds", value: "2015-07-08T02:43:34", want: time.Date(2015, 7, 8, 2, 43, 34, 0, time.UTC)},
+		{name: "Zulu", value: "2015-07-08T02:43:34.8Z", want: time.Date(2015, 7, 8, 2, 43, 34, 800000000, time.UTC)},
+		{name: "Invalid", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_CancelOrder(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse:     "",
+		},
+		{
+			name:            "Order Not Found",
+			apiResponseCode: 400,
+			apiResponse:     `{"status": -111, "error_message": "Order not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrOrderNotFound,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", "http://localhost/v1/me/cancelchildorder",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			err := b.CancelOrder(context.Background(), CancelOrderRequest{ProductCode: "BTC_JPY", ChildOrderAcceptanceId: "JRF1"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.CancelOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.CancelOrder() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetMyExecutions(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            GetPrivateExecutionsResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"id": 37233,
+						"child_order_id": "JOR20150707-060559-021935",
+						"side": "BUY",
+						"price": 33470,
+						"size": 0.01,
+						"commission": 0,
+						"exec_date": "2015-07-07T09:57:40.397",
+						"child_order_acceptance_id": "JRF1"
+					}
+				]
+			`,
+			want: GetPrivateExecutionsResponse{
+				{
+					Id:                     37233,
+					ChildOrderId:           "JOR20150707-060559-021935",
+					Side:                   SideBuy,
+					Price:                  33470,
+					Size:                   0.01,
+					ExecDate:               "2015-07-07T09:57:40.397",
+					ChildOrderAcceptanceId: "JRF1",
+				},
+			},
+		},
+		{
+			name:            "Unauthorized",
+			apiResponseCode: 401,
+			apiResponse:     `{"status": -500, "error_message": "Key not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getexecutions?product_code=BTC_JPY&child_order_acceptance_id=JRF1&count=10&before=37234",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetMyExecutions(context.Background(), "BTC_JPY", "JRF1", Pagination{Count: 10, Before: 37234})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetMyExecutions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetMyExecutions() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetMyExecutions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetChildOrders(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            GetChildOrdersResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"id": 138398,
+						"child_order_id": "JOR20150707-084555-022523",
+						"product_code": "BTC_JPY",
+						"side": "BUY",
+						"child_order_type": "LIMIT",
+						"price": 30000,
+						"average_price": 0,
+						"size": 0.1,
+						"child_order_state": "ACTIVE",
+						"expire_date": "2015-07-14T07:25:52",
+						"child_order_date": "2015-07-07T08:45:53",
+						"child_order_acceptance_id": "JRF20150707-084552-031927",
+						"outstanding_size": 0.1,
+						"cancel_size": 0,
+						"executed_size": 0,
+						"total_commission": 0
+					}
+				]
+			`,
+			want: GetChildOrdersResponse{
+				{
+					Id:                     138398,
+					ChildOrderId:           "JOR20150707-084555-022523",
+					ProductCode:            "BTC_JPY",
+					Side:                   SideBuy,
+					ChildOrderType:         ChildOrderTypeLimit,
+					Price:                  30000,
+					Size:                   0.1,
+					ChildOrderState:        ChildOrderStateActive,
+					ExpireDate:             "2015-07-14T07:25:52",
+					ChildOrderDate:         "2015-07-07T08:45:53",
+					ChildOrderAcceptanceId: "JRF20150707-084552-031927",
+					OutstandingSize:        0.1,
+				},
+			},
+		},
+		{
+			name:            "Unauthorized",
+			apiResponseCode: 401,
+			apiResponse:     `{"status": -500, "error_message": "Key not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getchildorders?product_code=BTC_JPY&child_order_state=ACTIVE",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetChildOrders(context.Background(), "BTC_JPY", ChildOrderStateActive)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetChildOrders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetChildOrders() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetChildOrders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetCoinIns(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            GetCoinInsResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"id": 100,
+						"order_id": "CDP20151227-024141-055555",
+						"currency_code": "BTC",
+						"amount": 0.00002,
+						"address": "1WriteySQufKZ2pVuM1oMhPrTtTVFq35j",
+						"tx_hash": "9f92ee65a176bb9545f7becb8706c50d07d4cee5ffca34d8be3ef11d411405ae",
+						"status": "COMPLETED",
+						"event_date": "2015-11-27T08:59:20.301"
+					}
+				]
+			`,
+			want: GetCoinInsResponse{
+				{
+					Id:           100,
+					OrderId:      "CDP20151227-024141-055555",
+					CurrencyCode: "BTC",
+					Amount:       0.00002,
+					Address:      "1WriteySQufKZ2pVuM1oMhPrTtTVFq35j",
+					TxHash:       "9f92ee65a176bb9545f7becb8706c50d07d4cee5ffca34d8be3ef11d411405ae",
+					Status:       "COMPLETED",
+					EventDate:    "2015-11-27T08:59:20.301",
+				},
+			},
+		},
+		{
+			name:            "Unauthorized",
+			apiResponseCode: 401,
+			apiResponse:     `{"status": -500, "error_message": "Key not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getcoinins?count=100",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetCoinIns(context.Background(), Pagination{Count: 100})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetCoinIns() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetCoinIns() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetCoinIns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetCoinOuts(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            GetCoinOutsResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"id": 500,
+						"order_id": "CWD20151224-014040-077777",
+						"currency_code": "BTC",
+						"amount": 0.1234,
+						"address": "1WriteySQufKZ2pVuM1oMhPrTtTVFq35j",
+						"tx_hash": "724c07dfd4044abcb390b0412c3e707dd5c4f373f0a52b3bd295ce32b478c60a",
+						"fee": 0.0005,
+						"additional_fee": 0.0001,
+						"status": "COMPLETED",
+						"event_date": "2015-12-24T01:40:40.397"
+					}
+				]
+			`,
+			want: GetCoinOutsResponse{
+				{
+					Id:            500,
+					OrderId:       "CWD20151224-014040-077777",
+					CurrencyCode:  "BTC",
+					Amount:        0.1234,
+					Address:       "1WriteySQufKZ2pVuM1oMhPrTtTVFq35j",
+					TxHash:        "724c07dfd4044abcb390b0412c3e707dd5c4f373f0a52b3bd295ce32b478c60a",
+					Fee:           0.0005,
+					AdditionalFee: 0.0001,
+					Status:        "COMPLETED",
+					EventDate:     "2015-12-24T01:40:40.397",
+				},
+			},
+		},
+		{
+			name:            "Unauthorized",
+			apiResponseCode: 401,
+			apiResponse:     `{"status": -500, "error_message": "Key not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getcoinouts",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetCoinOuts(context.Background(), Pagination{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetCoinOuts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetCoinOuts() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetCoinOuts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_GetPositions(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiResponseCode int
+		apiResponse     string
+		want            GetPositionsResponse
+		wantErr         bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			apiResponseCode: 200,
+			apiResponse: `
+				[
+					{
+						"product_code": "FX_BTC_JPY",
+						"side": "BUY",
+						"price": 36640,
+						"size": 5.0,
+						"commission": 0,
+						"swap_point_accumulate": -35,
+						"require_collateral": 120000,
+						"open_date": "2015-11-03T10:04:45.011",
+						"leverage": 3,
+						"pnl": 965,
+						"sfd": -0.5
+					}
+				]
+			`,
+			want: GetPositionsResponse{
+				{
+					ProductCode:         "FX_BTC_JPY",
+					Side:                SideBuy,
+					Price:               36640,
+					Size:                5.0,
+					SwapPointAccumulate: -35,
+					RequireCollateral:   120000,
+					OpenDate:            "2015-11-03T10:04:45.011",
+					Leverage:            3,
+					Pnl:                 965,
+					Sfd:                 -0.5,
+				},
+			},
+		},
+		{
+			name:            "Unauthorized",
+			apiResponseCode: 401,
+			apiResponse:     `{"status": -500, "error_message": "Key not found", "data": null}`,
+			wantErr:         true,
+			expectedError:   cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", "http://localhost/v1/me/getpositions?product_code=FX_BTC_JPY",
+			httpmock.NewStringResponder(tt.apiResponseCode, tt.apiResponse),
+		)
+
+		t.Run(tt.name, func(t *testing.T) {
+			b := BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+			}
+			got, err := b.GetPositions(context.Background(), "FX_BTC_JPY")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.GetPositions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.GetPositions() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetPositions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
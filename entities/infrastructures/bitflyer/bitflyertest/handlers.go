@@ -0,0 +1,426 @@
+package bitflyertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+)
+
+// The handlers below are called with s.mu held.
+
+func productCode(r *http.Request) string {
+	if v := r.URL.Query().Get("product_code"); v != "" {
+		return v
+	}
+	return "BTC_JPY"
+}
+
+func (s *Server) getMarkets(w http.ResponseWriter, r *http.Request, body []byte) {
+	res := bitflyer.GetMarketsResponse{}
+	for k := range s.boards {
+		res = append(res, bitflyer.MarketResponse{ProductCode: k, MarketType: "Spot"})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ProductCode < res[j].ProductCode })
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) board(w http.ResponseWriter, r *http.Request) (string, *bitflyer.BoardResponse, bool) {
+	code := productCode(r)
+	board, ok := s.boards[code]
+	if !ok {
+		writeError(w, http.StatusBadRequest, -1, fmt.Sprintf("product %s is not found", code))
+	}
+	return code, board, ok
+}
+
+func (s *Server) getBoard(w http.ResponseWriter, r *http.Request, body []byte) {
+	if _, board, ok := s.board(w, r); ok {
+		writeJSON(w, http.StatusOK, board)
+	}
+}
+
+func (s *Server) getTicker(w http.ResponseWriter, r *http.Request, body []byte) {
+	if code, board, ok := s.board(w, r); ok {
+		writeJSON(w, http.StatusOK, s.ticker(code, board))
+	}
+}
+
+func (s *Server) ticker(code string, board *bitflyer.BoardResponse) bitflyer.TickerResponse {
+	res := bitflyer.TickerResponse{
+		ProductCode: code,
+		State:       "RUNNING",
+		Timestamp:   s.now().UTC().Format(dateFormat),
+		TickId:      s.sequence,
+		Ltp:         board.MidPrice,
+	}
+	if len(board.Bids) > 0 {
+		res.BestBid, res.BestBidSize = board.Bids[0].Price, board.Bids[0].Size
+	}
+	if len(board.Asks) > 0 {
+		res.BestAsk, res.BestAskSize = board.Asks[0].Price, board.Asks[0].Size
+	}
+	for _, v := range board.Bids {
+		res.TotalBidDepth += v.Size
+	}
+	for _, v := range board.Asks {
+		res.TotalAskDepth += v.Size
+	}
+	if executions := s.executions[code]; len(executions) > 0 {
+		res.Ltp = executions[0].Price
+		for _, v := range executions {
+			res.Volume += v.Size
+		}
+		res.VolumeByProduct = res.Volume
+	}
+	return res
+}
+
+// paging reads count, before and after parameters as bitFlyer does.
+func paging(r *http.Request) (count int, before int64, after int64) {
+	q := r.URL.Query()
+	count, err := strconv.Atoi(q.Get("count"))
+	if err != nil || count <= 0 {
+		count = 100
+	}
+	before, _ = strconv.ParseInt(q.Get("before"), 10, 64)
+	after, _ = strconv.ParseInt(q.Get("after"), 10, 64)
+	return count, before, after
+}
+
+func inPage(id int64, before int64, after int64) bool {
+	return (before == 0 || id < before) && id > after
+}
+
+func (s *Server) getExecutions(w http.ResponseWriter, r *http.Request, body []byte) {
+	count, before, after := paging(r)
+
+	res := bitflyer.GetExecutionsResponse{}
+	for _, v := range s.executions[productCode(r)] {
+		if len(res) >= count {
+			break
+		}
+		if inPage(v.Id, before, after) {
+			res = append(res, v)
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getHealth(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "NORMAL"})
+}
+
+func (s *Server) getBalance(w http.ResponseWriter, r *http.Request, body []byte) {
+	reserved := s.reserved()
+
+	codes := make([]string, 0, len(s.balances))
+	for k := range s.balances {
+		codes = append(codes, k)
+	}
+	sort.Strings(codes)
+
+	res := bitflyer.GetBalancesResponse{}
+	for _, code := range codes {
+		res = append(res, bitflyer.BalanceResponse{
+			CurrencyCode: code,
+			Amount:       s.balances[code],
+			Available:    s.balances[code] - reserved[code],
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// getCollateral reports the JPY balance as collateral. The fake has no margin positions, so the keep rate is zero.
+func (s *Server) getCollateral(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeJSON(w, http.StatusOK, bitflyer.CollateralResponse{Collateral: s.balances["JPY"]})
+}
+
+// getCoinIns and getCoinOuts report no transfers: balances of the fake only move with orders.
+func (s *Server) getCoinIns(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeJSON(w, http.StatusOK, bitflyer.GetCoinInsResponse{})
+}
+
+func (s *Server) getCoinOuts(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeJSON(w, http.StatusOK, bitflyer.GetCoinOutsResponse{})
+}
+
+// getPositions reports no positions: the fake only trades spot products.
+func (s *Server) getPositions(w http.ResponseWriter, r *http.Request, body []byte) {
+	writeJSON(w, http.StatusOK, bitflyer.GetPositionsResponse{})
+}
+
+func currencies(productCode string) (string, string, bool) {
+	parts := strings.Split(productCode, "_")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// reserved returns the funds held by active orders per currency.
+func (s *Server) reserved() map[string]float64 {
+	res := map[string]float64{}
+	for _, v := range s.orders {
+		if v.ChildOrderState != bitflyer.ChildOrderStateActive {
+			continue
+		}
+		base, quote, _ := currencies(v.ProductCode)
+		if v.Side == bitflyer.SideBuy {
+			res[quote] += v.Price * v.OutstandingSize * (1 + s.cfg.FeeRate)
+		} else {
+			res[base] += v.OutstandingSize
+		}
+	}
+	return res
+}
+
+func (s *Server) sendChildOrder(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req bitflyer.SendOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, -1, "invalid request body")
+		return
+	}
+
+	board, ok := s.boards[req.ProductCode]
+	base, quote, pair := currencies(req.ProductCode)
+	switch {
+	case !ok || !pair:
+		writeError(w, http.StatusBadRequest, -1, fmt.Sprintf("product %s is not found", req.ProductCode))
+		return
+	case req.Side != bitflyer.SideBuy && req.Side != bitflyer.SideSell:
+		writeError(w, http.StatusBadRequest, -1, "side is invalid")
+		return
+	case req.ChildOrderType != bitflyer.ChildOrderTypeLimit && req.ChildOrderType != bitflyer.ChildOrderTypeMarket:
+		writeError(w, http.StatusBadRequest, -1, "child_order_type is invalid")
+		return
+	case req.Size <= 0:
+		writeError(w, http.StatusBadRequest, -110, "The minimum order size is invalid.")
+		return
+	case req.ChildOrderType == bitflyer.ChildOrderTypeLimit && req.Price <= 0:
+		writeError(w, http.StatusBadRequest, -106, "The price is invalid.")
+		return
+	}
+
+	reserved := s.reserved()
+	if req.Side == bitflyer.SideBuy {
+		price := req.Price
+		if req.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+			_, notional := sum(paper.Match(req.Side, 0, req.Size, board))
+			price = notional / req.Size
+		}
+		if req.Size*price*(1+s.cfg.FeeRate) > s.balances[quote]-reserved[quote] {
+			writeError(w, http.StatusBadRequest, -200, "Insufficient funds")
+			return
+		}
+	} else if req.Size > s.balances[base]-reserved[base] {
+		writeError(w, http.StatusBadRequest, -200, "Insufficient funds")
+		return
+	}
+
+	s.sequence++
+	now := s.now().UTC()
+	minuteToExpire := req.MinuteToExpire
+	if minuteToExpire <= 0 {
+		minuteToExpire = bitflyer.MiniuteToExpireDefault
+	}
+	order := &bitflyer.ChildOrderResponse{
+		Id:                     s.sequence,
+		ChildOrderId:           fmt.Sprintf("JOR%s-%06d", now.Format("20060102-150405"), s.sequence),
+		ProductCode:            req.ProductCode,
+		Side:                   req.Side,
+		ChildOrderType:         req.ChildOrderType,
+		Price:                  req.Price,
+		Size:                   req.Size,
+		ChildOrderState:        bitflyer.ChildOrderStateActive,
+		ExpireDate:             now.Add(time.Duration(minuteToExpire) * time.Minute).Format(dateFormat),
+		ChildOrderDate:         now.Format(dateFormat),
+		ChildOrderAcceptanceId: fmt.Sprintf("JRF%s-%06d", now.Format("20060102-150405"), s.sequence),
+		OutstandingSize:        req.Size,
+	}
+	s.orders = append(s.orders, order)
+
+	if req.TimeInForce == bitflyer.TimeInForceFOK {
+		if filled, _ := sum(paper.Match(req.Side, limitOf(order), req.Size, board)); filled < req.Size {
+			s.cancel(order)
+		}
+	}
+	if order.ChildOrderState == bitflyer.ChildOrderStateActive {
+		s.match(order)
+	}
+	if order.ChildOrderState == bitflyer.ChildOrderStateActive &&
+		(req.ChildOrderType == bitflyer.ChildOrderTypeMarket || req.TimeInForce == bitflyer.TimeInForceIOC) {
+		s.cancel(order)
+	}
+
+	writeJSON(w, http.StatusOK, bitflyer.OrderResponse{ChildOrderAcceptanceId: order.ChildOrderAcceptanceId})
+}
+
+func limitOf(order *bitflyer.ChildOrderResponse) float64 {
+	if order.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+		return 0
+	}
+	return order.Price
+}
+
+func sum(fills []paper.Fill) (size float64, notional float64) {
+	for _, v := range fills {
+		size += v.Size
+		notional += v.Price * v.Size
+	}
+	return size, notional
+}
+
+// match fills order against its board, consuming the liquidity of the board.
+func (s *Server) match(order *bitflyer.ChildOrderResponse) {
+	board := s.boards[order.ProductCode]
+	base, quote, _ := currencies(order.ProductCode)
+
+	for _, fill := range paper.Match(order.Side, limitOf(order), order.OutstandingSize, board) {
+		consume(board, order.Side, fill)
+
+		notional := fill.Price * fill.Size
+		commission := notional * s.cfg.FeeRate
+		if order.Side == bitflyer.SideBuy {
+			s.balances[base] += fill.Size
+			s.balances[quote] -= notional + commission
+		} else {
+			s.balances[base] -= fill.Size
+			s.balances[quote] += notional - commission
+		}
+
+		order.AveragePrice = (order.AveragePrice*order.ExecutedSize + notional) / (order.ExecutedSize + fill.Size)
+		order.ExecutedSize += fill.Size
+		order.OutstandingSize -= fill.Size
+		order.TotalCommission += commission
+
+		s.sequence++
+		execDate := s.now().UTC().Format(dateFormat)
+		execution := bitflyer.ExecutionResponse{
+			Id:       s.sequence,
+			Side:     order.Side,
+			Price:    fill.Price,
+			Size:     fill.Size,
+			ExecDate: execDate,
+		}
+		if order.Side == bitflyer.SideBuy {
+			execution.BuyChildOrderAcceptanceId = order.ChildOrderAcceptanceId
+		} else {
+			execution.SellChildOrderAcceptanceId = order.ChildOrderAcceptanceId
+		}
+		s.executions[order.ProductCode] = append(bitflyer.GetExecutionsResponse{execution}, s.executions[order.ProductCode]...)
+		s.myExecutions = append([]bitflyer.PrivateExecutionResponse{{
+			Id:                     s.sequence,
+			ChildOrderId:           order.ChildOrderId,
+			Side:                   order.Side,
+			Price:                  fill.Price,
+			Size:                   fill.Size,
+			Commission:             commission,
+			ExecDate:               execDate,
+			ChildOrderAcceptanceId: order.ChildOrderAcceptanceId,
+		}}, s.myExecutions...)
+	}
+
+	if order.OutstandingSize <= execution.SizeEpsilon {
+		order.OutstandingSize = 0
+		order.ChildOrderState = bitflyer.ChildOrderStateCompleted
+	}
+}
+
+// consume removes a filled size from the opposite side of board.
+func consume(board *bitflyer.BoardResponse, side bitflyer.ChildOrderSide, fill paper.Fill) {
+	levels := &board.Asks
+	if side == bitflyer.SideSell {
+		levels = &board.Bids
+	}
+
+	res := bitflyer.PriceResponses{}
+	for _, v := range *levels {
+		if v.Price == fill.Price {
+			v.Size -= fill.Size
+		}
+		if v.Size > execution.SizeEpsilon {
+			res = append(res, v)
+		}
+	}
+	*levels = res
+}
+
+func (s *Server) cancel(order *bitflyer.ChildOrderResponse) {
+	order.CancelSize = order.OutstandingSize
+	order.OutstandingSize = 0
+	order.ChildOrderState = bitflyer.ChildOrderStateCanceled
+}
+
+func (s *Server) cancelChildOrder(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req bitflyer.CancelOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, -1, "invalid request body")
+		return
+	}
+
+	for _, v := range s.orders {
+		if v.ProductCode != req.ProductCode || v.ChildOrderState != bitflyer.ChildOrderStateActive {
+			continue
+		}
+		if (req.ChildOrderId != "" && v.ChildOrderId == req.ChildOrderId) ||
+			(req.ChildOrderAcceptanceId != "" && v.ChildOrderAcceptanceId == req.ChildOrderAcceptanceId) {
+			s.cancel(v)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	writeError(w, http.StatusBadRequest, -111, "Order not found")
+}
+
+func (s *Server) getChildOrders(w http.ResponseWriter, r *http.Request, body []byte) {
+	q := r.URL.Query()
+	count, before, after := paging(r)
+
+	res := bitflyer.GetChildOrdersResponse{}
+	for i := len(s.orders) - 1; i >= 0 && len(res) < count; i-- {
+		v := s.orders[i]
+		switch {
+		case v.ProductCode != productCode(r),
+			!inPage(v.Id, before, after),
+			q.Get("child_order_state") != "" && string(v.ChildOrderState) != q.Get("child_order_state"),
+			q.Get("child_order_id") != "" && v.ChildOrderId != q.Get("child_order_id"),
+			q.Get("child_order_acceptance_id") != "" && v.ChildOrderAcceptanceId != q.Get("child_order_acceptance_id"):
+			continue
+		}
+		res = append(res, *v)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getMyExecutions(w http.ResponseWriter, r *http.Request, body []byte) {
+	q := r.URL.Query()
+	count, before, after := paging(r)
+
+	orders := map[string]string{}
+	for _, v := range s.orders {
+		orders[v.ChildOrderAcceptanceId] = v.ProductCode
+	}
+
+	res := bitflyer.GetPrivateExecutionsResponse{}
+	for _, v := range s.myExecutions {
+		if len(res) >= count {
+			break
+		}
+		switch {
+		case orders[v.ChildOrderAcceptanceId] != productCode(r),
+			!inPage(v.Id, before, after),
+			q.Get("child_order_acceptance_id") != "" && v.ChildOrderAcceptanceId != q.Get("child_order_acceptance_id"):
+			continue
+		}
+		res = append(res, v)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
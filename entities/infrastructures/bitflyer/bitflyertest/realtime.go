@@ -0,0 +1,275 @@
+package bitflyertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/internal/websocket"
+)
+
+// RealtimePath is the path of the fake Realtime API, JSON-RPC 2.0 over WebSocket.
+const RealtimePath = "/json-rpc"
+
+// realtimeInterval is how often subscribed channels are checked for changes.
+const realtimeInterval = 200 * time.Millisecond
+
+// subscriber is the state of a Realtime API connection.
+// Boards are published as snapshots whenever they change; lightning_board_ channels stay silent.
+type subscriber struct {
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	authed   bool
+	channels map[string]bool
+
+	boards     map[string]string
+	tickers    map[string]string
+	executions map[string]int64
+	orders     map[string]bitflyer.ChildOrderState
+	myExec     int64
+}
+
+type rpcRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Id     int             `json:"id"`
+}
+
+func (s *Server) serveRealtime(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sub := &subscriber{
+		conn:       conn,
+		channels:   map[string]bool{},
+		boards:     map[string]string{},
+		tickers:    map[string]string{},
+		executions: map[string]int64{},
+		orders:     map[string]bitflyer.ChildOrderState{},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req rpcRequest
+			if err := json.Unmarshal(raw, &req); err != nil {
+				return
+			}
+			s.handleRealtime(sub, req)
+		}
+	}()
+
+	ticker := time.NewTicker(realtimeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, v := range s.publish(sub) {
+				if err := conn.WriteMessage(v); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Server) handleRealtime(sub *subscriber, req rpcRequest) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	reply := func(result any, errMsg string) {
+		res := map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": result}
+		if errMsg != "" {
+			res = map[string]any{"jsonrpc": "2.0", "id": req.Id, "error": map[string]any{"code": -32000, "message": errMsg}}
+		}
+		raw, _ := json.Marshal(res)
+		sub.conn.WriteMessage(raw)
+	}
+
+	switch req.Method {
+	case "auth":
+		var params bitflyer.AuthParams
+		json.Unmarshal(req.Params, &params)
+		if params.ApiKey != s.cfg.APIKey || params.Signature != bitflyer.SignAuth(s.cfg.APISecret, params.Timestamp, params.Nonce) {
+			reply(nil, "invalid signature")
+			return
+		}
+		sub.authed = true
+		reply(true, "")
+	case "subscribe":
+		var params struct {
+			Channel string `json:"channel"`
+		}
+		json.Unmarshal(req.Params, &params)
+		if params.Channel == bitflyer.ChildOrderEventsChannel {
+			if !sub.authed {
+				reply(nil, "auth is required")
+				return
+			}
+			// Only events after the subscription are published.
+			s.mu.Lock()
+			for _, v := range s.orders {
+				sub.orders[v.ChildOrderAcceptanceId] = v.ChildOrderState
+			}
+			if len(s.myExecutions) > 0 {
+				sub.myExec = s.myExecutions[0].Id
+			}
+			s.mu.Unlock()
+		}
+		sub.channels[params.Channel] = true
+		reply(true, "")
+	case "unsubscribe":
+		var params struct {
+			Channel string `json:"channel"`
+		}
+		json.Unmarshal(req.Params, &params)
+		delete(sub.channels, params.Channel)
+		reply(true, "")
+	default:
+		reply(nil, "method not found")
+	}
+}
+
+// publish returns the notifications of changes since the last call.
+func (s *Server) publish(sub *subscriber) [][]byte {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := [][]byte{}
+	notify := func(channel string, message any) {
+		raw, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "channelMessage",
+			"params":  map[string]any{"channel": channel, "message": message},
+		})
+		messages = append(messages, raw)
+	}
+
+	for channel := range sub.channels {
+		switch {
+		case strings.HasPrefix(channel, "lightning_board_snapshot_"):
+			board, ok := s.boards[strings.TrimPrefix(channel, "lightning_board_snapshot_")]
+			if !ok {
+				continue
+			}
+			if raw, _ := json.Marshal(board); sub.boards[channel] != string(raw) {
+				sub.boards[channel] = string(raw)
+				notify(channel, board)
+			}
+		case strings.HasPrefix(channel, "lightning_ticker_"):
+			code := strings.TrimPrefix(channel, "lightning_ticker_")
+			board, ok := s.boards[code]
+			if !ok {
+				continue
+			}
+			ticker := s.ticker(code, board)
+			key := ticker
+			key.Timestamp = ""
+			if raw, _ := json.Marshal(key); sub.tickers[channel] != string(raw) {
+				sub.tickers[channel] = string(raw)
+				notify(channel, ticker)
+			}
+		case strings.HasPrefix(channel, "lightning_executions_"):
+			executions := s.executions[strings.TrimPrefix(channel, "lightning_executions_")]
+			// Executions are kept newest first and published oldest first.
+			res := bitflyer.GetExecutionsResponse{}
+			for i := len(executions) - 1; i >= 0; i-- {
+				if executions[i].Id > sub.executions[channel] {
+					res = append(res, executions[i])
+				}
+			}
+			if len(res) > 0 {
+				sub.executions[channel] = res[len(res)-1].Id
+				notify(channel, res)
+			}
+		case channel == bitflyer.ChildOrderEventsChannel:
+			if events := s.orderEvents(sub); len(events) > 0 {
+				notify(channel, events)
+			}
+		}
+	}
+	return messages
+}
+
+// orderEvents returns the child order events since the last call, with s.mu held.
+func (s *Server) orderEvents(sub *subscriber) []bitflyer.ChildOrderEvent {
+	now := s.now().UTC().Format(dateFormat)
+	events := []bitflyer.ChildOrderEvent{}
+	orders := map[string]*bitflyer.ChildOrderResponse{}
+
+	for _, v := range s.orders {
+		orders[v.ChildOrderAcceptanceId] = v
+		if _, ok := sub.orders[v.ChildOrderAcceptanceId]; ok {
+			continue
+		}
+		events = append(events, bitflyer.ChildOrderEvent{
+			ProductCode:            v.ProductCode,
+			ChildOrderId:           v.ChildOrderId,
+			ChildOrderAcceptanceId: v.ChildOrderAcceptanceId,
+			EventDate:              now,
+			EventType:              bitflyer.ChildOrderEventOrder,
+			ChildOrderType:         v.ChildOrderType,
+			Side:                   v.Side,
+			Price:                  v.Price,
+			Size:                   v.Size,
+			ExpireDate:             v.ExpireDate,
+		})
+	}
+
+	for i := len(s.myExecutions) - 1; i >= 0; i-- {
+		v := s.myExecutions[i]
+		if v.Id <= sub.myExec {
+			continue
+		}
+		sub.myExec = v.Id
+		event := bitflyer.ChildOrderEvent{
+			ChildOrderId:           v.ChildOrderId,
+			ChildOrderAcceptanceId: v.ChildOrderAcceptanceId,
+			EventDate:              v.ExecDate,
+			EventType:              bitflyer.ChildOrderEventExecution,
+			Side:                   v.Side,
+			Price:                  v.Price,
+			Size:                   v.Size,
+			ExecId:                 v.Id,
+			Commission:             v.Commission,
+		}
+		if order, ok := orders[v.ChildOrderAcceptanceId]; ok {
+			event.ProductCode = order.ProductCode
+			event.ChildOrderType = order.ChildOrderType
+			event.OutstandingSize = order.OutstandingSize
+		}
+		events = append(events, event)
+	}
+
+	for _, v := range s.orders {
+		previous := sub.orders[v.ChildOrderAcceptanceId]
+		sub.orders[v.ChildOrderAcceptanceId] = v.ChildOrderState
+		if v.ChildOrderState == bitflyer.ChildOrderStateCanceled && previous != bitflyer.ChildOrderStateCanceled {
+			events = append(events, bitflyer.ChildOrderEvent{
+				ProductCode:            v.ProductCode,
+				ChildOrderId:           v.ChildOrderId,
+				ChildOrderAcceptanceId: v.ChildOrderAcceptanceId,
+				EventDate:              now,
+				EventType:              bitflyer.ChildOrderEventCancel,
+				Price:                  v.Price,
+				Size:                   v.CancelSize,
+			})
+		}
+	}
+	return events
+}
@@ -0,0 +1,97 @@
+package bitflyertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// Duration is a time.Duration written as a string such as "250ms" in scenario files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Rule alters responses of matching requests to script failure scenarios.
+type Rule struct {
+	// Method matches the request method. Empty matches any method.
+	Method string `json:"method"`
+	// Path matches requests whose path starts with it. Empty matches any path.
+	Path string `json:"path"`
+	// Latency delays the response.
+	Latency Duration `json:"latency"`
+	// Status replaces the response with an error of this HTTP status. Zero serves the request normally.
+	Status int `json:"status"`
+	// Body is the error response body. A bitFlyer style error is used when empty.
+	Body string `json:"body"`
+	// Times limits how many requests the rule applies to. Zero applies it forever.
+	Times int `json:"times"`
+
+	applied int
+}
+
+func (r *Rule) matches(req *http.Request) bool {
+	if r.Times > 0 && r.applied >= r.Times {
+		return false
+	}
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	return strings.HasPrefix(req.URL.Path, r.Path)
+}
+
+// Scenario is the content of a scenario file.
+type Scenario struct {
+	// Boards replaces the initial boards per product code.
+	Boards map[string]bitflyer.BoardResponse `json:"boards"`
+	// Balances replaces the initial balances per currency code.
+	Balances map[string]float64 `json:"balances"`
+	Rules    []Rule             `json:"rules"`
+}
+
+// LoadScenario reads a scenario file.
+func LoadScenario(path string) (Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("can not read scenario %s: %w", path, err)
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(raw, &scenario); err != nil {
+		return Scenario{}, fmt.Errorf("can not parse scenario %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Apply sets the boards, balances and rules of scenario to cfg.
+func (s Scenario) Apply(cfg *Config) {
+	for k, v := range s.Boards {
+		if cfg.Boards == nil {
+			cfg.Boards = map[string]bitflyer.BoardResponse{}
+		}
+		cfg.Boards[k] = v
+	}
+	if s.Balances != nil {
+		cfg.Balances = s.Balances
+	}
+	cfg.Rules = append(cfg.Rules, s.Rules...)
+}
@@ -0,0 +1,343 @@
+// Package bitflyertest provides an in-memory stand-in of the bitFlyer Lightning HTTP API.
+//
+// The Server serves the public and private `/v1` endpoints, verifies request signatures
+// the same way bitFlyer does, and matches orders against in-memory boards.
+// The Realtime API is served at RealtimePath.
+// Rules inject latency, server errors and rate-limit responses.
+package bitflyertest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// Config is the initial state of a Server.
+type Config struct {
+	APIKey    string
+	APISecret string
+	// Boards are the initial boards per product code.
+	Boards map[string]bitflyer.BoardResponse
+	// Balances are the initial balances per currency code.
+	Balances map[string]float64
+	// FeeRate is charged in the quote currency on the notional of each fill.
+	FeeRate float64
+	Rules   []Rule
+}
+
+// DefaultConfig returns a configuration with BTC_JPY and ETH_JPY boards and 1,000,000 JPY.
+func DefaultConfig() Config {
+	return Config{
+		APIKey:    "fake-key",
+		APISecret: "fake-secret",
+		Boards: map[string]bitflyer.BoardResponse{
+			"BTC_JPY": ladder(5000000, 1000, 0.05),
+			"ETH_JPY": ladder(300000, 100, 0.5),
+		},
+		Balances: map[string]float64{"JPY": 1000000},
+	}
+}
+
+// ladder returns a board of 20 levels per side around mid.
+func ladder(mid float64, tick float64, size float64) bitflyer.BoardResponse {
+	board := bitflyer.BoardResponse{MidPrice: mid}
+	for i := 1; i <= 20; i++ {
+		board.Bids = append(board.Bids, bitflyer.PriceResponse{Price: mid - tick*float64(i), Size: size * float64(i)})
+		board.Asks = append(board.Asks, bitflyer.PriceResponse{Price: mid + tick*float64(i), Size: size * float64(i)})
+	}
+	return board
+}
+
+// signatureWindow is how far ACCESS-TIMESTAMP may be from the server clock.
+const signatureWindow = 5 * time.Minute
+
+// dateFormat is the format of dates in bitFlyer responses.
+const dateFormat = "2006-01-02T15:04:05.000"
+
+// Server is a fake bitFlyer API. It implements http.Handler.
+type Server struct {
+	mu  sync.Mutex
+	cfg Config
+	now func() time.Time
+
+	boards       map[string]*bitflyer.BoardResponse
+	balances     map[string]float64
+	orders       []*bitflyer.ChildOrderResponse
+	executions   map[string]bitflyer.GetExecutionsResponse
+	myExecutions []bitflyer.PrivateExecutionResponse
+	rules        []*Rule
+	sequence     int64
+	handlers     map[route]handlerFunc
+}
+
+// NewServer returns a Server with the initial state cfg.
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		cfg:        cfg,
+		now:        time.Now,
+		boards:     map[string]*bitflyer.BoardResponse{},
+		balances:   map[string]float64{},
+		executions: map[string]bitflyer.GetExecutionsResponse{},
+	}
+	s.handlers = s.routes()
+	for k, v := range cfg.Boards {
+		s.SetBoard(k, v)
+	}
+	for k, v := range cfg.Balances {
+		s.balances[k] = v
+	}
+	for _, v := range cfg.Rules {
+		s.AddRule(v)
+	}
+	return s
+}
+
+// NewTestServer starts a Server on a local port. The caller must Close the returned httptest.Server.
+func NewTestServer(cfg Config) (*Server, *httptest.Server) {
+	s := NewServer(cfg)
+	return s, httptest.NewServer(s)
+}
+
+// NewClient returns a bitFlyer client authenticated against the server listening at endPoint.
+func (s *Server) NewClient(endPoint string, opts ...bitflyer.Option) *bitflyer.BitFlyer {
+	return bitflyer.NewBitFlyer(config.Config{
+		BitFlyerApiKey:      s.cfg.APIKey,
+		BitFlyerApiSecret:   s.cfg.APISecret,
+		BitFlyerApiEndpoint: endPoint,
+	}, opts...)
+}
+
+// SetClock replaces the clock used for signature verification and dates.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// AddRule adds a rule altering responses. Rules are evaluated in the order they were added.
+func (s *Server) AddRule(rule Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, &rule)
+}
+
+// SetBoard replaces the board of productCode, and fills resting orders crossed by it.
+func (s *Server) SetBoard(productCode string, board bitflyer.BoardResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := bitflyer.BoardResponse{
+		MidPrice: board.MidPrice,
+		Bids:     append(bitflyer.PriceResponses{}, board.Bids...),
+		Asks:     append(bitflyer.PriceResponses{}, board.Asks...),
+	}
+	sort.Slice(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	sort.Slice(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+	s.boards[productCode] = &b
+
+	for _, v := range s.orders {
+		if v.ProductCode == productCode && v.ChildOrderState == bitflyer.ChildOrderStateActive {
+			s.match(v)
+		}
+	}
+}
+
+// Balances returns a copy of the current balances.
+func (s *Server) Balances() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := map[string]float64{}
+	for k, v := range s.balances {
+		res[k] = v
+	}
+	return res
+}
+
+// Orders returns a copy of all child orders.
+func (s *Server) Orders() bitflyer.GetChildOrdersResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := bitflyer.GetChildOrdersResponse{}
+	for _, v := range s.orders {
+		res = append(res, *v)
+	}
+	return res
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == RealtimePath {
+		s.serveRealtime(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, -1, "can not read request body")
+		return
+	}
+
+	if rule := s.rule(r); rule != nil {
+		if rule.Latency > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Duration(rule.Latency)):
+			}
+		}
+		if rule.Status != 0 {
+			writeRuleResponse(w, rule, s.clock())
+			return
+		}
+	}
+
+	handler := s.handlers[route{r.Method, r.URL.Path}]
+	if handler == nil {
+		writeError(w, http.StatusNotFound, -1, "not found")
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/v1/me/") {
+		if status, msg := s.verify(r, body); status != 0 {
+			writeError(w, http.StatusUnauthorized, status, msg)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	handler(w, r, body)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, body []byte)
+
+type route struct {
+	method string
+	path   string
+}
+
+func (s *Server) routes() map[route]handlerFunc {
+	return map[route]handlerFunc{
+		{"GET", "/v1/markets"}:              s.getMarkets,
+		{"GET", "/v1/getmarkets"}:           s.getMarkets,
+		{"GET", "/v1/board"}:                s.getBoard,
+		{"GET", "/v1/getboard"}:             s.getBoard,
+		{"GET", "/v1/ticker"}:               s.getTicker,
+		{"GET", "/v1/getticker"}:            s.getTicker,
+		{"GET", "/v1/executions"}:           s.getExecutions,
+		{"GET", "/v1/getexecutions"}:        s.getExecutions,
+		{"GET", "/v1/gethealth"}:            s.getHealth,
+		{"GET", "/v1/me/getbalance"}:        s.getBalance,
+		{"GET", "/v1/me/getcollateral"}:     s.getCollateral,
+		{"POST", "/v1/me/sendchildorder"}:   s.sendChildOrder,
+		{"POST", "/v1/me/cancelchildorder"}: s.cancelChildOrder,
+		{"GET", "/v1/me/getchildorders"}:    s.getChildOrders,
+		{"GET", "/v1/me/getexecutions"}:     s.getMyExecutions,
+		{"GET", "/v1/me/getcoinins"}:        s.getCoinIns,
+		{"GET", "/v1/me/getcoinouts"}:       s.getCoinOuts,
+		{"GET", "/v1/me/getpositions"}:      s.getPositions,
+	}
+}
+
+func (s *Server) clock() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+// rule returns the first rule matching r and counts it as applied.
+func (s *Server) rule(r *http.Request) *Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.rules {
+		if v.matches(r) {
+			v.applied++
+			return v
+		}
+	}
+	return nil
+}
+
+// verify checks the ACCESS-* headers. It returns a non-zero bitFlyer status on failure.
+func (s *Server) verify(r *http.Request, body []byte) (int, string) {
+	if r.Header.Get("ACCESS-KEY") != s.cfg.APIKey {
+		return -500, "Key not found"
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get("ACCESS-TIMESTAMP"), 10, 64)
+	if err != nil {
+		return -500, "Invalid timestamp"
+	}
+	if d := s.clock().Sub(time.Unix(timestamp, 0)); d > signatureWindow || d < -signatureWindow {
+		return -500, "Timestamp is out of range"
+	}
+
+	want := Sign(s.cfg.APISecret, r.Header.Get("ACCESS-TIMESTAMP"), r.Method, r.URL.RequestURI(), string(body))
+	if !hmac.Equal([]byte(r.Header.Get("ACCESS-SIGN")), []byte(want)) {
+		return -500, "Invalid signature"
+	}
+	return 0, ""
+}
+
+// Sign computes ACCESS-SIGN as documented by bitFlyer.
+// https://lightning.bitflyer.com/docs?lang=ja#%E8%AA%8D%E8%A8%BC
+func Sign(secret string, timestamp string, method string, path string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + method + path + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, httpStatus int, status int, msg string) {
+	writeJSON(w, httpStatus, map[string]any{
+		"status":        status,
+		"error_message": msg,
+		"data":          nil,
+	})
+}
+
+func writeRuleResponse(w http.ResponseWriter, rule *Rule, now time.Time) {
+	if rule.Status == http.StatusTooManyRequests {
+		w.Header().Set("X-RateLimit-Period", "300")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(now.Add(time.Second).Unix()))
+	}
+
+	if rule.Body != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rule.Status)
+		io.WriteString(w, rule.Body)
+		return
+	}
+
+	switch {
+	case rule.Status == http.StatusTooManyRequests:
+		writeError(w, rule.Status, -1, "Over API limit per period")
+	case rule.Status >= 500:
+		writeError(w, rule.Status, -1, "Internal server error")
+	default:
+		writeError(w, rule.Status, -1, http.StatusText(rule.Status))
+	}
+}
@@ -0,0 +1,192 @@
+package bitflyertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer/bitflyertest"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func newTestClient(t *testing.T, cfg bitflyertest.Config) (*bitflyertest.Server, *bitflyer.BitFlyer) {
+	t.Helper()
+	s, ts := bitflyertest.NewTestServer(cfg)
+	t.Cleanup(ts.Close)
+
+	client := s.NewClient(ts.URL)
+	client.SetRetryPolicy(bitflyer.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	return s, client
+}
+
+func TestServer_publicEndpoints(t *testing.T) {
+	_, client := newTestClient(t, bitflyertest.DefaultConfig())
+	ctx := context.Background()
+
+	markets, err := client.GetAvaiableMarkets(ctx)
+	if err != nil || len(markets) != 2 || markets[0].ProductCode != "BTC_JPY" {
+		t.Errorf("GetAvaiableMarkets() = %v, %v", markets, err)
+	}
+
+	board, err := client.GetBoard(ctx, "BTC_JPY")
+	if err != nil || board.MidPrice != 5000000 || len(board.Asks) != 20 || board.Asks[0].Price != 5001000 {
+		t.Errorf("GetBoard() = %v, %v", board, err)
+	}
+
+	if _, err := client.GetBoard(ctx, "UNKNOWN"); !errors.Is(err, cerror.ErrBadRequest) {
+		t.Errorf("GetBoard(UNKNOWN) error = %v, expectedErr %v", err, cerror.ErrBadRequest)
+	}
+}
+
+func TestServer_signature(t *testing.T) {
+	s, ts := bitflyertest.NewTestServer(bitflyertest.DefaultConfig())
+	defer ts.Close()
+	ctx := context.Background()
+
+	if _, err := s.NewClient(ts.URL).GetBalance(ctx); err != nil {
+		t.Errorf("GetBalance() with valid signature error = %v", err)
+	}
+
+	wrongSecret := bitflyer.NewBitFlyer(config.Config{
+		BitFlyerApiKey: "fake-key", BitFlyerApiSecret: "wrong", BitFlyerApiEndpoint: ts.URL,
+	})
+	if _, err := wrongSecret.GetBalance(ctx); !errors.Is(err, cerror.ErrUnAuthorized) {
+		t.Errorf("GetBalance() with wrong secret error = %v, expectedErr %v", err, cerror.ErrUnAuthorized)
+	}
+
+	staleClock := s.NewClient(ts.URL, bitflyer.WithClock(func() time.Time { return time.Now().Add(-time.Hour) }))
+	if _, err := staleClock.GetBalance(ctx); !errors.Is(err, cerror.ErrUnAuthorized) {
+		t.Errorf("GetBalance() with stale timestamp error = %v, expectedErr %v", err, cerror.ErrUnAuthorized)
+	}
+}
+
+func TestServer_orders(t *testing.T) {
+	s, client := newTestClient(t, bitflyertest.DefaultConfig())
+	ctx := context.Background()
+
+	res, err := client.SendOrder(ctx, bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideBuy, Size: 0.1,
+	})
+	if err != nil || res.ChildOrderAcceptanceId == "" {
+		t.Fatalf("SendOrder() = %v, %v", res, err)
+	}
+
+	// 0.05 at 5,001,000 and 0.05 at 5,002,000.
+	balances := s.Balances()
+	if balances["BTC"] != 0.1 || balances["JPY"] != 1000000-250050-250100 {
+		t.Errorf("balances after market buy = %v", balances)
+	}
+
+	board, _ := client.GetBoard(ctx, "BTC_JPY")
+	if board.Asks[0].Price != 5002000 || board.Asks[0].Size != 0.05 {
+		t.Errorf("liquidity is not consumed: best ask = %v", board.Asks[0])
+	}
+
+	// A resting sell is filled once a new board crosses it.
+	if _, err := client.SendOrder(ctx, bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideSell,
+		Price: 5100000, Size: 0.1, TimeInForce: bitflyer.TimeInForceGTC,
+	}); err != nil {
+		t.Fatalf("SendOrder() error = %v", err)
+	}
+	s.SetBoard("BTC_JPY", bitflyer.BoardResponse{MidPrice: 5150000, Bids: bitflyer.PriceResponses{{Price: 5110000, Size: 1}}})
+
+	orders := s.Orders()
+	if len(orders) != 2 || orders[1].ChildOrderState != bitflyer.ChildOrderStateCompleted || orders[1].AveragePrice != 5110000 {
+		t.Errorf("orders = %v", orders)
+	}
+
+	if _, err := client.SendOrder(ctx, bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideSell, Size: 1,
+	}); !errors.Is(err, cerror.ErrInsufficientFunds) {
+		t.Errorf("SendOrder() error = %v, expectedErr %v", err, cerror.ErrInsufficientFunds)
+	}
+}
+
+func TestServer_rules(t *testing.T) {
+	cfg := bitflyertest.DefaultConfig()
+	cfg.Rules = []bitflyertest.Rule{
+		{Method: "GET", Path: "/v1/markets", Status: 500, Times: 2},
+		{Method: "POST", Path: "/v1/me/sendchildorder", Status: 429},
+		{Path: "/v1/board", Latency: bitflyertest.Duration(time.Second)},
+	}
+	_, client := newTestClient(t, cfg)
+
+	if _, err := client.GetAvaiableMarkets(context.Background()); err != nil {
+		t.Errorf("GetAvaiableMarkets() should succeed after retries: %v", err)
+	}
+
+	_, err := client.SendOrder(context.Background(), bitflyer.SendOrderRequest{ProductCode: "BTC_JPY"})
+	if !errors.Is(err, cerror.ErrRateLimited) {
+		t.Errorf("SendOrder() error = %v, expectedErr %v", err, cerror.ErrRateLimited)
+	}
+	if status, ok := client.RateLimitStatus(bitflyer.EndpointOrder); !ok || status.Remaining != 0 {
+		t.Errorf("RateLimitStatus() = %v, %v", status, ok)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetBoard(ctx, "BTC_JPY"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetBoard() error = %v, expectedErr %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServer_realtime(t *testing.T) {
+	s, ts := bitflyertest.NewTestServer(bitflyertest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	realtime := bitflyer.NewRealtime(config.Config{
+		BitFlyerApiKey:           "fake-key",
+		BitFlyerApiSecret:        "fake-secret",
+		BitFlyerRealtimeEndpoint: "ws" + strings.TrimPrefix(ts.URL, "http") + bitflyertest.RealtimePath,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	channels := []string{
+		bitflyer.BoardSnapshotChannel("BTC_JPY"),
+		bitflyer.ExecutionsChannel("BTC_JPY"),
+		bitflyer.ChildOrderEventsChannel,
+	}
+	received := map[string]int{}
+	events := []bitflyer.ChildOrderEventType{}
+	err := realtime.Subscribe(ctx, channels, func(m bitflyer.ChannelMessage) {
+		received[m.Channel]++
+		switch m.Channel {
+		case bitflyer.BoardSnapshotChannel("BTC_JPY"):
+			// Trade once the first snapshot arrives.
+			if received[m.Channel] == 1 {
+				go client.SendOrder(ctx, bitflyer.SendOrderRequest{
+					ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideBuy, Size: 0.01,
+				})
+			}
+		case bitflyer.ChildOrderEventsChannel:
+			var v []bitflyer.ChildOrderEvent
+			json.Unmarshal(m.Message, &v)
+			for _, e := range v {
+				events = append(events, e.EventType)
+			}
+		}
+		if len(events) >= 2 && received[bitflyer.ExecutionsChannel("BTC_JPY")] > 0 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Realtime.Subscribe() error = %v", err)
+	}
+
+	want := []bitflyer.ChildOrderEventType{bitflyer.ChildOrderEventOrder, bitflyer.ChildOrderEventExecution}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("child order events = %v, want %v", events, want)
+	}
+	if received[bitflyer.ExecutionsChannel("BTC_JPY")] != 1 {
+		t.Errorf("received %v, want the execution of the order", received)
+	}
+}
@@ -0,0 +1,85 @@
+package bitflyer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// APIError is an error response of the bitFlyer API.
+// It unwraps to the sentinel error of its bitFlyer status, such as ErrInsufficientFunds, and also
+// matches the sentinel of its HTTP status, such as ErrBadRequest, with errors.Is.
+//
+// https://lightning.bitflyer.com/docs?lang=ja#%E3%82%A8%E3%83%A9%E3%83%BC
+type APIError struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int
+	// Status is the bitFlyer status code, which is negative on errors.
+	Status       int             `json:"status"`
+	ErrorMessage string          `json:"error_message"`
+	Data         json.RawMessage `json:"data"`
+	// Body is the raw response body, kept when it is not a JSON error response.
+	Body string
+
+	// cause is the sentinel of the bitFlyer status, or of the HTTP status when the bitFlyer one
+	// is unknown. httpCause is the sentinel of the HTTP status.
+	cause     error
+	httpCause error
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorMessage == "" {
+		return fmt.Sprintf("%v: unexpected response %d. reason = %s", e.cause, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("%v: unexpected response %d. status = %d, reason = %s", e.cause, e.StatusCode, e.Status, e.ErrorMessage)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is the sentinel of the HTTP status, so that callers checking
+// ErrBadRequest still match errors having a more specific sentinel.
+func (e *APIError) Is(target error) bool {
+	return target == e.httpCause
+}
+
+// statusErrors maps the documented bitFlyer status codes to sentinel errors.
+var statusErrors = map[int]error{
+	-200: cerror.ErrInsufficientFunds,
+	-205: cerror.ErrInsufficientFunds,
+	-208: cerror.ErrMarketHalted,
+	-111: cerror.ErrOrderNotFound,
+}
+
+// newAPIError decodes an error response body.
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode}
+	if err := json.Unmarshal(body, e); err != nil || e.ErrorMessage == "" {
+		e = &APIError{StatusCode: statusCode, Body: string(body)}
+	}
+	e.httpCause = httpError(statusCode)
+	e.cause = e.httpCause
+	if err, ok := statusErrors[e.Status]; ok {
+		e.cause = err
+	}
+
+	return e
+}
+
+// httpError returns the sentinel error of an HTTP status.
+func httpError(statusCode int) error {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return cerror.ErrBadRequest
+	case http.StatusUnauthorized:
+		return cerror.ErrUnAuthorized
+	case http.StatusNotFound:
+		return cerror.ErrResourceNotFound
+	case http.StatusTooManyRequests:
+		return cerror.ErrRateLimited
+	}
+	return cerror.ErrUnknown
+}
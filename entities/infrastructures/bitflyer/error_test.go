@@ -0,0 +1,90 @@
+package bitflyer
+
+import (
+	"errors"
+	"testing"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func Test_newAPIError(t *testing.T) {
+	type args struct {
+		statusCode int
+		body       string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantStatus  int
+		wantMessage string
+		expectedErr error
+	}{
+		{
+			name:        "insufficient funds by status",
+			args:        args{400, `{"status": -205, "error_message": "Margin amount is insufficient for this order.", "data": null}`},
+			wantStatus:  -205,
+			wantMessage: "Margin amount is insufficient for this order.",
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+		{
+			name:        "market halted by status",
+			args:        args{400, `{"status": -208, "error_message": "Order is not accepted", "data": null}`},
+			wantStatus:  -208,
+			wantMessage: "Order is not accepted",
+			expectedErr: cerror.ErrMarketHalted,
+		},
+		{
+			name:        "order not found by status",
+			args:        args{400, `{"status": -111, "error_message": "Order not found", "data": null}`},
+			wantStatus:  -111,
+			wantMessage: "Order not found",
+			expectedErr: cerror.ErrOrderNotFound,
+		},
+		{
+			name:        "undocumented status is not guessed from the message",
+			args:        args{400, `{"status": -999, "error_message": "Market is halted", "data": null}`},
+			wantStatus:  -999,
+			wantMessage: "Market is halted",
+			expectedErr: cerror.ErrBadRequest,
+		},
+		{
+			name:        "rate limited by HTTP status",
+			args:        args{429, `{"status": -1, "error_message": "Over API limit per period", "data": null}`},
+			wantStatus:  -1,
+			wantMessage: "Over API limit per period",
+			expectedErr: cerror.ErrRateLimited,
+		},
+		{
+			name:        "unknown status falls back to HTTP status",
+			args:        args{401, `{"status": -500, "error_message": "Invalid signature", "data": null}`},
+			wantStatus:  -500,
+			wantMessage: "Invalid signature",
+			expectedErr: cerror.ErrUnAuthorized,
+		},
+		{
+			name:        "not a JSON body",
+			args:        args{404, `<html></html>`},
+			expectedErr: cerror.ErrResourceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error = newAPIError(tt.args.statusCode, []byte(tt.args.body))
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("newAPIError() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
+			// The sentinel of the HTTP status still matches.
+			if tt.args.statusCode == 400 && !errors.Is(err, cerror.ErrBadRequest) {
+				t.Errorf("newAPIError() error = %v, expectedErr %v", err, cerror.ErrBadRequest)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("newAPIError() is not an *APIError")
+			}
+			if apiErr.Status != tt.wantStatus || apiErr.ErrorMessage != tt.wantMessage {
+				t.Errorf("newAPIError() = (%d, %s), want (%d, %s)", apiErr.Status, apiErr.ErrorMessage, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package bitflyer
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups API endpoints sharing the same bitFlyer request limit.
+// https://lightning.bitflyer.com/docs?lang=ja#api-%E5%88%B6%E9%99%90
+type EndpointClass string
+
+const (
+	// EndpointPublic is the class of the public HTTP API, limited per IP address.
+	EndpointPublic EndpointClass = "public"
+	// EndpointPrivate is the class of the `/v1/me/*` HTTP API, limited per API key.
+	EndpointPrivate EndpointClass = "private"
+	// EndpointOrder is the class of order and cancel endpoints, limited in addition to EndpointPrivate.
+	EndpointOrder EndpointClass = "order"
+)
+
+// RateLimit allows up to Requests calls in each Period.
+// A zero value disables the limit.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// DefaultRateLimits returns the limits published by bitFlyer.
+func DefaultRateLimits() map[EndpointClass]RateLimit {
+	return map[EndpointClass]RateLimit{
+		EndpointPublic:  {Requests: 500, Period: 5 * time.Minute},
+		EndpointPrivate: {Requests: 500, Period: 5 * time.Minute},
+		EndpointOrder:   {Requests: 300, Period: 5 * time.Minute},
+	}
+}
+
+// RateLimitStatus is the limit state reported by the X-RateLimit-* response headers.
+type RateLimitStatus struct {
+	Period    time.Duration
+	Remaining int
+	Reset     time.Time
+}
+
+var orderPaths = []string{
+	"/v1/me/sendchildorder",
+	"/v1/me/cancelchildorder",
+	"/v1/me/sendparentorder",
+	"/v1/me/cancelparentorder",
+	"/v1/me/cancelallchildorders",
+}
+
+// endpointClasses returns the classes a request to url is counted against, the most specific
+// one last.
+func endpointClasses(method string, url string) []EndpointClass {
+	if !strings.HasPrefix(url, "/v1/me/") {
+		return []EndpointClass{EndpointPublic}
+	}
+
+	if method == http.MethodPost {
+		for _, v := range orderPaths {
+			if strings.HasPrefix(url, v) {
+				return []EndpointClass{EndpointPrivate, EndpointOrder}
+			}
+		}
+	}
+	return []EndpointClass{EndpointPrivate}
+}
+
+// parseRateLimitStatus reads the X-RateLimit-* headers. It reports false when they are missing.
+func parseRateLimitStatus(h http.Header) (RateLimitStatus, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimitStatus{}, false
+	}
+
+	status := RateLimitStatus{Remaining: remaining}
+	if period, err := strconv.Atoi(h.Get("X-RateLimit-Period")); err == nil {
+		status.Period = time.Duration(period) * time.Second
+	}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		status.Reset = time.Unix(reset, 0)
+	}
+	return status, true
+}
+
+// tokenBucket is a client-side limiter allowing bursts of up to capacity requests.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+	now      func() time.Time
+}
+
+func newTokenBucket(limit RateLimit, now func() time.Time) *tokenBucket {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		return nil
+	}
+
+	return &tokenBucket{
+		capacity: float64(limit.Requests),
+		tokens:   float64(limit.Requests),
+		rate:     float64(limit.Requests) / limit.Period.Seconds(),
+		last:     now(),
+		now:      now,
+	}
+}
+
+// reserve takes a token if one is available, otherwise returns how long to wait for the next one.
+func (t *tokenBucket) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.capacity {
+		t.tokens = t.capacity
+	}
+	t.last = now
+
+	if t.tokens >= 1 {
+		t.tokens--
+		return 0
+	}
+	return time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+}
+
+// Wait blocks until a request is allowed or ctx is done.
+func (t *tokenBucket) Wait(ctx context.Context) error {
+	if t == nil {
+		return ctx.Err()
+	}
+
+	for {
+		wait := t.reserve()
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimiter throttles requests per endpoint class and keeps the limit state reported by the server.
+type rateLimiter struct {
+	buckets map[EndpointClass]*tokenBucket
+	now     func() time.Time
+
+	mu       sync.Mutex
+	observed map[EndpointClass]RateLimitStatus
+}
+
+func newRateLimiter(limits map[EndpointClass]RateLimit, now func() time.Time) *rateLimiter {
+	r := &rateLimiter{
+		buckets:  map[EndpointClass]*tokenBucket{},
+		now:      now,
+		observed: map[EndpointClass]RateLimitStatus{},
+	}
+	for class, limit := range limits {
+		r.buckets[class] = newTokenBucket(limit, now)
+	}
+	return r
+}
+
+// Wait blocks until a request counted against classes is allowed.
+// When the server reported an exhausted limit, it also waits for the reported reset time.
+func (r *rateLimiter) Wait(ctx context.Context, classes []EndpointClass) error {
+	if r == nil {
+		return ctx.Err()
+	}
+
+	for _, class := range classes {
+		if status, ok := r.Status(class); ok && status.Remaining <= 0 {
+			if wait := status.Reset.Sub(r.now()); wait > 0 {
+				if err := sleepContext(ctx, wait); err != nil {
+					return err
+				}
+			}
+		}
+		if err := r.buckets[class].Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe records the X-RateLimit-* headers of a response for class, the class of the endpoint
+// that made the request. The headers of an order request describe the order limit, not the
+// private one it is also counted against.
+func (r *rateLimiter) observe(class EndpointClass, h http.Header) {
+	if r == nil {
+		return
+	}
+
+	status, ok := parseRateLimitStatus(h)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observed[class] = status
+}
+
+// Status returns the last limit state reported for class.
+func (r *rateLimiter) Status(class EndpointClass) (RateLimitStatus, bool) {
+	if r == nil {
+		return RateLimitStatus{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.observed[class]
+	return status, ok
+}
@@ -0,0 +1,123 @@
+package bitflyer
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_endpointClasses(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+		want   []EndpointClass
+	}{
+		{method: "GET", url: "/v1/board?product_code=BTC_JPY", want: []EndpointClass{EndpointPublic}},
+		{method: "GET", url: "/v1/me/getbalance", want: []EndpointClass{EndpointPrivate}},
+		{method: "POST", url: "/v1/me/sendchildorder", want: []EndpointClass{EndpointPrivate, EndpointOrder}},
+		{method: "POST", url: "/v1/me/cancelchildorder", want: []EndpointClass{EndpointPrivate, EndpointOrder}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			if got := endpointClasses(tt.method, tt.url); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("endpointClasses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenBucket_reserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := newTokenBucket(RateLimit{Requests: 2, Period: 2 * time.Second}, func() time.Time { return now })
+
+	if got := bucket.reserve(); got != 0 {
+		t.Errorf("1st reserve() = %v, want 0", got)
+	}
+	if got := bucket.reserve(); got != 0 {
+		t.Errorf("2nd reserve() = %v, want 0", got)
+	}
+	if got := bucket.reserve(); got != time.Second {
+		t.Errorf("3rd reserve() = %v, want %v", got, time.Second)
+	}
+
+	now = now.Add(time.Second)
+	if got := bucket.reserve(); got != 0 {
+		t.Errorf("reserve() after refill = %v, want 0", got)
+	}
+}
+
+func TestTokenBucket_WaitCanceled(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{Requests: 1, Period: time.Hour}, time.Now)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBitFlyer_RateLimitStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost/v1/me/getbalance",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "[]")
+			resp.Header.Set("X-RateLimit-Period", "300")
+			resp.Header.Set("X-RateLimit-Remaining", "42")
+			resp.Header.Set("X-RateLimit-Reset", "1700000000")
+			return resp, nil
+		},
+	)
+
+	b := &BitFlyer{
+		hc:       http.DefaultClient,
+		endPoint: "http://localhost",
+		limiter:  newRateLimiter(DefaultRateLimits(), time.Now),
+	}
+
+	if _, ok := b.RateLimitStatus(EndpointPrivate); ok {
+		t.Fatalf("RateLimitStatus() reported a status before any request")
+	}
+
+	if _, err := b.GetBalance(context.Background()); err != nil {
+		t.Fatalf("BitFlyer.GetBalance() error = %v", err)
+	}
+
+	want := RateLimitStatus{Period: 300 * time.Second, Remaining: 42, Reset: time.Unix(1700000000, 0)}
+	got, ok := b.RateLimitStatus(EndpointPrivate)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("RateLimitStatus() = %v, %v, want %v", got, ok, want)
+	}
+	if _, ok := b.RateLimitStatus(EndpointPublic); ok {
+		t.Errorf("RateLimitStatus(EndpointPublic) should not be observed")
+	}
+
+	// The headers of an order request are the order limit, and leave the private one as it was.
+	httpmock.RegisterResponder("POST", "http://localhost/v1/me/sendchildorder",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `{"child_order_acceptance_id":"JRF20150707-050237-639234"}`)
+			resp.Header.Set("X-RateLimit-Period", "300")
+			resp.Header.Set("X-RateLimit-Remaining", "7")
+			resp.Header.Set("X-RateLimit-Reset", "1700000100")
+			return resp, nil
+		},
+	)
+	if _, err := b.SendOrder(context.Background(), SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: ChildOrderTypeMarket, Side: SideBuy, Size: 0.01}); err != nil {
+		t.Fatalf("BitFlyer.SendOrder() error = %v", err)
+	}
+	order := RateLimitStatus{Period: 300 * time.Second, Remaining: 7, Reset: time.Unix(1700000100, 0)}
+	if got, ok := b.RateLimitStatus(EndpointOrder); !ok || !reflect.DeepEqual(got, order) {
+		t.Errorf("RateLimitStatus(EndpointOrder) = %v, %v, want %v", got, ok, order)
+	}
+	if got, _ := b.RateLimitStatus(EndpointPrivate); !reflect.DeepEqual(got, want) {
+		t.Errorf("RateLimitStatus(EndpointPrivate) = %v after an order, want %v", got, want)
+	}
+}
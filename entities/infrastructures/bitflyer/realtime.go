@@ -0,0 +1,244 @@
+package bitflyer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/logging"
+	"github.com/sn1w/capital-go/internal/websocket"
+)
+
+// DefaultRealtimeEndpoint is the bitFlyer Realtime API endpoint, JSON-RPC 2.0 over WebSocket.
+//
+// https://bf-lightning-api.readme.io/docs/realtime-api
+const DefaultRealtimeEndpoint = "wss://ws.lightstream.bitflyer.com/json-rpc"
+
+// ChildOrderEventsChannel is the private channel of the events of the account's child orders.
+const ChildOrderEventsChannel = "child_order_events"
+
+// BoardSnapshotChannel is the channel of full boards of productCode.
+func BoardSnapshotChannel(productCode string) string {
+	return "lightning_board_snapshot_" + productCode
+}
+
+// BoardChannel is the channel of board updates of productCode. A zero size removes a price.
+func BoardChannel(productCode string) string {
+	return "lightning_board_" + productCode
+}
+
+// TickerChannel is the channel of tickers of productCode.
+func TickerChannel(productCode string) string {
+	return "lightning_ticker_" + productCode
+}
+
+// ExecutionsChannel is the channel of executions of productCode.
+func ExecutionsChannel(productCode string) string {
+	return "lightning_executions_" + productCode
+}
+
+// ChannelMessage is a message received on a subscribed channel.
+// Message is a BoardResponse, a TickerResponse, a GetExecutionsResponse
+// or a []ChildOrderEvent depending on the channel.
+type ChannelMessage struct {
+	Channel string          `json:"channel"`
+	Message json.RawMessage `json:"message"`
+}
+
+// ChildOrderEventType represents event_type of child_order_events.
+type ChildOrderEventType string
+
+const (
+	ChildOrderEventOrder        ChildOrderEventType = "ORDER"
+	ChildOrderEventOrderFailed  ChildOrderEventType = "ORDER_FAILED"
+	ChildOrderEventCancel       ChildOrderEventType = "CANCEL"
+	ChildOrderEventCancelFailed ChildOrderEventType = "CANCEL_FAILED"
+	ChildOrderEventExecution    ChildOrderEventType = "EXECUTION"
+	ChildOrderEventExpire       ChildOrderEventType = "EXPIRE"
+)
+
+type ChildOrderEvent struct {
+	ProductCode            string              `json:"product_code"`
+	ChildOrderId           string              `json:"child_order_id"`
+	ChildOrderAcceptanceId string              `json:"child_order_acceptance_id"`
+	EventDate              string              `json:"event_date"`
+	EventType              ChildOrderEventType `json:"event_type"`
+	ChildOrderType         ChildOrderType      `json:"child_order_type,omitempty"`
+	Side                   ChildOrderSide      `json:"side,omitempty"`
+	Price                  float64             `json:"price,omitempty"`
+	Size                   float64             `json:"size,omitempty"`
+	ExpireDate             string              `json:"expire_date,omitempty"`
+	Reason                 string              `json:"reason,omitempty"`
+	ExecId                 int64               `json:"exec_id,omitempty"`
+	Commission             float64             `json:"commission,omitempty"`
+	OutstandingSize        float64             `json:"outstanding_size,omitempty"`
+}
+
+// rpcMessage is a JSON-RPC 2.0 request, response or notification.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Id      int             `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// AuthParams are the params of the auth method.
+type AuthParams struct {
+	ApiKey    string `json:"api_key"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// SignAuth computes the signature of the auth method, HMAC-SHA256 of timestamp + nonce.
+func SignAuth(secret string, timestamp int64, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprint(timestamp) + nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// realtimeTimeout is how long a connection may stay silent before it is considered dead.
+const realtimeTimeout = 90 * time.Second
+
+// realtimePing is the interval of pings keeping a connection alive.
+const realtimePing = 30 * time.Second
+
+// Realtime is a client of the bitFlyer Realtime API.
+type Realtime struct {
+	endPoint  string
+	apiKey    string
+	apiSecret string
+	now       func() time.Time
+}
+
+// NewRealtime returns a Realtime client. BitFlyerRealtimeEndpoint overrides DefaultRealtimeEndpoint.
+func NewRealtime(cfg config.Config) *Realtime {
+	r := &Realtime{
+		endPoint:  DefaultRealtimeEndpoint,
+		apiKey:    cfg.BitFlyerApiKey,
+		apiSecret: cfg.BitFlyerApiSecret,
+		now:       time.Now,
+	}
+	if cfg.BitFlyerRealtimeEndpoint != "" {
+		r.endPoint = cfg.BitFlyerRealtimeEndpoint
+	}
+	return r
+}
+
+// Subscribe subscribes channels and calls handler with each message until ctx is cancelled
+// or the connection fails. Private channels such as ChildOrderEventsChannel authenticate first.
+// The caller reconnects by calling it again; messages in between are lost.
+func (r *Realtime) Subscribe(ctx context.Context, channels []string, handler func(ChannelMessage)) error {
+	conn, err := websocket.Dial(ctx, r.endPoint, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(realtimePing)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// Unblock ReadMessage.
+				conn.SetReadDeadline(time.Unix(1, 0))
+				return
+			case <-ticker.C:
+				conn.Ping()
+			}
+		}
+	}()
+
+	id := 0
+	call := func(method string, params any) error {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		id++
+		msg, err := json.Marshal(rpcMessage{JSONRPC: "2.0", Method: method, Params: raw, Id: id})
+		if err != nil {
+			return err
+		}
+		logging.Default().Debug("realtime request", "method", method, "id", id)
+		return conn.WriteMessage(msg)
+	}
+
+	private := false
+	for _, v := range channels {
+		if !strings.HasPrefix(v, "lightning_") {
+			private = true
+		}
+	}
+	authId := 0
+	if private {
+		if r.apiKey == "" || r.apiSecret == "" {
+			return fmt.Errorf("%w: private channels need BITFLYER_API_KEY and BITFLYER_API_SECRET", cerror.ErrUnAuthorized)
+		}
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		params := AuthParams{ApiKey: r.apiKey, Timestamp: r.now().UnixMilli(), Nonce: hex.EncodeToString(nonce)}
+		params.Signature = SignAuth(r.apiSecret, params.Timestamp, params.Nonce)
+		if err := call("auth", params); err != nil {
+			return err
+		}
+		authId = id
+	}
+	for _, v := range channels {
+		if err := call("subscribe", map[string]string{"channel": v}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		conn.SetReadDeadline(r.now().Add(realtimeTimeout))
+		raw, err := conn.ReadMessage()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, websocket.ErrClosed) {
+			return fmt.Errorf("realtime connection closed by the server: %w", err)
+		}
+		if err != nil {
+			return fmt.Errorf("realtime connection failed: %w", err)
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return fmt.Errorf("failed to parse realtime message: %w", err)
+		}
+		switch {
+		case msg.Error != nil && msg.Id == authId && authId != 0:
+			return fmt.Errorf("%w: realtime auth failed: %s", cerror.ErrUnAuthorized, msg.Error.Message)
+		case msg.Error != nil:
+			return fmt.Errorf("realtime request %d failed: %s", msg.Id, msg.Error.Message)
+		case msg.Method == "channelMessage":
+			var m ChannelMessage
+			if err := json.Unmarshal(msg.Params, &m); err != nil {
+				return fmt.Errorf("failed to parse realtime message: %w", err)
+			}
+			handler(m)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package bitflyer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/config"
+	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/websocket"
+)
+
+// realtimeServer answers auth with the secret "secret" and publishes one message per subscribed channel.
+func realtimeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		authed := false
+		for {
+			raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req rpcMessage
+			json.Unmarshal(raw, &req)
+
+			var res any
+			switch req.Method {
+			case "auth":
+				var params AuthParams
+				json.Unmarshal(req.Params, &params)
+				if params.Signature != SignAuth("secret", params.Timestamp, params.Nonce) {
+					res = map[string]any{"jsonrpc": "2.0", "id": req.Id, "error": map[string]any{"code": -32000, "message": "invalid signature"}}
+					break
+				}
+				authed = true
+				res = map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": true}
+			case "subscribe":
+				var params struct{ Channel string }
+				json.Unmarshal(req.Params, &params)
+				if params.Channel == ChildOrderEventsChannel && !authed {
+					res = map[string]any{"jsonrpc": "2.0", "id": req.Id, "error": map[string]any{"code": -32000, "message": "not authenticated"}}
+					break
+				}
+				raw, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": true})
+				conn.WriteMessage(raw)
+				res = map[string]any{
+					"jsonrpc": "2.0",
+					"method":  "channelMessage",
+					"params":  map[string]any{"channel": params.Channel, "message": map[string]any{"mid_price": 100}},
+				}
+			}
+			raw, _ = json.Marshal(res)
+			conn.WriteMessage(raw)
+		}
+	}))
+}
+
+func TestRealtime_Subscribe(t *testing.T) {
+	server := realtimeServer(t)
+	defer server.Close()
+	endPoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name          string
+		secret        string
+		channels      []string
+		want          []string
+		expectedError error
+	}{
+		{
+			name:     "Public Channels",
+			channels: []string{BoardSnapshotChannel("BTC_JPY"), TickerChannel("BTC_JPY")},
+			want:     []string{"lightning_board_snapshot_BTC_JPY", "lightning_ticker_BTC_JPY"},
+		},
+		{
+			name:     "Private Channel",
+			secret:   "secret",
+			channels: []string{ChildOrderEventsChannel},
+			want:     []string{"child_order_events"},
+		},
+		{
+			name:          "Invalid Secret",
+			secret:        "wrong",
+			channels:      []string{ChildOrderEventsChannel},
+			expectedError: cerror.ErrUnAuthorized,
+		},
+		{
+			name:          "Private Channel Without Keys",
+			channels:      []string{ChildOrderEventsChannel},
+			expectedError: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{BitFlyerRealtimeEndpoint: endPoint, BitFlyerApiSecret: tt.secret}
+			if tt.secret != "" {
+				cfg.BitFlyerApiKey = "key"
+			}
+			r := NewRealtime(cfg)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got := []string{}
+			err := r.Subscribe(ctx, tt.channels, func(m ChannelMessage) {
+				got = append(got, m.Channel)
+				if len(got) == len(tt.want) {
+					cancel()
+				}
+			})
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("Realtime.Subscribe() error = %v, expectedError %v", err, tt.expectedError)
+				}
+				return
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Realtime.Subscribe() error = %v, want %v", err, context.Canceled)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Realtime.Subscribe() received %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package bitflyer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func TestBitFlyer_retry(t *testing.T) {
+	type response struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name          string
+		method        string
+		responses     []response
+		wantCalls     int
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name:      "GET is retried after 5xx",
+			method:    "GET",
+			responses: []response{{500, "error"}, {200, "[]"}},
+			wantCalls: 2,
+		},
+		{
+			name:      "GET is retried after 429",
+			method:    "GET",
+			responses: []response{{429, "slow down"}, {503, "error"}, {200, "[]"}},
+			wantCalls: 3,
+		},
+		{
+			name:          "GET gives up after MaxAttempts",
+			method:        "GET",
+			responses:     []response{{500, "error"}, {500, "error"}, {500, "error"}, {200, "[]"}},
+			wantCalls:     3,
+			wantErr:       true,
+			expectedError: cerror.ErrUnknown,
+		},
+		{
+			name:          "GET is not retried after 4xx",
+			method:        "GET",
+			responses:     []response{{400, "bad"}, {200, "[]"}},
+			wantCalls:     1,
+			wantErr:       true,
+			expectedError: cerror.ErrBadRequest,
+		},
+		{
+			name:          "POST is never retried",
+			method:        "POST",
+			responses:     []response{{500, "error"}, {200, `{"child_order_acceptance_id": "test"}`}},
+			wantCalls:     1,
+			wantErr:       true,
+			expectedError: cerror.ErrUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			calls := 0
+			responder := func(req *http.Request) (*http.Response, error) {
+				res := tt.responses[calls]
+				calls++
+				return httpmock.NewStringResponse(res.code, res.body), nil
+			}
+			httpmock.RegisterResponder("GET", "http://localhost/v1/markets", responder)
+			httpmock.RegisterResponder("POST", "http://localhost/v1/me/sendchildorder", responder)
+
+			b := &BitFlyer{
+				hc:       http.DefaultClient,
+				endPoint: "http://localhost",
+				retry:    RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+			}
+
+			var err error
+			if tt.method == "GET" {
+				_, err = b.GetAvaiableMarkets(context.Background())
+			} else {
+				_, err = b.SendOrder(context.Background(), SendOrderRequest{})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyer.request() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("BitFlyer.request() error = %v, expectedError %v", err, tt.expectedError)
+				return
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("BitFlyer.request() calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	for retry, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if got := p.backoff(retry, nil); got <= 0 || got > limit {
+				t.Fatalf("RetryPolicy.backoff(%d) = %v, want (0, %v]", retry, got, limit)
+			}
+		}
+	}
+
+	resp := httpmock.NewStringResponse(429, "")
+	resp.Header.Set("Retry-After", "2")
+	long := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+	if got := long.backoff(1, resp); got != 2*time.Second {
+		t.Errorf("RetryPolicy.backoff() with Retry-After = %v, want %v", got, 2*time.Second)
+	}
+	// Retry-After is capped by MaxDelay.
+	if got := p.backoff(1, resp); got != 300*time.Millisecond {
+		t.Errorf("RetryPolicy.backoff() with a long Retry-After = %v, want %v", got, 300*time.Millisecond)
+	}
+}
@@ -0,0 +1,189 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// PageSize is the count of executions requested per page, the maximum of bitFlyer.
+const PageSize = 500
+
+// ExecutionsClient provides the execution history. *bitflyer.BitFlyer implements it
+// and throttles requests with its rate limiter.
+type ExecutionsClient interface {
+	GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error)
+}
+
+var _ ExecutionsClient = &bitflyer.BitFlyer{}
+
+// Fetcher downloads the execution history of a product into a Store.
+type Fetcher struct {
+	client ExecutionsClient
+	store  *Store
+	// OnPage is called after each stored page, to report progress.
+	OnPage func(p *Progress, fetched int)
+}
+
+// NewFetcher returns a Fetcher storing executions of client into store.
+func NewFetcher(client ExecutionsClient, store *Store) *Fetcher {
+	return &Fetcher{client: client, store: store}
+}
+
+// Fetch stores executions of productCode from now back to from.
+//
+// It first downloads executions newer than the stored ones, then fills gaps left by
+// interrupted downloads, and finally extends the history back to from. Progress is saved
+// after each page, so a cancelled Fetch resumes where it stopped.
+func (f *Fetcher) Fetch(ctx context.Context, productCode string, from time.Time) (*Progress, error) {
+	p, err := f.store.Progress(productCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executions newer than the stored ones.
+	if err := f.walk(ctx, productCode, p, 0, p.Newest(), from); err != nil {
+		return p, err
+	}
+
+	// Gaps between stored ranges.
+	for _, v := range p.Gaps() {
+		if err := f.walk(ctx, productCode, p, v.To+1, v.From-1, time.Time{}); err != nil {
+			return p, err
+		}
+	}
+
+	// Executions older than the stored ones.
+	if !p.Complete && (p.Oldest.IsZero() || !p.Oldest.Before(from)) {
+		if err := f.walk(ctx, productCode, p, p.Ranges[0].From, 0, from); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// walk pages backwards from before (exclusive, zero for the newest) down to after (exclusive),
+// or until an execution older than until is stored.
+func (f *Fetcher) walk(ctx context.Context, productCode string, p *Progress, before int64, after int64, until time.Time) error {
+	for {
+		page := bitflyer.Pagination{Count: PageSize, Before: before, After: after}
+		executions, err := f.client.GetExecutions(ctx, productCode, page)
+		if err != nil {
+			return fmt.Errorf("failed to fetch executions before %d: %w", before, err)
+		}
+
+		covered, oldest, err := f.store.cover(productCode, page, executions)
+		if err != nil {
+			return err
+		}
+		if covered.From <= covered.To {
+			p.Add(covered)
+		}
+		if !oldest.IsZero() && (p.Oldest.IsZero() || oldest.Before(p.Oldest)) {
+			p.Oldest = oldest
+		}
+		// A partial page without a lower bound is the beginning of the history.
+		if len(executions) < PageSize && after == 0 {
+			p.Complete = true
+		}
+		if err := f.store.SaveProgress(productCode, p); err != nil {
+			return err
+		}
+		if f.OnPage != nil {
+			f.OnPage(p, len(executions))
+		}
+
+		if len(executions) < PageSize {
+			return nil
+		}
+		if !until.IsZero() && oldest.Before(until) {
+			return nil
+		}
+		before = covered.From
+	}
+}
+
+// cover stores a page and returns the id range it proves complete and its oldest exec_date.
+//
+// A full page covers ids from its oldest execution up to before. A partial page reached
+// after, so it covers everything down to after.
+func (s *Store) cover(productCode string, page bitflyer.Pagination, executions bitflyer.GetExecutionsResponse) (Range, time.Time, error) {
+	if err := s.Append(productCode, executions); err != nil {
+		return Range{}, time.Time{}, err
+	}
+
+	r := Range{From: page.After + 1, To: page.Before - 1}
+	var oldest time.Time
+	for i, v := range executions {
+		if page.Before == 0 && (i == 0 || v.Id > r.To) {
+			r.To = v.Id
+		}
+		if len(executions) == PageSize && (i == 0 || v.Id < r.From) {
+			r.From = v.Id
+		}
+
+		execDate, err := bitflyer.ParseTime(v.ExecDate)
+		if err != nil {
+			return Range{}, time.Time{}, fmt.Errorf("can not parse exec_date of execution %d: %w", v.Id, err)
+		}
+		if oldest.IsZero() || execDate.Before(oldest) {
+			oldest = execDate
+		}
+	}
+	if page.Before == 0 && len(executions) == 0 {
+		// Nothing newer than after exists yet.
+		return Range{From: 1, To: 0}, oldest, nil
+	}
+	return r, oldest, nil
+}
+
+// Verify checks that the stored ranges of productCode have no gaps and that every stored
+// execution is unique and inside a stored range. It returns the problems found.
+func (s *Store) Verify(productCode string) ([]string, error) {
+	p, err := s.Progress(productCode)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.Days(productCode)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []string{}
+	if len(p.Ranges) == 0 {
+		problems = append(problems, "nothing is downloaded")
+	}
+	for _, v := range p.Gaps() {
+		problems = append(problems, fmt.Sprintf("ids %d to %d are missing", v.From, v.To))
+	}
+
+	seen := map[int64]bool{}
+	for _, day := range days {
+		executions, err := s.Executions(productCode, day)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range executions {
+			if seen[v.Id] {
+				problems = append(problems, fmt.Sprintf("execution %d is stored in more than one day", v.Id))
+			}
+			seen[v.Id] = true
+			if !p.contains(v.Id) {
+				problems = append(problems, fmt.Sprintf("execution %d is outside of the downloaded ranges", v.Id))
+			}
+		}
+	}
+	return problems, nil
+}
+
+func (p *Progress) contains(id int64) bool {
+	for _, v := range p.Ranges {
+		if v.From <= id && id <= v.To {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// fakeExecutions serves a history like /v1/executions, newest first.
+type fakeExecutions struct {
+	history bitflyer.GetExecutionsResponse
+	calls   int
+	// failAt makes the call of this number fail.
+	failAt int
+}
+
+func (f *fakeExecutions) GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+	f.calls++
+	if f.calls == f.failAt {
+		return nil, cerror.ErrRateLimited
+	}
+
+	res := bitflyer.GetExecutionsResponse{}
+	for i := len(f.history) - 1; i >= 0 && len(res) < page.Count; i-- {
+		v := f.history[i]
+		if (page.Before == 0 || v.Id < page.Before) && v.Id > page.After {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+// executions returns n executions, oldest first, one per minute from start.
+// Ids skip numbers as other products share them.
+func executions(firstID int64, start time.Time, n int) bitflyer.GetExecutionsResponse {
+	res := bitflyer.GetExecutionsResponse{}
+	for i := 0; i < n; i++ {
+		res = append(res, bitflyer.ExecutionResponse{
+			Id:       firstID + int64(i)*3,
+			Side:     bitflyer.SideBuy,
+			Price:    5000000 + float64(i),
+			Size:     0.01,
+			ExecDate: start.Add(time.Duration(i) * time.Minute).UTC().Format("2006-01-02T15:04:05.000"),
+		})
+	}
+	return res
+}
+
+// stored returns all stored executions, oldest first.
+func stored(t *testing.T, s *Store) bitflyer.GetExecutionsResponse {
+	t.Helper()
+	days, err := s.Days("BTC_JPY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := bitflyer.GetExecutionsResponse{}
+	for _, day := range days {
+		v, err := s.Executions("BTC_JPY", day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, v...)
+	}
+	return res
+}
+
+// start is 2023-04-01 20:00 JST, so the history spans two JST days.
+var start = time.Date(2023, 4, 1, 11, 0, 0, 0, time.UTC)
+
+func TestFetcher_Fetch(t *testing.T) {
+	history := executions(10, start, 1200)
+
+	tests := []struct {
+		name      string
+		failAt    int
+		from      time.Time
+		want      bitflyer.GetExecutionsResponse
+		wantCalls int
+	}{
+		{
+			name: "Whole History",
+			from: start.Add(-time.Hour),
+			want: history,
+			// Pages of 500, 500 and 200 executions.
+			wantCalls: 3,
+		},
+		{
+			name: "Until From",
+			from: start.Add(600 * time.Minute),
+			want: history[200:],
+			// The second page reaches executions older than from.
+			wantCalls: 2,
+		},
+		{
+			name:   "Resumed After Failure",
+			failAt: 2,
+			from:   start.Add(-time.Hour),
+			want:   history,
+			// The failed call, then the head (nothing new) and the remaining 2 pages.
+			wantCalls: 1 + 1 + 1 + 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(t.TempDir())
+			client := &fakeExecutions{history: history, failAt: tt.failAt}
+			f := NewFetcher(client, store)
+
+			_, err := f.Fetch(context.Background(), "BTC_JPY", tt.from)
+			if tt.failAt > 0 {
+				if !errors.Is(err, cerror.ErrRateLimited) {
+					t.Fatalf("Fetcher.Fetch() error = %v, expectedError %v", err, cerror.ErrRateLimited)
+				}
+				_, err = f.Fetch(context.Background(), "BTC_JPY", tt.from)
+			}
+			if err != nil {
+				t.Fatalf("Fetcher.Fetch() error = %v", err)
+			}
+
+			if got := stored(t, store); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stored %v executions from %v, want %v from %v", len(got), got[0].Id, len(tt.want), tt.want[0].Id)
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("Fetcher.Fetch() called %v times, want %v", client.calls, tt.wantCalls)
+			}
+			if problems, err := store.Verify("BTC_JPY"); err != nil || len(problems) != 0 {
+				t.Errorf("Store.Verify() = %v, %v", problems, err)
+			}
+		})
+	}
+}
+
+func TestFetcher_Fetch_newExecutionsAndGaps(t *testing.T) {
+	store := NewStore(t.TempDir())
+	history := executions(10, start, 600)
+	client := &fakeExecutions{history: history[:300]}
+	f := NewFetcher(client, store)
+	from := start.Add(-time.Hour)
+
+	if _, err := f.Fetch(context.Background(), "BTC_JPY", from); err != nil {
+		t.Fatal(err)
+	}
+
+	// Newer executions arrived, and an interrupted download stored only the newest of them.
+	client.history = history
+	p, err := store.Progress("BTC_JPY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Add(Range{From: history[550].Id, To: history[599].Id})
+	if err := store.Append("BTC_JPY", history[550:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveProgress("BTC_JPY", p); err != nil {
+		t.Fatal(err)
+	}
+	if problems, _ := store.Verify("BTC_JPY"); len(problems) != 1 {
+		t.Errorf("Store.Verify() = %v, want a gap", problems)
+	}
+
+	p, err = f.Fetch(context.Background(), "BTC_JPY", from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored(t, store); !reflect.DeepEqual(got, history) {
+		t.Errorf("stored %v executions, want %v", len(got), len(history))
+	}
+	if want := []Range{{From: 1, To: history[599].Id}}; !reflect.DeepEqual(p.Ranges, want) || !p.Complete {
+		t.Errorf("Progress = %+v, want ranges %v", p, want)
+	}
+}
@@ -0,0 +1,261 @@
+// Package history stores the bitFlyer execution history on the local disk.
+//
+// Executions are kept per product in gzip compressed JSON lines files partitioned by
+// JST date, such as `BTC_JPY/2023-04-01.jsonl.gz`. Each write appends a gzip member,
+// so an interrupted download never corrupts stored data. A progress file records the
+// id ranges known to be complete, which makes downloads resumable and gaps detectable.
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// dayLayout is the layout of the date in file names.
+const dayLayout = "2006-01-02"
+
+// fileSuffix is the suffix of execution files.
+const fileSuffix = ".jsonl.gz"
+
+// Range is an inclusive range of execution ids whose executions of the product are all stored.
+// As ids are shared by all products, most ids in a range belong to other products.
+type Range struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// Progress is the download state of a product.
+type Progress struct {
+	// Ranges are sorted and do not overlap nor touch each other. More than one range means gaps.
+	Ranges []Range `json:"ranges"`
+	// Oldest is the exec_date of the oldest stored execution.
+	Oldest time.Time `json:"oldest"`
+	// Complete reports that the beginning of the history was reached.
+	Complete bool `json:"complete"`
+}
+
+// Add merges r into the ranges.
+func (p *Progress) Add(r Range) {
+	ranges := append(append([]Range{}, p.Ranges...), r)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From < ranges[j].From })
+
+	merged := []Range{ranges[0]}
+	for _, v := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if v.From <= last.To+1 {
+			if v.To > last.To {
+				last.To = v.To
+			}
+			continue
+		}
+		merged = append(merged, v)
+	}
+	p.Ranges = merged
+}
+
+// Gaps returns the id ranges missing between the stored ranges.
+func (p *Progress) Gaps() []Range {
+	gaps := []Range{}
+	for i := 1; i < len(p.Ranges); i++ {
+		gaps = append(gaps, Range{From: p.Ranges[i-1].To + 1, To: p.Ranges[i].From - 1})
+	}
+	return gaps
+}
+
+// Newest returns the highest stored id, or zero.
+func (p *Progress) Newest() int64 {
+	if len(p.Ranges) == 0 {
+		return 0
+	}
+	return p.Ranges[len(p.Ranges)-1].To
+}
+
+// Store keeps executions under a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a Store under dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the default directory of the stored history.
+func DefaultDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "data")
+}
+
+func (s *Store) productDir(productCode string) string {
+	return filepath.Join(s.dir, productCode)
+}
+
+func (s *Store) progressPath(productCode string) string {
+	return filepath.Join(s.productDir(productCode), "progress.json")
+}
+
+// Progress loads the progress of productCode. A product never downloaded has no ranges.
+func (s *Store) Progress(productCode string) (*Progress, error) {
+	path := s.progressPath(productCode)
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Progress{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read progress %s: %w", path, err)
+	}
+
+	var p Progress
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("can not parse progress %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// SaveProgress writes the progress of productCode atomically.
+func (s *Store) SaveProgress(productCode string, p *Progress) error {
+	raw, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.productDir(productCode), 0775); err != nil {
+		return fmt.Errorf("can not create data directory: %w", err)
+	}
+
+	path := s.progressPath(productCode)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return fmt.Errorf("can not write progress %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("can not write progress %s: %w", path, err)
+	}
+	return nil
+}
+
+// Append stores executions into the files of their JST dates.
+func (s *Store) Append(productCode string, executions bitflyer.GetExecutionsResponse) error {
+	byDay := map[string]bitflyer.GetExecutionsResponse{}
+	for _, v := range executions {
+		execDate, err := bitflyer.ParseTime(v.ExecDate)
+		if err != nil {
+			return fmt.Errorf("can not parse exec_date of execution %d: %w", v.Id, err)
+		}
+		day := execDate.In(candle.JST).Format(dayLayout)
+		byDay[day] = append(byDay[day], v)
+	}
+
+	if err := os.MkdirAll(s.productDir(productCode), 0775); err != nil {
+		return fmt.Errorf("can not create data directory: %w", err)
+	}
+	for day, v := range byDay {
+		if err := s.appendFile(filepath.Join(s.productDir(productCode), day+fileSuffix), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendFile appends executions to path as a new gzip member.
+func (s *Store) appendFile(path string, executions bitflyer.GetExecutionsResponse) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return fmt.Errorf("can not open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// Write the member at once so that a crash leaves at most a truncated last member.
+	var buf strings.Builder
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, v := range executions {
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(f, buf.String()); err != nil {
+		return fmt.Errorf("can not write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// Days returns the JST dates with stored executions of productCode, oldest first.
+func (s *Store) Days(productCode string) ([]time.Time, error) {
+	entries, err := os.ReadDir(s.productDir(productCode))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	days := []time.Time{}
+	for _, v := range entries {
+		name := strings.TrimSuffix(v.Name(), fileSuffix)
+		if name == v.Name() {
+			continue
+		}
+		day, err := time.ParseInLocation(dayLayout, name, candle.JST)
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// Executions returns the stored executions of productCode on the JST date of day,
+// sorted by id without duplicates. A truncated last member written by a crash is ignored.
+func (s *Store) Executions(productCode string, day time.Time) (bitflyer.GetExecutionsResponse, error) {
+	path := filepath.Join(s.productDir(productCode), day.In(candle.JST).Format(dayLayout)+fileSuffix)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bitflyer.GetExecutionsResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("can not read %s: %w", path, err)
+	}
+
+	byID := map[int64]bitflyer.ExecutionResponse{}
+	decoder := json.NewDecoder(zr)
+	for {
+		var v bitflyer.ExecutionResponse
+		err := decoder.Decode(&v)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can not read %s: %w", path, err)
+		}
+		byID[v.Id] = v
+	}
+
+	res := make(bitflyer.GetExecutionsResponse, 0, len(byID))
+	for _, v := range byID {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+	return res, nil
+}
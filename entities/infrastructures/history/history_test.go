@@ -0,0 +1,80 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+func TestProgress_Add(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   []Range
+		add      Range
+		want     []Range
+		wantGaps []Range
+	}{
+		{name: "First", add: Range{From: 10, To: 20}, want: []Range{{10, 20}}, wantGaps: []Range{}},
+		{name: "Adjacent", ranges: []Range{{10, 20}}, add: Range{From: 21, To: 30}, want: []Range{{10, 30}}, wantGaps: []Range{}},
+		{name: "Overlapping", ranges: []Range{{10, 20}, {40, 50}}, add: Range{From: 15, To: 45}, want: []Range{{10, 50}}, wantGaps: []Range{}},
+		{name: "Gap", ranges: []Range{{10, 20}}, add: Range{From: 40, To: 50}, want: []Range{{10, 20}, {40, 50}}, wantGaps: []Range{{21, 39}}},
+		{name: "Older", ranges: []Range{{40, 50}}, add: Range{From: 1, To: 39}, want: []Range{{1, 50}}, wantGaps: []Range{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Progress{Ranges: tt.ranges}
+			p.Add(tt.add)
+			if !reflect.DeepEqual(p.Ranges, tt.want) {
+				t.Errorf("Progress.Add() = %v, want %v", p.Ranges, tt.want)
+			}
+			if got := p.Gaps(); !reflect.DeepEqual(got, tt.wantGaps) {
+				t.Errorf("Progress.Gaps() = %v, want %v", got, tt.wantGaps)
+			}
+		})
+	}
+}
+
+func TestStore_Append(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	// 2023-04-01 23:59 and 2023-04-02 00:00 in JST.
+	history := executions(10, time.Date(2023, 4, 1, 14, 59, 0, 0, time.UTC), 2)
+
+	// Appended twice, as a resumed download does.
+	for i := 0; i < 2; i++ {
+		if err := s.Append("BTC_JPY", history); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	days, err := s.Days("BTC_JPY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDays := []time.Time{
+		time.Date(2023, 4, 1, 0, 0, 0, 0, candle.JST),
+		time.Date(2023, 4, 2, 0, 0, 0, 0, candle.JST),
+	}
+	if !reflect.DeepEqual(days, wantDays) {
+		t.Errorf("Store.Days() = %v, want %v", days, wantDays)
+	}
+
+	// A crash left a truncated member at the end.
+	path := filepath.Join(dir, "BTC_JPY", "2023-04-02.jsonl.gz")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(raw, raw[:len(raw)/2]...), 0664); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Executions("BTC_JPY", wantDays[1])
+	if err != nil || !reflect.DeepEqual(got, history[1:]) {
+		t.Errorf("Store.Executions() = %v, %v, want %v", got, err, history[1:])
+	}
+}
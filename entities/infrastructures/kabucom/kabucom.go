@@ -2,12 +2,17 @@ package kabucom
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/http"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sn1w/capital-go/config"
 	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/autogen"
 	cerror "github.com/sn1w/capital-go/error"
+	"github.com/sn1w/capital-go/internal/transport"
 )
 
 type KabucomClient struct {
@@ -15,7 +20,7 @@ type KabucomClient struct {
 }
 
 func NewKabucomClient(cfg config.Config) *KabucomClient {
-	c, err := autogen.NewClientWithResponses(cfg.KabucomAPIHost, autogen.WithHTTPClient(http.DefaultClient))
+	c, err := autogen.NewClientWithResponses(cfg.KabucomAPIHost, autogen.WithHTTPClient(transport.NewClient()))
 	if err != nil {
 		panic(err)
 	}
@@ -24,8 +29,7 @@ func NewKabucomClient(cfg config.Config) *KabucomClient {
 	}
 }
 
-func (c *KabucomClient) GetToken(password string) (string, error) {
-	ctx := context.Background()
+func (c *KabucomClient) GetToken(ctx context.Context, password string) (string, error) {
 	res, err := c.client.TokenPostWithResponse(ctx, autogen.RequestToken{
 		APIPassword: password,
 	})
@@ -45,3 +49,337 @@ func (c *KabucomClient) GetToken(password string) (string, error) {
 
 	return *res.JSON200.Token, nil
 }
+
+// BoardLevel is a price level of a board.
+type BoardLevel struct {
+	Price float64 `json:"Price"`
+	Qty   float64 `json:"Qty"`
+}
+
+// BoardResponse is the board of a symbol, up to 10 levels per side.
+type BoardResponse struct {
+	Symbol       string
+	SymbolName   string
+	CurrentPrice float64
+	// Bids and Asks are ordered from the best price.
+	Bids []BoardLevel
+	Asks []BoardLevel
+}
+
+// GetBoard returns the board of symbol, such as "9433@1" (symbol@exchange).
+func (c *KabucomClient) GetBoard(ctx context.Context, token string, symbol string) (*BoardResponse, error) {
+	res, err := c.client.BoardGetWithResponse(ctx, symbol, &autogen.BoardGetParams{XAPIKEY: token})
+	if err != nil {
+		return nil, err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil || res.JSON200.Symbol == nil {
+		return nil, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+
+	// Buy1 to Buy10 and Sell1 to Sell10 are separate fields of different types; read them by name.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(res.Body, &fields); err != nil {
+		return nil, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+	level := func(name string) *BoardLevel {
+		var v BoardLevel
+		if raw, ok := fields[name]; !ok || json.Unmarshal(raw, &v) != nil || v.Qty <= 0 {
+			return nil
+		}
+		return &v
+	}
+
+	board := &BoardResponse{Symbol: *res.JSON200.Symbol}
+	if res.JSON200.SymbolName != nil {
+		board.SymbolName = *res.JSON200.SymbolName
+	}
+	if res.JSON200.CurrentPrice != nil {
+		board.CurrentPrice = *res.JSON200.CurrentPrice
+	}
+	for i := 1; i <= 10; i++ {
+		if v := level(fmt.Sprintf("Buy%d", i)); v != nil {
+			board.Bids = append(board.Bids, *v)
+		}
+		if v := level(fmt.Sprintf("Sell%d", i)); v != nil {
+			board.Asks = append(board.Asks, *v)
+		}
+	}
+	return board, nil
+}
+
+// Execution is a fill of an order.
+type Execution struct {
+	ID      string
+	OrderID string
+	// Symbol is the symbol and the exchange of the order, such as "9433@1".
+	Symbol string
+	// Side is "BUY" or "SELL".
+	Side  string
+	Price float64
+	Qty   float64
+	// Commission includes its tax.
+	Commission float64
+	Time       time.Time
+}
+
+// recTypeExecuted is the RecType of order details that are executions.
+const recTypeExecuted = 8
+
+// GetExecutions returns the executions of the orders of the day, read from the details of `GET /orders`.
+func (c *KabucomClient) GetExecutions(ctx context.Context, token string) ([]Execution, error) {
+	details := "true"
+	res, err := c.client.OrdersGetWithResponse(ctx, &autogen.OrdersGetParams{XAPIKEY: token, Details: &details})
+	if err != nil {
+		return nil, err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+
+	executions := []Execution{}
+	for _, o := range *res.JSON200 {
+		if o.ID == nil || o.Symbol == nil || o.Details == nil {
+			continue
+		}
+		symbol := *o.Symbol
+		if o.Exchange != nil {
+			symbol = fmt.Sprintf("%s@%d", symbol, *o.Exchange)
+		}
+		side := "SELL"
+		if o.Side != nil && *o.Side == "2" {
+			side = "BUY"
+		}
+
+		for _, d := range *o.Details {
+			if d.RecType == nil || *d.RecType != recTypeExecuted || d.ExecutionID == nil || d.Price == nil || d.Qty == nil {
+				continue
+			}
+			e := Execution{
+				ID:      *d.ExecutionID,
+				OrderID: *o.ID,
+				Symbol:  symbol,
+				Side:    side,
+				Price:   *d.Price,
+				Qty:     *d.Qty,
+			}
+			if d.Commission != nil {
+				e.Commission += *d.Commission
+			}
+			if d.CommissionTax != nil {
+				e.Commission += *d.CommissionTax
+			}
+			if d.ExecutionDay != nil {
+				e.Time = *d.ExecutionDay
+			}
+			executions = append(executions, e)
+		}
+	}
+	return executions, nil
+}
+
+// GetCashWallet returns the cash available to buy stocks, in JPY.
+func (c *KabucomClient) GetCashWallet(ctx context.Context, token string) (float64, error) {
+	res, err := c.client.WalletCashGetWithResponse(ctx, &autogen.WalletCashGetParams{XAPIKEY: token})
+	if err != nil {
+		return 0, err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return 0, err
+	}
+	if res.JSON200 == nil || res.JSON200.StockAccountWallet == nil {
+		return 0, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+	return *res.JSON200.StockAccountWallet, nil
+}
+
+// Position is a holding of a stock in the cash account.
+type Position struct {
+	// Symbol is the symbol and the exchange, such as "9433@1".
+	Symbol     string
+	SymbolName string
+	Qty        float64
+	// Price is the average price the stock was bought at.
+	Price        float64
+	CurrentPrice float64
+}
+
+// productCash is the Product of `GET /positions` selecting cash (現物) positions.
+const productCash = autogen.PositionsGetParamsProductN1
+
+// GetPositions returns the stocks held in the cash account.
+func (c *KabucomClient) GetPositions(ctx context.Context, token string) ([]Position, error) {
+	product := productCash
+	res, err := c.client.PositionsGetWithResponse(ctx, &autogen.PositionsGetParams{XAPIKEY: token, Product: &product})
+	if err != nil {
+		return nil, err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+
+	positions := []Position{}
+	for _, v := range *res.JSON200 {
+		if v.Symbol == nil || v.LeavesQty == nil || *v.LeavesQty == 0 {
+			continue
+		}
+		p := Position{Symbol: *v.Symbol, Qty: *v.LeavesQty}
+		if v.Exchange != nil {
+			p.Symbol = fmt.Sprintf("%s@%d", p.Symbol, *v.Exchange)
+		}
+		if v.SymbolName != nil {
+			p.SymbolName = *v.SymbolName
+		}
+		if v.Price != nil {
+			p.Price = *v.Price
+		}
+		if v.CurrentPrice != nil {
+			p.CurrentPrice = *v.CurrentPrice
+		}
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+
+// Symbol is the trading information of a symbol.
+type Symbol struct {
+	Symbol     string
+	SymbolName string
+	// TradingUnit is the lot size (売買単位), such as 100 shares.
+	TradingUnit float64
+}
+
+// GetSymbol returns the information of symbol, such as "9433@1" (symbol@exchange).
+func (c *KabucomClient) GetSymbol(ctx context.Context, token string, symbol string) (*Symbol, error) {
+	addinfo := "false"
+	res, err := c.client.SymbolGetWithResponse(ctx, symbol, &autogen.SymbolGetParams{XAPIKEY: token, Addinfo: &addinfo})
+	if err != nil {
+		return nil, err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil || res.JSON200.Symbol == nil || res.JSON200.TradingUnit == nil {
+		return nil, fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+
+	s := &Symbol{Symbol: *res.JSON200.Symbol, TradingUnit: *res.JSON200.TradingUnit}
+	if res.JSON200.SymbolName != nil {
+		s.SymbolName = *res.JSON200.SymbolName
+	}
+	return s, nil
+}
+
+// OrderRequest is a cash order of a stock, paid from and credited to the deposit of the account.
+type OrderRequest struct {
+	// Symbol is symbol@exchange, such as "1306@1".
+	Symbol string
+	// Side is "BUY" or "SELL".
+	Side string
+	Qty  float64
+	// Price is the limit price, zero for a market order.
+	Price float64
+	// Password is the trading password of the account.
+	Password string
+}
+
+const (
+	orderSideSell        = "1"
+	orderSideBuy         = "2"
+	securityTypeStock    = 1
+	cashMarginCash       = 1
+	delivTypeDeposit     = 2
+	fundTypeProtected    = "02"
+	fundTypeSell         = "  "
+	accountTypeSpecific  = 4
+	frontOrderTypeMarket = 10
+	frontOrderTypeLimit  = 20
+)
+
+// SendOrder sends a cash order (現物) of the specific account (特定口座), valid for the day, and
+// returns its order ID.
+func (c *KabucomClient) SendOrder(ctx context.Context, token string, order OrderRequest) (string, error) {
+	code, exchange, ok := strings.Cut(order.Symbol, "@")
+	if !ok {
+		return "", fmt.Errorf("%w: symbol %q must be symbol@exchange", cerror.ErrBadRequest, order.Symbol)
+	}
+	ex, err := strconv.ParseInt(exchange, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid exchange of %q", cerror.ErrBadRequest, order.Symbol)
+	}
+	if order.Qty <= 0 || order.Qty != math.Trunc(order.Qty) {
+		return "", fmt.Errorf("%w: quantity must be a positive integer, got %v", cerror.ErrBadRequest, order.Qty)
+	}
+
+	req := autogen.RequestSendOrder{
+		Symbol:         code,
+		Exchange:       int32(ex),
+		SecurityType:   securityTypeStock,
+		CashMargin:     cashMarginCash,
+		AccountType:    accountTypeSpecific,
+		Qty:            int32(order.Qty),
+		FrontOrderType: frontOrderTypeMarket,
+		Password:       order.Password,
+	}
+	switch order.Side {
+	case "BUY":
+		fund := fundTypeProtected
+		req.Side, req.DelivType, req.FundType = orderSideBuy, delivTypeDeposit, &fund
+	case "SELL":
+		fund := fundTypeSell
+		req.Side, req.FundType = orderSideSell, &fund
+	default:
+		return "", fmt.Errorf("%w: invalid side %q", cerror.ErrBadRequest, order.Side)
+	}
+	if order.Price > 0 {
+		req.FrontOrderType, req.Price = frontOrderTypeLimit, order.Price
+	}
+
+	res, err := c.client.SendorderPostWithResponse(ctx, &autogen.SendorderPostParams{XAPIKEY: token}, req)
+	if err != nil {
+		return "", err
+	}
+	defer res.HTTPResponse.Body.Close()
+
+	if err := statusError(res.StatusCode(), res.Body); err != nil {
+		return "", err
+	}
+	if res.JSON200 == nil || res.JSON200.OrderId == nil {
+		return "", fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnknownResponseFormat, res.Body)
+	}
+	if res.JSON200.Result != nil && *res.JSON200.Result != 0 {
+		return "", fmt.Errorf("%w: order rejected with result %d", cerror.ErrBadRequest, *res.JSON200.Result)
+	}
+	return *res.JSON200.OrderId, nil
+}
+
+// statusError maps the error statuses of the API to errors.
+func statusError(status int, body []byte) error {
+	switch {
+	case status == 401:
+		return fmt.Errorf("unexpected error %w, body = %s", cerror.ErrUnAuthorized, body)
+	case status == 400 || status == 404:
+		return fmt.Errorf("%w: body = %s", cerror.ErrBadRequest, body)
+	case status == 429:
+		return fmt.Errorf("%w: body = %s", cerror.ErrRateLimited, body)
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package kabucom
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/autogen"
 	cerror "github.com/sn1w/capital-go/error"
@@ -120,7 +123,7 @@ func TestKabucomClient_GetToken(t *testing.T) {
 			c := KabucomClient{
 				client: tt.fields.client,
 			}
-			got, err := c.GetToken(tt.args.password)
+			got, err := c.GetToken(context.Background(), tt.args.password)
 			if err != nil {
 				if !errors.Is(err, tt.wantErr) {
 					t.Errorf("KabucomClient.GetToken() error = %v, wantErr %v", err, tt.wantErr)
@@ -133,3 +136,316 @@ func TestKabucomClient_GetToken(t *testing.T) {
 		})
 	}
 }
+
+func response(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestKabucomClient_GetBoard(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		want    *BoardResponse
+		wantErr error
+	}{
+		{
+			name: "Success",
+			res: response(200, `
+			{
+				"Symbol": "9433",
+				"SymbolName": "ＫＤＤＩ",
+				"CurrentPrice": 4000,
+				"AskPrice": 3999,
+				"Sell1": {"Time": "2023-04-03T09:00:00+09:00", "Sign": "0101", "Price": 4001, "Qty": 100},
+				"Sell2": {"Price": 4002, "Qty": 200},
+				"Buy1": {"Sign": "0101", "Price": 3999, "Qty": 100},
+				"Buy2": {"Price": 0, "Qty": 0}
+			}
+			`),
+			want: &BoardResponse{
+				Symbol:       "9433",
+				SymbolName:   "ＫＤＤＩ",
+				CurrentPrice: 4000,
+				Bids:         []BoardLevel{{Price: 3999, Qty: 100}},
+				Asks:         []BoardLevel{{Price: 4001, Qty: 100}, {Price: 4002, Qty: 200}},
+			},
+		},
+		{
+			name:    "UnAuthorized",
+			res:     response(401, `{"Code": 4001009, "Message": "APIキー不一致"}`),
+			wantErr: cerror.ErrUnAuthorized,
+		},
+		{
+			name:    "Symbol Not Found",
+			res:     response(400, `{"Code": 4002001, "Message": "銘柄が見つからない"}`),
+			wantErr: cerror.ErrBadRequest,
+		},
+		{
+			name:    "Unknown Response Format",
+			res:     response(200, `{"Message": "OK Computer"}`),
+			wantErr: cerror.ErrUnknownResponseFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.GetBoard(context.Background(), "token", "9433@1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("KabucomClient.GetBoard() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKabucomClient_GetExecutions(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		want    []Execution
+		wantErr error
+	}{
+		{
+			name: "Success",
+			res: response(200, `
+			[
+				{
+					"ID": "20230403A02N00000001",
+					"Symbol": "9433",
+					"Exchange": 1,
+					"Side": "2",
+					"Details": [
+						{"SeqNum": 1, "ID": "D1", "RecType": 1, "Price": 0, "Qty": 100},
+						{"SeqNum": 2, "ID": "D2", "RecType": 8, "ExecutionID": "E1", "ExecutionDay": "2023-04-03T09:00:01+09:00",
+						 "Price": 4000, "Qty": 100, "Commission": 100, "CommissionTax": 10}
+					]
+				},
+				{"ID": "20230403A02N00000002", "Symbol": "7203", "Exchange": 1, "Side": "1", "Details": []}
+			]
+			`),
+			want: []Execution{
+				{
+					ID:         "E1",
+					OrderID:    "20230403A02N00000001",
+					Symbol:     "9433@1",
+					Side:       "BUY",
+					Price:      4000,
+					Qty:        100,
+					Commission: 110,
+					Time:       time.Date(2023, 4, 3, 0, 0, 1, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name:    "UnAuthorized",
+			res:     response(401, `{"Code": 4001009, "Message": "APIキー不一致"}`),
+			wantErr: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.GetExecutions(context.Background(), "token")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.GetExecutions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("KabucomClient.GetExecutions() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Time.Equal(tt.want[i].Time) {
+					t.Errorf("KabucomClient.GetExecutions()[%d].Time = %v, want %v", i, got[i].Time, tt.want[i].Time)
+				}
+				got[i].Time = tt.want[i].Time
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("KabucomClient.GetExecutions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKabucomClient_GetCashWallet(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		want    float64
+		wantErr error
+	}{
+		{
+			name: "Success",
+			res:  response(200, `{"StockAccountWallet": 1000000}`),
+			want: 1000000,
+		},
+		{
+			name:    "Unknown Response Format",
+			res:     response(200, `{}`),
+			wantErr: cerror.ErrUnknownResponseFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.GetCashWallet(context.Background(), "token")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.GetCashWallet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("KabucomClient.GetCashWallet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKabucomClient_GetPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		want    []Position
+		wantErr error
+	}{
+		{
+			name: "Success",
+			res: response(200, `
+			[
+				{"Symbol": "1306", "SymbolName": "ＴＯＰＩＸ連動型上場投資信託", "Exchange": 1, "Side": "2", "Price": 2000, "LeavesQty": 30, "CurrentPrice": 2100},
+				{"Symbol": "9433", "Exchange": 3, "Side": "2", "Price": 4000, "LeavesQty": 0}
+			]
+			`),
+			want: []Position{{Symbol: "1306@1", SymbolName: "ＴＯＰＩＸ連動型上場投資信託", Qty: 30, Price: 2000, CurrentPrice: 2100}},
+		},
+		{
+			name:    "UnAuthorized",
+			res:     response(401, `{"Code": 4001009, "Message": "APIキー不一致"}`),
+			wantErr: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.GetPositions(context.Background(), "token")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.GetPositions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("KabucomClient.GetPositions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKabucomClient_GetSymbol(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		want    *Symbol
+		wantErr error
+	}{
+		{
+			name: "Success",
+			res:  response(200, `{"Symbol": "1306", "SymbolName": "ＴＯＰＩＸ連動型上場投資信託", "Exchange": 1, "TradingUnit": 10}`),
+			want: &Symbol{Symbol: "1306", SymbolName: "ＴＯＰＩＸ連動型上場投資信託", TradingUnit: 10},
+		},
+		{
+			name:    "Symbol Not Found",
+			res:     response(404, `{"Code": 4002001, "Message": "銘柄が見つからない"}`),
+			wantErr: cerror.ErrBadRequest,
+		},
+		{
+			name:    "Unknown Response Format",
+			res:     response(200, `{"Symbol": "1306"}`),
+			wantErr: cerror.ErrUnknownResponseFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.GetSymbol(context.Background(), "token", "1306@1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.GetSymbol() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("KabucomClient.GetSymbol() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKabucomClient_SendOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		order   OrderRequest
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "Success",
+			res:   response(200, `{"Result": 0, "OrderId": "20200529A01N06848002"}`),
+			order: OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10, Password: "password"},
+			want:  "20200529A01N06848002",
+		},
+		{
+			name:    "Rejected",
+			res:     response(400, `{"Code": 4001005, "Message": "パラメータ変換エラー"}`),
+			order:   OrderRequest{Symbol: "1306@1", Side: "SELL", Qty: 10, Price: 2000, Password: "password"},
+			wantErr: cerror.ErrBadRequest,
+		},
+		{
+			name:    "Invalid Symbol",
+			res:     response(200, `{"Result": 0, "OrderId": "1"}`),
+			order:   OrderRequest{Symbol: "1306", Side: "BUY", Qty: 10},
+			wantErr: cerror.ErrBadRequest,
+		},
+		{
+			name:    "Fractional Quantity",
+			res:     response(200, `{"Result": 0, "OrderId": "1"}`),
+			order:   OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 0.5},
+			wantErr: cerror.ErrBadRequest,
+		},
+		{
+			name:    "Unknown Response Format",
+			res:     response(200, `{"Result": 0}`),
+			order:   OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10},
+			wantErr: cerror.ErrUnknownResponseFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KabucomClient{
+				client: NewMockedClient(tt.res),
+			}
+			got, err := c.SendOrder(context.Background(), "token", tt.order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("KabucomClient.SendOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("KabucomClient.SendOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,549 @@
+package kabucomtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/autogen"
+)
+
+// jst is the time zone of times in kabu STATION responses.
+var jst = time.FixedZone("JST", 9*60*60)
+
+// Values of the enums used by the fake server. See the descriptions in the embedded spec.
+const (
+	sideSell = "1"
+	sideBuy  = "2"
+
+	securityTypeStock = 1
+	cashMarginCash    = 1
+
+	frontOrderTypeMarket = 10
+	frontOrderTypeLimit  = 20
+
+	ordTypeZaraba = 1
+
+	stateProcessed = 3
+	stateFinished  = 5
+
+	recTypeAccepted = 1
+	recTypeOrdered  = 4
+	recTypeCanceled = 6
+	recTypeExpired  = 7
+	recTypeExecuted = 8
+
+	exchangeTSE = 1
+)
+
+// order is an order in the shape of an /orders element.
+type order struct {
+	ID           string   `json:"ID"`
+	State        int32    `json:"State"`
+	OrderState   int32    `json:"OrderState"`
+	OrdType      int32    `json:"OrdType"`
+	RecvTime     string   `json:"RecvTime"`
+	Symbol       string   `json:"Symbol"`
+	SymbolName   string   `json:"SymbolName"`
+	Exchange     int32    `json:"Exchange"`
+	ExchangeName string   `json:"ExchangeName"`
+	Price        float64  `json:"Price"`
+	OrderQty     float64  `json:"OrderQty"`
+	CumQty       float64  `json:"CumQty"`
+	Side         string   `json:"Side"`
+	CashMargin   int32    `json:"CashMargin"`
+	AccountType  int32    `json:"AccountType"`
+	DelivType    int32    `json:"DelivType"`
+	ExpireDay    int32    `json:"ExpireDay"`
+	Details      []detail `json:"Details"`
+
+	frontOrderType int32
+}
+
+// detail is an element of the Details of an order.
+type detail struct {
+	SeqNum       int32   `json:"SeqNum"`
+	ID           string  `json:"ID"`
+	RecType      int32   `json:"RecType"`
+	ExchangeID   string  `json:"ExchangeID,omitempty"`
+	State        int32   `json:"State"`
+	TransactTime string  `json:"TransactTime"`
+	OrdType      int32   `json:"OrdType"`
+	Price        float64 `json:"Price"`
+	Qty          float64 `json:"Qty"`
+	ExecutionID  string  `json:"ExecutionID,omitempty"`
+	ExecutionDay string  `json:"ExecutionDay,omitempty"`
+}
+
+func (o *order) active() bool {
+	return o.State != stateFinished
+}
+
+func (o *order) remaining() float64 {
+	return o.OrderQty - o.CumQty
+}
+
+// holding is a cash position of a symbol.
+type holding struct {
+	Exchange    int32
+	AccountType int32
+	Qty         float64
+	Price       float64
+}
+
+// The handlers below are called with s.mu held.
+
+func (s *Server) postToken(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	var req autogen.RequestToken
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	if req.APIPassword != s.cfg.APIPassword {
+		writeError(w, http.StatusUnauthorized, codeLoginFailed, "ログイン認証エラー")
+		return
+	}
+
+	s.sequence++
+	s.token = fmt.Sprintf("fake-token-%d", s.sequence)
+	writeJSON(w, http.StatusOK, autogen.TokenSuccess{ResultCode: ptr(int32(0)), Token: ptr(s.token)})
+}
+
+// symbol splits a path parameter such as "9433@1" into the symbol and the exchange.
+func symbol(v string) (string, int32) {
+	code, exchange, ok := strings.Cut(v, "@")
+	if !ok {
+		return code, exchangeTSE
+	}
+	n, err := strconv.ParseInt(exchange, 10, 32)
+	if err != nil {
+		return code, exchangeTSE
+	}
+	return code, int32(n)
+}
+
+func exchangeName(exchange int32) string {
+	switch exchange {
+	case 3:
+		return "名証"
+	case 5:
+		return "福証"
+	case 6:
+		return "札証"
+	case 9:
+		return "SOR"
+	}
+	return "東証"
+}
+
+func (s *Server) getBoard(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	code, exchange := symbol(params["symbol"])
+	board, ok := s.boards[code]
+	if !ok {
+		writeError(w, http.StatusBadRequest, codeNotFound, fmt.Sprintf("symbol %s is not found", code))
+		return
+	}
+
+	now := s.now().In(jst)
+	res := map[string]any{
+		"Symbol":           code,
+		"SymbolName":       board.SymbolName,
+		"Exchange":         exchange,
+		"ExchangeName":     exchangeName(exchange),
+		"SecurityType":     securityTypeStock,
+		"CurrentPrice":     board.CurrentPrice,
+		"CurrentPriceTime": now,
+	}
+	// The spec names the best bid AskPrice and the best ask BidPrice.
+	if len(board.Bids) > 0 {
+		res["AskPrice"] = board.Bids[0].Price
+		res["AskQty"] = board.Bids[0].Qty
+		res["AskTime"] = now
+	}
+	if len(board.Asks) > 0 {
+		res["BidPrice"] = board.Asks[0].Price
+		res["BidQty"] = board.Asks[0].Qty
+		res["BidTime"] = now
+	}
+	for i := 0; i < 10 && i < len(board.Bids); i++ {
+		res[fmt.Sprintf("Buy%d", i+1)] = map[string]any{"Price": board.Bids[i].Price, "Qty": board.Bids[i].Qty}
+	}
+	for i := 0; i < 10 && i < len(board.Asks); i++ {
+		res[fmt.Sprintf("Sell%d", i+1)] = map[string]any{"Price": board.Asks[i].Price, "Qty": board.Asks[i].Qty}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getSymbol(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	code, exchange := symbol(params["symbol"])
+	board, ok := s.boards[code]
+	if !ok {
+		writeError(w, http.StatusBadRequest, codeNotFound, fmt.Sprintf("symbol %s is not found", code))
+		return
+	}
+
+	unit := board.TradingUnit
+	if unit == 0 {
+		unit = 100
+	}
+	writeJSON(w, http.StatusOK, autogen.SymbolSuccess{
+		Symbol:       ptr(code),
+		SymbolName:   ptr(board.SymbolName),
+		DisplayName:  ptr(board.SymbolName),
+		Exchange:     ptr(exchange),
+		ExchangeName: ptr(exchangeName(exchange)),
+		TradingUnit:  ptr(unit),
+	})
+}
+
+func (s *Server) sendOrder(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	var req autogen.RequestSendOrder
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	if msg := s.checkOrder(req); msg != "" {
+		writeError(w, http.StatusBadRequest, codeValidation, msg)
+		return
+	}
+
+	board := s.boards[req.Symbol]
+	o := &order{
+		ID:             s.nextID("A02N"),
+		State:          stateProcessed,
+		OrderState:     stateProcessed,
+		OrdType:        ordTypeZaraba,
+		RecvTime:       s.now().In(jst).Format(time.RFC3339),
+		Symbol:         req.Symbol,
+		SymbolName:     board.SymbolName,
+		Exchange:       req.Exchange,
+		ExchangeName:   exchangeName(req.Exchange),
+		Price:          req.Price,
+		OrderQty:       float64(req.Qty),
+		Side:           req.Side,
+		CashMargin:     req.CashMargin,
+		AccountType:    req.AccountType,
+		DelivType:      req.DelivType,
+		ExpireDay:      req.ExpireDay,
+		frontOrderType: req.FrontOrderType,
+	}
+	if o.ExpireDay == 0 {
+		n, _ := strconv.ParseInt(s.now().In(jst).Format("20060102"), 10, 32)
+		o.ExpireDay = int32(n)
+	}
+	s.addDetail(o, recTypeAccepted, 0, 0, "")
+	s.addDetail(o, recTypeOrdered, o.Price, o.OrderQty, "")
+	s.orders = append(s.orders, o)
+
+	s.match(o)
+	if o.active() && o.frontOrderType == frontOrderTypeMarket {
+		// The unfilled quantity of a market order expires.
+		s.addDetail(o, recTypeExpired, 0, o.remaining(), "")
+		s.finish(o)
+	}
+
+	writeJSON(w, http.StatusOK, autogen.OrderSuccess{Result: ptr(int32(0)), OrderId: ptr(o.ID)})
+}
+
+// checkOrder returns why req can not be accepted, or an empty string.
+func (s *Server) checkOrder(req autogen.RequestSendOrder) string {
+	if req.Password != s.cfg.OrderPassword {
+		return "パスワードが一致しません"
+	}
+	if req.SecurityType != securityTypeStock || req.CashMargin != cashMarginCash {
+		return "the fake server supports cash stock orders only (SecurityType=1, CashMargin=1)"
+	}
+	if req.Side != sideBuy && req.Side != sideSell {
+		return fmt.Sprintf("invalid Side %q", req.Side)
+	}
+	if req.Qty <= 0 {
+		return "Qty must be positive"
+	}
+	switch req.FrontOrderType {
+	case frontOrderTypeMarket:
+		if req.Price != 0 {
+			return "Price must be 0 for market orders"
+		}
+	case frontOrderTypeLimit:
+		if req.Price <= 0 {
+			return "Price must be positive for limit orders"
+		}
+	default:
+		return fmt.Sprintf("the fake server supports FrontOrderType 10 and 20 only, got %d", req.FrontOrderType)
+	}
+
+	board, ok := s.boards[req.Symbol]
+	if !ok {
+		return fmt.Sprintf("symbol %s is not found", req.Symbol)
+	}
+
+	qty := float64(req.Qty)
+	if req.Side == sideSell {
+		if qty > s.sellable(req.Symbol)+execution.SizeEpsilon {
+			return "売付可能数量が不足しています"
+		}
+		return ""
+	}
+
+	cost := req.Price * qty
+	if req.FrontOrderType == frontOrderTypeMarket {
+		cost = 0
+		for _, v := range walk(board.Asks, sideBuy, 0, qty) {
+			cost += v.Price * v.Qty
+		}
+	}
+	if cost > s.wallet()+execution.SizeEpsilon {
+		return "買付余力が不足しています"
+	}
+	return ""
+}
+
+// walk returns the fills of an order against levels. A zero limit is a market order.
+func walk(levels []Level, side string, limit float64, qty float64) []Level {
+	fills := []Level{}
+	remaining := qty
+	for _, v := range levels {
+		if remaining <= execution.SizeEpsilon {
+			break
+		}
+		if limit > 0 && ((side == sideBuy && v.Price > limit) || (side == sideSell && v.Price < limit)) {
+			break
+		}
+
+		filled := v.Qty
+		if filled > remaining {
+			filled = remaining
+		}
+		fills = append(fills, Level{Price: v.Price, Qty: filled})
+		remaining -= filled
+	}
+	return fills
+}
+
+// match fills o against its board and consumes the traded liquidity.
+func (s *Server) match(o *order) {
+	board := s.boards[o.Symbol]
+	levels := &board.Asks
+	if o.Side == sideSell {
+		levels = &board.Bids
+	}
+
+	limit := 0.0
+	if o.frontOrderType == frontOrderTypeLimit {
+		limit = o.Price
+	}
+
+	for _, v := range walk(*levels, o.Side, limit, o.remaining()) {
+		s.fill(o, v.Price, v.Qty)
+		board.CurrentPrice = v.Price
+
+		(*levels)[0].Qty -= v.Qty
+		if (*levels)[0].Qty <= execution.SizeEpsilon {
+			*levels = (*levels)[1:]
+		}
+	}
+}
+
+// fill records an execution of o and updates the cash and the holding.
+func (s *Server) fill(o *order, price float64, qty float64) {
+	s.addDetail(o, recTypeExecuted, price, qty, s.nextID("E"))
+	o.CumQty += qty
+
+	h := s.holdings[o.Symbol]
+	if o.Side == sideBuy {
+		s.cash -= price * qty
+		if h == nil {
+			h = &holding{Exchange: o.Exchange, AccountType: o.AccountType}
+			s.holdings[o.Symbol] = h
+		}
+		h.Price = (h.Price*h.Qty + price*qty) / (h.Qty + qty)
+		h.Qty += qty
+	} else {
+		s.cash += price * qty
+		h.Qty -= qty
+		if h.Qty <= execution.SizeEpsilon {
+			delete(s.holdings, o.Symbol)
+		}
+	}
+
+	if o.remaining() <= execution.SizeEpsilon {
+		s.finish(o)
+	}
+}
+
+func (s *Server) finish(o *order) {
+	o.State = stateFinished
+	o.OrderState = stateFinished
+	for i := range o.Details {
+		o.Details[i].State = stateFinished
+	}
+}
+
+func (s *Server) addDetail(o *order, recType int32, price float64, qty float64, executionID string) {
+	now := s.now().In(jst)
+	d := detail{
+		SeqNum:       int32(len(o.Details) + 1),
+		ID:           s.nextID("D"),
+		RecType:      recType,
+		State:        stateProcessed,
+		TransactTime: now.Format(time.RFC3339),
+		OrdType:      ordTypeZaraba,
+		Price:        price,
+		Qty:          qty,
+		ExecutionID:  executionID,
+	}
+	if recType == recTypeExecuted {
+		d.ExecutionDay = now.Format(time.RFC3339)
+	}
+	if recType != recTypeAccepted {
+		d.ExchangeID = fmt.Sprintf("%08d", s.sequence)
+	}
+	o.Details = append(o.Details, d)
+}
+
+// nextID returns an ID such as "20220308A02N00000001".
+func (s *Server) nextID(kind string) string {
+	s.sequence++
+	return fmt.Sprintf("%s%s%08d", s.now().In(jst).Format("20060102"), kind, s.sequence)
+}
+
+// sellable returns the quantity of symbol not reserved by resting sell orders.
+func (s *Server) sellable(symbol string) float64 {
+	h := s.holdings[symbol]
+	if h == nil {
+		return 0
+	}
+	qty := h.Qty
+	for _, v := range s.orders {
+		if v.Symbol == symbol && v.Side == sideSell && v.active() {
+			qty -= v.remaining()
+		}
+	}
+	return qty
+}
+
+// wallet returns the cash not reserved by resting buy orders.
+func (s *Server) wallet() float64 {
+	cash := s.cash
+	for _, v := range s.orders {
+		if v.Side == sideBuy && v.active() {
+			cash -= v.Price * v.remaining()
+		}
+	}
+	return cash
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	var req autogen.RequestCancelOrder
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	if req.Password != s.cfg.OrderPassword {
+		writeError(w, http.StatusBadRequest, codeValidation, "パスワードが一致しません")
+		return
+	}
+
+	for _, v := range s.orders {
+		if v.ID != req.OrderId {
+			continue
+		}
+		if !v.active() {
+			writeError(w, http.StatusBadRequest, codeValidation, "取消できない注文です")
+			return
+		}
+		s.addDetail(v, recTypeCanceled, 0, v.remaining(), "")
+		s.finish(v)
+		writeJSON(w, http.StatusOK, autogen.OrderSuccess{Result: ptr(int32(0)), OrderId: ptr(v.ID)})
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, codeNotFound, fmt.Sprintf("order %s is not found", req.OrderId))
+}
+
+func (s *Server) getOrders(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	q := r.URL.Query()
+
+	res := []order{}
+	for _, v := range s.orders {
+		if id := q.Get("id"); id != "" && v.ID != id {
+			continue
+		}
+		if symbol := q.Get("symbol"); symbol != "" && v.Symbol != symbol {
+			continue
+		}
+		if side := q.Get("side"); side != "" && v.Side != side {
+			continue
+		}
+		if state := q.Get("state"); state != "" && strconv.Itoa(int(v.State)) != state {
+			continue
+		}
+
+		o := *v
+		if q.Get("details") == "false" {
+			o.Details = nil
+		}
+		res = append(res, o)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getPositions(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	q := r.URL.Query()
+
+	res := []autogen.PositionsSuccess{}
+	for _, code := range sortedKeys(s.holdings) {
+		if symbol := q.Get("symbol"); symbol != "" && code != symbol {
+			continue
+		}
+		if side := q.Get("side"); side != "" && side != sideBuy {
+			continue
+		}
+
+		h := s.holdings[code]
+		current := s.boards[code].CurrentPrice
+		res = append(res, autogen.PositionsSuccess{
+			Symbol:         ptr(code),
+			SymbolName:     ptr(s.boards[code].SymbolName),
+			Exchange:       ptr(h.Exchange),
+			ExchangeName:   ptr(exchangeName(h.Exchange)),
+			SecurityType:   ptr(int32(securityTypeStock)),
+			AccountType:    ptr(h.AccountType),
+			Side:           ptr(sideBuy),
+			Price:          ptr(h.Price),
+			LeavesQty:      ptr(h.Qty),
+			HoldQty:        ptr(h.Qty - s.sellable(code)),
+			CurrentPrice:   ptr(current),
+			Valuation:      ptr(current * h.Qty),
+			ProfitLoss:     ptr((current - h.Price) * h.Qty),
+			ProfitLossRate: ptr((current - h.Price) / h.Price * 100),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getWalletCash(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	writeJSON(w, http.StatusOK, autogen.WalletCashSuccess{StockAccountWallet: ptr(s.wallet())})
+}
+
+// getWalletMargin serves an empty margin wallet as margin trading is not simulated.
+func (s *Server) getWalletMargin(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) {
+	writeJSON(w, http.StatusOK, autogen.WalletMarginSuccess{MarginAccountWallet: ptr(0.0)})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,298 @@
+// Package kabucomtest provides an in-memory stand-in of the kabu STATION API.
+//
+// The Server validates every request against the OpenAPI spec embedded in the autogen
+// package, issues tokens, serves boards, and tracks cash orders, positions and wallets.
+package kabucomtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/autogen"
+)
+
+// BasePath is the path prefix of the kabu STATION API.
+const BasePath = "/kabusapi"
+
+// Level is a price level of a board.
+type Level struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+// Board is the order book of a symbol.
+type Board struct {
+	SymbolName   string  `json:"symbol_name"`
+	CurrentPrice float64 `json:"current_price"`
+	// TradingUnit is the lot size served by GET /symbol. Zero serves 100 shares.
+	TradingUnit float64 `json:"trading_unit"`
+	// Bids and Asks are ordered from the best price. Up to 10 levels are served.
+	Bids []Level `json:"bids"`
+	Asks []Level `json:"asks"`
+}
+
+// Config is the initial state of a Server.
+type Config struct {
+	// APIPassword is the password accepted by POST /token.
+	APIPassword string
+	// OrderPassword is the trading password required to send and cancel orders.
+	OrderPassword string
+	// Cash is the initial stock account wallet in JPY.
+	Cash float64
+	// Boards are the initial boards per symbol code such as "9433".
+	Boards map[string]Board
+}
+
+// DefaultConfig returns a configuration with boards of 9433 and 7203, and 1,000,000 JPY.
+func DefaultConfig() Config {
+	return Config{
+		APIPassword:   "fake-password",
+		OrderPassword: "fake-order-password",
+		Cash:          1000000,
+		Boards: map[string]Board{
+			"9433": ladder("ＫＤＤＩ", 4000, 1, 100),
+			"7203": ladder("トヨタ自動車", 2500, 0.5, 1000),
+		},
+	}
+}
+
+// ladder returns a board of 10 levels per side around price.
+func ladder(name string, price float64, tick float64, qty float64) Board {
+	board := Board{SymbolName: name, CurrentPrice: price}
+	for i := 1; i <= 10; i++ {
+		board.Bids = append(board.Bids, Level{Price: price - tick*float64(i), Qty: qty * float64(i)})
+		board.Asks = append(board.Asks, Level{Price: price + tick*float64(i), Qty: qty * float64(i)})
+	}
+	return board
+}
+
+// Error codes of ErrorResponse, following the ones kabu STATION returns.
+const (
+	codeInvalidParameter = 4001005
+	codeValidation       = 4001006
+	codeLoginFailed      = 4001007
+	codeAPIKeyMismatch   = 4001009
+	codeNotFound         = 4002001
+	codeNotImplemented   = 4002999
+)
+
+// Server is a fake kabu STATION API. It implements http.Handler.
+type Server struct {
+	mu  sync.Mutex
+	cfg Config
+	now func() time.Time
+
+	router   routers.Router
+	token    string
+	cash     float64
+	boards   map[string]*Board
+	orders   []*order
+	holdings map[string]*holding
+	sequence int64
+	handlers map[route]handlerFunc
+}
+
+// NewServer returns a Server with the initial state cfg.
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		cfg:      cfg,
+		now:      time.Now,
+		router:   newRouter(),
+		cash:     cfg.Cash,
+		boards:   map[string]*Board{},
+		holdings: map[string]*holding{},
+	}
+	s.handlers = s.routes()
+	for k, v := range cfg.Boards {
+		s.SetBoard(k, v)
+	}
+	return s
+}
+
+// NewTestServer starts a Server on a local port. The caller must Close the returned httptest.Server.
+// The API is served under BasePath of the httptest.Server URL.
+func NewTestServer(cfg Config) (*Server, *httptest.Server) {
+	s := NewServer(cfg)
+	return s, httptest.NewServer(s)
+}
+
+// NewClient returns a kabu STATION client for the server listening at endPoint, such as an
+// httptest.Server URL.
+func (s *Server) NewClient(endPoint string) *kabucom.KabucomClient {
+	return kabucom.NewKabucomClient(config.Config{
+		KabucomAPIHost: strings.TrimSuffix(endPoint, "/") + BasePath,
+	})
+}
+
+// newRouter returns a router of the embedded spec matching paths below BasePath on any host.
+func newRouter() routers.Router {
+	doc, err := autogen.GetSwagger()
+	if err != nil {
+		panic(err)
+	}
+	doc.Servers = openapi3.Servers{}
+
+	// Some examples of the spec do not conform to their schemas.
+	router, err := legacy.NewRouter(doc, openapi3.DisableExamplesValidation())
+	if err != nil {
+		panic(err)
+	}
+	return router
+}
+
+// SetClock replaces the clock used for order and execution times.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetBoard replaces the board of symbol, and fills resting orders crossed by it.
+func (s *Server) SetBoard(symbol string, board Board) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := Board{
+		SymbolName:   board.SymbolName,
+		CurrentPrice: board.CurrentPrice,
+		TradingUnit:  board.TradingUnit,
+		Bids:         append([]Level{}, board.Bids...),
+		Asks:         append([]Level{}, board.Asks...),
+	}
+	sort.Slice(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	sort.Slice(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+	s.boards[symbol] = &b
+
+	for _, v := range s.orders {
+		if v.Symbol == symbol && v.active() {
+			s.match(v)
+		}
+	}
+}
+
+// Cash returns the current stock account cash, including the amount reserved by resting orders.
+func (s *Server) Cash() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cash
+}
+
+// Token returns the last issued token. It is empty until POST /token succeeds.
+func (s *Server) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, BasePath+"/") {
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidParameter, "can not read request body")
+		return
+	}
+
+	req := r.Clone(r.Context())
+	req.URL.Path = strings.TrimPrefix(r.URL.Path, BasePath)
+	req.URL.RawPath = ""
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	spec, pathParams, err := s.router.FindRoute(req)
+	if errors.Is(err, routers.ErrMethodNotAllowed) {
+		writeError(w, http.StatusMethodNotAllowed, codeNotFound, "method not allowed")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+		return
+	}
+
+	if token := s.Token(); spec.Path != "/token" && (token == "" || r.Header.Get("X-API-KEY") != token) {
+		writeError(w, http.StatusUnauthorized, codeAPIKeyMismatch, "APIキー不一致")
+		return
+	}
+
+	if err := validate(r.Context(), req, spec, pathParams); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	handler := s.handlers[route{spec.Method, spec.Path}]
+	if handler == nil {
+		writeError(w, http.StatusNotImplemented, codeNotImplemented,
+			fmt.Sprintf("%s %s is not implemented by the fake server", spec.Method, spec.Path))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	handler(w, req, pathParams, body)
+}
+
+// validate checks parameters and the body of req against the spec.
+func validate(ctx context.Context, req *http.Request, spec *routers.Route, pathParams map[string]string) error {
+	return openapi3filter.ValidateRequest(ctx, &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      spec,
+	})
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte)
+
+type route struct {
+	method string
+	path   string
+}
+
+func (s *Server) routes() map[route]handlerFunc {
+	return map[route]handlerFunc{
+		{"POST", "/token"}:                 s.postToken,
+		{"GET", "/board/{symbol}"}:         s.getBoard,
+		{"GET", "/symbol/{symbol}"}:        s.getSymbol,
+		{"POST", "/sendorder"}:             s.sendOrder,
+		{"PUT", "/cancelorder"}:            s.cancelOrder,
+		{"GET", "/orders"}:                 s.getOrders,
+		{"GET", "/positions"}:              s.getPositions,
+		{"GET", "/wallet/cash"}:            s.getWalletCash,
+		{"GET", "/wallet/cash/{symbol}"}:   s.getWalletCash,
+		{"GET", "/wallet/margin"}:          s.getWalletMargin,
+		{"GET", "/wallet/margin/{symbol}"}: s.getWalletMargin,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, httpStatus int, code int32, msg string) {
+	writeJSON(w, httpStatus, autogen.ErrorResponse{Code: &code, Message: &msg})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,337 @@
+package kabucomtest_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/autogen"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/kabucomtest"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func newTestClient(t *testing.T, cfg kabucomtest.Config) (*kabucomtest.Server, *autogen.ClientWithResponses, string) {
+	t.Helper()
+	s, ts := kabucomtest.NewTestServer(cfg)
+	t.Cleanup(ts.Close)
+
+	token, err := s.NewClient(ts.URL).GetToken(context.Background(), cfg.APIPassword)
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	client, err := autogen.NewClientWithResponses(ts.URL + kabucomtest.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, client, token
+}
+
+func order(side string, frontOrderType int32, price float64, qty int32) autogen.RequestSendOrder {
+	return autogen.RequestSendOrder{
+		Symbol:         "9433",
+		Exchange:       1,
+		SecurityType:   1,
+		Side:           side,
+		CashMargin:     1,
+		DelivType:      2,
+		AccountType:    4,
+		Qty:            qty,
+		FrontOrderType: frontOrderType,
+		Price:          price,
+		Password:       "fake-order-password",
+	}
+}
+
+func TestServer_token(t *testing.T) {
+	s, ts := kabucomtest.NewTestServer(kabucomtest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+
+	if _, err := client.GetToken(context.Background(), "wrong"); !errors.Is(err, cerror.ErrUnAuthorized) {
+		t.Errorf("GetToken(wrong) error = %v, expectedError %v", err, cerror.ErrUnAuthorized)
+	}
+
+	token, err := client.GetToken(context.Background(), "fake-password")
+	if err != nil || token == "" || token != s.Token() {
+		t.Errorf("GetToken() = %v, %v, want %v", token, err, s.Token())
+	}
+}
+
+func TestServer_authenticationAndValidation(t *testing.T) {
+	_, client, token := newTestClient(t, kabucomtest.DefaultConfig())
+	ctx := context.Background()
+
+	res, err := client.WalletCashGetWithResponse(ctx, &autogen.WalletCashGetParams{XAPIKEY: "stale"})
+	if err != nil || res.StatusCode() != 401 || res.JSON401 == nil || *res.JSON401.Code != 4001009 {
+		t.Errorf("WalletCashGet(stale) = %v, %s, %v", res.StatusCode(), res.Body, err)
+	}
+
+	// Qty is missing.
+	raw, err := client.SendorderPostWithBodyWithResponse(ctx, &autogen.SendorderPostParams{XAPIKEY: token},
+		"application/json", strings.NewReader(`{"Symbol":"9433","Exchange":1,"SecurityType":1,"Side":"2"}`))
+	if err != nil || raw.StatusCode() != 400 || raw.JSON400 == nil || *raw.JSON400.Code != 4001005 {
+		t.Errorf("SendorderPost(invalid) = %v, %s, %v", raw.StatusCode(), raw.Body, err)
+	}
+}
+
+func TestServer_board(t *testing.T) {
+	_, client, token := newTestClient(t, kabucomtest.DefaultConfig())
+	ctx := context.Background()
+
+	res, err := client.BoardGetWithResponse(ctx, "9433@1", &autogen.BoardGetParams{XAPIKEY: token})
+	if err != nil || res.JSON200 == nil {
+		t.Fatalf("BoardGet() = %s, %v", res.Body, err)
+	}
+	board := res.JSON200
+	if *board.Symbol != "9433" || *board.CurrentPrice != 4000 || *board.AskPrice != 3999 || *board.BidPrice != 4001 {
+		t.Errorf("BoardGet() = %s", res.Body)
+	}
+	if board.Sell1 == nil || *board.Sell1.Price != 4001 || board.Buy10 == nil || *board.Buy10.Price != 3990 {
+		t.Errorf("BoardGet() levels = %s", res.Body)
+	}
+
+	res, err = client.BoardGetWithResponse(ctx, "0000@1", &autogen.BoardGetParams{XAPIKEY: token})
+	if err != nil || res.StatusCode() != 400 {
+		t.Errorf("BoardGet(unknown) = %v, %v", res.StatusCode(), err)
+	}
+}
+
+func TestServer_orders(t *testing.T) {
+	s, client, token := newTestClient(t, kabucomtest.DefaultConfig())
+	ctx := context.Background()
+	params := &autogen.SendorderPostParams{XAPIKEY: token}
+	s.SetBoard("9433", kabucomtest.Board{
+		CurrentPrice: 1000,
+		Asks:         []kabucomtest.Level{{Price: 1000, Qty: 100}, {Price: 1001, Qty: 1000}},
+	})
+
+	tests := []struct {
+		name      string
+		order     autogen.RequestSendOrder
+		wantState int32
+		wantCum   float64
+		wantCash  float64
+	}{
+		{
+			name:      "Market Buy",
+			order:     order("2", 10, 0, 300),
+			wantState: 5,
+			wantCum:   300,
+			wantCash:  1000000 - 100*1000 - 200*1001,
+		},
+		{
+			name:      "Resting Limit Sell",
+			order:     order("1", 20, 4100, 100),
+			wantState: 3,
+			wantCum:   0,
+			wantCash:  1000000 - 100*1000 - 200*1001,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.SendorderPostWithResponse(ctx, params, tt.order)
+			if err != nil || res.JSON200 == nil {
+				t.Fatalf("SendorderPost() = %s, %v", res.Body, err)
+			}
+
+			id := *res.JSON200.OrderId
+			orders, err := client.OrdersGetWithResponse(ctx, &autogen.OrdersGetParams{XAPIKEY: token, Id: &id})
+			if err != nil || orders.JSON200 == nil || len(*orders.JSON200) != 1 {
+				t.Fatalf("OrdersGet() = %s, %v", orders.Body, err)
+			}
+			got := (*orders.JSON200)[0]
+			if *got.State != tt.wantState || *got.CumQty != tt.wantCum {
+				t.Errorf("OrdersGet() = %s", orders.Body)
+			}
+			if cash := s.Cash(); cash != tt.wantCash {
+				t.Errorf("Cash() = %v, want %v", cash, tt.wantCash)
+			}
+		})
+	}
+
+	positions, err := client.PositionsGetWithResponse(ctx, &autogen.PositionsGetParams{XAPIKEY: token})
+	if err != nil || positions.JSON200 == nil || len(*positions.JSON200) != 1 {
+		t.Fatalf("PositionsGet() = %s, %v", positions.Body, err)
+	}
+	if p := (*positions.JSON200)[0]; *p.LeavesQty != 300 || *p.HoldQty != 100 || *p.Price != (100*1000+200*1001)/300.0 {
+		t.Errorf("PositionsGet() = %s", positions.Body)
+	}
+
+	// Selling more than the unreserved holding is rejected.
+	res, err := client.SendorderPostWithResponse(ctx, params, order("1", 20, 4100, 300))
+	if err != nil || res.StatusCode() != 400 {
+		t.Errorf("SendorderPost(oversell) = %v, %v", res.StatusCode(), err)
+	}
+
+	// The resting sell is filled by a new board.
+	s.SetBoard("9433", kabucomtest.Board{CurrentPrice: 4100, Bids: []kabucomtest.Level{{Price: 4200, Qty: 1000}}})
+	if cash, want := s.Cash(), 1000000-100*1000-200*1001+4200*100.0; cash != want {
+		t.Errorf("Cash() after fill = %v, want %v", cash, want)
+	}
+}
+
+func TestServer_cancelOrder(t *testing.T) {
+	_, client, token := newTestClient(t, kabucomtest.DefaultConfig())
+	ctx := context.Background()
+
+	res, err := client.SendorderPostWithResponse(ctx, &autogen.SendorderPostParams{XAPIKEY: token}, order("2", 20, 3000, 100))
+	if err != nil || res.JSON200 == nil {
+		t.Fatalf("SendorderPost() = %s, %v", res.Body, err)
+	}
+	id := *res.JSON200.OrderId
+
+	wallet, err := client.WalletCashGetWithResponse(ctx, &autogen.WalletCashGetParams{XAPIKEY: token})
+	if err != nil || wallet.JSON200 == nil || *wallet.JSON200.StockAccountWallet != 700000 {
+		t.Errorf("WalletCashGet() = %s, %v", wallet.Body, err)
+	}
+
+	tests := []struct {
+		name       string
+		body       autogen.RequestCancelOrder
+		wantStatus int
+	}{
+		{name: "Wrong Password", body: autogen.RequestCancelOrder{OrderId: id, Password: "wrong"}, wantStatus: 400},
+		{name: "Unknown Order", body: autogen.RequestCancelOrder{OrderId: "unknown", Password: "fake-order-password"}, wantStatus: 400},
+		{name: "Success", body: autogen.RequestCancelOrder{OrderId: id, Password: "fake-order-password"}, wantStatus: 200},
+		{name: "Already Canceled", body: autogen.RequestCancelOrder{OrderId: id, Password: "fake-order-password"}, wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.CancelorderPutWithResponse(ctx, &autogen.CancelorderPutParams{XAPIKEY: token}, tt.body)
+			if err != nil || res.StatusCode() != tt.wantStatus {
+				t.Errorf("CancelorderPut() = %v, %s, %v, want %v", res.StatusCode(), res.Body, err, tt.wantStatus)
+			}
+		})
+	}
+
+	wallet, err = client.WalletCashGetWithResponse(ctx, &autogen.WalletCashGetParams{XAPIKEY: token})
+	if err != nil || wallet.JSON200 == nil || *wallet.JSON200.StockAccountWallet != 1000000 {
+		t.Errorf("WalletCashGet() after cancel = %s, %v", wallet.Body, err)
+	}
+}
+
+func TestServer_boardClient(t *testing.T) {
+	s, ts := kabucomtest.NewTestServer(kabucomtest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	ctx := context.Background()
+
+	token, err := client.GetToken(ctx, "fake-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	board, err := client.GetBoard(ctx, token, "7203@1")
+	if err != nil {
+		t.Fatalf("KabucomClient.GetBoard() error = %v", err)
+	}
+	if len(board.Bids) != 10 || len(board.Asks) != 10 || board.Bids[0].Price != 2499.5 || board.Asks[9].Price != 2505 {
+		t.Errorf("KabucomClient.GetBoard() = %+v", board)
+	}
+
+	if _, err := client.GetBoard(ctx, "stale", "7203@1"); !errors.Is(err, cerror.ErrUnAuthorized) {
+		t.Errorf("KabucomClient.GetBoard(stale) error = %v, expectedError %v", err, cerror.ErrUnAuthorized)
+	}
+}
+
+func TestServer_executionsClient(t *testing.T) {
+	s, ts := kabucomtest.NewTestServer(kabucomtest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	ctx := context.Background()
+
+	token, err := client.GetToken(ctx, "fake-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := autogen.NewClientWithResponses(ts.URL + kabucomtest.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := raw.SendorderPostWithResponse(ctx, &autogen.SendorderPostParams{XAPIKEY: token}, order("2", 10, 0, 100))
+	if err != nil || res.JSON200 == nil {
+		t.Fatalf("SendorderPost() = %s, %v", res.Body, err)
+	}
+
+	executions, err := client.GetExecutions(ctx, token)
+	if err != nil {
+		t.Fatalf("KabucomClient.GetExecutions() error = %v", err)
+	}
+	if len(executions) != 1 || executions[0].Symbol != "9433@1" || executions[0].Side != "BUY" || executions[0].Qty != 100 ||
+		executions[0].OrderID != *res.JSON200.OrderId || executions[0].Time.IsZero() {
+		t.Errorf("KabucomClient.GetExecutions() = %+v", executions)
+	}
+
+	cash, err := client.GetCashWallet(ctx, token)
+	if err != nil {
+		t.Fatalf("KabucomClient.GetCashWallet() error = %v", err)
+	}
+	if cash != s.Cash() {
+		t.Errorf("KabucomClient.GetCashWallet() = %v, want %v", cash, s.Cash())
+	}
+}
+
+func TestServer_positionsClient(t *testing.T) {
+	s, ts := kabucomtest.NewTestServer(kabucomtest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	ctx := context.Background()
+
+	token, err := client.GetToken(ctx, "fake-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := autogen.NewClientWithResponses(ts.URL + kabucomtest.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := raw.SendorderPostWithResponse(ctx, &autogen.SendorderPostParams{XAPIKEY: token}, order("2", 10, 0, 100)); err != nil || res.JSON200 == nil {
+		t.Fatalf("SendorderPost() = %s, %v", res.Body, err)
+	}
+
+	positions, err := client.GetPositions(ctx, token)
+	if err != nil {
+		t.Fatalf("KabucomClient.GetPositions() error = %v", err)
+	}
+	if len(positions) != 1 || positions[0].Symbol != "9433@1" || positions[0].Qty != 100 || positions[0].CurrentPrice == 0 {
+		t.Errorf("KabucomClient.GetPositions() = %+v", positions)
+	}
+
+	symbol, err := client.GetSymbol(ctx, token, "9433@1")
+	if err != nil {
+		t.Fatalf("KabucomClient.GetSymbol() error = %v", err)
+	}
+	if symbol.Symbol != "9433" || symbol.TradingUnit != 100 {
+		t.Errorf("KabucomClient.GetSymbol() = %+v", symbol)
+	}
+	if _, err := client.GetSymbol(ctx, token, "0000@1"); !errors.Is(err, cerror.ErrBadRequest) {
+		t.Errorf("KabucomClient.GetSymbol(0000) error = %v, expectedError %v", err, cerror.ErrBadRequest)
+	}
+}
+
+func TestServer_sendOrderClient(t *testing.T) {
+	s, ts := kabucomtest.NewTestServer(kabucomtest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	ctx := context.Background()
+
+	token, err := client.GetToken(ctx, "fake-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := client.SendOrder(ctx, token, kabucom.OrderRequest{Symbol: "9433@1", Side: "BUY", Qty: 100, Password: "fake-order-password"})
+	if err != nil || id == "" {
+		t.Fatalf("KabucomClient.SendOrder() = %q, %v", id, err)
+	}
+	positions, err := client.GetPositions(ctx, token)
+	if err != nil || len(positions) != 1 || positions[0].Qty != 100 {
+		t.Errorf("KabucomClient.GetPositions() = %+v, %v", positions, err)
+	}
+
+	if _, err := client.SendOrder(ctx, token, kabucom.OrderRequest{Symbol: "9433@1", Side: "SELL", Qty: 100, Price: 1, Password: "wrong"}); !errors.Is(err, cerror.ErrBadRequest) {
+		t.Errorf("KabucomClient.SendOrder(wrong password) error = %v, expectedError %v", err, cerror.ErrBadRequest)
+	}
+}
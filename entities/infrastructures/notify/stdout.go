@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer writes notifications as lines, such as to stdout.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func newStdout(target string) (Notifier, error) {
+	return NewWriter(os.Stdout), nil
+}
+
+// Notify writes "<time> <title>: <text>".
+func (w *Writer) Notify(ctx context.Context, n Notification) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.w, "%s %s: %s\n", n.Time.Format(time.RFC3339), n.Title, n.Text)
+	return err
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/sn1w/capital-go/internal/transport"
+)
+
+// Webhook posts notifications as JSON to a URL.
+type Webhook struct {
+	url string
+	hc  *http.Client
+	// body returns the request body of a notification.
+	body func(n Notification) any
+}
+
+// NewWebhook returns a Webhook posting notifications as they are: {"time", "title", "text", "data"}.
+func NewWebhook(url string) (*Webhook, error) {
+	return newWebhookOf(url, func(n Notification) any { return n })
+}
+
+// NewSlack returns a Webhook posting to a Slack compatible incoming webhook: {"text"}.
+func NewSlack(url string) (*Webhook, error) {
+	return newWebhookOf(url, func(n Notification) any {
+		return map[string]string{"text": fmt.Sprintf("*%s*\n%s", n.Title, n.Text)}
+	})
+}
+
+func newWebhookOf(target string, body func(n Notification) any) (*Webhook, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook needs an http or https URL: %q", target)
+	}
+	return &Webhook{url: target, hc: transport.NewClient(), body: body}, nil
+}
+
+func newWebhook(target string) (Notifier, error) {
+	return NewWebhook(target)
+}
+
+func newSlack(target string) (Notifier, error) {
+	return NewSlack(target)
+}
+
+// Notify posts n, and fails unless the response status is 2xx.
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	raw, err := json.Marshal(w.body(n))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("webhook responded %d: %s", res.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	n := Notification{
+		Time:  time.Date(2023, 4, 1, 9, 0, 0, 0, time.UTC),
+		Title: "alert 1",
+		Text:  "BTC_JPY price 100 rose to 100 or above",
+		Data:  map[string]any{"id": 1},
+	}
+	tests := []struct {
+		name    string
+		new     func(url string) (*Webhook, error)
+		status  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "webhook",
+			new:    NewWebhook,
+			status: 200,
+			want:   `{"time":"2023-04-01T09:00:00Z","title":"alert 1","text":"BTC_JPY price 100 rose to 100 or above","data":{"id":1}}`,
+		},
+		{
+			name:   "slack",
+			new:    NewSlack,
+			status: 200,
+			want:   `{"text":"*alert 1*\nBTC_JPY price 100 rose to 100 or above"}`,
+		},
+		{
+			name:    "failure",
+			new:     NewWebhook,
+			status:  500,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				raw, _ := io.ReadAll(r.Body)
+				body = string(raw)
+				if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || !json.Valid(raw) {
+					w.WriteHeader(400)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			w, err := tt.new(ts.URL + "/hook")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Notify(context.Background(), n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Webhook.Notify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && body != tt.want {
+				t.Errorf("Webhook.Notify() posted %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
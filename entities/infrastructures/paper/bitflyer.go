@@ -0,0 +1,311 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// BitFlyer is a simulated bitFlyer account. It satisfies usecases.BitFlyerClient:
+// public data comes from MarketData, while orders are filled against its boards
+// and balances are kept in a Store.
+//
+// Fees are charged in the quote currency. Resting orders are matched again
+// against the current board whenever the account is accessed.
+type BitFlyer struct {
+	market MarketData
+	store  Store
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// NewBitFlyer returns a paper account trading against market.
+func NewBitFlyer(market MarketData, store Store) *BitFlyer {
+	return &BitFlyer{
+		market: market,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+// currencies splits a product code such as BTC_JPY into base and quote currencies.
+func currencies(productCode string) (base string, quote string, err error) {
+	parts := strings.Split(strings.TrimPrefix(productCode, "FX_"), "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%w: paper trading does not support %s", cerror.ErrBadRequest, productCode)
+	}
+	return parts[0], parts[1], nil
+}
+
+func (b *BitFlyer) GetAvaiableMarkets(ctx context.Context) (bitflyer.GetMarketsResponse, error) {
+	return b.market.GetAvaiableMarkets(ctx)
+}
+
+func (b *BitFlyer) GetBoard(ctx context.Context, productCode string) (*bitflyer.BoardResponse, error) {
+	return b.market.GetBoard(ctx, productCode)
+}
+
+// executionSource is MarketData providing the execution history, such as *bitflyer.BitFlyer.
+type executionSource interface {
+	GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error)
+}
+
+// GetExecutions returns the execution history of the market, if MarketData provides it.
+func (b *BitFlyer) GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+	source, ok := b.market.(executionSource)
+	if !ok {
+		return nil, fmt.Errorf("%w: the market data of paper trading has no executions", cerror.ErrBadRequest)
+	}
+	return source.GetExecutions(ctx, productCode, page)
+}
+
+// GetBalance returns the virtual balances. Funds reserved by resting orders are not available.
+func (b *BitFlyer) GetBalance(ctx context.Context) (bitflyer.GetBalancesResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := reservedFunds(state, currencies)
+	res := bitflyer.GetBalancesResponse{}
+	for _, code := range sortedKeys(state.Balances) {
+		res = append(res, bitflyer.BalanceResponse{
+			CurrencyCode: code,
+			Amount:       state.Balances[code],
+			Available:    state.Balances[code] - reserved[code],
+		})
+	}
+	return res, nil
+}
+
+// SendOrder fills the order against the current board. The unfilled part of a GTC limit order rests.
+func (b *BitFlyer) SendOrder(ctx context.Context, req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: size must be positive", cerror.ErrBadRequest)
+	}
+	if req.ChildOrderType != bitflyer.ChildOrderTypeMarket && req.Price <= 0 {
+		return nil, fmt.Errorf("%w: price must be positive for limit orders", cerror.ErrBadRequest)
+	}
+	base, quote, err := currencies(req.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := b.market.GetBoard(ctx, req.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Price
+	if req.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+		limit = 0
+	}
+	fills := Match(req.Side, limit, req.Size, board)
+	filled, notional := filledSize(fills)
+
+	if req.TimeInForce == bitflyer.TimeInForceFOK && filled < req.Size-execution.SizeEpsilon {
+		fills, filled, notional = nil, 0, 0
+	}
+
+	resting := req.ChildOrderType == bitflyer.ChildOrderTypeLimit &&
+		req.TimeInForce != bitflyer.TimeInForceIOC && req.TimeInForce != bitflyer.TimeInForceFOK
+	reserved := reservedFunds(state, currencies)
+	if req.Side == bitflyer.SideBuy {
+		need := notional * (1 + state.FeeRate)
+		if resting {
+			need = req.Price * req.Size * (1 + state.FeeRate)
+		}
+		if available := state.Balances[quote] - reserved[quote]; need > available+execution.SizeEpsilon {
+			return nil, fmt.Errorf("%w: need %f %s, available %f", cerror.ErrInsufficientFunds, need, quote, available)
+		}
+	} else if available := state.Balances[base] - reserved[base]; req.Size > available+execution.SizeEpsilon {
+		return nil, fmt.Errorf("%w: need %f %s, available %f", cerror.ErrInsufficientFunds, req.Size, base, available)
+	}
+
+	state.Sequence++
+	order := Order{
+		AcceptanceId:   fmt.Sprintf("PAPER%s-%06d", b.now().Format("20060102-150405"), state.Sequence),
+		ProductCode:    req.ProductCode,
+		ChildOrderType: req.ChildOrderType,
+		Side:           req.Side,
+		Price:          req.Price,
+		Size:           req.Size,
+		Remaining:      req.Size,
+		TimeInForce:    req.TimeInForce,
+		OrderedAt:      b.now(),
+		MatchedBoard:   boardKey(board),
+	}
+	execute(state, &order, fills, base, quote, b.now())
+
+	if resting && order.Remaining > execution.SizeEpsilon {
+		state.Orders = append(state.Orders, order)
+	}
+
+	if err := b.store.Save(state); err != nil {
+		return nil, err
+	}
+	return &bitflyer.OrderResponse{ChildOrderAcceptanceId: order.AcceptanceId}, nil
+}
+
+// CancelOrder removes a resting order. Its filled part stays executed.
+func (b *BitFlyer) CancelOrder(ctx context.Context, req bitflyer.CancelOrderRequest) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, err := b.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range state.Orders {
+		if v.ProductCode == req.ProductCode && v.AcceptanceId == req.ChildOrderAcceptanceId {
+			state.Orders = append(state.Orders[:i], state.Orders[i+1:]...)
+			return b.store.Save(state)
+		}
+	}
+	return fmt.Errorf("%w: %s", cerror.ErrOrderNotFound, req.ChildOrderAcceptanceId)
+}
+
+// GetMyExecutions returns the simulated fills of an order, or of every order when childOrderAcceptanceId
+// is empty, in the page, newest first as bitFlyer does.
+func (b *BitFlyer) GetMyExecutions(ctx context.Context, productCode string, childOrderAcceptanceId string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := bitflyer.GetPrivateExecutionsResponse{}
+	for i := len(state.Executions) - 1; i >= 0; i-- {
+		v, id := state.Executions[i], int64(i+1)
+		if page.Count > 0 && len(res) >= page.Count {
+			break
+		}
+		if v.ProductCode != productCode || (childOrderAcceptanceId != "" && v.AcceptanceId != childOrderAcceptanceId) ||
+			(page.Before > 0 && id >= page.Before) || id <= page.After {
+			continue
+		}
+		res = append(res, bitflyer.PrivateExecutionResponse{
+			Id:                     id,
+			Side:                   v.Side,
+			Price:                  v.Price,
+			Size:                   v.Size,
+			Commission:             v.Commission,
+			ExecDate:               v.ExecutedAt.UTC().Format("2006-01-02T15:04:05.000"),
+			ChildOrderAcceptanceId: v.AcceptanceId,
+		})
+	}
+	return res, nil
+}
+
+// GetCoinIns returns no deposits: the paper account is only funded by its initial balances.
+func (b *BitFlyer) GetCoinIns(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error) {
+	return bitflyer.GetCoinInsResponse{}, nil
+}
+
+// GetCoinOuts returns no withdrawals.
+func (b *BitFlyer) GetCoinOuts(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error) {
+	return bitflyer.GetCoinOutsResponse{}, nil
+}
+
+// GetPositions returns the position of an FX product. The paper broker settles FX products against
+// the spot balances, so the position is the balance of the base currency.
+func (b *BitFlyer) GetPositions(ctx context.Context, productCode string) (bitflyer.GetPositionsResponse, error) {
+	if !strings.HasPrefix(productCode, "FX_") {
+		return bitflyer.GetPositionsResponse{}, nil
+	}
+	base, _, err := currencies(productCode)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := bitflyer.GetPositionsResponse{}
+	if size := state.Balances[base]; size > 0 {
+		res = append(res, bitflyer.PositionResponse{ProductCode: productCode, Side: bitflyer.SideBuy, Size: size})
+	}
+	return res, nil
+}
+
+// GetChildOrders returns the resting orders of productCode, newest first.
+// Filled and cancelled orders are not kept, so other states return none.
+func (b *BitFlyer) GetChildOrders(ctx context.Context, productCode string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := bitflyer.GetChildOrdersResponse{}
+	if state != "" && state != bitflyer.ChildOrderStateActive {
+		return res, nil
+	}
+	for i := len(current.Orders) - 1; i >= 0; i-- {
+		v := current.Orders[i]
+		if v.ProductCode != productCode {
+			continue
+		}
+		res = append(res, bitflyer.ChildOrderResponse{
+			ProductCode:            v.ProductCode,
+			Side:                   v.Side,
+			ChildOrderType:         v.ChildOrderType,
+			Price:                  v.Price,
+			Size:                   v.Size,
+			ChildOrderState:        bitflyer.ChildOrderStateActive,
+			ChildOrderDate:         v.OrderedAt.UTC().Format("2006-01-02T15:04:05.000"),
+			ChildOrderAcceptanceId: v.AcceptanceId,
+			OutstandingSize:        v.Remaining,
+			ExecutedSize:           v.Size - v.Remaining,
+		})
+	}
+	return res, nil
+}
+
+// load reads the state and matches resting orders against the current boards.
+func (b *BitFlyer) load(ctx context.Context) (*State, error) {
+	state, err := b.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Orders) == 0 {
+		return state, nil
+	}
+
+	board := func(productCode string) (*bitflyer.BoardResponse, error) {
+		return b.market.GetBoard(ctx, productCode)
+	}
+	if err := matchOrders(state, currencies, board, b.now()); err != nil {
+		return nil, err
+	}
+	if err := b.store.Save(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
@@ -0,0 +1,277 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func newTestBoards() *RecordedBoards {
+	return NewRecordedBoards(map[string][]bitflyer.BoardResponse{
+		"BTC_JPY": {
+			{
+				MidPrice: 100,
+				Bids:     bitflyer.PriceResponses{{Price: 99, Size: 1}},
+				Asks:     bitflyer.PriceResponses{{Price: 101, Size: 0.5}, {Price: 102, Size: 1}},
+			},
+			{
+				MidPrice: 95,
+				Bids:     bitflyer.PriceResponses{{Price: 94, Size: 1}},
+				Asks:     bitflyer.PriceResponses{{Price: 96, Size: 2}},
+			},
+		},
+	})
+}
+
+func balances(t *testing.T, b *BitFlyer) map[string]bitflyer.BalanceResponse {
+	t.Helper()
+	res, err := b.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("BitFlyer.GetBalance() error = %v", err)
+	}
+	m := map[string]bitflyer.BalanceResponse{}
+	for _, v := range res {
+		m[v.CurrencyCode] = v
+	}
+	return m
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestBitFlyer_SendOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         bitflyer.SendOrderRequest
+		wantBTC     float64
+		wantJPY     float64
+		wantOrders  int
+		expectedErr error
+	}{
+		{
+			name: "market buy fills across levels with fees",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideBuy, Size: 1,
+			},
+			wantBTC: 1,
+			wantJPY: 1000 - (101*0.5+102*0.5)*1.01,
+		},
+		{
+			name: "GTC limit buy rests partially",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+				Price: 101, Size: 1, TimeInForce: bitflyer.TimeInForceGTC,
+			},
+			wantBTC:    0.5,
+			wantJPY:    1000 - 101*0.5*1.01,
+			wantOrders: 1,
+		},
+		{
+			name: "IOC limit buy drops the rest",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+				Price: 101, Size: 1, TimeInForce: bitflyer.TimeInForceIOC,
+			},
+			wantBTC: 0.5,
+			wantJPY: 1000 - 101*0.5*1.01,
+		},
+		{
+			name: "FOK limit buy is not filled partially",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+				Price: 101, Size: 1, TimeInForce: bitflyer.TimeInForceFOK,
+			},
+			wantJPY: 1000,
+		},
+		{
+			name: "insufficient JPY",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+				Price: 101, Size: 100,
+			},
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+		{
+			name: "insufficient BTC",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideSell, Size: 1,
+			},
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+		{
+			name: "invalid size",
+			req: bitflyer.SendOrderRequest{
+				ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideBuy,
+			},
+			expectedErr: cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MemoryStore{State: &State{FeeRate: 0.01, Balances: map[string]float64{"JPY": 1000}}}
+			b := NewBitFlyer(newTestBoards(), store)
+
+			res, err := b.SendOrder(context.Background(), tt.req)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("BitFlyer.SendOrder() error = %v, expectedErr %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil || res.ChildOrderAcceptanceId == "" {
+				t.Fatalf("BitFlyer.SendOrder() = %v, %v", res, err)
+			}
+
+			if got := store.State.Balances["BTC"]; !almostEqual(got, tt.wantBTC) {
+				t.Errorf("BTC = %v, want %v", got, tt.wantBTC)
+			}
+			if got := store.State.Balances["JPY"]; !almostEqual(got, tt.wantJPY) {
+				t.Errorf("JPY = %v, want %v", got, tt.wantJPY)
+			}
+			if got := len(store.State.Orders); got != tt.wantOrders {
+				t.Errorf("resting orders = %v, want %v", got, tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_restingOrder(t *testing.T) {
+	store := &MemoryStore{State: &State{FeeRate: 0, Balances: map[string]float64{"JPY": 1000}}}
+	b := NewBitFlyer(newTestBoards(), store)
+
+	_, err := b.SendOrder(context.Background(), bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+		Price: 100, Size: 1, TimeInForce: bitflyer.TimeInForceGTC,
+	})
+	if err != nil {
+		t.Fatalf("BitFlyer.SendOrder() error = %v", err)
+	}
+
+	// The order was placed against the first board, and is filled when the second board is observed.
+	got := balances(t, b)
+	if !almostEqual(got["BTC"].Amount, 1) || !almostEqual(got["JPY"].Amount, 1000-96) {
+		t.Errorf("balances after fill = %v", got)
+	}
+	if len(store.State.Orders) != 0 {
+		t.Errorf("resting orders = %v, want none", store.State.Orders)
+	}
+
+	// The last board is replayed again, but it must not fill anything twice.
+	got = balances(t, b)
+	if !almostEqual(got["BTC"].Amount, 1) {
+		t.Errorf("BTC after replay = %v, want 1", got["BTC"].Amount)
+	}
+}
+
+func TestBitFlyer_GetBalanceReserved(t *testing.T) {
+	store := &MemoryStore{State: &State{FeeRate: 0, Balances: map[string]float64{"JPY": 1000}}}
+	b := NewBitFlyer(NewRecordedBoards(map[string][]bitflyer.BoardResponse{
+		"BTC_JPY": {{MidPrice: 100, Asks: bitflyer.PriceResponses{{Price: 101, Size: 1}}}},
+	}), store)
+
+	_, err := b.SendOrder(context.Background(), bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+		Price: 90, Size: 2, TimeInForce: bitflyer.TimeInForceGTC,
+	})
+	if err != nil {
+		t.Fatalf("BitFlyer.SendOrder() error = %v", err)
+	}
+
+	got := balances(t, b)["JPY"]
+	if !almostEqual(got.Amount, 1000) || !almostEqual(got.Available, 820) {
+		t.Errorf("JPY = %v, want amount 1000 and available 820", got)
+	}
+}
+
+func TestBitFlyer_GetPositions(t *testing.T) {
+	store := &MemoryStore{State: &State{Balances: map[string]float64{"JPY": 1000, "BTC": 0.5}}}
+	b := NewBitFlyer(newTestBoards(), store)
+
+	tests := []struct {
+		name        string
+		productCode string
+		want        bitflyer.GetPositionsResponse
+	}{
+		{
+			name:        "FX",
+			productCode: "FX_BTC_JPY",
+			want:        bitflyer.GetPositionsResponse{{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideBuy, Size: 0.5}},
+		},
+		{
+			name:        "Spot",
+			productCode: "BTC_JPY",
+			want:        bitflyer.GetPositionsResponse{},
+		},
+		{
+			name:        "No Balance",
+			productCode: "FX_ETH_JPY",
+			want:        bitflyer.GetPositionsResponse{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.GetPositions(context.Background(), tt.productCode)
+			if err != nil {
+				t.Fatalf("BitFlyer.GetPositions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyer.GetPositions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyer_CancelOrder(t *testing.T) {
+	store := &MemoryStore{State: &State{FeeRate: 0, Balances: map[string]float64{"JPY": 1000}}}
+	b := NewBitFlyer(NewRecordedBoards(map[string][]bitflyer.BoardResponse{
+		"BTC_JPY": {{MidPrice: 100, Asks: bitflyer.PriceResponses{{Price: 101, Size: 1}}}},
+	}), store)
+
+	res, err := b.SendOrder(context.Background(), bitflyer.SendOrderRequest{
+		ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy,
+		Price: 101, Size: 2, TimeInForce: bitflyer.TimeInForceGTC,
+	})
+	if err != nil {
+		t.Fatalf("BitFlyer.SendOrder() error = %v", err)
+	}
+
+	orders, err := b.GetChildOrders(context.Background(), "BTC_JPY", bitflyer.ChildOrderStateActive)
+	if err != nil {
+		t.Fatalf("BitFlyer.GetChildOrders() error = %v", err)
+	}
+	if len(orders) != 1 || orders[0].OutstandingSize != 1 || orders[0].ExecutedSize != 1 {
+		t.Errorf("BitFlyer.GetChildOrders() = %v, want 1 order with 1 outstanding", orders)
+	}
+
+	req := bitflyer.CancelOrderRequest{ProductCode: "BTC_JPY", ChildOrderAcceptanceId: res.ChildOrderAcceptanceId}
+	if err := b.CancelOrder(context.Background(), req); err != nil {
+		t.Fatalf("BitFlyer.CancelOrder() error = %v", err)
+	}
+	if orders, err := b.GetChildOrders(context.Background(), "BTC_JPY", ""); err != nil || len(orders) != 0 {
+		t.Errorf("BitFlyer.GetChildOrders() = %v, %v, want none after the cancel", orders, err)
+	}
+	if err := b.CancelOrder(context.Background(), req); !errors.Is(err, cerror.ErrOrderNotFound) {
+		t.Errorf("BitFlyer.CancelOrder() error = %v, expectedError %v", err, cerror.ErrOrderNotFound)
+	}
+
+	// The part filled before the cancel stays executed, and nothing is reserved any more.
+	executions, err := b.GetMyExecutions(context.Background(), "BTC_JPY", res.ChildOrderAcceptanceId, bitflyer.Pagination{})
+	if err != nil {
+		t.Fatalf("BitFlyer.GetMyExecutions() error = %v", err)
+	}
+	if len(executions) != 1 || executions[0].Size != 1 || executions[0].Price != 101 {
+		t.Errorf("BitFlyer.GetMyExecutions() = %v, want 1 filled at 101", executions)
+	}
+	if all, err := b.GetMyExecutions(context.Background(), "BTC_JPY", "", bitflyer.Pagination{}); err != nil || !reflect.DeepEqual(all, executions) {
+		t.Errorf("BitFlyer.GetMyExecutions(all) = %v, %v, want %v", all, err, executions)
+	}
+	if got := balances(t, b)["JPY"]; !almostEqual(got.Available, 1000-101) {
+		t.Errorf("JPY = %v, want 899 available", got)
+	}
+}
@@ -0,0 +1,76 @@
+package paper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// MarketData provides the public data orders are matched against.
+// *bitflyer.BitFlyer provides live boards.
+type MarketData interface {
+	GetAvaiableMarkets(ctx context.Context) (bitflyer.GetMarketsResponse, error)
+	GetBoard(ctx context.Context, productCode string) (*bitflyer.BoardResponse, error)
+}
+
+var _ MarketData = &bitflyer.BitFlyer{}
+
+// RecordedBoards replays boards recorded per product code.
+// Each GetBoard call returns the next board of the product, and the last one once exhausted.
+type RecordedBoards struct {
+	mu     sync.Mutex
+	boards map[string][]bitflyer.BoardResponse
+	cursor map[string]int
+}
+
+// NewRecordedBoards returns MarketData replaying boards.
+func NewRecordedBoards(boards map[string][]bitflyer.BoardResponse) *RecordedBoards {
+	return &RecordedBoards{boards: boards, cursor: map[string]int{}}
+}
+
+// LoadRecordedBoards reads a JSON file shaped as `{"BTC_JPY": [<board>, ...]}`,
+// where each board has the format of `GET /v1/board`.
+func LoadRecordedBoards(path string) (*RecordedBoards, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read recorded boards %s: %w", path, err)
+	}
+
+	boards := map[string][]bitflyer.BoardResponse{}
+	if err := json.Unmarshal(raw, &boards); err != nil {
+		return nil, fmt.Errorf("can not parse recorded boards %s: %w", path, err)
+	}
+	return NewRecordedBoards(boards), nil
+}
+
+func (r *RecordedBoards) GetAvaiableMarkets(ctx context.Context) (bitflyer.GetMarketsResponse, error) {
+	markets := bitflyer.GetMarketsResponse{}
+	for productCode := range r.boards {
+		markets = append(markets, bitflyer.MarketResponse{ProductCode: productCode, MarketType: "Spot"})
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].ProductCode < markets[j].ProductCode })
+	return markets, nil
+}
+
+func (r *RecordedBoards) GetBoard(ctx context.Context, productCode string) (*bitflyer.BoardResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	boards := r.boards[productCode]
+	if len(boards) == 0 {
+		return nil, fmt.Errorf("%w: no recorded board for %s", cerror.ErrResourceNotFound, productCode)
+	}
+
+	i := r.cursor[productCode]
+	if i < len(boards)-1 {
+		r.cursor[productCode] = i + 1
+	}
+	board := boards[i]
+	return &board, nil
+}
@@ -0,0 +1,151 @@
+package paper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// Fill is a (partial) execution of an order against one board level.
+type Fill struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// Match walks the opposite side of board and returns the fills of an order.
+// A zero limit is a market order. The board is not modified.
+func Match(side bitflyer.ChildOrderSide, limit float64, size float64, board *bitflyer.BoardResponse) []Fill {
+	var levels bitflyer.PriceResponses
+	if side == bitflyer.SideBuy {
+		levels = append(levels, board.Asks...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price < levels[j].Price })
+	} else {
+		levels = append(levels, board.Bids...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price > levels[j].Price })
+	}
+
+	fills := []Fill{}
+	remaining := size
+	for _, v := range levels {
+		if remaining <= execution.SizeEpsilon {
+			break
+		}
+		if limit > 0 && !crosses(side, limit, v.Price) {
+			break
+		}
+
+		filled := v.Size
+		if filled > remaining {
+			filled = remaining
+		}
+		fills = append(fills, Fill{Price: v.Price, Size: filled})
+		remaining -= filled
+	}
+
+	return fills
+}
+
+// crosses reports whether an order at limit can trade with a level at price.
+func crosses(side bitflyer.ChildOrderSide, limit float64, price float64) bool {
+	if side == bitflyer.SideBuy {
+		return price <= limit
+	}
+	return price >= limit
+}
+
+// filledSize returns the total size and notional of fills.
+func filledSize(fills []Fill) (size float64, notional float64) {
+	for _, v := range fills {
+		size += v.Size
+		notional += v.Price * v.Size
+	}
+	return size, notional
+}
+
+// boardKey identifies a board snapshot.
+func boardKey(board *bitflyer.BoardResponse) string {
+	raw, _ := json.Marshal(board)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// pairFunc splits a product code into the currency received by buys and the currency paid for them.
+type pairFunc func(productCode string) (base string, quote string, err error)
+
+// matchOrders matches the resting orders of state against the boards returned by board, and drops
+// the filled ones. A board already matched is skipped, so that its liquidity is not consumed twice.
+func matchOrders(state *State, pair pairFunc, board func(productCode string) (*bitflyer.BoardResponse, error), at time.Time) error {
+	boards := map[string]*bitflyer.BoardResponse{}
+	open := state.Orders[:0]
+	for _, order := range state.Orders {
+		b, ok := boards[order.ProductCode]
+		if !ok {
+			var err error
+			if b, err = board(order.ProductCode); err != nil {
+				return err
+			}
+			boards[order.ProductCode] = b
+		}
+
+		order := order
+		if key := boardKey(b); key != order.MatchedBoard {
+			base, quote, _ := pair(order.ProductCode)
+			execute(state, &order, Match(order.Side, order.Price, order.Remaining, b), base, quote, at)
+			order.MatchedBoard = key
+		}
+		if order.Remaining > execution.SizeEpsilon {
+			open = append(open, order)
+		}
+	}
+	state.Orders = open
+	return nil
+}
+
+// execute applies fills of order to the balances of base and quote.
+func execute(state *State, order *Order, fills []Fill, base string, quote string, at time.Time) {
+	for _, v := range fills {
+		notional := v.Price * v.Size
+		commission := notional * state.FeeRate
+
+		if order.Side == bitflyer.SideBuy {
+			state.Balances[base] += v.Size
+			state.Balances[quote] -= notional + commission
+		} else {
+			state.Balances[base] -= v.Size
+			state.Balances[quote] += notional - commission
+		}
+		order.Remaining -= v.Size
+
+		state.Executions = append(state.Executions, Execution{
+			AcceptanceId: order.AcceptanceId,
+			ProductCode:  order.ProductCode,
+			Side:         order.Side,
+			Price:        v.Price,
+			Size:         v.Size,
+			Commission:   commission,
+			ExecutedAt:   at,
+		})
+	}
+}
+
+// reservedFunds returns the funds held by resting orders per currency.
+func reservedFunds(state *State, pair pairFunc) map[string]float64 {
+	reserved := map[string]float64{}
+	for _, v := range state.Orders {
+		base, quote, err := pair(v.ProductCode)
+		if err != nil {
+			continue
+		}
+		if v.Side == bitflyer.SideBuy {
+			reserved[quote] += v.Price * v.Remaining * (1 + state.FeeRate)
+		} else {
+			reserved[base] += v.Remaining
+		}
+	}
+	return reserved
+}
@@ -0,0 +1,59 @@
+package paper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+func TestMatch(t *testing.T) {
+	board := &bitflyer.BoardResponse{
+		MidPrice: 100,
+		Bids:     bitflyer.PriceResponses{{Price: 98, Size: 2}, {Price: 99, Size: 1}},
+		Asks:     bitflyer.PriceResponses{{Price: 102, Size: 1}, {Price: 101, Size: 0.5}},
+	}
+	type args struct {
+		side  bitflyer.ChildOrderSide
+		limit float64
+		size  float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want []Fill
+	}{
+		{
+			name: "market buy walks asks from the best price",
+			args: args{bitflyer.SideBuy, 0, 1},
+			want: []Fill{{Price: 101, Size: 0.5}, {Price: 102, Size: 0.5}},
+		},
+		{
+			name: "limit buy stops at the limit",
+			args: args{bitflyer.SideBuy, 101.5, 1},
+			want: []Fill{{Price: 101, Size: 0.5}},
+		},
+		{
+			name: "limit sell below bids",
+			args: args{bitflyer.SideSell, 98, 2.5},
+			want: []Fill{{Price: 99, Size: 1}, {Price: 98, Size: 1.5}},
+		},
+		{
+			name: "limit not crossing",
+			args: args{bitflyer.SideSell, 100, 1},
+			want: []Fill{},
+		},
+		{
+			name: "market order larger than the book",
+			args: args{bitflyer.SideBuy, 0, 10},
+			want: []Fill{{Price: 101, Size: 0.5}, {Price: 102, Size: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.args.side, tt.args.limit, tt.args.size, board); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// KabucomMarketData provides the tokens, boards and symbols stock orders are matched against.
+// *kabucom.KabucomClient provides live boards of kabu STATION.
+type KabucomMarketData interface {
+	GetToken(ctx context.Context, password string) (string, error)
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
+	GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error)
+}
+
+var _ KabucomMarketData = &kabucom.KabucomClient{}
+
+// Kabucom is a simulated kabu STATION cash account. It satisfies usecases.KabucomClient:
+// tokens and market data come from KabucomMarketData, while orders are filled against its boards
+// and the cash and stocks are kept in a Store, as BitFlyer does. Stocks are the balances of their
+// symbols, such as "1306@1", and cash the balance of JPY.
+//
+// Orders are valid for the day: resting orders are matched again against the current board
+// whenever the account is accessed, and expire once their day is over in JST.
+type Kabucom struct {
+	market KabucomMarketData
+	store  Store
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// NewKabucom returns a paper account trading against market.
+func NewKabucom(market KabucomMarketData, store Store) *Kabucom {
+	return &Kabucom{
+		market: market,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+// DefaultKabucomStatePath returns the default path of the paper kabu STATION state file.
+func DefaultKabucomStatePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "paper-kabucom.json")
+}
+
+// stockCurrencies splits a symbol such as 1306@1 into the stock and the cash paying for it.
+func stockCurrencies(symbol string) (base string, quote string, err error) {
+	if code, exchange, ok := strings.Cut(symbol, "@"); !ok || code == "" || exchange == "" {
+		return "", "", fmt.Errorf("%w: symbol %q must be symbol@exchange", cerror.ErrBadRequest, symbol)
+	}
+	return symbol, "JPY", nil
+}
+
+// GetToken returns a token of the market data.
+func (k *Kabucom) GetToken(ctx context.Context, password string) (string, error) {
+	return k.market.GetToken(ctx, password)
+}
+
+func (k *Kabucom) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	return k.market.GetBoard(ctx, token, symbol)
+}
+
+func (k *Kabucom) GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error) {
+	return k.market.GetSymbol(ctx, token, symbol)
+}
+
+// GetCashWallet returns the virtual cash. Cash reserved by resting buys is not available.
+func (k *Kabucom) GetCashWallet(ctx context.Context, token string) (float64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	return state.Balances["JPY"] - reservedFunds(state, stockCurrencies)["JPY"], nil
+}
+
+// GetPositions returns the virtual stocks at their moving average cost, valued at the current
+// price of their boards.
+func (k *Kabucom) GetPositions(ctx context.Context, token string) ([]kabucom.Position, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := averageCosts(state.Executions)
+	positions := []kabucom.Position{}
+	for _, symbol := range sortedKeys(state.Balances) {
+		qty := state.Balances[symbol]
+		if symbol == "JPY" || qty <= execution.SizeEpsilon {
+			continue
+		}
+		board, err := k.market.GetBoard(ctx, token, symbol)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, kabucom.Position{
+			Symbol:       symbol,
+			SymbolName:   board.SymbolName,
+			Qty:          qty,
+			Price:        costs[symbol],
+			CurrentPrice: board.CurrentPrice,
+		})
+	}
+	return positions, nil
+}
+
+// GetExecutions returns the simulated fills, oldest first. Unlike kabu STATION, the fills of
+// earlier days are returned too.
+func (k *Kabucom) GetExecutions(ctx context.Context, token string) ([]kabucom.Execution, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	executions := []kabucom.Execution{}
+	for i, v := range state.Executions {
+		executions = append(executions, kabucom.Execution{
+			ID:         fmt.Sprintf("PAPER-E%06d", i+1),
+			OrderID:    v.AcceptanceId,
+			Symbol:     v.ProductCode,
+			Side:       string(v.Side),
+			Price:      v.Price,
+			Qty:        v.Size,
+			Commission: v.Commission,
+			Time:       v.ExecutedAt,
+		})
+	}
+	return executions, nil
+}
+
+// SendOrder fills the order against the current board. The unfilled part of a limit order rests
+// until the end of the day, and the unfilled part of a market order is dropped.
+func (k *Kabucom) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	base, quote, err := stockCurrencies(order.Symbol)
+	if err != nil {
+		return "", err
+	}
+	if order.Qty <= 0 || order.Qty != math.Trunc(order.Qty) {
+		return "", fmt.Errorf("%w: quantity must be a positive integer, got %v", cerror.ErrBadRequest, order.Qty)
+	}
+	if order.Price < 0 {
+		return "", fmt.Errorf("%w: price must not be negative, got %v", cerror.ErrBadRequest, order.Price)
+	}
+	side := bitflyer.SideBuy
+	switch order.Side {
+	case "BUY":
+	case "SELL":
+		side = bitflyer.SideSell
+	default:
+		return "", fmt.Errorf("%w: invalid side %q", cerror.ErrBadRequest, order.Side)
+	}
+	symbol, err := k.market.GetSymbol(ctx, token, order.Symbol)
+	if err != nil {
+		return "", err
+	}
+	if unit := symbol.TradingUnit; unit > 0 && math.Mod(order.Qty, unit) != 0 {
+		return "", fmt.Errorf("%w: quantity must be a multiple of the trading unit %v, got %v", cerror.ErrBadRequest, unit, order.Qty)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	board, err := k.board(ctx, token, order.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	fills := Match(side, order.Price, order.Qty, board)
+	_, notional := filledSize(fills)
+	resting := order.Price > 0
+
+	reserved := reservedFunds(state, stockCurrencies)
+	if side == bitflyer.SideBuy {
+		need := notional * (1 + state.FeeRate)
+		if resting {
+			need = order.Price * order.Qty * (1 + state.FeeRate)
+		}
+		if available := state.Balances[quote] - reserved[quote]; need > available+execution.SizeEpsilon {
+			return "", fmt.Errorf("%w: need %f %s, available %f", cerror.ErrInsufficientFunds, need, quote, available)
+		}
+	} else if available := state.Balances[base] - reserved[base]; order.Qty > available+execution.SizeEpsilon {
+		return "", fmt.Errorf("%w: need %v %s, available %v", cerror.ErrInsufficientFunds, order.Qty, base, available)
+	}
+
+	state.Sequence++
+	o := Order{
+		AcceptanceId:   fmt.Sprintf("PAPER%s-%06d", k.now().Format("20060102-150405"), state.Sequence),
+		ProductCode:    order.Symbol,
+		ChildOrderType: bitflyer.ChildOrderTypeMarket,
+		Side:           side,
+		Price:          order.Price,
+		Size:           order.Qty,
+		Remaining:      order.Qty,
+		OrderedAt:      k.now(),
+		MatchedBoard:   boardKey(board),
+	}
+	if resting {
+		o.ChildOrderType = bitflyer.ChildOrderTypeLimit
+	}
+	execute(state, &o, fills, base, quote, k.now())
+
+	if resting && o.Remaining > execution.SizeEpsilon {
+		state.Orders = append(state.Orders, o)
+	}
+
+	if err := k.store.Save(state); err != nil {
+		return "", err
+	}
+	return o.AcceptanceId, nil
+}
+
+// board returns the board of symbol shaped as a bitFlyer board, to be matched against.
+func (k *Kabucom) board(ctx context.Context, token string, symbol string) (*bitflyer.BoardResponse, error) {
+	board, err := k.market.GetBoard(ctx, token, symbol)
+	if err != nil {
+		return nil, err
+	}
+	res := &bitflyer.BoardResponse{MidPrice: board.CurrentPrice}
+	for _, v := range board.Bids {
+		res.Bids = append(res.Bids, bitflyer.PriceResponse{Price: v.Price, Size: v.Qty})
+	}
+	for _, v := range board.Asks {
+		res.Asks = append(res.Asks, bitflyer.PriceResponse{Price: v.Price, Size: v.Qty})
+	}
+	return res, nil
+}
+
+// load reads the state, expires the resting orders of earlier days, and matches the others
+// against the current boards.
+func (k *Kabucom) load(ctx context.Context, token string) (*State, error) {
+	state, err := k.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Orders) == 0 {
+		return state, nil
+	}
+
+	y, m, d := k.now().In(candle.JST).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, candle.JST)
+	open := state.Orders[:0]
+	for _, v := range state.Orders {
+		if !v.OrderedAt.Before(today) {
+			open = append(open, v)
+		}
+	}
+	state.Orders = open
+
+	board := func(symbol string) (*bitflyer.BoardResponse, error) {
+		return k.board(ctx, token, symbol)
+	}
+	if err := matchOrders(state, stockCurrencies, board, k.now()); err != nil {
+		return nil, err
+	}
+	if err := k.store.Save(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// averageCosts returns the moving average cost of the stocks held after executions, by symbol.
+// Commissions are not included, as kabu STATION does not include them.
+func averageCosts(executions []Execution) map[string]float64 {
+	held := map[string]float64{}
+	costs := map[string]float64{}
+	for _, v := range executions {
+		if v.Side == bitflyer.SideBuy {
+			costs[v.ProductCode] = (costs[v.ProductCode]*held[v.ProductCode] + v.Price*v.Size) / (held[v.ProductCode] + v.Size)
+			held[v.ProductCode] += v.Size
+		} else {
+			held[v.ProductCode] -= v.Size
+		}
+	}
+	return costs
+}
@@ -0,0 +1,204 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedKabucomMarket struct {
+	boards map[string]*kabucom.BoardResponse
+}
+
+func (m *mockedKabucomMarket) GetToken(ctx context.Context, password string) (string, error) {
+	return "token", nil
+}
+
+func (m *mockedKabucomMarket) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	board, ok := m.boards[symbol]
+	if !ok {
+		return nil, cerror.ErrResourceNotFound
+	}
+	return board, nil
+}
+
+func (m *mockedKabucomMarket) GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error) {
+	return &kabucom.Symbol{Symbol: symbol, SymbolName: "TOPIX ETF", TradingUnit: 10}, nil
+}
+
+func newTestKabucomMarket() *mockedKabucomMarket {
+	return &mockedKabucomMarket{boards: map[string]*kabucom.BoardResponse{
+		"1306@1": {
+			Symbol: "1306", SymbolName: "TOPIX ETF", CurrentPrice: 100,
+			Bids: []kabucom.BoardLevel{{Price: 99, Qty: 100}},
+			Asks: []kabucom.BoardLevel{{Price: 101, Qty: 10}, {Price: 102, Qty: 100}},
+		},
+	}}
+}
+
+func TestKabucom_SendOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         kabucom.OrderRequest
+		wantStock   float64
+		wantJPY     float64
+		wantOrders  int
+		expectedErr error
+	}{
+		{
+			name:      "market buy fills across levels with fees",
+			req:       kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 20},
+			wantStock: 20,
+			wantJPY:   10000 - (101*10+102*10)*1.01,
+		},
+		{
+			name:       "limit buy rests partially",
+			req:        kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 20, Price: 101},
+			wantStock:  10,
+			wantJPY:    10000 - 101*10*1.01,
+			wantOrders: 1,
+		},
+		{
+			name:        "insufficient JPY",
+			req:         kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 100, Price: 101},
+			wantJPY:     10000,
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+		{
+			name:        "insufficient stock",
+			req:         kabucom.OrderRequest{Symbol: "1306@1", Side: "SELL", Qty: 10},
+			wantJPY:     10000,
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+		{
+			name:        "not a multiple of the trading unit",
+			req:         kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 15},
+			wantJPY:     10000,
+			expectedErr: cerror.ErrBadRequest,
+		},
+		{
+			name:        "symbol without exchange",
+			req:         kabucom.OrderRequest{Symbol: "1306", Side: "BUY", Qty: 10},
+			wantJPY:     10000,
+			expectedErr: cerror.ErrBadRequest,
+		},
+		{
+			name:        "invalid side",
+			req:         kabucom.OrderRequest{Symbol: "1306@1", Side: "HOLD", Qty: 10},
+			wantJPY:     10000,
+			expectedErr: cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MemoryStore{State: &State{FeeRate: 0.01, Balances: map[string]float64{"JPY": 10000}}}
+			k := NewKabucom(newTestKabucomMarket(), store)
+
+			id, err := k.SendOrder(context.Background(), "token", tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Kabucom.SendOrder() = %v, %v, expectedErr %v", id, err, tt.expectedErr)
+			}
+			if err == nil && id == "" {
+				t.Errorf("Kabucom.SendOrder() returned no order id")
+			}
+
+			if got := store.State.Balances["1306@1"]; !almostEqual(got, tt.wantStock) {
+				t.Errorf("1306@1 = %v, want %v", got, tt.wantStock)
+			}
+			if got := store.State.Balances["JPY"]; !almostEqual(got, tt.wantJPY) {
+				t.Errorf("JPY = %v, want %v", got, tt.wantJPY)
+			}
+			if got := len(store.State.Orders); got != tt.wantOrders {
+				t.Errorf("resting orders = %v, want %v", got, tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestKabucom_restingOrder(t *testing.T) {
+	market := newTestKabucomMarket()
+	store := &MemoryStore{State: &State{Balances: map[string]float64{"JPY": 10000}}}
+	k := NewKabucom(market, store)
+	now := time.Date(2024, 1, 5, 10, 0, 0, 0, candle.JST)
+	k.now = func() time.Time { return now }
+
+	for _, price := range []float64{95, 90} {
+		if _, err := k.SendOrder(context.Background(), "token", kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10, Price: price}); err != nil {
+			t.Fatalf("Kabucom.SendOrder() error = %v", err)
+		}
+	}
+	cash, err := k.GetCashWallet(context.Background(), "token")
+	if err != nil || !almostEqual(cash, 10000-950-900) {
+		t.Errorf("Kabucom.GetCashWallet() = %v, %v, want %v", cash, err, 10000-950-900)
+	}
+
+	// The board falls through the first order only.
+	market.boards["1306@1"] = &kabucom.BoardResponse{
+		Symbol: "1306", SymbolName: "TOPIX ETF", CurrentPrice: 94,
+		Asks: []kabucom.BoardLevel{{Price: 94, Qty: 100}},
+	}
+	positions, err := k.GetPositions(context.Background(), "token")
+	want := []kabucom.Position{{Symbol: "1306@1", SymbolName: "TOPIX ETF", Qty: 10, Price: 94, CurrentPrice: 94}}
+	if err != nil || !reflect.DeepEqual(positions, want) {
+		t.Errorf("Kabucom.GetPositions() = %v, %v, want %v", positions, err, want)
+	}
+	if len(store.State.Orders) != 1 {
+		t.Errorf("resting orders = %v, want 1", store.State.Orders)
+	}
+
+	// The other order expires with the day.
+	now = now.Add(24 * time.Hour)
+	cash, err = k.GetCashWallet(context.Background(), "token")
+	if err != nil || !almostEqual(cash, 10000-940) {
+		t.Errorf("Kabucom.GetCashWallet() = %v, %v, want %v", cash, err, 10000-940)
+	}
+	if len(store.State.Orders) != 0 {
+		t.Errorf("resting orders = %v, want none", store.State.Orders)
+	}
+}
+
+func TestKabucom_GetExecutions(t *testing.T) {
+	store := &MemoryStore{State: &State{Balances: map[string]float64{"JPY": 10000}}}
+	k := NewKabucom(newTestKabucomMarket(), store)
+	now := time.Date(2024, 1, 5, 10, 0, 0, 0, candle.JST)
+	k.now = func() time.Time { return now }
+
+	for _, v := range []kabucom.OrderRequest{
+		{Symbol: "1306@1", Side: "BUY", Qty: 20},
+		{Symbol: "1306@1", Side: "SELL", Qty: 10},
+	} {
+		if _, err := k.SendOrder(context.Background(), "token", v); err != nil {
+			t.Fatalf("Kabucom.SendOrder() error = %v", err)
+		}
+	}
+
+	got, err := k.GetExecutions(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("Kabucom.GetExecutions() error = %v", err)
+	}
+	want := []kabucom.Execution{
+		{ID: "PAPER-E000001", Symbol: "1306@1", Side: "BUY", Price: 101, Qty: 10, Time: now},
+		{ID: "PAPER-E000002", Symbol: "1306@1", Side: "BUY", Price: 102, Qty: 10, Time: now},
+		{ID: "PAPER-E000003", Symbol: "1306@1", Side: "SELL", Price: 99, Qty: 10, Time: now},
+	}
+	for i := range got {
+		if got[i].OrderID == "" {
+			t.Errorf("execution %d has no order id", i)
+		}
+		got[i].OrderID = ""
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Kabucom.GetExecutions() = %v, want %v", got, want)
+	}
+
+	positions, err := k.GetPositions(context.Background(), "token")
+	if err != nil || len(positions) != 1 || !almostEqual(positions[0].Price, 101.5) {
+		t.Errorf("Kabucom.GetPositions() = %v, %v, want an average cost of 101.5", positions, err)
+	}
+}
@@ -0,0 +1,14 @@
+// Package paper provides simulated brokers for trading without real money.
+// They satisfy the client interfaces used by usecases, so every command works unchanged with --paper.
+package paper
+
+import "sort"
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
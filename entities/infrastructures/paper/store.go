@@ -0,0 +1,143 @@
+package paper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// DefaultFeeRate is the trading fee charged on the notional of each fill.
+const DefaultFeeRate = 0.0015
+
+// DefaultBalances are the virtual balances of a new paper account.
+func DefaultBalances() map[string]float64 {
+	return map[string]float64{"JPY": 1000000}
+}
+
+// Order is a simulated child order.
+type Order struct {
+	AcceptanceId   string                   `json:"child_order_acceptance_id"`
+	ProductCode    string                   `json:"product_code"`
+	ChildOrderType bitflyer.ChildOrderType  `json:"child_order_type"`
+	Side           bitflyer.ChildOrderSide  `json:"side"`
+	Price          float64                  `json:"price"`
+	Size           float64                  `json:"size"`
+	Remaining      float64                  `json:"outstanding_size"`
+	TimeInForce    bitflyer.TimeInForceType `json:"time_in_force"`
+	OrderedAt      time.Time                `json:"child_order_date"`
+	// MatchedBoard identifies the last board the order was matched against,
+	// so that the same liquidity is not consumed twice.
+	MatchedBoard string `json:"matched_board"`
+}
+
+// Execution is a simulated fill of an Order.
+type Execution struct {
+	AcceptanceId string                  `json:"child_order_acceptance_id"`
+	ProductCode  string                  `json:"product_code"`
+	Side         bitflyer.ChildOrderSide `json:"side"`
+	Price        float64                 `json:"price"`
+	Size         float64                 `json:"size"`
+	Commission   float64                 `json:"commission"`
+	ExecutedAt   time.Time               `json:"exec_date"`
+}
+
+// State is the persisted paper account.
+type State struct {
+	FeeRate    float64            `json:"fee_rate"`
+	Balances   map[string]float64 `json:"balances"`
+	Orders     []Order            `json:"orders"`
+	Executions []Execution        `json:"executions"`
+	Sequence   int                `json:"sequence"`
+}
+
+// NewState returns the state of a new paper account.
+func NewState() *State {
+	return &State{
+		FeeRate:  DefaultFeeRate,
+		Balances: DefaultBalances(),
+	}
+}
+
+// Store loads and saves State.
+type Store interface {
+	Load() (*State, error)
+	Save(*State) error
+}
+
+// FileStore keeps State as a JSON file so that paper orders survive between commands.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a Store backed by the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultStatePath returns the default path of the paper state file.
+func DefaultStatePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "paper.json")
+}
+
+// Load reads the state file. A missing file yields a new account.
+func (f *FileStore) Load() (*State, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read paper state %s: %w", f.path, err)
+	}
+
+	state := NewState()
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, fmt.Errorf("can not parse paper state %s: %w", f.path, err)
+	}
+	if state.Balances == nil {
+		state.Balances = map[string]float64{}
+	}
+	return state, nil
+}
+
+// Save writes the state file atomically.
+func (f *FileStore) Save(state *State) error {
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can not marshal paper state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		return fmt.Errorf("can not create paper state directory: %w", err)
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return fmt.Errorf("can not write paper state %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("can not write paper state %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// MemoryStore keeps State in memory. It is useful for tests.
+type MemoryStore struct {
+	State *State
+}
+
+func (m *MemoryStore) Load() (*State, error) {
+	if m.State == nil {
+		m.State = NewState()
+	}
+	return m.State, nil
+}
+
+func (m *MemoryStore) Save(state *State) error {
+	m.State = state
+	return nil
+}
@@ -0,0 +1,33 @@
+package paper
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "nested", "paper.json"))
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("FileStore.Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(state, NewState()) {
+		t.Errorf("FileStore.Load() of a missing file = %v, want %v", state, NewState())
+	}
+
+	state.Balances["BTC"] = 0.5
+	state.Sequence = 3
+	if err := store.Save(state); err != nil {
+		t.Fatalf("FileStore.Save() error = %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("FileStore.Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("FileStore.Load() = %v, want %v", got, state)
+	}
+}
@@ -0,0 +1,81 @@
+package tradecsv
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+// bitFlyerTimeLayouts are the layouts of 取引日時, in JST.
+var bitFlyerTimeLayouts = []string{"2006/01/02 15:04:05", "2006/01/02 15:04", "2006-01-02 15:04:05"}
+
+// readBitFlyer reads the trade history of bitFlyer. Buys and sells (買い, 売り) are fills, deposits
+// (受取, 預入) and withdrawals (外部送付, 送付) of crypto assets are transfers, and other rows such
+// as JPY deposits are skipped.
+func readBitFlyer(t *table, rows [][]string) (*Records, error) {
+	if err := t.require("取引日時", "通貨", "取引種別", "取引価格", "通貨1", "通貨1数量", "手数料"); err != nil {
+		return nil, err
+	}
+
+	records := &Records{Fills: []journal.Fill{}, Transfers: []journal.Transfer{}, Precision: time.Second}
+	ids := rowIDs{}
+	for i, row := range rows {
+		t.row = i + 2
+		var at time.Time
+		var err error
+		for _, layout := range bitFlyerTimeLayouts {
+			if at, err = time.ParseInLocation(layout, t.get(row, "取引日時"), candle.JST); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, t.errorf("invalid 取引日時 %q", t.get(row, "取引日時"))
+		}
+		values := map[string]float64{}
+		for _, name := range []string{"取引価格", "通貨1数量", "手数料"} {
+			v, err := t.number(row, name)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = math.Abs(v)
+		}
+
+		switch kind := t.get(row, "取引種別"); kind {
+		case "買い", "売り":
+			side := "BUY"
+			if kind == "売り" {
+				side = "SELL"
+			}
+			records.Fills = append(records.Fills, journal.Fill{
+				Broker:      journal.BitFlyer,
+				ID:          ids.id(row),
+				ProductCode: strings.ReplaceAll(t.get(row, "通貨"), "/", "_"),
+				OrderID:     t.get(row, "注文 ID"),
+				Side:        side,
+				Price:       values["取引価格"],
+				Size:        values["通貨1数量"],
+				Commission:  values["手数料"],
+				Time:        at,
+			})
+		case "受取", "預入", "外部送付", "送付":
+			amount := values["通貨1数量"]
+			if kind == "外部送付" || kind == "送付" {
+				amount = -amount
+			}
+			records.Transfers = append(records.Transfers, journal.Transfer{
+				Broker:   journal.BitFlyer,
+				ID:       ids.id(row),
+				Currency: t.get(row, "通貨1"),
+				Amount:   amount,
+				Fee:      values["手数料"],
+				Time:     at,
+			})
+		default:
+			records.Skipped++
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,80 @@
+package tradecsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+func TestRead_BitFlyer(t *testing.T) {
+	input := "\ufeff取引日時,通貨,取引種別,取引価格,通貨1,通貨1数量,手数料,通貨1の対円レート,通貨2,通貨2数量,自己・媒介,注文 ID,備考\n" +
+		"2026/01/10 12:34:56,BTC/JPY,買い,\"5,000,000\",BTC,0.01,-0.000015,\"5,000,000\",JPY,\"-50,000\",自己,JRF20260110-1,\n" +
+		"2026/01/11 09:00:00,BTC/JPY,売り,\"5,100,000\",BTC,-0.005,-0.0000075,\"5,100,000\",JPY,\"25,500\",自己,JRF20260111-1,\n" +
+		"2026/01/12 10:00:00,JPY,入金,0,JPY,\"100,000\",0,1,,,,,\n" +
+		"2026/01/13 11:00:00,BTC,外部送付,0,BTC,-0.004,-0.0004,\"5,000,000\",,,,,\n" +
+		"2026/01/14 12:00,ETH,受取,0,ETH,0.1,0,\"400,000\",,,,,\n"
+
+	actual, err := Read(BitFlyer, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	expectedFills := []journal.Fill{
+		{Broker: journal.BitFlyer, ProductCode: "BTC_JPY", OrderID: "JRF20260110-1", Side: "BUY", Price: 5000000, Size: 0.01, Commission: 0.000015,
+			Time: time.Date(2026, 1, 10, 12, 34, 56, 0, candle.JST)},
+		{Broker: journal.BitFlyer, ProductCode: "BTC_JPY", OrderID: "JRF20260111-1", Side: "SELL", Price: 5100000, Size: 0.005, Commission: 0.0000075,
+			Time: time.Date(2026, 1, 11, 9, 0, 0, 0, candle.JST)},
+	}
+	expectedTransfers := []journal.Transfer{
+		{Broker: journal.BitFlyer, Currency: "BTC", Amount: -0.004, Fee: 0.0004, Time: time.Date(2026, 1, 13, 11, 0, 0, 0, candle.JST)},
+		{Broker: journal.BitFlyer, Currency: "ETH", Amount: 0.1, Time: time.Date(2026, 1, 14, 12, 0, 0, 0, candle.JST)},
+	}
+	for i := range actual.Fills {
+		if !strings.HasPrefix(actual.Fills[i].ID, "csv-") {
+			t.Errorf("Read() id = %s, expected a csv- id", actual.Fills[i].ID)
+		}
+		actual.Fills[i].ID = ""
+	}
+	for i := range actual.Transfers {
+		actual.Transfers[i].ID = ""
+	}
+	if !reflect.DeepEqual(actual.Fills, expectedFills) {
+		t.Errorf("Read() fills = %+v, expected %+v", actual.Fills, expectedFills)
+	}
+	if !reflect.DeepEqual(actual.Transfers, expectedTransfers) {
+		t.Errorf("Read() transfers = %+v, expected %+v", actual.Transfers, expectedTransfers)
+	}
+	if actual.Skipped != 1 || actual.Precision != time.Second {
+		t.Errorf("Read() skipped = %d, precision = %s, expected 1, 1s", actual.Skipped, actual.Precision)
+	}
+}
+
+func TestRead_BitFlyerErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedError string
+	}{
+		{
+			name:          "missing columns",
+			input:         "取引日時,通貨,取引種別\n",
+			expectedError: "missing columns: 取引価格, 通貨1, 通貨1数量, 手数料",
+		},
+		{
+			name:          "invalid time",
+			input:         "取引日時,通貨,取引種別,取引価格,通貨1,通貨1数量,手数料\n2026-01-10T12:34:56,BTC/JPY,買い,1,BTC,1,0\n",
+			expectedError: `row 2: invalid 取引日時 "2026-01-10T12:34:56"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(BitFlyer, strings.NewReader(tt.input))
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("Read() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
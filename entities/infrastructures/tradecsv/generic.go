@@ -0,0 +1,118 @@
+package tradecsv
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+// FillColumns are the columns of fills of the generic schema:
+//   - time: RFC 3339, such as 2026-01-10T12:34:56+09:00.
+//   - broker: bitflyer, kabucom or paper.
+//   - product_code: the product of bitFlyer, such as BTC_JPY, or the symbol of kabu STATION, such as 9433@1.
+//   - side: BUY or SELL.
+//   - price, size: the price and the size of the fill.
+//   - commission: in the asset for bitFlyer and in JPY for kabu STATION.
+//   - order_id: the acceptance id of the order, optional.
+//   - id: the id of the fill in the broker. Rows without one are identified by their content.
+var FillColumns = []string{"time", "broker", "product_code", "side", "price", "size", "commission", "order_id", "id"}
+
+// TransferColumns are the columns of transfers of the generic schema:
+//   - time and broker as fills.
+//   - currency: the crypto asset, such as BTC.
+//   - amount: positive for deposits and negative for withdrawals.
+//   - fee: charged in the asset on top of the amount.
+//   - id: the id of the transfer in the broker, optional as for fills.
+var TransferColumns = []string{"time", "broker", "currency", "amount", "fee", "id"}
+
+var brokers = []string{journal.BitFlyer, journal.Kabucom, journal.Paper, journal.PaperKabucom}
+
+// readGeneric reads fills or transfers, told apart by the header.
+func readGeneric(t *table, rows [][]string) (*Records, error) {
+	records := &Records{Fills: []journal.Fill{}, Transfers: []journal.Transfer{}}
+	_, fills := t.has("product_code")
+	columns := TransferColumns
+	if fills {
+		columns = FillColumns
+	}
+	// The id is optional.
+	if err := t.require(columns[:len(columns)-1]...); err != nil {
+		return nil, err
+	}
+
+	ids := rowIDs{}
+	for i, row := range rows {
+		t.row = i + 2
+		at, err := time.Parse(time.RFC3339, t.get(row, "time"))
+		if err != nil {
+			return nil, t.errorf("invalid time %q", t.get(row, "time"))
+		}
+		broker := t.get(row, "broker")
+		if !contains(brokers, broker) {
+			return nil, t.errorf("unsupported broker %q (supported: %s)", broker, strings.Join(brokers, ", "))
+		}
+		id := t.get(row, "id")
+		if id == "" {
+			id = ids.id(row)
+		}
+
+		if !fills {
+			amount, err := t.number(row, "amount")
+			if err != nil {
+				return nil, err
+			}
+			fee, err := t.number(row, "fee")
+			if err != nil {
+				return nil, err
+			}
+			records.Transfers = append(records.Transfers, journal.Transfer{
+				Broker:   broker,
+				ID:       id,
+				Currency: t.get(row, "currency"),
+				Amount:   amount,
+				Fee:      fee,
+				Time:     at,
+			})
+			continue
+		}
+
+		side := strings.ToUpper(t.get(row, "side"))
+		if side != "BUY" && side != "SELL" {
+			return nil, t.errorf("invalid side %q (supported: BUY, SELL)", t.get(row, "side"))
+		}
+		values := map[string]float64{}
+		for _, name := range []string{"price", "size", "commission"} {
+			if values[name], err = t.number(row, name); err != nil {
+				return nil, err
+			}
+		}
+		records.Fills = append(records.Fills, journal.Fill{
+			Broker:      broker,
+			ID:          id,
+			ProductCode: t.get(row, "product_code"),
+			OrderID:     t.get(row, "order_id"),
+			Side:        side,
+			Price:       values["price"],
+			Size:        values["size"],
+			Commission:  values["commission"],
+			Time:        at,
+		})
+	}
+	return records, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNumber formats v without exponent, rounded to 1e-8 as quantities of crypto assets are.
+func formatNumber(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.8f", v), "0"), ".")
+}
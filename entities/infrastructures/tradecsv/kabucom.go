@@ -0,0 +1,119 @@
+package tradecsv
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+// kabucomExchanges are the exchange codes of kabu STATION by market name. Unknown markets are TSE.
+var kabucomExchanges = map[string]string{
+	"東証": "1",
+	"名証": "3",
+	"福証": "5",
+	"札証": "6",
+}
+
+// readKabucom reads the execution history of au kabucom securities. Fills have the date only, at
+// midnight in JST, and keep the order of the file, oldest first. Margin trades (信用) are skipped, as
+// the journal keeps cash trades.
+func readKabucom(t *table, rows [][]string) (*Records, error) {
+	if err := t.require("約定日"); err != nil {
+		return nil, err
+	}
+	code, ok := t.has("銘柄コード", "銘柄ｺｰﾄﾞ")
+	if !ok {
+		return nil, t.require("銘柄コード")
+	}
+	side, ok := t.has("売買", "取引", "売買区分")
+	if !ok {
+		return nil, t.require("売買")
+	}
+	qty, ok := t.has("約定数量", "数量")
+	if !ok {
+		return nil, t.require("約定数量")
+	}
+	price, ok := t.has("約定単価", "単価")
+	if !ok {
+		return nil, t.require("約定単価")
+	}
+	commissions := []string{}
+	for _, v := range []string{"手数料", "手数料等", "税額", "消費税"} {
+		if _, ok := t.has(v); ok {
+			commissions = append(commissions, v)
+		}
+	}
+
+	type dated struct {
+		at  time.Time
+		row []string
+		n   int
+	}
+	list := []dated{}
+	for i, row := range rows {
+		t.row = i + 2
+		var at time.Time
+		var err error
+		for _, layout := range []string{"2006/1/2", "2006-01-02"} {
+			if at, err = time.ParseInLocation(layout, t.get(row, "約定日"), candle.JST); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, t.errorf("invalid 約定日 %q", t.get(row, "約定日"))
+		}
+		list = append(list, dated{at: at, row: row, n: i + 2})
+	}
+	// Histories are listed newest first.
+	if len(list) > 1 && list[0].at.After(list[len(list)-1].at) {
+		for a, b := 0, len(list)-1; a < b; a, b = a+1, b-1 {
+			list[a], list[b] = list[b], list[a]
+		}
+	}
+
+	records := &Records{Fills: []journal.Fill{}, Transfers: []journal.Transfer{}, Precision: 24 * time.Hour}
+	ids := rowIDs{}
+	for _, v := range list {
+		t.row = v.n
+		s := t.get(v.row, side)
+		if strings.Contains(s, "信用") || (!strings.Contains(s, "買") && !strings.Contains(s, "売")) {
+			records.Skipped++
+			continue
+		}
+		f := journal.Fill{
+			Broker:  journal.Kabucom,
+			ID:      ids.id(v.row),
+			OrderID: t.get(v.row, "注文番号"),
+			Side:    "BUY",
+			Time:    v.at,
+		}
+		if strings.Contains(s, "売") {
+			f.Side = "SELL"
+		}
+		exchange, ok := kabucomExchanges[t.get(v.row, "市場")]
+		if !ok {
+			exchange = "1"
+		}
+		f.ProductCode = t.get(v.row, code) + "@" + exchange
+
+		var err error
+		if f.Size, err = t.number(v.row, qty); err != nil {
+			return nil, err
+		}
+		if f.Price, err = t.number(v.row, price); err != nil {
+			return nil, err
+		}
+		for _, name := range commissions {
+			c, err := t.number(v.row, name)
+			if err != nil {
+				return nil, err
+			}
+			f.Commission += math.Abs(c)
+		}
+		records.Fills = append(records.Fills, f)
+	}
+	return records, nil
+}
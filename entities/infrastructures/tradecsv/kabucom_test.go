@@ -0,0 +1,87 @@
+package tradecsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+func TestRead_Kabucom(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expected        []journal.Fill
+		expectedSkipped int
+	}{
+		{
+			name: "newest first",
+			input: "約定日,受渡日,銘柄コード,銘柄名,市場,売買,約定数量,約定単価,手数料,消費税,注文番号\n" +
+				"2026/1/20,2026/1/22,9433,KDDI,名証,現物売,100,\"4,600\",99,9,20260120A01\n" +
+				"2026/1/15,2026/1/17,6501,日立,東証,信用新規買,100,\"3,500\",0,0,20260115A01\n" +
+				"2026/1/10,2026/1/14,9433,KDDI,東証,現物買,\"1,000\",\"4,500\",\"1,070\",107,20260110A01\n",
+			expected: []journal.Fill{
+				{Broker: journal.Kabucom, ProductCode: "9433@1", OrderID: "20260110A01", Side: "BUY", Price: 4500, Size: 1000, Commission: 1177,
+					Time: time.Date(2026, 1, 10, 0, 0, 0, 0, candle.JST)},
+				{Broker: journal.Kabucom, ProductCode: "9433@3", OrderID: "20260120A01", Side: "SELL", Price: 4600, Size: 100, Commission: 108,
+					Time: time.Date(2026, 1, 20, 0, 0, 0, 0, candle.JST)},
+			},
+			expectedSkipped: 1,
+		},
+		{
+			name: "other column names without a market",
+			input: "約定日,銘柄ｺｰﾄﾞ,取引,数量,単価,手数料等\n" +
+				"2026-01-10,7203,株式現物買,100,3000,0\n",
+			expected: []journal.Fill{
+				{Broker: journal.Kabucom, ProductCode: "7203@1", Side: "BUY", Price: 3000, Size: 100, Time: time.Date(2026, 1, 10, 0, 0, 0, 0, candle.JST)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Read(Kabucom, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			for i := range actual.Fills {
+				actual.Fills[i].ID = ""
+			}
+			if !reflect.DeepEqual(actual.Fills, tt.expected) {
+				t.Errorf("Read() fills = %+v, expected %+v", actual.Fills, tt.expected)
+			}
+			if actual.Skipped != tt.expectedSkipped || actual.Precision != 24*time.Hour {
+				t.Errorf("Read() skipped = %d, precision = %s, expected %d, 24h", actual.Skipped, actual.Precision, tt.expectedSkipped)
+			}
+		})
+	}
+}
+
+func TestRead_KabucomErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedError string
+	}{
+		{
+			name:          "missing code",
+			input:         "約定日,売買,約定数量,約定単価\n",
+			expectedError: "missing columns: 銘柄コード",
+		},
+		{
+			name:          "invalid date",
+			input:         "約定日,銘柄コード,売買,約定数量,約定単価\n1/10,9433,現物買,100,4500\n",
+			expectedError: `row 2: invalid 約定日 "1/10"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(Kabucom, strings.NewReader(tt.input))
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("Read() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
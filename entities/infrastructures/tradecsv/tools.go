@@ -0,0 +1,119 @@
+package tradecsv
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+// toolTimeLayout is the layout of times of the tax tools, in JST.
+const toolTimeLayout = "2006/01/02 15:04:05"
+
+// cryptoPair returns the assets of a spot product of bitFlyer, such as BTC and JPY of BTC_JPY.
+// Only the fills of the live account are exported: derivatives, stocks and the paper broker are not.
+func cryptoPair(f journal.Fill) (string, string, bool) {
+	if f.Broker != journal.BitFlyer {
+		return "", "", false
+	}
+	parts := strings.Split(f.ProductCode, "_")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// writeCryptact writes the custom file of Cryptact. Transfers between own wallets are not income
+// events: only the fees of withdrawals are written, as SENDFEE.
+func writeCryptact(w *csv.Writer, fills []journal.Fill, transfers []journal.Transfer) {
+	w.Write([]string{"Timestamp", "Action", "Source", "Base", "Volume", "Price", "Counter", "Fee", "FeeCcy", "Comment"})
+	for _, v := range fills {
+		base, counter, ok := cryptoPair(v)
+		if !ok {
+			continue
+		}
+		w.Write([]string{
+			v.Time.In(candle.JST).Format(toolTimeLayout),
+			v.Side,
+			"bitFlyer",
+			base,
+			formatNumber(v.Size),
+			formatNumber(v.Price),
+			counter,
+			formatNumber(v.Commission),
+			base,
+			v.ID,
+		})
+	}
+	for _, v := range transfers {
+		if v.Broker != journal.BitFlyer || v.Amount >= 0 || v.Fee == 0 {
+			continue
+		}
+		w.Write([]string{
+			v.Time.In(candle.JST).Format(toolTimeLayout),
+			"SENDFEE",
+			"bitFlyer",
+			v.Currency,
+			formatNumber(v.Fee),
+			"",
+			"JPY",
+			"0",
+			"JPY",
+			v.ID,
+		})
+	}
+}
+
+// writeGtax writes the generic format of Gtax: the assets received (+) and given (-) by each
+// trade (売買), withdrawal (送付) and deposit (受取).
+func writeGtax(w *csv.Writer, fills []journal.Fill, transfers []journal.Transfer) {
+	w.Write([]string{"取引所名", "日時（JST）", "取引種別", "取引通貨名(+)", "取引量(+)", "取引通貨名(-)", "取引量(-)",
+		"取引額時価", "手数料通貨名", "手数料数量"})
+	type row struct {
+		at     time.Time
+		record []string
+	}
+	rows := []row{}
+	for _, v := range fills {
+		base, counter, ok := cryptoPair(v)
+		if !ok {
+			continue
+		}
+		plus, plusSize, minus, minusSize := base, v.Size, counter, v.Size*v.Price
+		if v.Side == "SELL" {
+			plus, plusSize, minus, minusSize = counter, v.Size*v.Price, base, v.Size
+		}
+		value := ""
+		if counter == "JPY" {
+			value = formatNumber(v.Size * v.Price)
+		}
+		rows = append(rows, row{at: v.Time, record: []string{
+			"bitFlyer", v.Time.In(candle.JST).Format(toolTimeLayout), "売買",
+			plus, formatNumber(plusSize), minus, formatNumber(minusSize),
+			value, base, formatNumber(v.Commission),
+		}})
+	}
+	for _, v := range transfers {
+		if v.Broker != journal.BitFlyer {
+			continue
+		}
+		record := []string{"bitFlyer", v.Time.In(candle.JST).Format(toolTimeLayout), "受取", v.Currency, formatNumber(v.Amount), "", "",
+			"", v.Currency, formatNumber(v.Fee)}
+		if v.Amount < 0 {
+			record = []string{"bitFlyer", v.Time.In(candle.JST).Format(toolTimeLayout), "送付", "", "", v.Currency, formatNumber(-v.Amount),
+				"", v.Currency, formatNumber(v.Fee)}
+		}
+		rows = append(rows, row{at: v.Time, record: record})
+	}
+	// Trades and transfers are listed in the order of time.
+	for i := 1; i < len(rows); i++ {
+		for j := i; j > 0 && rows[j].at.Before(rows[j-1].at); j-- {
+			rows[j], rows[j-1] = rows[j-1], rows[j]
+		}
+	}
+	for _, v := range rows {
+		w.Write(v.record)
+	}
+}
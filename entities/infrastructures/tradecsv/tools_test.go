@@ -0,0 +1,63 @@
+package tradecsv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+var (
+	toolFills = []journal.Fill{
+		{Broker: journal.BitFlyer, ID: "2", ProductCode: "BTC_JPY", Side: "SELL", Price: 5100000, Size: 0.005, Commission: 0.0000075,
+			Time: time.Date(2026, 1, 11, 9, 0, 0, 0, candle.JST)},
+		{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Commission: 0.000015,
+			Time: time.Date(2026, 1, 10, 3, 34, 56, 0, time.UTC)},
+		{Broker: journal.BitFlyer, ID: "3", ProductCode: "FX_BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: time.Date(2026, 1, 10, 0, 0, 0, 0, candle.JST)},
+		{Broker: journal.Kabucom, ID: "4", ProductCode: "9433@1", Side: "BUY", Price: 4500, Size: 100, Time: time.Date(2026, 1, 10, 0, 0, 0, 0, candle.JST)},
+		{Broker: journal.Paper, ID: "5", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: time.Date(2026, 1, 10, 0, 0, 0, 0, candle.JST)},
+	}
+	toolTransfers = []journal.Transfer{
+		{Broker: journal.BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.004, Fee: 0.0004, Time: time.Date(2026, 1, 10, 11, 0, 0, 0, candle.JST)},
+		{Broker: journal.BitFlyer, ID: "in-1", Currency: "ETH", Amount: 0.1, Time: time.Date(2026, 1, 12, 12, 0, 0, 0, candle.JST)},
+	}
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		expected string
+	}{
+		{
+			name:   "cryptact",
+			format: Cryptact,
+			expected: "Timestamp,Action,Source,Base,Volume,Price,Counter,Fee,FeeCcy,Comment\n" +
+				"2026/01/11 09:00:00,SELL,bitFlyer,BTC,0.005,5100000,JPY,0.0000075,BTC,2\n" +
+				"2026/01/10 12:34:56,BUY,bitFlyer,BTC,0.01,5000000,JPY,0.000015,BTC,1\n" +
+				"2026/01/10 11:00:00,SENDFEE,bitFlyer,BTC,0.0004,,JPY,0,JPY,out-1\n",
+		},
+		{
+			name:   "gtax",
+			format: Gtax,
+			expected: "取引所名,日時（JST）,取引種別,取引通貨名(+),取引量(+),取引通貨名(-),取引量(-),取引額時価,手数料通貨名,手数料数量\n" +
+				"bitFlyer,2026/01/10 11:00:00,送付,,,BTC,0.004,,BTC,0.0004\n" +
+				"bitFlyer,2026/01/10 12:34:56,売買,BTC,0.01,JPY,50000,50000,BTC,0.000015\n" +
+				"bitFlyer,2026/01/11 09:00:00,売買,JPY,25500,BTC,0.005,25500,BTC,0.0000075\n" +
+				"bitFlyer,2026/01/12 12:00:00,受取,ETH,0.1,,,,ETH,0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := Write(tt.format, buf, toolFills, toolTransfers); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Write() = \n%s, expected \n%s", buf.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package tradecsv reads and writes trades in the CSV formats of brokers and tax tools, so that
+// history kept elsewhere can be loaded into the journal and the journal used elsewhere.
+//
+// Columns are found by their header, so extra columns and other orders are accepted. Files must
+// be UTF-8, with or without a byte order mark: convert Shift_JIS downloads first, for example
+// with `iconv -f SHIFT_JIS -t UTF-8`.
+package tradecsv
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+// Format is a CSV format.
+type Format string
+
+const (
+	// Generic is the documented schema of capital-go, written by `journal export --kind fills` and
+	// `--kind transfers`.
+	Generic Format = "generic"
+	// BitFlyer is the trade history (取引履歴) downloaded from bitFlyer.
+	BitFlyer Format = "bitflyer"
+	// Kabucom is the execution history (約定履歴) downloaded from au kabucom securities.
+	Kabucom Format = "kabucom"
+	// Cryptact is the custom file format (カスタムファイル) of Cryptact.
+	Cryptact Format = "cryptact"
+	// Gtax is the generic format (汎用フォーマット) of Gtax.
+	Gtax Format = "gtax"
+)
+
+// ReadFormats are the formats Read accepts, and WriteFormats the formats Write writes.
+var (
+	ReadFormats  = []Format{Generic, BitFlyer, Kabucom}
+	WriteFormats = []Format{Cryptact, Gtax}
+)
+
+// Records are the fills and transfers of a file.
+type Records struct {
+	Fills     []journal.Fill
+	Transfers []journal.Transfer
+	// Precision is the precision of the times of the format, such as a second when they have no
+	// fraction. The same trade pulled from the API has a time within it.
+	Precision time.Duration
+	// Skipped is the number of rows which are neither fills nor transfers, such as JPY deposits.
+	Skipped int
+}
+
+// Read reads the records of r in format.
+func Read(format Format, r io.Reader) (*Records, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(raw) {
+		return nil, errors.New("the file is not UTF-8. convert it first, for example with `iconv -f SHIFT_JIS -t UTF-8`")
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\ufeff"))
+
+	cr := csv.NewReader(bytes.NewReader(raw))
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("can not parse the CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	t := &table{columns: map[string]int{}}
+	for i, v := range rows[0] {
+		t.columns[strings.TrimSpace(v)] = i
+	}
+
+	var records *Records
+	switch format {
+	case Generic:
+		records, err = readGeneric(t, rows[1:])
+	case BitFlyer:
+		records, err = readBitFlyer(t, rows[1:])
+	case Kabucom:
+		records, err = readKabucom(t, rows[1:])
+	default:
+		return nil, fmt.Errorf("unsupported format %q to read (supported: %s)", format, join(ReadFormats))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Write writes fills and transfers in format.
+func Write(format Format, w io.Writer, fills []journal.Fill, transfers []journal.Transfer) error {
+	cw := csv.NewWriter(w)
+	switch format {
+	case Cryptact:
+		writeCryptact(cw, fills, transfers)
+	case Gtax:
+		writeGtax(cw, fills, transfers)
+	default:
+		return fmt.Errorf("unsupported format %q to write (supported: %s)", format, join(WriteFormats))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func join(formats []Format) string {
+	names := []string{}
+	for _, v := range formats {
+		names = append(names, string(v))
+	}
+	return strings.Join(names, ", ")
+}
+
+// table finds the columns of rows by their header.
+type table struct {
+	columns map[string]int
+	// row is the number of the current row in the file, for errors.
+	row int
+}
+
+// has returns the first of names in the header.
+func (t *table) has(names ...string) (string, bool) {
+	for _, v := range names {
+		if _, ok := t.columns[v]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// require fails unless every column of names is in the header.
+func (t *table) require(names ...string) error {
+	missing := []string{}
+	for _, v := range names {
+		if _, ok := t.columns[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// get returns the value of column name in row, empty when the row is shorter.
+func (t *table) get(row []string, name string) string {
+	i, ok := t.columns[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// number parses the value of column name in row, ignoring thousands separators. Empty values are zero.
+func (t *table) number(row []string, name string) (float64, error) {
+	v := strings.NewReplacer(",", "", "+", "", " ", "").Replace(t.get(row, name))
+	if v == "" || v == "-" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, t.errorf("invalid %s %q", name, t.get(row, name))
+	}
+	return f, nil
+}
+
+func (t *table) errorf(format string, args ...any) error {
+	return fmt.Errorf("row %d: %s", t.row, fmt.Sprintf(format, args...))
+}
+
+// rowIDs gives ids to the rows of a file without ids.
+type rowIDs map[string]int
+
+// id returns the id of row. The same row of the same file always has the same id, so importing
+// a file twice adds nothing. Identical rows, such as two fills of an order at the same price and
+// second, are told apart by their occurrence.
+func (ids rowIDs) id(row []string) string {
+	sum := sha1.Sum([]byte(strings.Join(row, "\x00")))
+	key := hex.EncodeToString(sum[:8])
+	ids[key]++
+	if ids[key] == 1 {
+		return "csv-" + key
+	}
+	return fmt.Sprintf("csv-%s-%d", key, ids[key])
+}
@@ -0,0 +1,131 @@
+package tradecsv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+func TestRead_Generic(t *testing.T) {
+	at := time.Date(2026, 1, 10, 12, 34, 56, 0, candle.JST)
+
+	tests := []struct {
+		name          string
+		input         string
+		expected      *Records
+		expectedError string
+	}{
+		{
+			name: "fills with a byte order mark and columns in another order",
+			input: "\ufeffid,time,broker,product_code,side,price,size,commission,order_id,memo\n" +
+				"1,2026-01-10T12:34:56+09:00,bitflyer,BTC_JPY,buy,\"5,000,000\",0.01,0.000015,JRF1,x\n",
+			expected: &Records{
+				Fills: []journal.Fill{
+					{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", OrderID: "JRF1", Side: "BUY", Price: 5000000, Size: 0.01, Commission: 0.000015, Time: at},
+				},
+				Transfers: []journal.Transfer{},
+			},
+		},
+		{
+			name:  "transfers",
+			input: "time,broker,currency,amount,fee,id\n2026-01-10T12:34:56+09:00,bitflyer,BTC,-0.5,0.0004,out-1\n",
+			expected: &Records{
+				Fills:     []journal.Fill{},
+				Transfers: []journal.Transfer{{Broker: journal.BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.5, Fee: 0.0004, Time: at}},
+			},
+		},
+		{
+			name:          "missing columns",
+			input:         "time,broker,product_code,side\n",
+			expectedError: "missing columns: price, size, commission, order_id",
+		},
+		{
+			name:          "invalid time",
+			input:         "time,broker,currency,amount,fee\n2026/01/10,bitflyer,BTC,1,0\n",
+			expectedError: `row 2: invalid time "2026/01/10"`,
+		},
+		{
+			name:          "unsupported broker",
+			input:         "time,broker,currency,amount,fee\n2026-01-10T12:34:56+09:00,sbi,BTC,1,0\n",
+			expectedError: `row 2: unsupported broker "sbi"`,
+		},
+		{
+			name:          "invalid number",
+			input:         "time,broker,product_code,side,price,size,commission,order_id\n2026-01-10T12:34:56+09:00,kabucom,9433@1,BUY,abc,100,0,\n",
+			expectedError: `row 2: invalid price "abc"`,
+		},
+		{
+			name:          "not UTF-8",
+			input:         "time,broker\n\x93\xfa\x95\x74\n",
+			expectedError: "the file is not UTF-8",
+		},
+		{
+			name:          "empty",
+			input:         "",
+			expectedError: "the file is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Read(Generic, strings.NewReader(tt.input))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Read() error = %v, expectedError %v", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			for i := range actual.Fills {
+				actual.Fills[i].Time = actual.Fills[i].Time.In(candle.JST)
+			}
+			for i := range actual.Transfers {
+				actual.Transfers[i].Time = actual.Transfers[i].Time.In(candle.JST)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Read() = %+v, expected %+v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRead_IDs(t *testing.T) {
+	// Two identical fills of an order get different ids, and the same file the same ids.
+	input := "time,broker,product_code,side,price,size,commission,order_id\n" +
+		"2026-01-10T12:34:56+09:00,bitflyer,BTC_JPY,BUY,5000000,0.01,0,JRF1\n" +
+		"2026-01-10T12:34:56+09:00,bitflyer,BTC_JPY,BUY,5000000,0.01,0,JRF1\n"
+	first, err := Read(Generic, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Read(Generic, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Fills[0].ID == first.Fills[1].ID {
+		t.Errorf("Read() ids = %s, %s, expected different ids", first.Fills[0].ID, first.Fills[1].ID)
+	}
+	for i := range first.Fills {
+		if first.Fills[i].ID != second.Fills[i].ID {
+			t.Errorf("Read() id = %s, expected %s", second.Fills[i].ID, first.Fills[i].ID)
+		}
+	}
+}
+
+func TestRead_UnsupportedFormat(t *testing.T) {
+	if _, err := Read(Cryptact, strings.NewReader("a\n")); err == nil {
+		t.Error("Read() error = nil, expected unsupported format")
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	if err := Write(Generic, &bytes.Buffer{}, nil, nil); err == nil {
+		t.Error("Write() error = nil, expected unsupported format")
+	}
+}
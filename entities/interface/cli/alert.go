@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/alert"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type AlertCLI struct {
+	useCase usecases.AlertUseCase
+}
+
+func NewAlertCli(usecase usecases.AlertUseCase) AlertCLI {
+	return AlertCLI{useCase: usecase}
+}
+
+type AlertAddArgument struct {
+	ProductCode string
+	Condition   string
+	Threshold   float64
+	Window      string
+	Note        string
+}
+
+func (c *AlertCLI) Add(arg AlertAddArgument) (string, error) {
+	a := alert.Alert{
+		ProductCode: arg.ProductCode,
+		Condition:   alert.Condition(arg.Condition),
+		Threshold:   arg.Threshold,
+		Window:      arg.Window,
+		Note:        arg.Note,
+	}
+	// Keep rate alerts watch the account, whatever the product code.
+	if a.Condition == alert.KeepRateBelow {
+		a.ProductCode = ""
+	}
+
+	res, err := c.useCase.Add(a)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("added alert %d: %s\n", res.ID, res), nil
+}
+
+func (c *AlertCLI) List() (string, error) {
+	alerts, err := c.useCase.List()
+	if err != nil {
+		return "", err
+	}
+	if len(alerts) == 0 {
+		return "no alerts. add one with 'alert add'.\n", nil
+	}
+
+	output := ""
+	for _, v := range alerts {
+		output += fmt.Sprintf("%d, %s, %s", v.ID, v, v.CreatedAt.In(candle.JST).Format(time.RFC3339))
+		if v.Note != "" {
+			output += ", " + v.Note
+		}
+		output += "\n"
+	}
+	return output, nil
+}
+
+func (c *AlertCLI) Remove(id int) (string, error) {
+	if err := c.useCase.Remove(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("removed alert %d\n", id), nil
+}
+
+// Run evaluates the alerts until ctx is cancelled, writing failures to events.
+func (c *AlertCLI) Run(ctx context.Context, interval time.Duration, events io.Writer) (string, error) {
+	if interval <= 0 {
+		return "", fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	c.useCase.OnEvent = func(e usecases.AlertEvent) {
+		fmt.Fprintf(events, "%s error: %v\n", e.Time.In(candle.JST).Format(time.RFC3339), e.Err)
+	}
+
+	if err := c.useCase.Run(ctx, interval); err != nil && err != ctx.Err() {
+		return "", err
+	}
+	return "stopped alerts.\n", nil
+}
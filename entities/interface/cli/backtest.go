@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/backtest"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/strategy"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type BacktestCLI struct {
+	useCase usecases.BacktestUseCase
+}
+
+func NewBacktestCli(usecase usecases.BacktestUseCase) BacktestCLI {
+	return BacktestCLI{useCase: usecase}
+}
+
+type BacktestArgument struct {
+	ProductCode string
+	Strategy    string
+	// Params are strategy parameters such as "fast=5".
+	Params   []string
+	Interval string
+	From     time.Time
+	To       time.Time
+	Cash     float64
+	FeeRate  float64
+	Slippage float64
+	// Format is "text" or "json".
+	Format string
+}
+
+func (c *BacktestCLI) Run(ctx context.Context, arg BacktestArgument) (string, error) {
+	interval, err := candle.ParseInterval(arg.Interval)
+	if err != nil {
+		return "", err
+	}
+	params, err := strategy.ParseParams(arg.Params)
+	if err != nil {
+		return "", err
+	}
+	if arg.Format != "text" && arg.Format != "json" {
+		return "", fmt.Errorf("unsupported format %q (supported: text, json)", arg.Format)
+	}
+
+	res, err := c.useCase.Run(ctx, usecases.BacktestQuery{
+		ProductCode: arg.ProductCode,
+		Strategy:    arg.Strategy,
+		Params:      params,
+		From:        arg.From,
+		To:          arg.To,
+		Config: backtest.Config{
+			Interval: interval,
+			Cash:     arg.Cash,
+			FeeRate:  arg.FeeRate,
+			Slippage: arg.Slippage,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if arg.Format == "json" {
+		output, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output) + "\n", nil
+	}
+
+	output := fmt.Sprintf("strategy: %s %s (%s)\n", arg.Strategy, arg.ProductCode, arg.Interval)
+	output += fmt.Sprintf("period: %s - %s\n", res.From.In(candle.JST).Format(time.RFC3339), res.To.In(candle.JST).Format(time.RFC3339))
+	output += fmt.Sprintf("initial cash: %f\n", res.InitialCash)
+	output += fmt.Sprintf("final equity: %f\n", res.FinalEquity)
+	output += fmt.Sprintf("pnl: %f (%.2f%%)\n", res.PnL, res.Return*100)
+	output += fmt.Sprintf("max drawdown: %f (%.2f%%)\n", res.MaxDrawdown, res.MaxDrawdownRate*100)
+	output += fmt.Sprintf("sharpe: %.2f\n", res.Sharpe)
+	output += fmt.Sprintf("trades: %d, wins: %d, win rate: %.2f%%\n", res.Trades, res.Wins, res.WinRate*100)
+	output += fmt.Sprintf("fees: %f\n", res.Fees)
+	output += fmt.Sprintf("open position: %f\n", res.Position)
+	output += fmt.Sprintf("rejected orders: %d\n", res.Rejected)
+
+	output += "\nTrade Log\n===========\n"
+	output += "Time, Order, Side, Price, Size, Fee, PnL\n"
+	for _, v := range res.Fills {
+		output += fmt.Sprintf("%s, %s, %s, %f, %f, %f, %f\n",
+			v.Time.In(candle.JST).Format(time.RFC3339), v.OrderID, v.Side, v.Price, v.Size, v.Fee, v.PnL)
+	}
+
+	return output, nil
+}
@@ -1,9 +1,16 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"sort"
+	"strconv"
+	"time"
 
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
 	"github.com/sn1w/capital-go/entities/usecases"
 )
 
@@ -21,10 +28,21 @@ type CreateOrderArgument struct {
 	Price       float64
 	ProductCode string
 	Buy         bool
+	// Market sends a market order, without Price.
+	Market bool
 }
 
-func (c *BitFlyerCLI) GetAvaiableMarkets() (string, error) {
-	res, err := c.useCase.ShowAvaiableMarkets()
+type CandlesArgument struct {
+	ProductCode string
+	Interval    string
+	From        time.Time
+	To          time.Time
+	// Format is "csv" or "json".
+	Format string
+}
+
+func (c *BitFlyerCLI) GetAvaiableMarkets(ctx context.Context) (string, error) {
+	res, err := c.useCase.ShowAvaiableMarkets(ctx)
 
 	if err != nil {
 		return "", err
@@ -39,58 +57,146 @@ func (c *BitFlyerCLI) GetAvaiableMarkets() (string, error) {
 	return output, nil
 }
 
-func (c *BitFlyerCLI) GetBoard(productCode string) (string, error) {
-	res, err := c.useCase.GetBoard(productCode)
+func (c *BitFlyerCLI) GetBoard(ctx context.Context, productCode string, arg BoardArgument) (string, error) {
+	if err := arg.options().Validate(); err != nil {
+		return "", err
+	}
+
+	res, err := c.useCase.GetBoard(ctx, productCode)
 
 	if err != nil {
 		return "", err
 	}
 
-	sort.Slice(res.Asks, func(i, j int) bool { return res.Asks[i].Price > res.Asks[j].Price })
+	return formatBoard(res, arg)
+}
 
-	output := fmt.Sprintf("mid_price: %f\n", res.MidPrice)
-	output += "\nAsk\n===========\n"
-	askLen := len(res.Asks)
+func (c *BitFlyerCLI) GetBalance(ctx context.Context) (string, error) {
+	res, err := c.useCase.GetBalance(ctx)
+	if err != nil {
+		return "", err
+	}
 
-	for _, v := range res.Asks[(askLen - 10):] {
-		output += fmt.Sprintf("Price: %f, Size: %f\n", v.Price, v.Size)
+	output := ""
+	for _, v := range res {
+		output += fmt.Sprintf("%s, %f, %f\n", v.CurrencyCode, v.Amount, v.Available)
 	}
-	output += "\nBid\n===========\n"
-	for _, v := range res.Bids[:10] {
-		output += fmt.Sprintf("Price: %f, Size: %f\n", v.Price, v.Size)
+
+	return output, nil
+}
+
+func (c *BitFlyerCLI) CreateOrder(ctx context.Context, arg CreateOrderArgument) (string, error) {
+	res, err := c.useCase.CreateOrder(ctx, createOrderQuery(arg))
+	if err != nil {
+		return "", err
 	}
 
+	output := res.OrderAcceeptanceId
+
 	return output, nil
 }
 
-func (c *BitFlyerCLI) GetBalance() (string, error) {
-	res, err := c.useCase.GetBalance()
+// PreviewOrder summarizes the order to confirm before it is sent.
+func (c *BitFlyerCLI) PreviewOrder(ctx context.Context, arg CreateOrderArgument) (string, error) {
+	preview, err := c.useCase.PreviewOrder(ctx, createOrderQuery(arg))
 	if err != nil {
 		return "", err
 	}
+	return formatOrderPreview(preview), nil
+}
 
-	output := ""
-	for _, v := range res {
-		output += fmt.Sprintf("%s, %f, %f\n", v.CurrencyCode, v.Amount, v.Available)
+// DryRunOrder runs the validation and the risk checks of the order,
+// and shows the request body that would be signed and sent.
+func (c *BitFlyerCLI) DryRunOrder(ctx context.Context, arg CreateOrderArgument) (string, error) {
+	req := createOrderQuery(arg)
+	preview, err := c.useCase.PreviewOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if err := c.useCase.CheckOrder(ctx, req); err != nil {
+		return "", err
 	}
 
+	output := formatOrderPreview(preview)
+	output += "\nRisk Check: passed\n"
+	output += "\nPOST /v1/me/sendchildorder\n"
+	output += preview.Body + "\n"
 	return output, nil
 }
 
-func (c *BitFlyerCLI) CreateOrder(arg CreateOrderArgument) (string, error) {
-	orderReq := usecases.OrderCreate{
+func createOrderQuery(arg CreateOrderArgument) usecases.OrderCreate {
+	return usecases.OrderCreate{
 		Size:        arg.Size,
 		Price:       arg.Price,
 		Buy:         arg.Buy,
 		ProductCode: arg.ProductCode,
+		Market:      arg.Market,
+	}
+}
+
+func formatOrderPreview(p *usecases.OrderPreview) string {
+	market := p.Type == string(bitflyer.ChildOrderTypeMarket)
+	price := strconv.FormatFloat(p.Price, 'f', -1, 64)
+	if market {
+		price = "market"
+	}
+
+	output := fmt.Sprintf("Product Code: %s\n", p.ProductCode)
+	output += fmt.Sprintf("Side: %s\n", p.Side)
+	output += fmt.Sprintf("Type: %s\n", p.Type)
+	output += fmt.Sprintf("Price: %s\n", price)
+	output += fmt.Sprintf("Size: %s\n", strconv.FormatFloat(p.Size, 'f', -1, 64))
+	if market {
+		output += fmt.Sprintf("Notional: %.0f (estimated at the best quote)\n", p.Notional)
+	} else {
+		output += fmt.Sprintf("Notional: %.0f\n", p.Notional)
 	}
+	output += fmt.Sprintf("Mid Price: %s (%+.2f%% from mid)\n", strconv.FormatFloat(p.MidPrice, 'f', -1, 64), p.Distance*100)
+	return output
+}
 
-	res, err := c.useCase.CreateOrder(orderReq)
+func (c *BitFlyerCLI) GetCandles(ctx context.Context, arg CandlesArgument) (string, error) {
+	interval, err := candle.ParseInterval(arg.Interval)
 	if err != nil {
 		return "", err
 	}
+	if arg.Format != "csv" && arg.Format != "json" {
+		return "", fmt.Errorf("unsupported format %q (supported: csv, json)", arg.Format)
+	}
 
-	output := res.OrderAcceeptanceId
+	res, err := c.useCase.GetCandles(ctx, usecases.CandleQuery{
+		ProductCode: arg.ProductCode,
+		Interval:    interval,
+		From:        arg.From,
+		To:          arg.To,
+	})
+	if err != nil {
+		return "", err
+	}
 
-	return output, nil
+	if arg.Format == "json" {
+		output, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output) + "\n", nil
+	}
+
+	var output bytes.Buffer
+	w := csv.NewWriter(&output)
+	w.Write([]string{"time", "open", "high", "low", "close", "volume", "trades"})
+	for _, v := range res {
+		w.Write([]string{
+			v.Time.Format(time.RFC3339),
+			strconv.FormatFloat(v.Open, 'f', -1, 64),
+			strconv.FormatFloat(v.High, 'f', -1, 64),
+			strconv.FormatFloat(v.Low, 'f', -1, 64),
+			strconv.FormatFloat(v.Close, 'f', -1, 64),
+			strconv.FormatFloat(v.Volume, 'f', -1, 64),
+			strconv.Itoa(v.Trades),
+		})
+	}
+	w.Flush()
+
+	return output.String(), w.Error()
 }
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sn1w/capital-go/entities/board"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+// BoardArgument selects how boards are shown by the bitFlyer and kabucom board commands.
+type BoardArgument struct {
+	// Depth is the number of levels per side. Zero shows them all.
+	Depth int
+	// Tick groups prices into buckets of this width. Zero disables grouping.
+	Tick float64
+}
+
+func (a BoardArgument) options() board.Options {
+	return board.Options{Depth: a.Depth, Tick: a.Tick}
+}
+
+// formatBoard shows asks above bids, each closest to the spread in the middle, with a summary.
+func formatBoard(res usecases.BoardInformation, arg BoardArgument) (string, error) {
+	book, err := board.Build(res.MidPrice, levels(res.Bids), levels(res.Asks), arg.options())
+	if err != nil {
+		return "", err
+	}
+
+	s := book.Summary
+	output := fmt.Sprintf("mid_price: %s\n", formatQuote(s.MidPrice))
+	if s.BestBid > 0 && s.BestAsk > 0 {
+		output += fmt.Sprintf("spread: %s (%.4f%%)\n", formatFloat(s.Spread), s.SpreadRatio*100)
+	} else {
+		output += "spread: -\n"
+	}
+	output += fmt.Sprintf("imbalance: %+.4f (bid %s / ask %s)\n", s.Imbalance, formatFloat(s.BidSize), formatFloat(s.AskSize))
+
+	output += "\nAsk\n===========\n"
+	if len(book.Asks) == 0 {
+		output += "(no orders)\n"
+	}
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		output += formatLevel(book.Asks[i])
+	}
+	output += "\nBid\n===========\n"
+	if len(book.Bids) == 0 {
+		output += "(no orders)\n"
+	}
+	for _, v := range book.Bids {
+		output += formatLevel(v)
+	}
+
+	return output, nil
+}
+
+func levels(prices usecases.BoardPrices) []board.Level {
+	res := make([]board.Level, 0, len(prices))
+	for _, v := range prices {
+		res = append(res, board.Level{Price: v.Price, Size: v.Size})
+	}
+	return res
+}
+
+func formatLevel(v board.Level) string {
+	return fmt.Sprintf("Price: %s, Size: %s, Cumulative: %s\n", formatFloat(v.Price), formatFloat(v.Size), formatFloat(v.Cumulative))
+}
+
+// formatQuote formats a price, or "-" when there is none.
+func formatQuote(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return formatFloat(v)
+}
+
+// formatFloat formats v without trailing zeros, dropping the float error of sums such as 0.30000000000000004.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(execution.Round(v), 'f', -1, 64)
+}
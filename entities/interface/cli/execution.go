@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type ExecutionCLI struct {
+	useCase usecases.ExecutionUseCase
+}
+
+func NewExecutionCli(usecase usecases.ExecutionUseCase) ExecutionCLI {
+	return ExecutionCLI{useCase: usecase}
+}
+
+type ExecutionArgument struct {
+	ProductCode string
+	Buy         bool
+	Size        float64
+	// Duration and Slices are used by TWAP.
+	Duration time.Duration
+	Slices   int
+	// Display is used by iceberg.
+	Display float64
+	// Price is the limit price of iceberg, and the worst price of TWAP slices.
+	Price float64
+	// MaxSlippage is used by chase, in percent.
+	MaxSlippage  float64
+	PollInterval time.Duration
+}
+
+// Run executes an order with algorithm until it is filled, stopped or ctx is cancelled, writing the
+// child orders and the progress to events.
+func (c *ExecutionCLI) Run(ctx context.Context, algorithm string, arg ExecutionArgument, events io.Writer) (string, error) {
+	c.useCase.OnEvent = func(e usecases.ExecutionEvent) {
+		at := e.Time.In(candle.JST).Format(time.RFC3339)
+		if e.Err != nil {
+			fmt.Fprintf(events, "%s error: %v\n", at, e.Err)
+			return
+		}
+		fmt.Fprintf(events, "%s %s\n", at, e.Message)
+	}
+
+	res, err := c.useCase.Run(ctx, executionQuery(algorithm, arg))
+	if res == nil {
+		return "", err
+	}
+
+	output := fmt.Sprintf("stopped %s on %s. filled: %s of %s", algorithm, arg.ProductCode, formatFloat(res.Filled), formatFloat(res.Size))
+	if res.Filled > 0 {
+		output += ", average price: " + formatFloat(res.AveragePrice)
+	}
+	output += fmt.Sprintf(", orders: %d", res.Orders)
+	if res.Reason != "" {
+		output += " (" + res.Reason + ")"
+	}
+	output += "\n"
+	if err != nil {
+		// The summary is still shown, as some child orders may be open.
+		fmt.Fprint(events, output)
+		return "", err
+	}
+	return output, nil
+}
+
+// Preview summarizes the parent order and its first child order, to confirm before it starts.
+func (c *ExecutionCLI) Preview(ctx context.Context, algorithm string, arg ExecutionArgument) (string, error) {
+	query := executionQuery(algorithm, arg)
+	first, err := c.useCase.FirstOrder(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	at := "market"
+	if !first.Market {
+		at = formatFloat(first.Price)
+	}
+	output := formatExecutionOrder(query.Order)
+	output += fmt.Sprintf("First Order: %s %s at %s\n", sideName(first.Buy), formatFloat(first.Size), at)
+	return output, nil
+}
+
+// DryRun runs the validation and the risk checks of the first child order, and shows the request
+// body that would be signed and sent first.
+func (c *ExecutionCLI) DryRun(ctx context.Context, algorithm string, arg ExecutionArgument) (string, error) {
+	query := executionQuery(algorithm, arg)
+	preview, err := c.useCase.DryRun(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	output := formatExecutionOrder(query.Order)
+	output += "\nFirst Order:\n"
+	output += formatOrderPreview(preview)
+	output += "\nRisk Check: passed\n"
+	output += "\nPOST /v1/me/sendchildorder\n"
+	output += preview.Body + "\n"
+	return output, nil
+}
+
+func executionQuery(algorithm string, arg ExecutionArgument) usecases.ExecutionQuery {
+	return usecases.ExecutionQuery{
+		Order: execution.Order{
+			Algorithm:   execution.Algorithm(algorithm),
+			ProductCode: arg.ProductCode,
+			Buy:         arg.Buy,
+			Size:        arg.Size,
+			Duration:    arg.Duration,
+			Slices:      arg.Slices,
+			Display:     arg.Display,
+			Price:       arg.Price,
+			MaxSlippage: arg.MaxSlippage,
+		},
+		PollInterval: arg.PollInterval,
+	}
+}
+
+func formatExecutionOrder(o execution.Order) string {
+	output := fmt.Sprintf("Algorithm: %s\n", o.Algorithm)
+	output += fmt.Sprintf("Product Code: %s\n", o.ProductCode)
+	output += fmt.Sprintf("Side: %s\n", sideName(o.Buy))
+	output += fmt.Sprintf("Size: %s\n", formatFloat(o.Size))
+	switch o.Algorithm {
+	case execution.TWAP:
+		output += fmt.Sprintf("Duration: %s\n", o.Duration)
+		output += fmt.Sprintf("Slices: %d\n", o.Slices)
+		if o.Price > 0 {
+			output += fmt.Sprintf("Worst Price: %s\n", formatFloat(o.Price))
+		} else {
+			output += "Worst Price: no limit\n"
+		}
+	case execution.Iceberg:
+		output += fmt.Sprintf("Price: %s\n", formatFloat(o.Price))
+		output += fmt.Sprintf("Display: %s\n", formatFloat(o.Display))
+	case execution.Chase:
+		output += fmt.Sprintf("Max Slippage: %s%%\n", formatFloat(o.MaxSlippage))
+	}
+	return output
+}
+
+func sideName(buy bool) string {
+	if buy {
+		return string(bitflyer.SideBuy)
+	}
+	return string(bitflyer.SideSell)
+}
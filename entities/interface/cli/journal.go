@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/tradecsv"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type JournalCLI struct {
+	useCase usecases.JournalUseCase
+}
+
+func NewJournalCli(usecase usecases.JournalUseCase) JournalCLI {
+	return JournalCLI{useCase: usecase}
+}
+
+type JournalSyncArgument struct {
+	ProductCodes []string
+	KabucomToken string
+}
+
+func (c *JournalCLI) Sync(ctx context.Context, arg JournalSyncArgument) (string, error) {
+	res, err := c.useCase.Sync(ctx, usecases.JournalSyncQuery{ProductCodes: arg.ProductCodes, KabucomToken: arg.KabucomToken})
+	if err != nil {
+		return "", err
+	}
+
+	brokers := make([]string, 0, len(res.Fills))
+	for k := range res.Fills {
+		brokers = append(brokers, k)
+	}
+	sort.Strings(brokers)
+	output := ""
+	for _, v := range brokers {
+		output += fmt.Sprintf("%s: %d new fills\n", v, res.Fills[v])
+	}
+	output += fmt.Sprintf("transfers: %d new\n", res.Transfers)
+	output += fmt.Sprintf("balance snapshots: %d\n", res.Snapshots)
+	return output, nil
+}
+
+// JournalKinds are the kinds of records of `journal list` and `journal export`.
+var JournalKinds = []string{"orders", "fills", "transfers", "balances"}
+
+type JournalListArgument struct {
+	Kind        string
+	Broker      string
+	ProductCode string
+	// From and To bound the records, ignored when zero.
+	From time.Time
+	To   time.Time
+}
+
+func (a JournalListArgument) validate() error {
+	for _, v := range JournalKinds {
+		if a.Kind == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported kind %q (supported: %s)", a.Kind, strings.Join(JournalKinds, ", "))
+}
+
+func (a JournalListArgument) filter() journal.Filter {
+	return journal.Filter{Broker: a.Broker, ProductCode: a.ProductCode, From: a.From, To: a.To}
+}
+
+func (c *JournalCLI) List(arg JournalListArgument) (string, error) {
+	if err := arg.validate(); err != nil {
+		return "", err
+	}
+	j, err := c.useCase.List(arg.filter())
+	if err != nil {
+		return "", err
+	}
+
+	output := ""
+	switch arg.Kind {
+	case "orders":
+		for _, v := range j.Orders {
+			output += formatJournalOrder(v)
+		}
+	case "fills":
+		for _, v := range j.Fills {
+			output += formatJournalFill(v)
+		}
+	case "transfers":
+		for _, v := range j.Transfers {
+			output += fmt.Sprintf("%s, %s, %s, %s, fee %s, %s\n", formatJournalTime(v.Time), v.Broker, v.Currency,
+				formatFloat(v.Amount), formatFloat(v.Fee), v.ID)
+		}
+	case "balances":
+		for _, s := range j.Snapshots {
+			for _, v := range s.Balances {
+				output += fmt.Sprintf("%s, %s, %s, %s, %s\n", formatJournalTime(s.Time), s.Broker, v.Currency, formatFloat(v.Amount), formatFloat(v.Available))
+			}
+		}
+	}
+	if output == "" {
+		return fmt.Sprintf("no %s in the journal.\n", arg.Kind), nil
+	}
+	return output, nil
+}
+
+// Show returns the order id and its fills, with their average price.
+func (c *JournalCLI) Show(id int) (string, error) {
+	o, fills, err := c.useCase.Show(id)
+	if err != nil {
+		return "", err
+	}
+
+	price := "MARKET"
+	if o.Price > 0 {
+		price = formatFloat(o.Price)
+	}
+	output := fmt.Sprintf("ID: %d\n", o.ID)
+	output += fmt.Sprintf("Broker: %s\n", o.Broker)
+	output += fmt.Sprintf("Product Code: %s\n", o.ProductCode)
+	output += fmt.Sprintf("Acceptance ID: %s\n", o.AcceptanceID)
+	output += fmt.Sprintf("Side: %s\n", o.Side)
+	output += fmt.Sprintf("Type: %s\n", o.Type)
+	output += fmt.Sprintf("Price: %s\n", price)
+	output += fmt.Sprintf("Size: %s\n", formatFloat(o.Size))
+	output += fmt.Sprintf("Sent At: %s\n", formatJournalTime(o.SentAt))
+
+	size, notional, commission := 0.0, 0.0, 0.0
+	for _, v := range fills {
+		size += v.Size
+		notional += v.Price * v.Size
+		commission += v.Commission
+	}
+	output += fmt.Sprintf("Filled: %s", formatFloat(size))
+	if size > 0 {
+		output += fmt.Sprintf(" @ %s average, commission %s", formatFloat(notional/size), formatFloat(commission))
+	}
+	output += "\n"
+	if len(fills) == 0 {
+		output += "no fills in the journal. run 'journal sync' to pull them.\n"
+	}
+	for _, v := range fills {
+		output += "  " + formatJournalFill(v)
+	}
+	return output, nil
+}
+
+// JournalImportFormats are the formats of `journal import`.
+var JournalImportFormats = []string{string(tradecsv.Generic), string(tradecsv.BitFlyer), string(tradecsv.Kabucom)}
+
+// Import adds the fills and transfers of the file name in format to the journal.
+func (c *JournalCLI) Import(format string, name string, r io.Reader) (string, error) {
+	records, err := tradecsv.Read(tradecsv.Format(format), r)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	res, err := c.useCase.Import(records)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s: %d new fills, %d new transfers, %d duplicates, %d rows skipped\n", name, res.Fills, res.Transfers,
+		res.Duplicates, res.Skipped), nil
+}
+
+// JournalExportFormats are the formats of `journal export`. The formats of the tax tools have
+// the fills and transfers of the live bitFlyer account, whatever the kind.
+var JournalExportFormats = []string{"csv", "json", string(tradecsv.Cryptact), string(tradecsv.Gtax)}
+
+type JournalExportArgument struct {
+	JournalListArgument
+	Format string
+}
+
+// Export returns the records as CSV or JSON, or in the format of a tax tool.
+func (c *JournalCLI) Export(arg JournalExportArgument) (string, error) {
+	if err := arg.validate(); err != nil {
+		return "", err
+	}
+	supported := false
+	for _, v := range JournalExportFormats {
+		supported = supported || arg.Format == v
+	}
+	if !supported {
+		return "", fmt.Errorf("unsupported format %q (supported: %s)", arg.Format, strings.Join(JournalExportFormats, ", "))
+	}
+	j, err := c.useCase.List(arg.filter())
+	if err != nil {
+		return "", err
+	}
+
+	if arg.Format != "csv" && arg.Format != "json" {
+		var output bytes.Buffer
+		err := tradecsv.Write(tradecsv.Format(arg.Format), &output, j.Fills, j.Transfers)
+		return output.String(), err
+	}
+
+	if arg.Format == "json" {
+		var records any
+		switch arg.Kind {
+		case "orders":
+			records = j.Orders
+		case "fills":
+			records = j.Fills
+		case "transfers":
+			records = j.Transfers
+		case "balances":
+			records = j.Snapshots
+		}
+		output, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output) + "\n", nil
+	}
+
+	var output bytes.Buffer
+	w := csv.NewWriter(&output)
+	switch arg.Kind {
+	case "orders":
+		w.Write([]string{"id", "sent_at", "broker", "product_code", "side", "type", "price", "size", "acceptance_id"})
+		for _, v := range j.Orders {
+			w.Write([]string{
+				strconv.Itoa(v.ID),
+				v.SentAt.Format(time.RFC3339),
+				v.Broker,
+				v.ProductCode,
+				v.Side,
+				v.Type,
+				formatFloat(v.Price),
+				formatFloat(v.Size),
+				v.AcceptanceID,
+			})
+		}
+	case "fills":
+		w.Write(tradecsv.FillColumns)
+		for _, v := range j.Fills {
+			w.Write([]string{
+				v.Time.Format(time.RFC3339),
+				v.Broker,
+				v.ProductCode,
+				v.Side,
+				formatFloat(v.Price),
+				formatFloat(v.Size),
+				formatFloat(v.Commission),
+				v.OrderID,
+				v.ID,
+			})
+		}
+	case "transfers":
+		w.Write(tradecsv.TransferColumns)
+		for _, v := range j.Transfers {
+			w.Write([]string{v.Time.Format(time.RFC3339), v.Broker, v.Currency, formatFloat(v.Amount), formatFloat(v.Fee), v.ID})
+		}
+	case "balances":
+		w.Write([]string{"time", "broker", "currency", "amount", "available"})
+		for _, s := range j.Snapshots {
+			for _, v := range s.Balances {
+				w.Write([]string{s.Time.Format(time.RFC3339), s.Broker, v.Currency, formatFloat(v.Amount), formatFloat(v.Available)})
+			}
+		}
+	}
+	w.Flush()
+
+	return output.String(), w.Error()
+}
+
+func formatJournalOrder(o journal.Order) string {
+	price := "MARKET"
+	if o.Price > 0 {
+		price = formatFloat(o.Price)
+	}
+	return fmt.Sprintf("%d, %s, %s, %s, %s, %s, %s, %s\n", o.ID, formatJournalTime(o.SentAt), o.Broker, o.ProductCode,
+		o.Side, price, formatFloat(o.Size), o.AcceptanceID)
+}
+
+func formatJournalFill(f journal.Fill) string {
+	return fmt.Sprintf("%s, %s, %s, %s, %s, %s, commission %s, %s\n", formatJournalTime(f.Time), f.Broker, f.ProductCode,
+		f.Side, formatFloat(f.Price), formatFloat(f.Size), formatFloat(f.Commission), f.OrderID)
+}
+
+func formatJournalTime(t time.Time) string {
+	return t.In(candle.JST).Format(time.RFC3339)
+}
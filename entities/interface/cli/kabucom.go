@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sn1w/capital-go/entities/usecases"
@@ -14,8 +15,8 @@ func NewKabucomCli(usecase usecases.KabucomUseCase) KabucomCLI {
 	return KabucomCLI{usecase: usecase}
 }
 
-func (c *KabucomCLI) Authorization(pwd string) (string, error) {
-	res, err := c.usecase.DoAuthorize(pwd)
+func (c *KabucomCLI) Authorization(ctx context.Context, pwd string) (string, error) {
+	res, err := c.usecase.DoAuthorize(ctx, pwd)
 
 	if err != nil {
 		return "", err
@@ -23,3 +24,17 @@ func (c *KabucomCLI) Authorization(pwd string) (string, error) {
 
 	return fmt.Sprintf("token: %s\n", res), nil
 }
+
+func (c *KabucomCLI) GetBoard(ctx context.Context, token string, symbol string, arg BoardArgument) (string, error) {
+	if err := arg.options().Validate(); err != nil {
+		return "", err
+	}
+
+	res, err := c.usecase.GetBoard(ctx, token, symbol)
+
+	if err != nil {
+		return "", err
+	}
+
+	return formatBoard(res, arg)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/pnl"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type PnLCLI struct {
+	useCase usecases.PnLUseCase
+}
+
+func NewPnLCli(usecase usecases.PnLUseCase) PnLCLI {
+	return PnLCLI{useCase: usecase}
+}
+
+type PnLArgument struct {
+	Method string
+	// From and To bound the period, ignored when zero.
+	From         time.Time
+	To           time.Time
+	Broker       string
+	ProductCode  string
+	KabucomToken string
+}
+
+// Report returns the profit and loss by product and by day, then the totals.
+func (c *PnLCLI) Report(ctx context.Context, arg PnLArgument) (string, error) {
+	method, err := pnl.ParseMethod(arg.Method)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.useCase.Report(ctx, usecases.PnLQuery{
+		Method:       method,
+		From:         arg.From,
+		To:           arg.To,
+		Broker:       arg.Broker,
+		ProductCode:  arg.ProductCode,
+		KabucomToken: arg.KabucomToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output := fmt.Sprintf("Method: %s\n", r.Method)
+	output += fmt.Sprintf("Period: %s - %s\n", formatPnLTime(r.From, "beginning"), formatPnLTime(r.To, "now"))
+	if len(r.Products) == 0 {
+		return output + "no fills in the journal. run 'journal sync' to pull them.\n", nil
+	}
+
+	output += "By product:\n"
+	for _, v := range r.Products {
+		output += fmt.Sprintf("  %s %s: realized %s, fees %s, net %s, %d fills", v.Broker, v.ProductCode,
+			formatFloat(v.Realized), formatFloat(v.Fees), formatFloat(v.Net()), v.Trades)
+		if v.Position != 0 {
+			output += fmt.Sprintf(", position %s @ %s", formatFloat(v.Position), formatFloat(v.AverageCost))
+			if v.Price > 0 {
+				output += fmt.Sprintf(", unrealized %s @ %s", formatFloat(v.Unrealized), formatFloat(v.Price))
+			} else {
+				output += ", no quote"
+			}
+		}
+		output += "\n"
+	}
+	output += "By day:\n"
+	for _, v := range r.Days {
+		output += fmt.Sprintf("  %s: realized %s, fees %s, net %s, %d fills\n", v.Day.Format("2006-01-02"),
+			formatFloat(v.Realized), formatFloat(v.Fees), formatFloat(v.Net()), v.Trades)
+	}
+	output += fmt.Sprintf("Realized: %s\n", formatFloat(r.Realized))
+	output += fmt.Sprintf("Fees: %s\n", formatFloat(r.Fees))
+	output += fmt.Sprintf("Net: %s\n", formatFloat(r.Net()))
+	output += fmt.Sprintf("Unrealized: %s\n", formatFloat(r.Unrealized))
+	return output, nil
+}
+
+func formatPnLTime(t time.Time, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.In(candle.JST).Format(time.RFC3339)
+}
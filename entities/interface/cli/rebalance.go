@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sn1w/capital-go/entities/rebalance"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type RebalanceCLI struct {
+	useCase usecases.RebalanceUseCase
+}
+
+func NewRebalanceCli(usecase usecases.RebalanceUseCase) RebalanceCLI {
+	return RebalanceCLI{useCase: usecase}
+}
+
+type RebalanceArgument struct {
+	// TargetFile is the YAML file of the target allocation.
+	TargetFile   string
+	KabucomToken string
+	// KabucomOrderPassword is needed to send the orders of stocks.
+	KabucomOrderPassword string
+}
+
+// RebalanceOrders are the orders of a plan, sells first.
+type RebalanceOrders struct {
+	Orders []rebalance.Order
+	// Unfunded is set when a broker lacks the cash of its buys, and the orders must not be sent.
+	Unfunded error
+}
+
+// Plan returns the plan bringing the holdings to the target, and its orders.
+func (c *RebalanceCLI) Plan(ctx context.Context, arg RebalanceArgument) (string, RebalanceOrders, error) {
+	target, err := rebalance.LoadTarget(arg.TargetFile)
+	if err != nil {
+		return "", RebalanceOrders{}, err
+	}
+	plan, err := c.useCase.Plan(ctx, usecases.RebalanceQuery{Target: target, KabucomToken: arg.KabucomToken})
+	if err != nil {
+		return "", RebalanceOrders{}, err
+	}
+
+	output := fmt.Sprintf("Value: %s JPY\n", formatFloat(plan.Value))
+	output += fmt.Sprintf("Tolerance: %s\n", formatPercent(plan.Tolerance))
+	output += "Allocation (target, now, after):\n"
+	for _, v := range plan.Allocations {
+		band := ""
+		if !v.InBand {
+			band = ", out of band"
+		}
+		output += fmt.Sprintf("  %s: %s, %s, %s%s\n", v.Asset, formatPercent(v.Target), formatPercent(v.Before), formatPercent(v.After), band)
+	}
+
+	if len(plan.Orders) == 0 {
+		output += "no orders: the holdings are within the tolerance.\n"
+	} else {
+		output += "Orders:\n"
+	}
+	for _, v := range plan.Orders {
+		output += fmt.Sprintf("  %s %s %s %s @ %s, %s JPY\n", v.Broker, v.ProductCode, v.Side, formatFloat(v.Size), formatFloat(v.Price), formatFloat(v.Notional))
+	}
+	for _, v := range plan.Skipped {
+		output += fmt.Sprintf("  skipped %s %s %s %s: below the lot size\n", v.Broker, v.ProductCode, v.Side, formatFloat(v.Size))
+	}
+	unfunded := plan.Funded()
+	if unfunded != nil {
+		output += unfunded.Error() + ".\n"
+	}
+	return output, RebalanceOrders{Orders: plan.Orders, Unfunded: unfunded}, nil
+}
+
+// DryRunOrder runs the risk checks of an order of the plan, without sending it.
+func (c *RebalanceCLI) DryRunOrder(ctx context.Context, arg RebalanceArgument, order rebalance.Order) (string, error) {
+	if err := c.useCase.CheckOrder(ctx, order, rebalanceQuery(arg)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s %s at market: risk check passed\n", order.Broker, order.ProductCode, order.Side, formatFloat(order.Size)), nil
+}
+
+// SendOrder sends an order of the plan as a market order.
+func (c *RebalanceCLI) SendOrder(ctx context.Context, arg RebalanceArgument, order rebalance.Order) (string, error) {
+	id, err := c.useCase.SendOrder(ctx, order, rebalanceQuery(arg))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s %s at market: %s\n", order.Broker, order.ProductCode, order.Side, formatFloat(order.Size), id), nil
+}
+
+func rebalanceQuery(arg RebalanceArgument) usecases.RebalanceQuery {
+	return usecases.RebalanceQuery{KabucomToken: arg.KabucomToken, KabucomOrderPassword: arg.KabucomOrderPassword}
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v*100)
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/schedule"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type ScheduleCLI struct {
+	useCase usecases.ScheduleUseCase
+}
+
+func NewScheduleCli(usecase usecases.ScheduleUseCase) ScheduleCLI {
+	return ScheduleCLI{useCase: usecase}
+}
+
+type ScheduleAddArgument struct {
+	Cron        string
+	ProductCode string
+	// Side is "BUY" or "SELL".
+	Side string
+	// Amount is the JPY of each order, or Size a fixed size.
+	Amount    float64
+	Size      float64
+	OrderType string
+	Note      string
+	// KabucomToken and KabucomOrderPassword check the orders of kabu STATION symbols.
+	KabucomToken         string
+	KabucomOrderPassword string
+}
+
+func (c *ScheduleCLI) Add(arg ScheduleAddArgument) (string, error) {
+	res, err := c.useCase.Add(scheduleOf(arg))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("added schedule %d: %s\n", res.ID, res), nil
+}
+
+// Preview shows the schedule with the order it would send at the current board, once it passes
+// the risk checks.
+func (c *ScheduleCLI) Preview(ctx context.Context, arg ScheduleAddArgument) (string, error) {
+	s := scheduleOf(arg)
+	order, err := c.useCase.Preview(ctx, s, usecases.ScheduleRunOptions{
+		KabucomToken:         arg.KabucomToken,
+		KabucomOrderPassword: arg.KabucomOrderPassword,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output := fmt.Sprintf("Schedule: %s\n", s)
+	if order.Next != nil {
+		output += fmt.Sprintf("First Order: %s\n", formatScheduleTime(order.Next.At))
+	}
+	output += fmt.Sprintf("Order At Current Prices: %s %s\n", s.Side, formatRunOrder(schedule.Run{Size: order.Size, Price: order.Price}))
+	return output, nil
+}
+
+// DryRunAdd validates the schedule and runs the risk checks of its order, without storing it.
+func (c *ScheduleCLI) DryRunAdd(ctx context.Context, arg ScheduleAddArgument) (string, error) {
+	output, err := c.Preview(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+	return output + "\nRisk Check: passed\nthe schedule was not added.\n", nil
+}
+
+func scheduleOf(arg ScheduleAddArgument) schedule.Schedule {
+	return schedule.Schedule{
+		Cron:        arg.Cron,
+		ProductCode: arg.ProductCode,
+		Side:        arg.Side,
+		Amount:      arg.Amount,
+		Size:        arg.Size,
+		OrderType:   schedule.OrderType(arg.OrderType),
+		Note:        arg.Note,
+	}
+}
+
+func (c *ScheduleCLI) List() (string, error) {
+	statuses, err := c.useCase.List()
+	if err != nil {
+		return "", err
+	}
+	if len(statuses) == 0 {
+		return "no schedules. add one with 'schedule add'.\n", nil
+	}
+
+	output := ""
+	for _, v := range statuses {
+		output += fmt.Sprintf("%d, %s", v.Schedule.ID, v.Schedule)
+		if v.Next != nil {
+			output += ", next " + formatScheduleTime(v.Next.At)
+		}
+		if v.Last != nil {
+			output += fmt.Sprintf(", last %s %s", formatScheduleTime(v.Last.Slot), v.Last.Status)
+		}
+		if v.Schedule.Note != "" {
+			output += ", " + v.Schedule.Note
+		}
+		output += "\n"
+	}
+	return output, nil
+}
+
+func (c *ScheduleCLI) Remove(id int) (string, error) {
+	if err := c.useCase.Remove(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("removed schedule %d\n", id), nil
+}
+
+// History lists the recorded runs of the schedule id, or of every schedule when id is zero.
+func (c *ScheduleCLI) History(id int) (string, error) {
+	runs, err := c.useCase.Runs(id)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "no runs.\n", nil
+	}
+
+	output := ""
+	for _, v := range runs {
+		output += fmt.Sprintf("%d, %s, %s", v.ScheduleID, formatScheduleTime(v.Slot), v.Status)
+		if v.Size > 0 {
+			output += ", " + formatRunOrder(v)
+		}
+		if v.OrderID != "" {
+			output += ", " + v.OrderID
+		}
+		if v.Error != "" {
+			output += ", " + v.Error
+		}
+		output += "\n"
+	}
+	return output, nil
+}
+
+type ScheduleRunArgument struct {
+	KabucomToken         string
+	KabucomOrderPassword string
+	Interval             time.Duration
+	Grace                time.Duration
+	// Once runs the due slots once and returns, to be run by an external scheduler such as cron.
+	Once bool
+}
+
+// Run sends the orders of the schedules until ctx is cancelled, writing runs and failures to events.
+func (c *ScheduleCLI) Run(ctx context.Context, arg ScheduleRunArgument, events io.Writer) (string, error) {
+	if arg.Interval <= 0 && !arg.Once {
+		return "", fmt.Errorf("interval must be positive, got %s", arg.Interval)
+	}
+
+	c.useCase.OnEvent = func(e usecases.ScheduleEvent) {
+		fmt.Fprintln(events, formatScheduleEvent(e))
+	}
+	opts := usecases.ScheduleRunOptions{
+		KabucomToken:         arg.KabucomToken,
+		KabucomOrderPassword: arg.KabucomOrderPassword,
+		Grace:                arg.Grace,
+	}
+
+	if arg.Once {
+		c.useCase.RunDue(ctx, opts)
+		return "", nil
+	}
+	if err := c.useCase.Run(ctx, opts, arg.Interval); err != nil && err != ctx.Err() {
+		return "", err
+	}
+	return "stopped schedules.\n", nil
+}
+
+func formatScheduleEvent(e usecases.ScheduleEvent) string {
+	output := e.Time.In(candle.JST).Format(time.RFC3339)
+	if e.Run == nil {
+		if e.Schedule.ID != 0 {
+			output += fmt.Sprintf(" schedule %d", e.Schedule.ID)
+		}
+		return output + fmt.Sprintf(" error: %v", e.Err)
+	}
+
+	r := e.Run
+	output += fmt.Sprintf(" schedule %d: %s %s", r.ScheduleID, r.Status, formatScheduleTime(r.Slot))
+	switch r.Status {
+	case schedule.Sent:
+		output += fmt.Sprintf(", %s %s, order %s", e.Schedule.Side, formatRunOrder(*r), r.OrderID)
+	case schedule.Failed:
+		output += ": " + r.Error
+	case schedule.Missed:
+		output += ": later than the grace period"
+	}
+	if e.Missed > 0 {
+		output += fmt.Sprintf(" (%d earlier slots skipped)", e.Missed)
+	}
+	return output
+}
+
+func formatRunOrder(r schedule.Run) string {
+	if r.Price == 0 {
+		return formatFloat(r.Size) + " at market"
+	}
+	return fmt.Sprintf("%s @ %s", formatFloat(r.Size), formatFloat(r.Price))
+}
+
+func formatScheduleTime(t time.Time) string {
+	return t.In(candle.JST).Format("2006-01-02 15:04")
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/strategy"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type StrategyCLI struct {
+	useCase usecases.StrategyUseCase
+}
+
+func NewStrategyCli(usecase usecases.StrategyUseCase) StrategyCLI {
+	return StrategyCLI{useCase: usecase}
+}
+
+type StrategyRunArgument struct {
+	ProductCode string
+	Strategy    string
+	// Params are strategy parameters such as "fast=5".
+	Params       []string
+	Interval     string
+	PollInterval time.Duration
+	Flatten      bool
+}
+
+// Run runs the strategy until ctx is cancelled, writing its orders and failures to events.
+func (c *StrategyCLI) Run(ctx context.Context, arg StrategyRunArgument, events io.Writer) (string, error) {
+	query, err := strategyRunQuery(arg)
+	if err != nil {
+		return "", err
+	}
+
+	c.useCase.OnEvent = func(e usecases.StrategyEvent) {
+		at := e.Time.In(candle.JST).Format(time.RFC3339)
+		if e.Err != nil {
+			fmt.Fprintf(events, "%s error: %v\n", at, e.Err)
+			return
+		}
+		fmt.Fprintf(events, "%s %s\n", at, e.Message)
+	}
+
+	res, err := c.useCase.Run(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("stopped %s on %s. orders: %d, position: %f\n", arg.Strategy, arg.ProductCode, res.Orders, res.Position), nil
+}
+
+// Preview summarizes the strategy, its product and its order size, to confirm before it starts.
+func (c *StrategyCLI) Preview(ctx context.Context, arg StrategyRunArgument) (string, error) {
+	query, err := strategyRunQuery(arg)
+	if err != nil {
+		return "", err
+	}
+	preview, err := c.useCase.Preview(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	output := fmt.Sprintf("Strategy: %s\n", preview.Strategy)
+	output += fmt.Sprintf("Product Code: %s\n", preview.ProductCode)
+	if size, ok := preview.Params["size"]; ok {
+		output += fmt.Sprintf("Size: %s per order (about %.0f at the mid price %s)\n", formatFloat(size), size*preview.MidPrice, formatFloat(preview.MidPrice))
+	}
+	params := []string{}
+	for _, k := range sortedParamNames(preview.Params) {
+		params = append(params, k+"="+formatFloat(preview.Params[k]))
+	}
+	output += fmt.Sprintf("Parameters: %s\n", strings.Join(params, ", "))
+	output += fmt.Sprintf("Interval: %s\n", arg.Interval)
+	if arg.Flatten {
+		output += "On Stop: cancel resting orders and sell the position\n"
+	} else {
+		output += "On Stop: cancel resting orders and keep the position\n"
+	}
+	return output, nil
+}
+
+// DryRun builds the strategy and shows its summary, without starting it or sending anything.
+func (c *StrategyCLI) DryRun(ctx context.Context, arg StrategyRunArgument) (string, error) {
+	output, err := c.Preview(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+	return output + "\nDry Run: the strategy was built and not started. No order was sent.\n", nil
+}
+
+func strategyRunQuery(arg StrategyRunArgument) (usecases.StrategyRunQuery, error) {
+	interval, err := candle.ParseInterval(arg.Interval)
+	if err != nil {
+		return usecases.StrategyRunQuery{}, err
+	}
+	params, err := strategy.ParseParams(arg.Params)
+	if err != nil {
+		return usecases.StrategyRunQuery{}, err
+	}
+	if arg.PollInterval <= 0 {
+		return usecases.StrategyRunQuery{}, fmt.Errorf("poll interval must be positive, got %s", arg.PollInterval)
+	}
+	return usecases.StrategyRunQuery{
+		ProductCode:  arg.ProductCode,
+		Strategy:     arg.Strategy,
+		Params:       params,
+		Interval:     interval,
+		PollInterval: arg.PollInterval,
+		Flatten:      arg.Flatten,
+	}, nil
+}
+
+func sortedParamNames(params strategy.Params) []string {
+	names := make([]string, 0, len(params))
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/pnl"
+	"github.com/sn1w/capital-go/entities/tax"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type TaxCLI struct {
+	useCase usecases.TaxUseCase
+}
+
+func NewTaxCli(usecase usecases.TaxUseCase) TaxCLI {
+	return TaxCLI{useCase: usecase}
+}
+
+type TaxArgument struct {
+	Year   int
+	Method string
+}
+
+// TaxKinds are the kinds of `tax export`.
+var TaxKinds = []string{"crypto", "stocks"}
+
+func (c *TaxCLI) report(arg TaxArgument) (*usecases.TaxReport, error) {
+	method, err := pnl.ParseMethod(arg.Method)
+	if err != nil {
+		return nil, err
+	}
+	return c.useCase.Report(usecases.TaxQuery{Year: arg.Year, Method: method})
+}
+
+// Report returns the crypto income by asset and the capital gains of stocks by symbol.
+func (c *TaxCLI) Report(arg TaxArgument) (string, error) {
+	r, err := c.report(arg)
+	if err != nil {
+		return "", err
+	}
+
+	output := fmt.Sprintf("Year: %d\n", r.Crypto.Year)
+	output += fmt.Sprintf("Crypto assets (%s):\n", taxMethodNames[r.Crypto.Method])
+	if len(r.Crypto.Currencies) == 0 {
+		output += "  no crypto assets held or traded. run 'journal sync' to pull the fills.\n"
+	}
+	for _, v := range r.Crypto.Currencies {
+		output += fmt.Sprintf("  %s: proceeds %s, cost %s, expenses %s, income %s, held %s @ %s\n", v.Currency,
+			formatYen(v.Sold.Amount), formatYen(v.Cost), formatYen(v.Expenses), formatYen(v.Income()),
+			formatFloat(v.Closing.Quantity), formatYen(v.AverageCost))
+	}
+	output += fmt.Sprintf("  Income: %s\n", formatYen(r.Crypto.Income()))
+	if len(r.Skipped) > 0 {
+		output += fmt.Sprintf("  Not computed: %s\n", strings.Join(r.Skipped, ", "))
+	}
+
+	output += "Stocks:\n"
+	if len(r.Stocks.Symbols) == 0 {
+		output += "  no stocks sold.\n"
+	}
+	for _, v := range r.Stocks.Symbols {
+		output += fmt.Sprintf("  %s: sold %s, proceeds %s, cost %s, commission %s, gain %s\n", v.Symbol,
+			formatFloat(v.Qty), formatYen(v.Proceeds), formatYen(v.Cost), formatYen(v.Commission), formatYen(v.Gain()))
+	}
+	output += fmt.Sprintf("  Gain: %s\n", formatYen(r.Stocks.Gain()))
+	return output, nil
+}
+
+// taxMethodNames are the names of the methods in the calculation sheets.
+var taxMethodNames = map[pnl.Method]string{
+	pnl.MovingAverage: "移動平均法",
+	pnl.TotalAverage:  "総平均法",
+}
+
+// Export returns the calculation sheet of crypto assets, or the capital gains of stocks, as CSV.
+// The CSV is UTF-8 with a byte order mark, so that spreadsheets open it with the right encoding.
+func (c *TaxCLI) Export(arg TaxArgument, kind string) (string, error) {
+	if kind != "crypto" && kind != "stocks" {
+		return "", fmt.Errorf("unsupported kind %q (supported: %s)", kind, strings.Join(TaxKinds, ", "))
+	}
+	r, err := c.report(arg)
+	if err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+	output.WriteString("\ufeff")
+	w := csv.NewWriter(&output)
+	if kind == "crypto" {
+		writeCryptoSheet(w, r.Crypto)
+	} else {
+		writeStockSheet(w, r.Stocks)
+	}
+	w.Flush()
+
+	return output.String(), w.Error()
+}
+
+// writeCryptoSheet writes the sections of 暗号資産の計算書: the ledger of each asset, then the
+// calculation of the income.
+func writeCryptoSheet(w *csv.Writer, r *tax.CryptoReport) {
+	w.Write([]string{fmt.Sprintf("暗号資産の計算書（%s用）", taxMethodNames[r.Method]), fmt.Sprintf("%d年分", r.Year)})
+	w.Write(nil)
+	w.Write([]string{"1 取引の状況"})
+	w.Write([]string{"暗号資産の名称", "月日", "摘要",
+		"購入等 数量", "購入等 単価", "購入等 金額",
+		"売却等 数量", "売却等 単価", "売却等 金額",
+		"手数料等 数量",
+		"残高 数量", "残高 単価", "残高 金額"})
+	for _, c := range r.Currencies {
+		w.Write([]string{c.Currency, "", "年始残高", "", "", "", "", "", "", "",
+			formatFloat(c.Opening.Quantity), formatYen(c.Opening.UnitCost()), formatYen(c.Opening.Amount)})
+		for _, e := range c.Entries {
+			in := []string{"", "", ""}
+			out := []string{"", "", ""}
+			row := []string{formatFloat(e.Quantity), formatYen(e.Price), formatYen(e.Amount)}
+			if e.Kind == tax.EntryBuy || e.Kind == tax.EntryDeposit {
+				in = row
+			} else {
+				out = row
+			}
+			record := []string{c.Currency, e.Time.In(candle.JST).Format("2006/01/02"), taxEntryNames[e.Kind]}
+			record = append(record, in...)
+			record = append(record, out...)
+			record = append(record, formatFloat(e.Fee),
+				formatFloat(e.Balance.Quantity), formatYen(e.Balance.UnitCost()), formatYen(e.Balance.Amount))
+			w.Write(record)
+		}
+	}
+
+	unit := "総平均単価"
+	if r.Method == pnl.MovingAverage {
+		unit = "年末単価"
+	}
+	w.Write(nil)
+	w.Write([]string{"2 所得金額の計算"})
+	w.Write([]string{"暗号資産の名称",
+		"年始残高 数量", "年始残高 金額", "年中購入 数量", "年中購入 金額", "年中売却 数量", "売却価額(A)",
+		"年末残高 数量", "年末残高 金額", unit, "売却原価(B)", "必要経費(C)", "所得金額(A-B-C)"})
+	sold, cost, expenses := 0.0, 0.0, 0.0
+	for _, c := range r.Currencies {
+		w.Write([]string{c.Currency,
+			formatFloat(c.Opening.Quantity), formatYen(c.Opening.Amount),
+			formatFloat(c.Bought.Quantity), formatYen(c.Bought.Amount),
+			formatFloat(c.Sold.Quantity), formatYen(c.Sold.Amount),
+			formatFloat(c.Closing.Quantity), formatYen(c.Closing.Amount),
+			formatYen(c.AverageCost), formatYen(c.Cost), formatYen(c.Expenses), formatYen(c.Income())})
+		sold += c.Sold.Amount
+		cost += c.Cost
+		expenses += c.Expenses
+	}
+	w.Write([]string{"合計", "", "", "", "", "", formatYen(sold), "", "", "", formatYen(cost), formatYen(expenses), formatYen(r.Income())})
+}
+
+// taxEntryNames are the descriptions (摘要) of entries.
+var taxEntryNames = map[string]string{
+	tax.EntryBuy:        "購入",
+	tax.EntrySell:       "売却",
+	tax.EntryDeposit:    "入庫",
+	tax.EntryWithdrawal: "出庫",
+}
+
+func writeStockSheet(w *csv.Writer, r *tax.StockReport) {
+	w.Write([]string{"株式等の譲渡所得", fmt.Sprintf("%d年分", r.Year)})
+	w.Write([]string{"銘柄", "売却数量", "譲渡収入金額(A)", "取得費(B)", "譲渡費用(C)", "譲渡損益(A-B-C)", "年末保有数量"})
+	for _, v := range r.Symbols {
+		w.Write([]string{v.Symbol, formatFloat(v.Qty), formatYen(v.Proceeds), formatYen(v.Cost), formatYen(v.Commission),
+			formatYen(v.Gain()), formatFloat(v.Holding)})
+	}
+	w.Write([]string{"合計", "", "", "", "", formatYen(r.Gain()), ""})
+}
+
+// formatYen formats an amount in JPY, rounded to the yen.
+func formatYen(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
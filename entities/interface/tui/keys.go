@@ -0,0 +1,71 @@
+package tui
+
+import "unicode/utf8"
+
+// Key is a key press read from a terminal in raw mode.
+type Key struct {
+	// Rune is the character typed, or zero for special keys.
+	Rune    rune
+	Special SpecialKey
+}
+
+type SpecialKey int
+
+const (
+	KeyNone SpecialKey = iota
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyUp
+	KeyDown
+	KeyCtrlC
+)
+
+// ParseKeys splits the bytes read from a raw terminal into keys.
+// Escape sequences other than the up and down arrows are dropped.
+func ParseKeys(b []byte) []Key {
+	keys := []Key{}
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, Key{Special: KeyUp})
+			case 'B':
+				keys = append(keys, Key{Special: KeyDown})
+			}
+			// Skip the parameters up to the final byte of the sequence.
+			n := 2
+			for n < len(b) && (b[n] < 0x40 || b[n] > 0x7e) {
+				n++
+			}
+			b = b[min(n+1, len(b)):]
+		case b[0] == 0x1b:
+			keys = append(keys, Key{Special: KeyEscape})
+			b = b[1:]
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, Key{Special: KeyEnter})
+			b = b[1:]
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, Key{Special: KeyBackspace})
+			b = b[1:]
+		case b[0] == 0x03:
+			keys = append(keys, Key{Special: KeyCtrlC})
+			b = b[1:]
+		case b[0] < 0x20:
+			b = b[1:]
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, Key{Rune: r})
+			b = b[n:]
+		}
+	}
+	return keys
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
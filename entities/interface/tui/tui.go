@@ -0,0 +1,436 @@
+// Package tui renders a full-screen terminal view of a market: the depth ladder, recent trades,
+// the ticker, open orders and balances, and turns key presses into order actions.
+//
+// App holds no connections; the caller feeds it market states, key presses and the terminal size,
+// writes Render to the terminal and runs the actions HandleKey returns.
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+type ActionKind int
+
+const (
+	ActionQuit ActionKind = iota + 1
+	// ActionSend sends Action.Order.
+	ActionSend
+	// ActionCancel cancels the open order Action.OrderAcceptanceId.
+	ActionCancel
+)
+
+// Action is a confirmed request of the user.
+type Action struct {
+	Kind              ActionKind
+	Order             usecases.OrderCreate
+	OrderAcceptanceId string
+}
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeInput
+	modeConfirm
+)
+
+// App is the state of the terminal UI.
+type App struct {
+	productCode string
+	// trading enables orders, which need an authorized or paper account.
+	trading bool
+	state   usecases.MarketState
+	width   int
+	height  int
+
+	mode     mode
+	buy      bool
+	input    string
+	question string
+	pending  *Action
+	selected int
+	status   string
+}
+
+// NewApp returns an App for productCode. Without trading, order keys only explain why they are disabled.
+func NewApp(productCode string, trading bool) *App {
+	return &App{productCode: productCode, trading: trading, width: 80, height: 24}
+}
+
+// SetState replaces the market state shown.
+func (a *App) SetState(state usecases.MarketState) {
+	a.state = state
+	if a.selected >= len(state.Orders) {
+		a.selected = len(state.Orders) - 1
+	}
+	if a.selected < 0 {
+		a.selected = 0
+	}
+}
+
+// SetSize sets the terminal size in cells.
+func (a *App) SetSize(width int, height int) {
+	a.width, a.height = width, height
+}
+
+// SetStatus shows msg in the status line, such as the result of an action.
+func (a *App) SetStatus(msg string) {
+	a.status = msg
+}
+
+// HandleKey updates the App with a key press, and returns the action to run once confirmed.
+func (a *App) HandleKey(k Key) *Action {
+	if k.Special == KeyCtrlC {
+		return &Action{Kind: ActionQuit}
+	}
+
+	switch a.mode {
+	case modeInput:
+		return a.handleInput(k)
+	case modeConfirm:
+		switch {
+		case k.Rune == 'y' || k.Rune == 'Y':
+			action := a.pending
+			a.mode, a.pending, a.status = modeNormal, nil, "sending..."
+			return action
+		case k.Rune == 'n' || k.Rune == 'N' || k.Special == KeyEscape:
+			a.mode, a.pending, a.status = modeNormal, nil, "cancelled."
+		}
+		return nil
+	}
+
+	switch {
+	case k.Rune == 'q':
+		return &Action{Kind: ActionQuit}
+	case k.Rune == 'b' || k.Rune == 's':
+		if !a.trading {
+			a.status = "orders need BITFLYER_API_KEY and BITFLYER_API_SECRET, or --paper."
+			return nil
+		}
+		a.mode, a.buy, a.input, a.status = modeInput, k.Rune == 'b', "", ""
+	case k.Rune == 'j' || k.Special == KeyDown:
+		if a.selected < len(a.state.Orders)-1 {
+			a.selected++
+		}
+	case k.Rune == 'k' || k.Special == KeyUp:
+		if a.selected > 0 {
+			a.selected--
+		}
+	case k.Rune == 'c':
+		if len(a.state.Orders) == 0 {
+			a.status = "no open order to cancel."
+			return nil
+		}
+		order := a.state.Orders[a.selected]
+		a.pending = &Action{Kind: ActionCancel, OrderAcceptanceId: order.OrderAcceptanceId}
+		a.question = fmt.Sprintf("Cancel %s %s @ %s (%s)? [y/n]",
+			order.Side, formatSize(order.Outstanding), formatPrice(order.Price), order.OrderAcceptanceId)
+		a.mode = modeConfirm
+	}
+	return nil
+}
+
+func (a *App) handleInput(k Key) *Action {
+	switch {
+	case k.Special == KeyEscape:
+		a.mode, a.status = modeNormal, "cancelled."
+	case k.Special == KeyBackspace:
+		if len(a.input) > 0 {
+			a.input = a.input[:len(a.input)-1]
+		}
+	case k.Special == KeyEnter:
+		order, err := a.parseOrder(a.input)
+		if err != nil {
+			a.status = err.Error()
+			return nil
+		}
+		a.pending = &Action{Kind: ActionSend, Order: order}
+		a.question = a.summary(order) + " [y/n]"
+		a.mode, a.status = modeConfirm, ""
+	case k.Rune != 0 && (k.Rune == ' ' || k.Rune == '.' || k.Rune == 'm' || (k.Rune >= '0' && k.Rune <= '9')):
+		a.input += string(k.Rune)
+	}
+	return nil
+}
+
+// parseOrder reads "<price> <size>". A price of "m" is a market order.
+func (a *App) parseOrder(input string) (usecases.OrderCreate, error) {
+	fields := strings.Fields(input)
+	if len(fields) != 2 {
+		return usecases.OrderCreate{}, fmt.Errorf("enter a price (m for market) and a size, such as \"5000000 0.01\".")
+	}
+
+	price := 0.0
+	market := fields[0] == "m"
+	if !market {
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || v <= 0 {
+			return usecases.OrderCreate{}, fmt.Errorf("invalid price %q.", fields[0])
+		}
+		price = v
+	}
+	size, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || size <= 0 {
+		return usecases.OrderCreate{}, fmt.Errorf("invalid size %q.", fields[1])
+	}
+	return usecases.OrderCreate{ProductCode: a.productCode, Price: price, Size: size, Buy: a.buy, Market: market}, nil
+}
+
+// summary describes order against the current board, like the confirmation of `orders buy|sell`.
+func (a *App) summary(order usecases.OrderCreate) string {
+	side := "SELL"
+	if order.Buy {
+		side = "BUY"
+	}
+
+	price := order.Price
+	kind, at := "LIMIT", formatPrice(order.Price)
+	if order.Market {
+		kind, at = "MARKET", "market"
+		// Market orders are estimated at the best opposite quote.
+		if order.Buy && len(a.state.Asks) > 0 {
+			price = a.state.Asks[0].Price
+		}
+		if !order.Buy && len(a.state.Bids) > 0 {
+			price = a.state.Bids[0].Price
+		}
+	}
+
+	summary := fmt.Sprintf("Send %s %s %s %s @ %s", side, kind, formatSize(order.Size), order.ProductCode, at)
+	if price > 0 {
+		summary += fmt.Sprintf(", notional %s", formatPrice(price*order.Size))
+	}
+	if mid := a.state.MidPrice; mid > 0 && price > 0 {
+		summary += fmt.Sprintf(", %+.2f%% from mid", (price-mid)/mid*100)
+	}
+	return summary + "?"
+}
+
+const (
+	styleNone = ""
+	styleBid  = "\x1b[32m"
+	styleAsk  = "\x1b[31m"
+	styleBold = "\x1b[1m"
+	styleDim  = "\x1b[2m"
+	styleSel  = "\x1b[7m"
+	resetAttr = "\x1b[0m"
+)
+
+// cell is a line of a pane.
+type cell struct {
+	text  string
+	style string
+}
+
+// Render returns the escape sequences drawing the whole screen.
+func (a *App) Render() string {
+	width, height := a.width, a.height
+	if width < 20 || height < 6 {
+		return "\x1b[H\x1b[2J" + "terminal too small"
+	}
+
+	lines := []string{a.header()}
+	body := height - 3
+
+	ladderWidth := 36
+	tradesWidth := 36
+	accountWidth := width - ladderWidth - tradesWidth - 2
+	if accountWidth < 28 {
+		// Narrow terminals show the ladder and the trades only.
+		accountWidth = 0
+		tradesWidth = width - ladderWidth - 1
+		if tradesWidth < 20 {
+			ladderWidth, tradesWidth = width, 0
+		}
+	}
+
+	panes := [][]cell{a.ladder(body)}
+	widths := []int{ladderWidth}
+	if tradesWidth > 0 {
+		panes = append(panes, a.trades(body))
+		widths = append(widths, tradesWidth)
+	}
+	if accountWidth > 0 {
+		panes = append(panes, a.account(body))
+		widths = append(widths, accountWidth)
+	}
+
+	for i := 0; i < body; i++ {
+		line := ""
+		for j, pane := range panes {
+			c := cell{}
+			if i < len(pane) {
+				c = pane[i]
+			}
+			if j > 0 {
+				line += " "
+			}
+			line += styled(pad(c.text, widths[j]), c.style)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, styled(pad(a.statusLine(), width), styleDim), a.promptLine())
+
+	return "\x1b[H" + strings.Join(lines, "\x1b[K\r\n") + "\x1b[K\x1b[J"
+}
+
+func (a *App) header() string {
+	s := a.state
+	connection := "connected"
+	if !s.Connected {
+		connection = "connecting..."
+	}
+	header := fmt.Sprintf("%s  LTP %s  Bid %s  Ask %s", a.productCode,
+		formatPrice(s.Ticker.Ltp), formatPrice(s.Ticker.BestBid), formatPrice(s.Ticker.BestAsk))
+	if s.Ticker.BestBid > 0 && s.Ticker.BestAsk > 0 {
+		header += fmt.Sprintf("  Spread %s", formatPrice(s.Ticker.BestAsk-s.Ticker.BestBid))
+	}
+	header += fmt.Sprintf("  Vol %s  [%s]", formatSize(s.Ticker.Volume), connection)
+	return styled(pad(header, a.width), styleBold)
+}
+
+// ladder shows asks above bids around the mid price, as many levels as fit.
+func (a *App) ladder(height int) []cell {
+	rows := []cell{{text: fmt.Sprintf("%14s %12s", "Price", "Size"), style: styleBold}}
+	levels := (height - 2) / 2
+	if levels < 0 {
+		levels = 0
+	}
+
+	asks := a.state.Asks
+	if len(asks) > levels {
+		asks = asks[:levels]
+	}
+	for i := 0; i < levels-len(asks); i++ {
+		rows = append(rows, cell{})
+	}
+	for i := len(asks) - 1; i >= 0; i-- {
+		rows = append(rows, cell{text: fmt.Sprintf("%14s %12s", formatPrice(asks[i].Price), formatSize(asks[i].Size)), style: styleAsk})
+	}
+
+	rows = append(rows, cell{text: fmt.Sprintf("%14s  mid", formatPrice(a.state.MidPrice)), style: styleBold})
+
+	bids := a.state.Bids
+	if len(bids) > levels {
+		bids = bids[:levels]
+	}
+	for _, v := range bids {
+		rows = append(rows, cell{text: fmt.Sprintf("%14s %12s", formatPrice(v.Price), formatSize(v.Size)), style: styleBid})
+	}
+	if len(a.state.Asks) == 0 && len(a.state.Bids) == 0 {
+		rows = append(rows, cell{text: "  no orders on the board", style: styleDim})
+	}
+	return rows
+}
+
+func (a *App) trades(height int) []cell {
+	rows := []cell{{text: fmt.Sprintf("%-8s %-4s %12s %9s", "Time", "Side", "Price", "Size"), style: styleBold}}
+	for _, v := range a.state.Trades {
+		if len(rows) >= height {
+			break
+		}
+		style := styleBid
+		if v.Side == "SELL" {
+			style = styleAsk
+		}
+		rows = append(rows, cell{
+			text:  fmt.Sprintf("%-8s %-4s %12s %9s", v.Time.In(candle.JST).Format("15:04:05"), v.Side, formatPrice(v.Price), formatSize(v.Size)),
+			style: style,
+		})
+	}
+	return rows
+}
+
+func (a *App) account(height int) []cell {
+	rows := []cell{{text: "Open Orders", style: styleBold}}
+	switch {
+	case !a.trading:
+		rows = append(rows, cell{text: "authorization required", style: styleDim})
+	case a.state.AccountErr != nil:
+		rows = append(rows, cell{text: a.state.AccountErr.Error(), style: styleAsk})
+	case len(a.state.Orders) == 0:
+		rows = append(rows, cell{text: "none", style: styleDim})
+	}
+	// Keep room for the balances below.
+	maxOrders := height/2 - 1
+	for i, v := range a.state.Orders {
+		if i >= maxOrders {
+			rows = append(rows, cell{text: fmt.Sprintf("... %d more", len(a.state.Orders)-i), style: styleDim})
+			break
+		}
+		style := styleNone
+		if i == a.selected {
+			style = styleSel
+		}
+		rows = append(rows, cell{text: fmt.Sprintf("%-4s %12s %9s", v.Side, formatPrice(v.Price), formatSize(v.Outstanding)), style: style})
+	}
+
+	rows = append(rows, cell{}, cell{text: "Balances", style: styleBold})
+	for _, v := range a.state.Balances {
+		if len(rows) >= height {
+			break
+		}
+		if v.Amount == 0 {
+			continue
+		}
+		rows = append(rows, cell{text: fmt.Sprintf("%-5s %14s %14s", v.CurrencyCode, formatSize(v.Amount), formatSize(v.Available))})
+	}
+	return rows
+}
+
+func (a *App) statusLine() string {
+	switch {
+	case a.status != "":
+		return a.status
+	case a.state.Err != nil:
+		return a.state.Err.Error()
+	}
+	return ""
+}
+
+func (a *App) promptLine() string {
+	switch a.mode {
+	case modeInput:
+		side := "SELL"
+		if a.buy {
+			side = "BUY"
+		}
+		return fmt.Sprintf("%s %s price (m for market) and size: %s", side, a.productCode, a.input)
+	case modeConfirm:
+		return styled(a.question, styleBold)
+	}
+	return "b buy  s sell  j/k select order  c cancel order  q quit"
+}
+
+// formatPrice formats a price without trailing zeros, such as 5000000 or 0.0123.
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatSize formats a size with up to 8 decimals, the precision of crypto assets.
+func formatSize(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 8, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// pad cuts or pads s to exactly width characters.
+func pad(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+func styled(s string, style string) string {
+	if style == styleNone {
+		return s
+	}
+	return style + s + resetAttr
+}
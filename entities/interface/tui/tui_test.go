@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/usecases"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Key
+	}{
+		{name: "runes", input: "b1 ", expected: []Key{{Rune: 'b'}, {Rune: '1'}, {Rune: ' '}}},
+		{name: "specials", input: "\r\x7f\x03", expected: []Key{{Special: KeyEnter}, {Special: KeyBackspace}, {Special: KeyCtrlC}}},
+		{name: "arrows", input: "\x1b[A\x1bOB", expected: []Key{{Special: KeyUp}, {Special: KeyDown}}},
+		{name: "other sequences are dropped", input: "\x1b[1;5Cq", expected: []Key{{Rune: 'q'}}},
+		{name: "escape", input: "\x1b", expected: []Key{{Special: KeyEscape}}},
+		{name: "truncated sequence", input: "\x1b[", expected: []Key{{Special: KeyEscape}, {Rune: '['}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeys([]byte(tt.input)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseKeys() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func typeKeys(a *App, input string) *Action {
+	var action *Action
+	for _, k := range ParseKeys([]byte(input)) {
+		if res := a.HandleKey(k); res != nil {
+			action = res
+		}
+	}
+	return action
+}
+
+func testState() usecases.MarketState {
+	return usecases.MarketState{
+		ProductCode: "BTC_JPY",
+		MidPrice:    100,
+		Bids:        usecases.BoardPrices{{Price: 99, Size: 1}, {Price: 98, Size: 2}},
+		Asks:        usecases.BoardPrices{{Price: 101, Size: 1}, {Price: 102, Size: 2}},
+		Ticker:      usecases.Ticker{BestBid: 99, BestAsk: 101, Ltp: 100},
+		Trades:      []usecases.MarketTrade{{Time: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Side: "BUY", Price: 101, Size: 0.1}},
+		Orders: []usecases.OpenOrder{
+			{OrderAcceptanceId: "JRF1", Side: "BUY", Price: 90, Outstanding: 1},
+			{OrderAcceptanceId: "JRF2", Side: "SELL", Price: 110, Outstanding: 1},
+		},
+		Balances:  usecases.Balances{{CurrencyCode: "JPY", Amount: 1000, Available: 900}},
+		Connected: true,
+	}
+}
+
+func TestApp_HandleKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		trading  bool
+		input    string
+		expected *Action
+		prompt   string
+		status   string
+	}{
+		{
+			name:     "limit buy",
+			trading:  true,
+			input:    "b99 0.5\ry",
+			expected: &Action{Kind: ActionSend, Order: usecases.OrderCreate{ProductCode: "BTC_JPY", Price: 99, Size: 0.5, Buy: true}},
+		},
+		{
+			name:     "market sell",
+			trading:  true,
+			input:    "sm 1\ry",
+			expected: &Action{Kind: ActionSend, Order: usecases.OrderCreate{ProductCode: "BTC_JPY", Size: 1, Market: true}},
+		},
+		{
+			name:    "confirmation",
+			trading: true,
+			input:   "b103 2\r",
+			prompt:  "Send BUY LIMIT 2 BTC_JPY @ 103, notional 206, +3.00% from mid? [y/n]",
+		},
+		{
+			name:    "declined",
+			trading: true,
+			input:   "b99 1\rn",
+			status:  "cancelled.",
+		},
+		{
+			name:    "backspace and escape",
+			trading: true,
+			input:   "b99 12\x7f",
+			prompt:  "BUY BTC_JPY price (m for market) and size: 99 1",
+		},
+		{
+			name:    "invalid input",
+			trading: true,
+			input:   "b99\r",
+			status:  "enter a price (m for market) and a size, such as \"5000000 0.01\".",
+		},
+		{
+			name:    "orders need authorization",
+			trading: false,
+			input:   "b",
+			status:  "orders need BITFLYER_API_KEY and BITFLYER_API_SECRET, or --paper.",
+		},
+		{
+			name:     "cancel the selected order",
+			trading:  true,
+			input:    "jcy",
+			expected: &Action{Kind: ActionCancel, OrderAcceptanceId: "JRF2"},
+		},
+		{
+			name:    "cancel confirmation",
+			trading: true,
+			input:   "jjkc",
+			prompt:  "Cancel BUY 1 @ 90 (JRF1)? [y/n]",
+		},
+		{
+			name:     "quit",
+			input:    "q",
+			expected: &Action{Kind: ActionQuit},
+		},
+		{
+			name:     "ctrl-c while typing",
+			trading:  true,
+			input:    "b1\x03",
+			expected: &Action{Kind: ActionQuit},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp("BTC_JPY", tt.trading)
+			a.SetState(testState())
+			got := typeKeys(a, tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("App.HandleKey() = %+v, expected %+v", got, tt.expected)
+			}
+			if tt.prompt != "" && !strings.Contains(a.promptLine(), tt.prompt) {
+				t.Errorf("App.promptLine() = %q, expected %q", a.promptLine(), tt.prompt)
+			}
+			if tt.status != "" && a.statusLine() != tt.status {
+				t.Errorf("App.statusLine() = %q, expected %q", a.statusLine(), tt.status)
+			}
+		})
+	}
+}
+
+func TestApp_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    usecases.MarketState
+		width    int
+		height   int
+		contains []string
+	}{
+		{
+			name:     "all panes",
+			state:    testState(),
+			width:    120,
+			height:   20,
+			contains: []string{"LTP 100", "Spread 2", "[connected]", "102", "100  mid", "09:00:00 BUY", "Open Orders", "JPY"},
+		},
+		{
+			name:     "narrow",
+			state:    testState(),
+			width:    60,
+			height:   10,
+			contains: []string{"101", "99"},
+		},
+		{
+			name:     "empty",
+			state:    usecases.MarketState{Err: errors.New("realtime connection lost")},
+			width:    80,
+			height:   24,
+			contains: []string{"[connecting...]", "no orders on the board", "realtime connection lost"},
+		},
+		{
+			name:     "too small",
+			state:    testState(),
+			width:    10,
+			height:   3,
+			contains: []string{"terminal too small"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewApp("BTC_JPY", true)
+			a.SetState(tt.state)
+			a.SetSize(tt.width, tt.height)
+			got := a.Render()
+			for _, v := range tt.contains {
+				if !strings.Contains(got, v) {
+					t.Errorf("App.Render() = %q, expected to contain %q", got, v)
+				}
+			}
+			if tt.height >= 6 {
+				if lines := strings.Count(got, "\r\n") + 1; lines != tt.height {
+					t.Errorf("App.Render() has %d lines, expected %d", lines, tt.height)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package journal records the orders sent by capital-go, the fills of the accounts and
+// snapshots of their balances, so that trading history survives the brokers' retention.
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// Brokers recorded in the journal.
+const (
+	BitFlyer = "bitflyer"
+	Kabucom  = "kabucom"
+	// Paper is the paper broker simulating bitFlyer.
+	Paper = "paper"
+	// PaperKabucom is the paper broker simulating kabu STATION.
+	PaperKabucom = "paper-kabucom"
+)
+
+// Order is an order accepted by a broker.
+type Order struct {
+	// ID is the local id of the order, in the order they were sent.
+	ID          int    `json:"id"`
+	Broker      string `json:"broker"`
+	ProductCode string `json:"product_code"`
+	// AcceptanceID is the id of the order given by the broker.
+	AcceptanceID string `json:"acceptance_id"`
+	// Side is "BUY" or "SELL", and Type "LIMIT" or "MARKET".
+	Side string `json:"side"`
+	Type string `json:"type"`
+	// Price is zero for market orders.
+	Price  float64   `json:"price"`
+	Size   float64   `json:"size"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// Fill is an execution of an order, pulled from the execution history of a broker.
+type Fill struct {
+	Broker string `json:"broker"`
+	// ID is the execution id given by the broker. It identifies the fill in the broker.
+	ID          string `json:"id"`
+	ProductCode string `json:"product_code"`
+	// OrderID is the acceptance id of the order filled.
+	OrderID    string    `json:"order_id"`
+	Side       string    `json:"side"`
+	Price      float64   `json:"price"`
+	Size       float64   `json:"size"`
+	Commission float64   `json:"commission"`
+	Time       time.Time `json:"time"`
+	// Precision is the precision of Time when the fill is imported from a file, such as a second when
+	// the file has no fractions of a second. Time is then truncated to it. Zero for fills of the API.
+	Precision time.Duration `json:"precision,omitempty"`
+}
+
+// sameAs reports whether f and o are the same fill, one imported from a file and the other pulled
+// from the API: they have the same content, at the same time to the precision of the file.
+func (f Fill) sameAs(o Fill) bool {
+	return (f.Precision == 0) != (o.Precision == 0) && f.Broker == o.Broker && f.ProductCode == o.ProductCode &&
+		f.Side == o.Side && f.Price == o.Price && f.Size == o.Size && sameTime(f.Time, f.Precision, o.Time, o.Precision)
+}
+
+// Transfer is a deposit or a withdrawal of a crypto asset.
+type Transfer struct {
+	Broker string `json:"broker"`
+	// ID is the id given by the broker, prefixed with "in-" for deposits and "out-" for withdrawals.
+	ID       string `json:"id"`
+	Currency string `json:"currency"`
+	// Amount is positive for deposits and negative for withdrawals.
+	Amount float64 `json:"amount"`
+	// Fee is charged in the currency, on top of Amount.
+	Fee  float64   `json:"fee"`
+	Time time.Time `json:"time"`
+	// Precision is the precision of Time when the transfer is imported from a file, as for fills.
+	Precision time.Duration `json:"precision,omitempty"`
+}
+
+// sameAs reports whether t and o are the same transfer, one imported from a file and the other
+// pulled from the API, as Fill.sameAs does.
+func (t Transfer) sameAs(o Transfer) bool {
+	return (t.Precision == 0) != (o.Precision == 0) && t.Broker == o.Broker && t.Currency == o.Currency &&
+		t.Amount == o.Amount && sameTime(t.Time, t.Precision, o.Time, o.Precision)
+}
+
+// sameTime reports whether a and b are the same time, when the one of the coarser precision is
+// truncated to it.
+func sameTime(a time.Time, aPrecision time.Duration, b time.Time, bPrecision time.Duration) bool {
+	if aPrecision < bPrecision {
+		a, aPrecision, b = b, bPrecision, a
+	}
+	if aPrecision == 0 {
+		return a.Equal(b)
+	}
+	return !b.Before(a) && b.Before(a.Add(aPrecision))
+}
+
+// Balance is the balance of a currency.
+type Balance struct {
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	Available float64 `json:"available"`
+}
+
+// Snapshot is the balances of a broker at a time.
+type Snapshot struct {
+	Broker   string    `json:"broker"`
+	Time     time.Time `json:"time"`
+	Balances []Balance `json:"balances"`
+}
+
+// Journal is the content of a journal: orders by id, fills, transfers and snapshots by time.
+type Journal struct {
+	// Version is the schema version of the journal.
+	Version   int        `json:"version"`
+	Orders    []Order    `json:"orders"`
+	Fills     []Fill     `json:"fills"`
+	Transfers []Transfer `json:"transfers"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// Filter selects records. Zero fields select every record.
+type Filter struct {
+	Broker      string
+	ProductCode string
+	// From and To bound the time of records, From inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+}
+
+func (f Filter) matches(broker string, productCode string, t time.Time) bool {
+	switch {
+	case f.Broker != "" && broker != f.Broker:
+		return false
+	case f.ProductCode != "" && productCode != f.ProductCode:
+		return false
+	case !f.From.IsZero() && t.Before(f.From):
+		return false
+	case !f.To.IsZero() && !t.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Filter returns the records of j selected by f. Transfers and snapshots are selected by broker and time only.
+func (j *Journal) Filter(f Filter) *Journal {
+	res := &Journal{Version: j.Version, Orders: []Order{}, Fills: []Fill{}, Transfers: []Transfer{}, Snapshots: []Snapshot{}}
+	for _, v := range j.Orders {
+		if f.matches(v.Broker, v.ProductCode, v.SentAt) {
+			res.Orders = append(res.Orders, v)
+		}
+	}
+	for _, v := range j.Fills {
+		if f.matches(v.Broker, v.ProductCode, v.Time) {
+			res.Fills = append(res.Fills, v)
+		}
+	}
+	account := Filter{Broker: f.Broker, From: f.From, To: f.To}
+	for _, v := range j.Transfers {
+		if account.matches(v.Broker, "", v.Time) {
+			res.Transfers = append(res.Transfers, v)
+		}
+	}
+	for _, v := range j.Snapshots {
+		if account.matches(v.Broker, "", v.Time) {
+			res.Snapshots = append(res.Snapshots, v)
+		}
+	}
+	return res
+}
+
+// Order returns the order id and its fills. It fails with ErrResourceNotFound when there is none.
+func (j *Journal) Order(id int) (Order, []Fill, error) {
+	for _, o := range j.Orders {
+		if o.ID != id {
+			continue
+		}
+		fills := []Fill{}
+		for _, v := range j.Fills {
+			if v.Broker == o.Broker && v.OrderID == o.AcceptanceID {
+				fills = append(fills, v)
+			}
+		}
+		return o, fills, nil
+	}
+	return Order{}, nil, fmt.Errorf("%w: order %d", cerror.ErrResourceNotFound, id)
+}
+
+// ProductCodes returns the products of the orders of broker, sorted.
+func (j *Journal) ProductCodes(broker string) []string {
+	seen := map[string]bool{}
+	codes := []string{}
+	for _, v := range j.Orders {
+		if v.Broker == broker && !seen[v.ProductCode] {
+			seen[v.ProductCode] = true
+			codes = append(codes, v.ProductCode)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// sort orders the records of j.
+func (j *Journal) sort() {
+	sort.SliceStable(j.Orders, func(a, b int) bool { return j.Orders[a].ID < j.Orders[b].ID })
+	sort.SliceStable(j.Fills, func(a, b int) bool { return j.Fills[a].Time.Before(j.Fills[b].Time) })
+	sort.SliceStable(j.Transfers, func(a, b int) bool { return j.Transfers[a].Time.Before(j.Transfers[b].Time) })
+	sort.SliceStable(j.Snapshots, func(a, b int) bool { return j.Snapshots[a].Time.Before(j.Snapshots[b].Time) })
+}
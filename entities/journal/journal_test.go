@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func testJournal() *Journal {
+	at := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	return &Journal{
+		Version: SchemaVersion,
+		Orders: []Order{
+			{ID: 1, Broker: BitFlyer, ProductCode: "BTC_JPY", AcceptanceID: "JRF1", SentAt: at},
+			{ID: 2, Broker: Paper, ProductCode: "BTC_JPY", AcceptanceID: "JRF1", SentAt: at.Add(time.Hour)},
+			{ID: 3, Broker: BitFlyer, ProductCode: "ETH_JPY", AcceptanceID: "JRF3", SentAt: at.Add(2 * time.Hour)},
+		},
+		Fills: []Fill{
+			{Broker: BitFlyer, ID: "1", ProductCode: "BTC_JPY", OrderID: "JRF1", Time: at},
+			{Broker: Paper, ID: "1", ProductCode: "BTC_JPY", OrderID: "JRF1", Time: at.Add(time.Hour)},
+			{Broker: BitFlyer, ID: "2", ProductCode: "BTC_JPY", OrderID: "JRF1", Time: at.Add(2 * time.Hour)},
+		},
+		Transfers: []Transfer{
+			{Broker: BitFlyer, ID: "in-1", Currency: "BTC", Amount: 0.1, Time: at},
+			{Broker: BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.1, Fee: 0.0004, Time: at.Add(2 * time.Hour)},
+		},
+		Snapshots: []Snapshot{
+			{Broker: BitFlyer, Time: at},
+			{Broker: Kabucom, Time: at.Add(time.Hour)},
+		},
+	}
+}
+
+func TestJournal_Filter(t *testing.T) {
+	at := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		filter    Filter
+		orders    []int
+		fills     []string
+		transfers int
+		snapshots int
+	}{
+		{name: "all", orders: []int{1, 2, 3}, fills: []string{"1", "1", "2"}, transfers: 2, snapshots: 2},
+		{name: "broker", filter: Filter{Broker: BitFlyer}, orders: []int{1, 3}, fills: []string{"1", "2"}, transfers: 2, snapshots: 1},
+		{name: "product", filter: Filter{ProductCode: "ETH_JPY"}, orders: []int{3}, fills: []string{}, transfers: 2, snapshots: 2},
+		{name: "period", filter: Filter{From: at.Add(time.Hour), To: at.Add(2 * time.Hour)}, orders: []int{2}, fills: []string{"1"}, snapshots: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testJournal().Filter(tt.filter)
+			orders := []int{}
+			for _, v := range got.Orders {
+				orders = append(orders, v.ID)
+			}
+			fills := []string{}
+			for _, v := range got.Fills {
+				fills = append(fills, v.ID)
+			}
+			if !reflect.DeepEqual(orders, tt.orders) || !reflect.DeepEqual(fills, tt.fills) || len(got.Transfers) != tt.transfers || len(got.Snapshots) != tt.snapshots {
+				t.Errorf("Journal.Filter() = %v %v %d %d, expected %v %v %d %d", orders, fills, len(got.Transfers), len(got.Snapshots),
+					tt.orders, tt.fills, tt.transfers, tt.snapshots)
+			}
+		})
+	}
+}
+
+func TestJournal_Order(t *testing.T) {
+	j := testJournal()
+
+	o, fills, err := j.Order(1)
+	if err != nil {
+		t.Fatalf("Journal.Order() error = %v", err)
+	}
+	// The fills of the paper order with the same acceptance id are not its fills.
+	if o.ID != 1 || len(fills) != 2 || fills[0].ID != "1" || fills[1].ID != "2" {
+		t.Errorf("Journal.Order() = %v, %v", o, fills)
+	}
+
+	if _, _, err := j.Order(9); !errors.Is(err, cerror.ErrResourceNotFound) {
+		t.Errorf("Journal.Order() error = %v, expectedError %v", err, cerror.ErrResourceNotFound)
+	}
+}
+
+func TestJournal_ProductCodes(t *testing.T) {
+	if got := testJournal().ProductCodes(BitFlyer); !reflect.DeepEqual(got, []string{"BTC_JPY", "ETH_JPY"}) {
+		t.Errorf("Journal.ProductCodes() = %v", got)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+
+package journal
+
+// lockFile does nothing on this platform: the journal is only locked within the process.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
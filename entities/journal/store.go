@@ -0,0 +1,332 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// migration upgrades a journal document by one schema version.
+type migration func(doc map[string]json.RawMessage) error
+
+// migrations upgrade a journal of version i to version i+1. Append new ones: a journal
+// written by an older capital-go is upgraded when it is loaded.
+var migrations = []migration{
+	// 0 to 1 creates the tables.
+	func(doc map[string]json.RawMessage) error {
+		for _, table := range []string{"orders", "fills", "snapshots"} {
+			if _, ok := doc[table]; !ok {
+				doc[table] = json.RawMessage("[]")
+			}
+		}
+		return nil
+	},
+	// 1 to 2 adds the transfers.
+	func(doc map[string]json.RawMessage) error {
+		doc["transfers"] = json.RawMessage("[]")
+		return nil
+	},
+	// 2 to 3 sets the precision of the records imported from files: a second for bitFlyer and a day
+	// for kabu STATION.
+	func(doc map[string]json.RawMessage) error {
+		precisions := map[string]time.Duration{BitFlyer: time.Second, Kabucom: 24 * time.Hour}
+		for _, table := range []string{"fills", "transfers"} {
+			keys := []struct {
+				Broker string `json:"broker"`
+				ID     string `json:"id"`
+			}{}
+			records := []map[string]json.RawMessage{}
+			if err := json.Unmarshal(doc[table], &keys); err != nil {
+				return err
+			}
+			if err := json.Unmarshal(doc[table], &records); err != nil {
+				return err
+			}
+			for i, v := range keys {
+				if p, ok := precisions[v.Broker]; ok && strings.HasPrefix(v.ID, "csv-") {
+					records[i]["precision"] = json.RawMessage(fmt.Sprint(int64(p)))
+				}
+			}
+			raw, err := json.Marshal(records)
+			if err != nil {
+				return err
+			}
+			doc[table] = raw
+		}
+		return nil
+	},
+}
+
+// SchemaVersion is the schema version of journals written by this version.
+var SchemaVersion = len(migrations)
+
+// FileStore keeps the journal in a JSON file. Each call holds a lock of the file, so that
+// commands running at once, such as `strategy run` and `tui`, never lose each other's records.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore backed by the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultPath returns the default path of the journal file.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "journal.json")
+}
+
+// Load returns the journal, migrated to SchemaVersion. A missing file is an empty journal.
+func (f *FileStore) Load() (*Journal, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return f.load()
+}
+
+// AddOrder records o with the next id, and returns it.
+func (f *FileStore) AddOrder(o Order) (Order, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return Order{}, err
+	}
+	defer unlock()
+
+	j, err := f.load()
+	if err != nil {
+		return Order{}, err
+	}
+	o.ID = 1
+	if len(j.Orders) > 0 {
+		o.ID = j.Orders[len(j.Orders)-1].ID + 1
+	}
+	j.Orders = append(j.Orders, o)
+	if err := f.save(j); err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+// AddFills records the fills not recorded yet, and returns how many were added.
+// Fills are identified by their broker and id, so pulling the same history twice adds nothing.
+// A fill imported from a file and one pulled from the API have other ids, so they are also the same
+// when they have the same content at the same time to the precision of the file. The one of the API
+// is then kept, so that later pulls know its id.
+//
+// Each recorded fill is the same as one fill at most, so that two fills of the same price and size
+// in a second are both recorded against one of the other source.
+func (f *FileStore) AddFills(fills []Fill) (int, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	j, err := f.load()
+	if err != nil {
+		return 0, err
+	}
+	known := map[[2]string]bool{}
+	for _, v := range j.Fills {
+		known[[2]string{v.Broker, v.ID}] = true
+	}
+	recorded := len(j.Fills)
+	matched := map[int]bool{}
+	added, replaced := 0, 0
+	for _, v := range fills {
+		key := [2]string{v.Broker, v.ID}
+		if known[key] {
+			continue
+		}
+		known[key] = true
+
+		i := 0
+		for ; i < recorded; i++ {
+			if !matched[i] && j.Fills[i].sameAs(v) {
+				break
+			}
+		}
+		switch {
+		case i == recorded:
+			j.Fills = append(j.Fills, v)
+			added++
+		case v.Precision == 0:
+			matched[i] = true
+			j.Fills[i] = v
+			replaced++
+		default:
+			matched[i] = true
+		}
+	}
+	if added == 0 && replaced == 0 {
+		return 0, nil
+	}
+	if err := f.save(j); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// AddTransfers records the transfers not recorded yet, and returns how many were added.
+// Transfers are identified by their broker and id, and matched across sources, as fills are.
+func (f *FileStore) AddTransfers(transfers []Transfer) (int, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	j, err := f.load()
+	if err != nil {
+		return 0, err
+	}
+	known := map[[2]string]bool{}
+	for _, v := range j.Transfers {
+		known[[2]string{v.Broker, v.ID}] = true
+	}
+	recorded := len(j.Transfers)
+	matched := map[int]bool{}
+	added, replaced := 0, 0
+	for _, v := range transfers {
+		key := [2]string{v.Broker, v.ID}
+		if known[key] {
+			continue
+		}
+		known[key] = true
+
+		i := 0
+		for ; i < recorded; i++ {
+			if !matched[i] && j.Transfers[i].sameAs(v) {
+				break
+			}
+		}
+		switch {
+		case i == recorded:
+			j.Transfers = append(j.Transfers, v)
+			added++
+		case v.Precision == 0:
+			matched[i] = true
+			j.Transfers[i] = v
+			replaced++
+		default:
+			matched[i] = true
+		}
+	}
+	if added == 0 && replaced == 0 {
+		return 0, nil
+	}
+	if err := f.save(j); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// AddSnapshot records s.
+func (f *FileStore) AddSnapshot(s Snapshot) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	j, err := f.load()
+	if err != nil {
+		return err
+	}
+	j.Snapshots = append(j.Snapshots, s)
+	return f.save(j)
+}
+
+// lock locks the journal against other goroutines and processes, and returns a function unlocking it.
+func (f *FileStore) lock() (func(), error) {
+	f.mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("can not create journal directory: %w", err)
+	}
+	unlock, err := lockFile(f.path + ".lock")
+	if err != nil {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("can not lock journal %s: %w", f.path, err)
+	}
+	return func() {
+		unlock()
+		f.mu.Unlock()
+	}, nil
+}
+
+func (f *FileStore) load() (*Journal, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		raw = []byte("{}")
+	} else if err != nil {
+		return nil, fmt.Errorf("can not read journal %s: %w", f.path, err)
+	}
+
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("can not parse journal %s: %w", f.path, err)
+	}
+	if err := migrate(doc); err != nil {
+		return nil, fmt.Errorf("can not migrate journal %s: %w", f.path, err)
+	}
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{}
+	if err := json.Unmarshal(raw, j); err != nil {
+		return nil, fmt.Errorf("can not parse journal %s: %w", f.path, err)
+	}
+	j.sort()
+	return j, nil
+}
+
+// migrate upgrades doc to SchemaVersion.
+func migrate(doc map[string]json.RawMessage) error {
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return fmt.Errorf("invalid version %s", raw)
+		}
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("version %d is newer than %d. please upgrade capital-go", version, SchemaVersion)
+	}
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return fmt.Errorf("version %d to %d: %w", version, version+1, err)
+		}
+	}
+	doc["version"] = json.RawMessage(fmt.Sprint(version))
+	return nil
+}
+
+func (f *FileStore) save(j *Journal) error {
+	j.Version = SchemaVersion
+	raw, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		return fmt.Errorf("can not create journal directory: %w", err)
+	}
+	// Replace the file at once, so that a crash never leaves it half written.
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return fmt.Errorf("can not write journal %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("can not write journal %s: %w", f.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "dir", "journal.json"))
+	at := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	j, err := f.Load()
+	if err != nil || len(j.Orders) != 0 || len(j.Fills) != 0 || len(j.Snapshots) != 0 {
+		t.Fatalf("FileStore.Load() = %v, %v, want an empty journal", j, err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		o, err := f.AddOrder(Order{Broker: BitFlyer, ProductCode: "BTC_JPY", AcceptanceID: "JRF1", SentAt: at})
+		if err != nil || o.ID != i {
+			t.Fatalf("FileStore.AddOrder() = %v, %v, want id %d", o, err, i)
+		}
+	}
+
+	fills := []Fill{
+		{Broker: BitFlyer, ID: "2", OrderID: "JRF1", Time: at.Add(2 * time.Second)},
+		{Broker: BitFlyer, ID: "1", OrderID: "JRF1", Time: at.Add(time.Second)},
+	}
+	if added, err := f.AddFills(fills); err != nil || added != 2 {
+		t.Fatalf("FileStore.AddFills() = %v, %v, want 2", added, err)
+	}
+	// The same id of another broker is another fill.
+	fills = append(fills, Fill{Broker: Kabucom, ID: "1", Time: at})
+	if added, err := f.AddFills(fills); err != nil || added != 1 {
+		t.Fatalf("FileStore.AddFills() = %v, %v, want 1", added, err)
+	}
+
+	transfers := []Transfer{{Broker: BitFlyer, ID: "in-1", Currency: "BTC", Amount: 0.1, Time: at}}
+	for _, expected := range []int{1, 0} {
+		if added, err := f.AddTransfers(transfers); err != nil || added != expected {
+			t.Fatalf("FileStore.AddTransfers() = %v, %v, want %d", added, err, expected)
+		}
+	}
+
+	if err := f.AddSnapshot(Snapshot{Broker: BitFlyer, Time: at, Balances: []Balance{{Currency: "JPY", Amount: 1}}}); err != nil {
+		t.Fatalf("FileStore.AddSnapshot() error = %v", err)
+	}
+
+	j, err = f.Load()
+	if err != nil {
+		t.Fatalf("FileStore.Load() error = %v", err)
+	}
+	if j.Version != SchemaVersion || len(j.Orders) != 2 || len(j.Fills) != 3 || len(j.Transfers) != 1 || len(j.Snapshots) != 1 {
+		t.Errorf("FileStore.Load() = %+v", j)
+	}
+	if j.Fills[0].Broker != Kabucom || j.Fills[1].ID != "1" || j.Fills[2].ID != "2" {
+		t.Errorf("FileStore.Load() fills = %+v, want them ordered by time", j.Fills)
+	}
+}
+
+func TestFileStore_concurrentStores(t *testing.T) {
+	// Stores of the same file stand for commands running at once.
+	path := filepath.Join(t.TempDir(), "journal.json")
+	stores := []*FileStore{NewFileStore(path), NewFileStore(path)}
+
+	var wg sync.WaitGroup
+	for _, f := range stores {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(f *FileStore) {
+				defer wg.Done()
+				if _, err := f.AddOrder(Order{Broker: BitFlyer, ProductCode: "BTC_JPY"}); err != nil {
+					t.Errorf("FileStore.AddOrder() error = %v", err)
+				}
+			}(f)
+		}
+	}
+	wg.Wait()
+
+	j, err := stores[0].Load()
+	if err != nil {
+		t.Fatalf("FileStore.Load() error = %v", err)
+	}
+	if len(j.Orders) != 20 || j.Orders[19].ID != 20 {
+		t.Errorf("FileStore.Load() has %d orders, want 20 with ids 1 to 20", len(j.Orders))
+	}
+}
+
+func TestFileStore_migrate(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		orders        int
+		precisions    []time.Duration
+		expectedError string
+	}{
+		{
+			name:    "unversioned",
+			content: `{"orders": [{"id": 1, "broker": "bitflyer"}]}`,
+			orders:  1,
+		},
+		{
+			name:    "without transfers",
+			content: `{"version": 1, "orders": [{"id": 1, "broker": "bitflyer"}], "fills": [], "snapshots": []}`,
+			orders:  1,
+		},
+		{
+			name: "without precisions",
+			content: `{"version": 2, "orders": [], "fills": [{"broker": "bitflyer", "id": "csv-a"}, {"broker": "kabucom", "id": "csv-b"},
+				{"broker": "bitflyer", "id": "1"}], "transfers": [], "snapshots": []}`,
+			precisions: []time.Duration{time.Second, 24 * time.Hour, 0},
+		},
+		{
+			name:    "current",
+			content: `{"version": 3, "orders": [], "fills": [], "transfers": [], "snapshots": []}`,
+		},
+		{
+			name:          "newer",
+			content:       `{"version": 99}`,
+			expectedError: "please upgrade capital-go",
+		},
+		{
+			name:          "broken",
+			content:       `[]`,
+			expectedError: "can not parse journal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "journal.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0664); err != nil {
+				t.Fatal(err)
+			}
+			j, err := NewFileStore(path).Load()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("FileStore.Load() error = %v, expectedError %v", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FileStore.Load() error = %v", err)
+			}
+			if j.Version != SchemaVersion || len(j.Orders) != tt.orders || j.Fills == nil || j.Transfers == nil || j.Snapshots == nil {
+				t.Errorf("FileStore.Load() = %+v", j)
+			}
+			for i, v := range tt.precisions {
+				if j.Fills[i].Precision != v {
+					t.Errorf("FileStore.Load() fill %s precision = %v, expected %v", j.Fills[i].ID, j.Fills[i].Precision, v)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStore_sameRecords(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	at := time.Date(2023, 4, 1, 0, 0, 1, 0, time.UTC)
+
+	// Imported from a file without fractions of a second: two fills of the same content, and a
+	// withdrawal.
+	imported := []Fill{
+		{Broker: BitFlyer, ID: "csv-a", ProductCode: "BTC_JPY", Side: "BUY", Price: 100, Size: 1, Time: at, Precision: time.Second},
+		{Broker: BitFlyer, ID: "csv-b", ProductCode: "BTC_JPY", Side: "BUY", Price: 100, Size: 1, Time: at, Precision: time.Second},
+	}
+	if added, err := f.AddFills(imported); err != nil || added != 2 {
+		t.Fatalf("FileStore.AddFills() = %v, %v, want 2", added, err)
+	}
+	if added, err := f.AddTransfers([]Transfer{{Broker: BitFlyer, ID: "csv-c", Currency: "BTC", Amount: -0.1, Time: at, Precision: time.Second}}); err != nil || added != 1 {
+		t.Fatalf("FileStore.AddTransfers() = %v, %v, want 1", added, err)
+	}
+
+	// Pulled from the API: one of the two fills, a fill of the next second and the withdrawal.
+	pulled := []Fill{
+		{Broker: BitFlyer, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 100, Size: 1, Time: at.Add(300 * time.Millisecond)},
+		{Broker: BitFlyer, ID: "2", ProductCode: "BTC_JPY", Side: "BUY", Price: 100, Size: 1, Time: at.Add(time.Second)},
+	}
+	if added, err := f.AddFills(pulled); err != nil || added != 1 {
+		t.Fatalf("FileStore.AddFills() = %v, %v, want 1", added, err)
+	}
+	if added, err := f.AddTransfers([]Transfer{{Broker: BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.1, Time: at.Add(time.Millisecond)}}); err != nil || added != 0 {
+		t.Fatalf("FileStore.AddTransfers() = %v, %v, want 0", added, err)
+	}
+	// Importing the file again adds nothing.
+	if added, err := f.AddFills(imported); err != nil || added != 0 {
+		t.Fatalf("FileStore.AddFills() = %v, %v, want 0", added, err)
+	}
+
+	j, err := f.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, v := range j.Fills {
+		ids = append(ids, v.ID)
+	}
+	for _, v := range j.Transfers {
+		ids = append(ids, v.ID)
+	}
+	// The records of the API replace the imported ones.
+	if expected := []string{"csv-b", "1", "2", "out-1"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("FileStore.Load() = %v, expected %v", ids, expected)
+	}
+}
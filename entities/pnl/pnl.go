@@ -0,0 +1,273 @@
+// Package pnl computes the profit and loss of fills with a cost method.
+//
+// Fills are grouped into positions by broker and product. A fill against the position closes
+// it and realizes the difference between its price and the cost of the closed size, then opens
+// the other side with the rest. The cost of the closed size depends on the method:
+//   - FIFO closes the oldest fills first, at their own prices.
+//   - MovingAverage closes at the average price of the position, updated on every fill opening it.
+//   - TotalAverage closes at the average price of the position carried into the calendar year
+//     (JST) and every buy of the year, as the Japanese tax rules compute the income of crypto
+//     assets. Sells of a year are valued with buys after them, so the average of the current
+//     year moves until it ends. It supports long positions only.
+//
+// Realized profits are before fees. Fees are charged on the day of the fill, opening or not.
+package pnl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+)
+
+// Method is a cost method.
+type Method string
+
+const (
+	FIFO          Method = "fifo"
+	MovingAverage Method = "moving-average"
+	TotalAverage  Method = "total-average"
+)
+
+// Methods are the supported cost methods.
+var Methods = []Method{FIFO, MovingAverage, TotalAverage}
+
+// ParseMethod returns the method named s.
+func ParseMethod(s string) (Method, error) {
+	names := []string{}
+	for _, v := range Methods {
+		if string(v) == s {
+			return v, nil
+		}
+		names = append(names, string(v))
+	}
+	return "", fmt.Errorf("unsupported method %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+// Key identifies a position.
+type Key struct {
+	Broker      string `json:"broker"`
+	ProductCode string `json:"product_code"`
+}
+
+// Fill is an execution of an order.
+type Fill struct {
+	Key
+	Time time.Time `json:"time"`
+	// Side is "BUY" or "SELL".
+	Side  string  `json:"side"`
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+	// Fee is the commission in the quote currency.
+	Fee float64 `json:"fee"`
+}
+
+// signed returns the size of f, negative for sells.
+func (f Fill) signed() float64 {
+	if f.Side == "SELL" {
+		return -f.Size
+	}
+	return f.Size
+}
+
+// Trade is a fill and the profit it realized.
+type Trade struct {
+	Fill
+	// Closed is the size of the position closed by the fill.
+	Closed float64 `json:"closed"`
+	// Realized is the profit of the closed size, before fees.
+	Realized float64 `json:"realized"`
+}
+
+// Position is an open position.
+type Position struct {
+	Key
+	// Size is positive for long positions and negative for short ones.
+	Size float64 `json:"size"`
+	// AverageCost is the average price of the open size.
+	AverageCost float64 `json:"average_cost"`
+}
+
+// Book is the result of a calculation.
+type Book struct {
+	// Trades are in the order of fills.
+	Trades []Trade
+	// Positions are the open positions, sorted by broker and product.
+	Positions []Position
+}
+
+// Calculate returns the trades and the positions of fills with method.
+func Calculate(fills []Fill, method Method) (*Book, error) {
+	sorted := append([]Fill{}, fills...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Time.Before(sorted[b].Time) })
+
+	keys := []Key{}
+	byKey := map[Key][]Fill{}
+	for _, v := range sorted {
+		if v.Side != "BUY" && v.Side != "SELL" {
+			return nil, fmt.Errorf("unsupported side %q of a fill of %s", v.Side, v.ProductCode)
+		}
+		if _, ok := byKey[v.Key]; !ok {
+			keys = append(keys, v.Key)
+		}
+		byKey[v.Key] = append(byKey[v.Key], v)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Broker != keys[b].Broker {
+			return keys[a].Broker < keys[b].Broker
+		}
+		return keys[a].ProductCode < keys[b].ProductCode
+	})
+
+	trades := map[Key][]Trade{}
+	book := &Book{Trades: []Trade{}, Positions: []Position{}}
+	for _, k := range keys {
+		var t []Trade
+		var p Position
+		var err error
+		switch method {
+		case FIFO:
+			t, p = fifo(byKey[k])
+		case MovingAverage:
+			t, p = movingAverage(byKey[k])
+		case TotalAverage:
+			t, p, err = totalAverage(byKey[k])
+		default:
+			err = fmt.Errorf("unsupported method %q", method)
+		}
+		if err != nil {
+			return nil, err
+		}
+		trades[k] = t
+		if p.Size != 0 {
+			p.Key = k
+			book.Positions = append(book.Positions, p)
+		}
+	}
+
+	// Trades go back to the order of fills.
+	next := map[Key]int{}
+	for _, v := range sorted {
+		book.Trades = append(book.Trades, trades[v.Key][next[v.Key]])
+		next[v.Key]++
+	}
+	return book, nil
+}
+
+type lot struct {
+	size  float64
+	price float64
+}
+
+func fifo(fills []Fill) ([]Trade, Position) {
+	trades := []Trade{}
+	lots := []lot{}
+	for _, f := range fills {
+		t := Trade{Fill: f}
+		size := f.signed()
+		for len(lots) > 0 && math.Abs(size) > execution.SizeEpsilon && sign(lots[0].size) != sign(size) {
+			closed := math.Min(math.Abs(size), math.Abs(lots[0].size))
+			t.Closed += closed
+			t.Realized += closed * (f.Price - lots[0].price) * sign(lots[0].size)
+			lots[0].size -= closed * sign(lots[0].size)
+			size -= closed * sign(size)
+			if math.Abs(lots[0].size) <= execution.SizeEpsilon {
+				lots = lots[1:]
+			}
+		}
+		if math.Abs(size) > execution.SizeEpsilon {
+			lots = append(lots, lot{size: size, price: f.Price})
+		}
+		trades = append(trades, t)
+	}
+
+	p := Position{}
+	notional := 0.0
+	for _, v := range lots {
+		p.Size += v.size
+		notional += v.size * v.price
+	}
+	if p.Size != 0 {
+		p.AverageCost = notional / p.Size
+	}
+	return trades, p
+}
+
+func movingAverage(fills []Fill) ([]Trade, Position) {
+	trades := []Trade{}
+	p := Position{}
+	for _, f := range fills {
+		t := Trade{Fill: f}
+		size := f.signed()
+		if p.Size != 0 && sign(p.Size) != sign(size) {
+			closed := math.Min(math.Abs(size), math.Abs(p.Size))
+			t.Closed = closed
+			t.Realized = closed * (f.Price - p.AverageCost) * sign(p.Size)
+			p.Size -= closed * sign(p.Size)
+			size -= closed * sign(size)
+			if math.Abs(p.Size) <= execution.SizeEpsilon {
+				p = Position{}
+			}
+		}
+		if math.Abs(size) > execution.SizeEpsilon {
+			p.AverageCost = (math.Abs(p.Size)*p.AverageCost + math.Abs(size)*f.Price) / (math.Abs(p.Size) + math.Abs(size))
+			p.Size += size
+		}
+		trades = append(trades, t)
+	}
+	return trades, p
+}
+
+func totalAverage(fills []Fill) ([]Trade, Position, error) {
+	trades := []Trade{}
+	p := Position{}
+	for start := 0; start < len(fills); {
+		year := fills[start].Time.In(candle.JST).Year()
+		end := start
+		size, notional := p.Size, p.Size*p.AverageCost
+		for ; end < len(fills) && fills[end].Time.In(candle.JST).Year() == year; end++ {
+			if fills[end].Side == "BUY" {
+				size += fills[end].Size
+				notional += fills[end].Size * fills[end].Price
+			}
+		}
+		if size > 0 {
+			p.AverageCost = notional / size
+		}
+
+		for _, f := range fills[start:end] {
+			t := Trade{Fill: f}
+			if f.Side == "BUY" {
+				p.Size += f.Size
+			} else {
+				if f.Size > p.Size+execution.SizeEpsilon {
+					return nil, Position{}, fmt.Errorf("%s sells %v %s on %s beyond the position of %v: %s supports long positions only",
+						f.Broker, f.Size, f.ProductCode, f.Time.In(candle.JST).Format(time.RFC3339), p.Size, TotalAverage)
+				}
+				t.Closed = f.Size
+				t.Realized = f.Size * (f.Price - p.AverageCost)
+				p.Size -= f.Size
+				if p.Size <= execution.SizeEpsilon {
+					p.Size = 0
+				}
+			}
+			trades = append(trades, t)
+		}
+		start = end
+	}
+	if p.Size == 0 {
+		p.AverageCost = 0
+	}
+	return trades, p, nil
+}
+
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
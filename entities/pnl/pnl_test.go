@@ -0,0 +1,124 @@
+package pnl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	btc  = Key{Broker: "bitflyer", ProductCode: "BTC_JPY"}
+	ntt  = Key{Broker: "kabucom", ProductCode: "9432@1"}
+	base = time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func fill(k Key, at time.Duration, side string, price float64, size float64, fee float64) Fill {
+	return Fill{Key: k, Time: base.Add(at), Side: side, Price: price, Size: size, Fee: fee}
+}
+
+func TestParseMethod(t *testing.T) {
+	for _, v := range Methods {
+		if got, err := ParseMethod(string(v)); err != nil || got != v {
+			t.Errorf("ParseMethod(%q) = %v, %v", v, got, err)
+		}
+	}
+	if _, err := ParseMethod("lifo"); err == nil {
+		t.Errorf("ParseMethod(lifo) error = nil, expected one")
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	fills := []Fill{
+		fill(btc, 0, "BUY", 100, 1, 1),
+		fill(ntt, time.Minute, "BUY", 4000, 100, 0),
+		fill(btc, 2*time.Minute, "BUY", 200, 1, 0),
+		fill(btc, 3*time.Minute, "SELL", 300, 1, 0),
+		fill(ntt, 4*time.Minute, "SELL", 4100, 100, 0),
+		fill(btc, 5*time.Minute, "SELL", 250, 1.5, 0),
+	}
+
+	tests := []struct {
+		name      string
+		method    Method
+		closed    []float64
+		realized  []float64
+		positions []Position
+	}{
+		{
+			name:      "fifo",
+			method:    FIFO,
+			closed:    []float64{0, 0, 0, 1, 100, 1},
+			realized:  []float64{0, 0, 0, 200, 10000, 50},
+			positions: []Position{{Key: btc, Size: -0.5, AverageCost: 250}},
+		},
+		{
+			name:      "moving average",
+			method:    MovingAverage,
+			closed:    []float64{0, 0, 0, 1, 100, 1},
+			realized:  []float64{0, 0, 0, 150, 10000, 100},
+			positions: []Position{{Key: btc, Size: -0.5, AverageCost: 250}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(fills, tt.method)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			for i, v := range got.Trades {
+				if v.Fill != fills[i] || !near(v.Closed, tt.closed[i]) || !near(v.Realized, tt.realized[i]) {
+					t.Errorf("Calculate() trade %d = %+v, expected closed %v, realized %v", i, v, tt.closed[i], tt.realized[i])
+				}
+			}
+			if !reflect.DeepEqual(got.Positions, tt.positions) {
+				t.Errorf("Calculate() positions = %+v, expected %+v", got.Positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestCalculate_totalAverage(t *testing.T) {
+	// 2023-12-31T15:00Z is 2024-01-01 in JST.
+	newYear := time.Date(2023, 12, 31, 15, 0, 0, 0, time.UTC).Sub(base)
+	fills := []Fill{
+		fill(btc, 0, "BUY", 100, 1, 0),
+		fill(btc, time.Hour, "SELL", 150, 1, 0),
+		fill(btc, 2*time.Hour, "BUY", 200, 1, 0),
+		fill(btc, newYear, "BUY", 300, 1, 0),
+		fill(btc, newYear+time.Hour, "SELL", 400, 1, 0),
+	}
+
+	got, err := Calculate(fills, TotalAverage)
+	if err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	// The sell of 2023 is valued at the average of both buys of 2023, and 2024 carries the
+	// remaining position at that average.
+	expected := []float64{0, 0, 0, 0, 175}
+	for i, v := range got.Trades {
+		if !near(v.Realized, expected[i]) {
+			t.Errorf("Calculate() trade %d realized %v, expected %v", i, v.Realized, expected[i])
+		}
+	}
+	if !reflect.DeepEqual(got.Positions, []Position{{Key: btc, Size: 1, AverageCost: 225}}) {
+		t.Errorf("Calculate() positions = %+v", got.Positions)
+	}
+
+	if _, err := Calculate(append(fills, fill(btc, newYear+2*time.Hour, "SELL", 400, 2, 0)), TotalAverage); err == nil {
+		t.Errorf("Calculate() error = nil, expected one for a short position")
+	}
+}
+
+func TestCalculate_errors(t *testing.T) {
+	if _, err := Calculate([]Fill{fill(btc, 0, "HOLD", 100, 1, 0)}, FIFO); err == nil {
+		t.Errorf("Calculate() error = nil, expected one for an unknown side")
+	}
+	if _, err := Calculate([]Fill{fill(btc, 0, "BUY", 100, 1, 0)}, "lifo"); err == nil {
+		t.Errorf("Calculate() error = nil, expected one for an unknown method")
+	}
+}
+
+func near(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
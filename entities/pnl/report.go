@@ -0,0 +1,125 @@
+package pnl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+// Quotes are the current prices of products.
+type Quotes map[Key]float64
+
+// ProductPnL is the profit and loss of a product.
+type ProductPnL struct {
+	Key
+	Realized float64 `json:"realized"`
+	Fees     float64 `json:"fees"`
+	// Trades is the number of fills.
+	Trades int `json:"trades"`
+	// Position and AverageCost are of the open position.
+	Position    float64 `json:"position"`
+	AverageCost float64 `json:"average_cost"`
+	// Price is the quote the position is marked at, zero when there is none.
+	Price      float64 `json:"price"`
+	Unrealized float64 `json:"unrealized"`
+}
+
+// Net returns the realized profit net of fees.
+func (p ProductPnL) Net() float64 {
+	return p.Realized - p.Fees
+}
+
+// DayPnL is the profit and loss of a day in JST.
+type DayPnL struct {
+	Day      time.Time `json:"day"`
+	Realized float64   `json:"realized"`
+	Fees     float64   `json:"fees"`
+	Trades   int       `json:"trades"`
+}
+
+// Net returns the realized profit net of fees.
+func (d DayPnL) Net() float64 {
+	return d.Realized - d.Fees
+}
+
+// Report is the profit and loss of a period.
+type Report struct {
+	Method Method `json:"method"`
+	// From and To bound the period, From inclusive and To exclusive. Zero values are unbounded.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Products are the products traded in the period or still held, sorted by broker and product.
+	Products []ProductPnL `json:"products"`
+	// Days are the days with fills, in order.
+	Days       []DayPnL `json:"days"`
+	Realized   float64  `json:"realized"`
+	Fees       float64  `json:"fees"`
+	Unrealized float64  `json:"unrealized"`
+}
+
+// Net returns the realized profit net of fees.
+func (r *Report) Net() float64 {
+	return r.Realized - r.Fees
+}
+
+// NewReport returns the profit and loss of the trades of book in the period. The open positions
+// of book are marked at quotes; positions without a quote have no unrealized profit.
+func NewReport(book *Book, method Method, from time.Time, to time.Time, quotes Quotes) *Report {
+	r := &Report{Method: method, From: from, To: to, Products: []ProductPnL{}, Days: []DayPnL{}}
+
+	products := map[Key]*ProductPnL{}
+	product := func(k Key) *ProductPnL {
+		if _, ok := products[k]; !ok {
+			products[k] = &ProductPnL{Key: k}
+		}
+		return products[k]
+	}
+	days := map[time.Time]*DayPnL{}
+	for _, v := range book.Trades {
+		if (!from.IsZero() && v.Time.Before(from)) || (!to.IsZero() && !v.Time.Before(to)) {
+			continue
+		}
+		p := product(v.Key)
+		p.Realized += v.Realized
+		p.Fees += v.Fee
+		p.Trades++
+
+		t := v.Time.In(candle.JST)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, candle.JST)
+		if _, ok := days[day]; !ok {
+			days[day] = &DayPnL{Day: day}
+		}
+		days[day].Realized += v.Realized
+		days[day].Fees += v.Fee
+		days[day].Trades++
+
+		r.Realized += v.Realized
+		r.Fees += v.Fee
+	}
+	for _, v := range book.Positions {
+		p := product(v.Key)
+		p.Position = v.Size
+		p.AverageCost = v.AverageCost
+		if price, ok := quotes[v.Key]; ok && price > 0 {
+			p.Price = price
+			p.Unrealized = v.Size * (price - v.AverageCost)
+			r.Unrealized += p.Unrealized
+		}
+	}
+
+	for _, v := range products {
+		r.Products = append(r.Products, *v)
+	}
+	sort.Slice(r.Products, func(a, b int) bool {
+		if r.Products[a].Broker != r.Products[b].Broker {
+			return r.Products[a].Broker < r.Products[b].Broker
+		}
+		return r.Products[a].ProductCode < r.Products[b].ProductCode
+	})
+	for _, v := range days {
+		r.Days = append(r.Days, *v)
+	}
+	sort.Slice(r.Days, func(a, b int) bool { return r.Days[a].Day.Before(r.Days[b].Day) })
+	return r
+}
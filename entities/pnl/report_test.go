@@ -0,0 +1,80 @@
+package pnl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+func TestNewReport(t *testing.T) {
+	// base is 9:00 JST, so fills 15 hours later are on the next day in JST.
+	book, err := Calculate([]Fill{
+		fill(btc, 0, "BUY", 100, 2, 1),
+		fill(ntt, time.Hour, "BUY", 4000, 100, 0),
+		fill(btc, 2*time.Hour, "SELL", 150, 1, 1),
+		fill(btc, 15*time.Hour, "SELL", 130, 0.5, 0.5),
+		fill(btc, 48*time.Hour, "SELL", 90, 0.5, 0),
+	}, FIFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2023, 4, d, 0, 0, 0, 0, candle.JST) }
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		quotes   Quotes
+		expected *Report
+	}{
+		{
+			name:   "every trade",
+			quotes: Quotes{ntt: 4200},
+			expected: &Report{
+				Method: FIFO,
+				Products: []ProductPnL{
+					{Key: btc, Realized: 60, Fees: 2.5, Trades: 4},
+					{Key: ntt, Trades: 1, Position: 100, AverageCost: 4000, Price: 4200, Unrealized: 20000},
+				},
+				Days: []DayPnL{
+					{Day: day(1), Realized: 50, Fees: 2, Trades: 3},
+					{Day: day(2), Realized: 15, Fees: 0.5, Trades: 1},
+					{Day: day(3), Realized: -5, Trades: 1},
+				},
+				Realized:   60,
+				Fees:       2.5,
+				Unrealized: 20000,
+			},
+		},
+		{
+			name: "period without a quote",
+			from: base.Add(time.Hour),
+			to:   base.Add(48 * time.Hour),
+			expected: &Report{
+				Method: FIFO,
+				From:   base.Add(time.Hour),
+				To:     base.Add(48 * time.Hour),
+				Products: []ProductPnL{
+					{Key: btc, Realized: 65, Fees: 1.5, Trades: 2},
+					{Key: ntt, Trades: 1, Position: 100, AverageCost: 4000},
+				},
+				Days: []DayPnL{
+					{Day: day(1), Realized: 50, Fees: 1, Trades: 2},
+					{Day: day(2), Realized: 15, Fees: 0.5, Trades: 1},
+				},
+				Realized: 65,
+				Fees:     1.5,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewReport(book, FIFO, tt.from, tt.to, tt.quotes)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("NewReport() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package rebalance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// Holding is an asset of the target held in an account.
+type Holding struct {
+	Asset  string
+	Broker string
+	// Quantity is the amount held, zero for assets not held yet.
+	Quantity float64
+	// Price is the value of a unit in JPY, 1 for cash.
+	Price float64
+	// MinSize is the smallest size of an order, and Step the increment of sizes above it.
+	MinSize float64
+	Step    float64
+}
+
+// Order is an order of the plan, at the current price.
+type Order struct {
+	Asset       string
+	Broker      string
+	ProductCode string
+	// Side is "BUY" or "SELL".
+	Side     string
+	Size     float64
+	Price    float64
+	Notional float64
+}
+
+// Allocation is the weight of an asset before and after the orders.
+type Allocation struct {
+	Asset  string
+	Target float64
+	Before float64
+	After  float64
+	// InBand reports whether Before is within the tolerance of Target.
+	InBand bool
+}
+
+// Plan are the orders bringing holdings within the tolerance of a target.
+type Plan struct {
+	// Value is the value of the holdings of the target, in JPY.
+	Value       float64
+	Tolerance   float64
+	Allocations []Allocation
+	// Orders are sells first, so that their proceeds pay for the buys.
+	Orders []Order
+	// Skipped are orders smaller than the minimum size of their asset, which are not sent.
+	Skipped []Order
+	// Cash is the JPY of each broker after the orders. It is negative when the buys of a broker
+	// need more than it holds, so that cash must be moved between the accounts first.
+	Cash map[string]float64
+}
+
+// Funded reports whether every broker has the cash of its buys after its sells. Orders of a plan
+// which is not funded would fail once the cash runs out, leaving the holdings half rebalanced.
+func (p *Plan) Funded() error {
+	brokers := []string{}
+	for broker, cash := range p.Cash {
+		if cash < 0 {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil
+	}
+	sort.Strings(brokers)
+
+	needs := []string{}
+	for _, v := range brokers {
+		needs = append(needs, fmt.Sprintf("%s needs %s JPY more cash for its buys", v, strconv.FormatFloat(-p.Cash[v], 'f', -1, 64)))
+	}
+	return fmt.Errorf("%w: %s: move cash between the accounts first", cerror.ErrInsufficientFunds, strings.Join(needs, ", "))
+}
+
+// NewPlan plans the smallest orders bringing every asset of holdings within the tolerance of its
+// target weight. Assets out of the band are traded to its nearest edge, then, when cash is still
+// out of its own band, the assets furthest from their targets are traded within their bands.
+// Sizes are rounded down to the steps of the assets.
+func NewPlan(target *Target, holdings []Holding) (*Plan, error) {
+	plan := &Plan{Tolerance: target.Tolerance, Cash: map[string]float64{}}
+	for _, v := range holdings {
+		if v.Price <= 0 {
+			return nil, fmt.Errorf("no price of %s", v.Asset)
+		}
+		plan.Value += v.Quantity * v.Price
+		if v.Asset == Cash {
+			plan.Cash[v.Broker] += v.Quantity
+		}
+	}
+	if plan.Value <= 0 {
+		return nil, errors.New("the holdings of the target have no value")
+	}
+
+	// Weights of the assets other than cash, with the band of each.
+	type position struct {
+		Holding
+		target, before, desired float64
+		low, high               float64
+	}
+	positions := []*position{}
+	for _, v := range holdings {
+		if v.Asset == Cash {
+			continue
+		}
+		t := target.Weight(v.Asset)
+		p := &position{
+			Holding: v,
+			target:  t,
+			before:  v.Quantity * v.Price / plan.Value,
+			low:     math.Max(0, t-target.Tolerance),
+			high:    math.Min(1, t+target.Tolerance),
+		}
+		p.desired = math.Min(math.Max(p.before, p.low), p.high)
+		positions = append(positions, p)
+	}
+
+	cashTarget := target.Weight(Cash)
+	cash := 1.0
+	for _, v := range positions {
+		cash -= v.desired
+	}
+	if excess := cash - (cashTarget + target.Tolerance); excess > 0 {
+		// Buy the most underweight assets first.
+		sort.SliceStable(positions, func(i, j int) bool {
+			return positions[i].desired-positions[i].target < positions[j].desired-positions[j].target
+		})
+		for _, v := range positions {
+			buy := math.Min(excess, v.high-v.desired)
+			v.desired += buy
+			excess -= buy
+		}
+	}
+	if deficit := math.Max(0, cashTarget-target.Tolerance) - cash; deficit > 0 {
+		// Sell the most overweight assets first.
+		sort.SliceStable(positions, func(i, j int) bool {
+			return positions[i].desired-positions[i].target > positions[j].desired-positions[j].target
+		})
+		for _, v := range positions {
+			sell := math.Min(deficit, v.desired-v.low)
+			v.desired -= sell
+			deficit -= sell
+		}
+	}
+
+	after := map[string]float64{}
+	cashAfter := 1.0
+	for _, v := range positions {
+		size := (v.desired - v.before) * plan.Value / v.Price
+		o := Order{Asset: v.Asset, Broker: v.Broker, Side: "BUY", Price: v.Price}
+		if size < 0 {
+			o.Side = "SELL"
+		}
+		o.Size = execution.RoundLot(math.Abs(size), v.Step)
+		if o.Size == 0 && math.Abs(size) < 1e-8 {
+			after[v.Asset] = v.before
+			cashAfter -= v.before
+			continue
+		}
+		if _, code, err := Market(v.Asset); err == nil {
+			o.ProductCode = code
+		}
+		o.Notional = o.Size * o.Price
+		if o.Size == 0 || o.Size < v.MinSize {
+			o.Size, o.Notional = math.Abs(size), math.Abs(size)*o.Price
+			plan.Skipped = append(plan.Skipped, o)
+			after[v.Asset] = v.before
+			cashAfter -= v.before
+			continue
+		}
+
+		plan.Orders = append(plan.Orders, o)
+		quantity := v.Quantity + o.Size
+		if o.Side == "SELL" {
+			quantity = v.Quantity - o.Size
+			plan.Cash[v.Broker] += o.Notional
+		} else {
+			plan.Cash[v.Broker] -= o.Notional
+		}
+		after[v.Asset] = quantity * v.Price / plan.Value
+		cashAfter -= after[v.Asset]
+	}
+	sort.SliceStable(plan.Orders, func(i, j int) bool {
+		if plan.Orders[i].Side != plan.Orders[j].Side {
+			return plan.Orders[i].Side == "SELL"
+		}
+		return plan.Orders[i].Asset < plan.Orders[j].Asset
+	})
+	sort.SliceStable(plan.Skipped, func(i, j int) bool { return plan.Skipped[i].Asset < plan.Skipped[j].Asset })
+
+	cashBefore := 1.0
+	for _, v := range positions {
+		cashBefore -= v.before
+	}
+	sort.SliceStable(positions, func(i, j int) bool { return positions[i].Asset < positions[j].Asset })
+	for _, v := range positions {
+		plan.Allocations = append(plan.Allocations, Allocation{
+			Asset:  v.Asset,
+			Target: v.target,
+			Before: v.before,
+			After:  after[v.Asset],
+			InBand: v.before >= v.low-1e-9 && v.before <= v.high+1e-9,
+		})
+	}
+	plan.Allocations = append(plan.Allocations, Allocation{
+		Asset:  Cash,
+		Target: cashTarget,
+		Before: cashBefore,
+		After:  cashAfter,
+		InBand: math.Abs(cashBefore-cashTarget) <= target.Tolerance+1e-9,
+	})
+	return plan, nil
+}
@@ -0,0 +1,191 @@
+package rebalance
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/journal"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// formatOrders formats orders to 8 decimals, as sizes are.
+func formatOrders(orders []Order) []string {
+	res := []string{}
+	for _, v := range orders {
+		res = append(res, fmt.Sprintf("%s %s %s %s %.8f @ %.0f", v.Broker, v.ProductCode, v.Side, v.Asset, v.Size, v.Price))
+	}
+	return res
+}
+
+func formatAllocations(allocations []Allocation) []string {
+	res := []string{}
+	for _, v := range allocations {
+		res = append(res, fmt.Sprintf("%s %.4f %.4f %.4f %v", v.Asset, v.Target, v.Before, v.After, v.InBand))
+	}
+	return res
+}
+
+func TestNewPlan(t *testing.T) {
+	target := &Target{Tolerance: 0.05, Weights: map[string]float64{"1306@1": 60, "BTC": 20, "JPY": 20}}
+	holdings := func(jpy float64, etf float64, btc float64) []Holding {
+		return []Holding{
+			{Asset: Cash, Broker: journal.BitFlyer, Quantity: jpy / 4, Price: 1},
+			{Asset: Cash, Broker: journal.Kabucom, Quantity: jpy * 3 / 4, Price: 1},
+			{Asset: "1306@1", Broker: journal.Kabucom, Quantity: etf, Price: 2000, MinSize: 10, Step: 10},
+			{Asset: "BTC", Broker: journal.BitFlyer, Quantity: btc, Price: 5000000, MinSize: 0.001, Step: 0.00000001},
+		}
+	}
+
+	tests := []struct {
+		name                string
+		holdings            []Holding
+		expectedOrders      []string
+		expectedSkipped     []string
+		expectedAllocations []string
+		expectedCash        map[string]float64
+	}{
+		{
+			name: "too much cash buys to the edges of the bands, then the most underweight",
+			// 400,000 JPY, 400,000 of the ETF and 100,000 of BTC.
+			holdings: holdings(400000, 200, 0.02),
+			expectedOrders: []string{
+				"kabucom 1306@1 BUY 1306@1 70.00000000 @ 2000",
+				"bitflyer BTC_JPY BUY BTC 0.00700000 @ 5000000",
+			},
+			expectedSkipped: []string{},
+			expectedAllocations: []string{
+				"1306@1 0.6000 0.4444 0.6000 false",
+				"BTC 0.2000 0.1111 0.1500 false",
+				"JPY 0.2000 0.4444 0.2500 false",
+			},
+			expectedCash: map[string]float64{journal.BitFlyer: 65000, journal.Kabucom: 160000},
+		},
+		{
+			name:            "within the bands",
+			holdings:        holdings(200000, 290, 0.044),
+			expectedOrders:  []string{},
+			expectedSkipped: []string{},
+			expectedAllocations: []string{
+				"1306@1 0.6000 0.5800 0.5800 true",
+				"BTC 0.2000 0.2200 0.2200 true",
+				"JPY 0.2000 0.2000 0.2000 true",
+			},
+			expectedCash: map[string]float64{journal.BitFlyer: 50000, journal.Kabucom: 150000},
+		},
+		{
+			name: "no cash sells to the edges of the bands, rounded down to the lots",
+			// 1,000,000 of the ETF and 0 BTC.
+			holdings: holdings(0, 500, 0),
+			expectedOrders: []string{
+				"kabucom 1306@1 SELL 1306@1 170.00000000 @ 2000",
+				"bitflyer BTC_JPY BUY BTC 0.03000000 @ 5000000",
+			},
+			expectedSkipped: []string{},
+			expectedAllocations: []string{
+				"1306@1 0.6000 1.0000 0.6600 false",
+				"BTC 0.2000 0.0000 0.1500 false",
+				"JPY 0.2000 0.0000 0.1900 false",
+			},
+			expectedCash: map[string]float64{journal.BitFlyer: -150000, journal.Kabucom: 340000},
+		},
+		{
+			name:            "orders below the minimum size",
+			holdings:        holdings(251000, 300, 0.0298),
+			expectedOrders:  []string{},
+			expectedSkipped: []string{"bitflyer BTC_JPY BUY BTC 0.00020000 @ 5000000"},
+			expectedAllocations: []string{
+				"1306@1 0.6000 0.6000 0.6000 true",
+				"BTC 0.2000 0.1490 0.1490 false",
+				"JPY 0.2000 0.2510 0.2510 false",
+			},
+			expectedCash: map[string]float64{journal.BitFlyer: 62750, journal.Kabucom: 188250},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewPlan(target, tt.holdings)
+			if err != nil {
+				t.Fatalf("NewPlan() error = %v", err)
+			}
+			if orders := formatOrders(actual.Orders); !reflect.DeepEqual(orders, tt.expectedOrders) {
+				t.Errorf("NewPlan() orders = %v, expected %v", orders, tt.expectedOrders)
+			}
+			if skipped := formatOrders(actual.Skipped); !reflect.DeepEqual(skipped, tt.expectedSkipped) {
+				t.Errorf("NewPlan() skipped = %v, expected %v", skipped, tt.expectedSkipped)
+			}
+			if allocations := formatAllocations(actual.Allocations); !reflect.DeepEqual(allocations, tt.expectedAllocations) {
+				t.Errorf("NewPlan() allocations = %v, expected %v", allocations, tt.expectedAllocations)
+			}
+			if !reflect.DeepEqual(actual.Cash, tt.expectedCash) {
+				t.Errorf("NewPlan() cash = %v, expected %v", actual.Cash, tt.expectedCash)
+			}
+		})
+	}
+}
+
+func TestNewPlan_Errors(t *testing.T) {
+	target := &Target{Tolerance: 0.05, Weights: map[string]float64{"BTC": 1}}
+	tests := []struct {
+		name          string
+		holdings      []Holding
+		expectedError string
+	}{
+		{
+			name:          "no price",
+			holdings:      []Holding{{Asset: "BTC", Broker: journal.BitFlyer, Quantity: 1}},
+			expectedError: "no price of BTC",
+		},
+		{
+			name:          "no value",
+			holdings:      []Holding{{Asset: "BTC", Broker: journal.BitFlyer, Price: 5000000}},
+			expectedError: "the holdings of the target have no value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPlan(target, tt.holdings)
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("NewPlan() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestPlan_Funded(t *testing.T) {
+	tests := []struct {
+		name          string
+		cash          map[string]float64
+		expectedError string
+	}{
+		{
+			name: "funded",
+			cash: map[string]float64{journal.BitFlyer: 0, journal.Kabucom: 1000},
+		},
+		{
+			name:          "short",
+			cash:          map[string]float64{journal.BitFlyer: -150000, journal.Kabucom: 340000},
+			expectedError: "insufficient funds: bitflyer needs 150000 JPY more cash for its buys: move cash between the accounts first",
+		},
+		{
+			name:          "both short",
+			cash:          map[string]float64{journal.Kabucom: -2, journal.BitFlyer: -1.5},
+			expectedError: "insufficient funds: bitflyer needs 1.5 JPY more cash for its buys, kabucom needs 2 JPY more cash for its buys: move cash between the accounts first",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Plan{Cash: tt.cash}).Funded()
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("Plan.Funded() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, cerror.ErrInsufficientFunds) || err.Error() != tt.expectedError {
+				t.Errorf("Plan.Funded() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
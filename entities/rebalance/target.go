@@ -0,0 +1,131 @@
+// Package rebalance plans the orders bringing holdings back to a target allocation.
+//
+// Targets are kept in a YAML file, such as:
+//
+//	# 60% TOPIX ETF on kabu STATION, 20% BTC on bitFlyer and 20% JPY in the accounts.
+//	tolerance: 0.05
+//	weights:
+//	  1306@1: 60
+//	  BTC: 20
+//	  JPY: 20
+//	lots:
+//	  XRP: 1
+//
+// Assets are named by the symbol of kabu STATION (symbol@exchange), by the currency of bitFlyer,
+// traded against JPY, or JPY for the cash of both accounts.
+package rebalance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/journal"
+	"gopkg.in/yaml.v3"
+)
+
+// Cash is the asset of the cash of the accounts.
+const Cash = "JPY"
+
+// DefaultTolerance is the tolerance of targets without one.
+const DefaultTolerance = 0.05
+
+// Target is a target allocation.
+type Target struct {
+	// Tolerance is the band around the target weight of each asset within which it is not traded,
+	// such as 0.05 for 5 percentage points of the portfolio.
+	Tolerance float64 `yaml:"tolerance"`
+	// Weights are the target weights by asset. They are relative: 60, 20, 20 is 0.6, 0.2, 0.2.
+	Weights map[string]float64 `yaml:"weights"`
+	// Lots are the sizes of orders by asset, overriding the ones of the brokers.
+	Lots map[string]float64 `yaml:"lots"`
+}
+
+// LoadTarget reads the target file at path.
+func LoadTarget(path string) (*Target, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read target %s: %w", path, err)
+	}
+
+	t := &Target{Tolerance: -1}
+	if err := yaml.Unmarshal(raw, t); err != nil {
+		return nil, fmt.Errorf("can not parse target %s: %w", path, err)
+	}
+	if t.Tolerance == -1 {
+		t.Tolerance = DefaultTolerance
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid target %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Validate reports the first invalid setting of t.
+func (t *Target) Validate() error {
+	if t.Tolerance < 0 || t.Tolerance >= 1 {
+		return fmt.Errorf("tolerance must be at least 0 and less than 1, got %v", t.Tolerance)
+	}
+	if len(t.Weights) == 0 {
+		return errors.New("weights are empty")
+	}
+	sum := 0.0
+	for _, name := range t.Assets() {
+		w := t.Weights[name]
+		if w < 0 || math.IsNaN(w) {
+			return fmt.Errorf("weight of %s must not be negative, got %v", name, w)
+		}
+		if _, _, err := Market(name); err != nil {
+			return err
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return errors.New("weights sum to zero")
+	}
+	for name, v := range t.Lots {
+		if v <= 0 {
+			return fmt.Errorf("lot of %s must be positive, got %v", name, v)
+		}
+	}
+	return nil
+}
+
+// Assets returns the assets of the weights, sorted.
+func (t *Target) Assets() []string {
+	names := make([]string, 0, len(t.Weights))
+	for k := range t.Weights {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Weight returns the normalized target weight of asset.
+func (t *Target) Weight(asset string) float64 {
+	sum := 0.0
+	for _, v := range t.Weights {
+		sum += v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return t.Weights[asset] / sum
+}
+
+// Market returns the broker and the product code trading asset: kabu STATION for symbols such as
+// 1306@1, and the JPY pair of bitFlyer for currencies such as BTC. Cash has neither.
+func Market(asset string) (string, string, error) {
+	switch {
+	case asset == Cash:
+		return "", "", nil
+	case strings.Contains(asset, "@"):
+		return journal.Kabucom, asset, nil
+	case asset == "" || strings.ContainsAny(asset, "_ /"):
+		return "", "", fmt.Errorf("invalid asset %q: name a symbol such as 1306@1, a currency such as BTC, or JPY", asset)
+	}
+	return journal.BitFlyer, asset + "_JPY", nil
+}
@@ -0,0 +1,116 @@
+package rebalance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+func TestLoadTarget(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      *Target
+		expectedError string
+	}{
+		{
+			name:  "with the default tolerance",
+			input: "weights:\n  1306@1: 60\n  BTC: 20\n  JPY: 20\n",
+			expected: &Target{
+				Tolerance: DefaultTolerance,
+				Weights:   map[string]float64{"1306@1": 60, "BTC": 20, "JPY": 20},
+			},
+		},
+		{
+			name:  "with tolerance and lots",
+			input: "tolerance: 0\nweights:\n  XRP: 1\nlots:\n  XRP: 1\n",
+			expected: &Target{
+				Weights: map[string]float64{"XRP": 1},
+				Lots:    map[string]float64{"XRP": 1},
+			},
+		},
+		{
+			name:          "invalid YAML",
+			input:         "weights: [",
+			expectedError: "can not parse target",
+		},
+		{
+			name:          "no weights",
+			input:         "tolerance: 0.1\n",
+			expectedError: "weights are empty",
+		},
+		{
+			name:          "negative weight",
+			input:         "weights:\n  BTC: -1\n  JPY: 2\n",
+			expectedError: "weight of BTC must not be negative",
+		},
+		{
+			name:          "zero weights",
+			input:         "weights:\n  BTC: 0\n",
+			expectedError: "weights sum to zero",
+		},
+		{
+			name:          "product code",
+			input:         "weights:\n  BTC_JPY: 1\n",
+			expectedError: `invalid asset "BTC_JPY"`,
+		},
+		{
+			name:          "tolerance",
+			input:         "tolerance: 1\nweights:\n  BTC: 1\n",
+			expectedError: "tolerance must be at least 0 and less than 1",
+		},
+		{
+			name:          "lot",
+			input:         "weights:\n  BTC: 1\nlots:\n  BTC: 0\n",
+			expectedError: "lot of BTC must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "target.yaml")
+			if err := os.WriteFile(path, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			actual, err := LoadTarget(path)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("LoadTarget() error = %v, expectedError %v", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadTarget() error = %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("LoadTarget() = %+v, expected %+v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarket(t *testing.T) {
+	tests := []struct {
+		asset               string
+		expectedBroker      string
+		expectedProductCode string
+	}{
+		{asset: "1306@1", expectedBroker: journal.Kabucom, expectedProductCode: "1306@1"},
+		{asset: "BTC", expectedBroker: journal.BitFlyer, expectedProductCode: "BTC_JPY"},
+		{asset: Cash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			broker, code, err := Market(tt.asset)
+			if err != nil {
+				t.Fatalf("Market() error = %v", err)
+			}
+			if broker != tt.expectedBroker || code != tt.expectedProductCode {
+				t.Errorf("Market() = %s, %s, expected %s, %s", broker, code, tt.expectedBroker, tt.expectedProductCode)
+			}
+		})
+	}
+}
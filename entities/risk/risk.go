@@ -0,0 +1,196 @@
+// Package risk checks orders against pre-trade limits before they are sent.
+//
+// Limits are grouped in named profiles kept in a JSON file, such as:
+//
+//	{
+//	  "default": {"max_order_notional": 1000000, "price_band": 0.05},
+//	  "bot": {"allowed_products": ["BTC_JPY"], "max_position": {"BTC_JPY": 0.1}, "daily_loss_limit": 50000}
+//	}
+//
+// Zero or empty limits are disabled.
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// DefaultProfile is the profile used unless another one is selected.
+const DefaultProfile = "default"
+
+// Limits are the pre-trade limits of a profile.
+type Limits struct {
+	// MaxOrderNotional is the largest price * size of an order, in the quote currency.
+	MaxOrderNotional float64 `json:"max_order_notional"`
+	// MaxPosition is the largest holding of the base currency per product code once an order fills.
+	MaxPosition map[string]float64 `json:"max_position"`
+	// PriceBand is the largest deviation of a limit price from the mid price, such as 0.05 for 5%.
+	PriceBand float64 `json:"price_band"`
+	// DailyLossLimit is the loss of the account equity in JPY since the start of the JST day
+	// from which orders increasing a position are rejected.
+	DailyLossLimit float64 `json:"daily_loss_limit"`
+	// AllowedProducts are the only product codes orders may be sent for.
+	AllowedProducts []string `json:"allowed_products"`
+}
+
+// DefaultLimits are used when no profile file exists. They reject orders worth more than
+// 1,000,000 in the quote currency and limit prices 5% away from the mid price.
+func DefaultLimits() Limits {
+	return Limits{MaxOrderNotional: 1000000, PriceBand: 0.05}
+}
+
+// Profiles are limits by profile name.
+type Profiles map[string]Limits
+
+// DefaultPath returns the default path of the profile file.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "risk.json")
+}
+
+// LoadProfiles reads the profile file. A missing file yields the default profile with DefaultLimits.
+func LoadProfiles(path string) (Profiles, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Profiles{DefaultProfile: DefaultLimits()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read risk profiles %s: %w", path, err)
+	}
+
+	profiles := Profiles{}
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return nil, fmt.Errorf("can not parse risk profiles %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// Profile returns the limits of the profile name.
+// The default profile has DefaultLimits unless the file defines it.
+func (p Profiles) Profile(name string) (Limits, error) {
+	limits, ok := p[name]
+	if !ok && name == DefaultProfile {
+		return DefaultLimits(), nil
+	}
+	if !ok {
+		names := make([]string, 0, len(p))
+		for k := range p {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return Limits{}, fmt.Errorf("unknown risk profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return limits, nil
+}
+
+// Order is an order to check.
+type Order struct {
+	ProductCode string
+	Side        bitflyer.ChildOrderSide
+	// Price is the limit price. Zero is a market order.
+	Price float64
+	Size  float64
+}
+
+// Market is the state orders are checked against.
+type Market struct {
+	BestBid float64
+	BestAsk float64
+	Mid     float64
+	// Position is the base currency held, or the net position of FX products, negative when short.
+	Position float64
+	// DailyLoss is the loss of the account equity since the start of the day, in JPY.
+	DailyLoss float64
+}
+
+// Rejection is an order rejected by a limit. It unwraps to cerror.ErrRiskRejected.
+type Rejection struct {
+	// Rule is the name of the limit in the profile file.
+	Rule   string
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%v by %s: %s", cerror.ErrRiskRejected, r.Rule, r.Reason)
+}
+
+func (r *Rejection) Unwrap() error {
+	return cerror.ErrRiskRejected
+}
+
+// CheckProduct returns a *Rejection when orders for productCode are not allowed.
+func (l Limits) CheckProduct(productCode string) error {
+	if len(l.AllowedProducts) > 0 && !contains(l.AllowedProducts, productCode) {
+		return &Rejection{"allowed_products", fmt.Sprintf("%s is not one of %s", productCode, strings.Join(l.AllowedProducts, ", "))}
+	}
+	return nil
+}
+
+// Check returns a *Rejection when the order breaks a limit.
+func (l Limits) Check(order Order, market Market) error {
+	if err := l.CheckProduct(order.ProductCode); err != nil {
+		return err
+	}
+
+	if l.PriceBand > 0 && order.Price > 0 && market.Mid > 0 {
+		if deviation := math.Abs(order.Price-market.Mid) / market.Mid; deviation > l.PriceBand {
+			return &Rejection{"price_band", fmt.Sprintf("price %v is %.2f%% away from the mid price %v, more than %.2f%%",
+				order.Price, deviation*100, market.Mid, l.PriceBand*100)}
+		}
+	}
+
+	// Market orders are estimated at the best opposite quote, and rejected without one, as their
+	// notional is unknown.
+	price := order.Price
+	if price == 0 {
+		price = market.BestAsk
+		if order.Side == bitflyer.SideSell {
+			price = market.BestBid
+		}
+		if price <= 0 && l.MaxOrderNotional > 0 {
+			return &Rejection{"max_order_notional", fmt.Sprintf("a market %s order of %v cannot be valued without an opposite quote on the board",
+				strings.ToLower(string(order.Side)), order.Size)}
+		}
+	}
+	if notional := price * order.Size; l.MaxOrderNotional > 0 && notional > l.MaxOrderNotional {
+		return &Rejection{"max_order_notional", fmt.Sprintf("%v at %v is worth %.0f, more than %.0f",
+			order.Size, price, notional, l.MaxOrderNotional)}
+	}
+
+	position := market.Position + order.Size
+	if order.Side == bitflyer.SideSell {
+		position = market.Position - order.Size
+	}
+	// Short positions of FX products are limited like long ones.
+	if max, ok := l.MaxPosition[order.ProductCode]; ok && math.Abs(position) > max+execution.SizeEpsilon && math.Abs(position) > math.Abs(market.Position) {
+		return &Rejection{"max_position", fmt.Sprintf("the position of %s would be %v, more than %v", order.ProductCode, position, max)}
+	}
+
+	// Orders reducing the position are still allowed, to cut losses.
+	reducing := order.Side == bitflyer.SideSell && order.Size <= market.Position+execution.SizeEpsilon ||
+		order.Side == bitflyer.SideBuy && order.Size <= -market.Position+execution.SizeEpsilon
+	if l.DailyLossLimit > 0 && market.DailyLoss >= l.DailyLossLimit && !reducing {
+		return &Rejection{"daily_loss_limit", fmt.Sprintf("today's loss %.0f JPY reached the limit %.0f JPY; only orders reducing the position are allowed",
+			market.DailyLoss, l.DailyLossLimit)}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package risk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func TestLimits_Check(t *testing.T) {
+	limits := Limits{
+		MaxOrderNotional: 1000000,
+		MaxPosition:      map[string]float64{"BTC_JPY": 0.2, "FX_BTC_JPY": 0.2},
+		PriceBand:        0.05,
+		DailyLossLimit:   10000,
+		AllowedProducts:  []string{"BTC_JPY", "ETH_JPY", "FX_BTC_JPY"},
+	}
+	market := Market{BestBid: 4999000, BestAsk: 5001000, Mid: 5000000, Position: 0.1}
+
+	tests := []struct {
+		name      string
+		order     Order
+		dailyLoss float64
+		// market replaces the default market when set.
+		market   *Market
+		wantRule string
+	}{
+		{name: "Accepted", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01}},
+		{name: "Typo In Size", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Price: 5000000, Size: 10}, wantRule: "max_order_notional"},
+		{name: "Market Order Estimated At Best Ask", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Size: 0.2}, wantRule: "max_order_notional"},
+		{name: "Typo In Price", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideSell, Price: 500000, Size: 0.01}, wantRule: "price_band"},
+		{name: "Not Allowed", order: Order{ProductCode: "XRP_JPY", Side: bitflyer.SideBuy, Price: 70, Size: 1}, wantRule: "allowed_products"},
+		{name: "Position Over Limit", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Price: 5000000, Size: 0.15}, wantRule: "max_position"},
+		{name: "Daily Loss Reached", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01}, dailyLoss: 10000, wantRule: "daily_loss_limit"},
+		{name: "Reducing After Daily Loss", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideSell, Price: 5000000, Size: 0.1}, dailyLoss: 10000},
+		{name: "Market Order Without Asks", order: Order{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Size: 10}, market: &Market{BestBid: 4999000, Mid: 4999000}, wantRule: "max_order_notional"},
+		{name: "Short Position Over Limit", order: Order{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideSell, Price: 5000000, Size: 0.15}, market: &Market{BestBid: 4999000, BestAsk: 5001000, Mid: 5000000, Position: -0.1}, wantRule: "max_position"},
+		{name: "Covering Short After Daily Loss", order: Order{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideBuy, Price: 5000000, Size: 0.1}, dailyLoss: 10000, market: &Market{BestBid: 4999000, BestAsk: 5001000, Mid: 5000000, Position: -0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := market
+			if tt.market != nil {
+				m = *tt.market
+			}
+			m.DailyLoss = tt.dailyLoss
+
+			err := limits.Check(tt.order, m)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("Limits.Check() error = %v", err)
+				}
+				return
+			}
+
+			var rejection *Rejection
+			if !errors.As(err, &rejection) || rejection.Rule != tt.wantRule {
+				t.Fatalf("Limits.Check() error = %v, want a rejection by %s", err, tt.wantRule)
+			}
+			if !errors.Is(err, cerror.ErrRiskRejected) {
+				t.Errorf("Limits.Check() error = %v, expectedError %v", err, cerror.ErrRiskRejected)
+			}
+		})
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+
+	profiles, err := LoadProfiles(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadProfiles() error = %v", err)
+	}
+	if got, err := profiles.Profile(DefaultProfile); err != nil || got.MaxOrderNotional != DefaultLimits().MaxOrderNotional {
+		t.Errorf("Profiles.Profile() = %v, %v, want DefaultLimits()", got, err)
+	}
+
+	path := filepath.Join(dir, "risk.json")
+	if err := os.WriteFile(path, []byte(`{"bot": {"allowed_products": ["BTC_JPY"], "max_position": {"BTC_JPY": 0.1}}}`), 0664); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("LoadProfiles() error = %v", err)
+	}
+	if got, err := profiles.Profile("bot"); err != nil || got.MaxPosition["BTC_JPY"] != 0.1 {
+		t.Errorf("Profiles.Profile() = %v, %v", got, err)
+	}
+	if got, err := profiles.Profile(DefaultProfile); err != nil || got.PriceBand != DefaultLimits().PriceBand {
+		t.Errorf("Profiles.Profile() = %v, %v, want DefaultLimits()", got, err)
+	}
+	if _, err := profiles.Profile("unknown"); err == nil {
+		t.Errorf("Profiles.Profile() of a profile missing from the file succeeded")
+	}
+}
+
+func TestFileState_DailyLoss(t *testing.T) {
+	state := NewFileState(filepath.Join(t.TempDir(), "risk-state.json"))
+
+	steps := []struct {
+		account string
+		day     string
+		equity  float64
+		want    float64
+	}{
+		{account: "bitflyer", day: "2023-04-01", equity: 100000, want: 0},
+		{account: "bitflyer", day: "2023-04-01", equity: 95000, want: 5000},
+		{account: "paper", day: "2023-04-01", equity: 1000000, want: 0},
+		// A new day starts from the current equity.
+		{account: "bitflyer", day: "2023-04-02", equity: 90000, want: 0},
+		{account: "bitflyer", day: "2023-04-02", equity: 92000, want: -2000},
+	}
+	for i, v := range steps {
+		got, err := state.DailyLoss(v.account, v.day, v.equity)
+		if err != nil {
+			t.Fatalf("FileState.DailyLoss() error = %v", err)
+		}
+		if got != v.want {
+			t.Errorf("step %d: FileState.DailyLoss() = %v, want %v", i, got, v.want)
+		}
+	}
+}
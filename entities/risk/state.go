@@ -0,0 +1,73 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// day is the equity of an account at its first check of a day.
+type day struct {
+	Day         string  `json:"day"`
+	StartEquity float64 `json:"start_equity"`
+}
+
+// FileState keeps the equity at the start of the day per account as a JSON file,
+// so that the daily loss is measured across commands.
+type FileState struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileState returns a FileState backed by the file at path.
+func NewFileState(path string) *FileState {
+	return &FileState{path: path}
+}
+
+// DefaultStatePath returns the default path of the daily state file.
+func DefaultStatePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "risk-state.json")
+}
+
+// DailyLoss returns the loss of equity since the first call of the day for account.
+// The first call of a day records equity as the start of the day and returns zero.
+func (f *FileState) DailyLoss(account string, today string, equity float64) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	days := map[string]day{}
+	raw, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("can not read risk state %s: %w", f.path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, &days); err != nil {
+			return 0, fmt.Errorf("can not parse risk state %s: %w", f.path, err)
+		}
+	}
+
+	d, ok := days[account]
+	if ok && d.Day == today {
+		return d.StartEquity - equity, nil
+	}
+
+	days[account] = day{Day: today, StartEquity: equity}
+	raw, err = json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		return 0, fmt.Errorf("can not create risk state directory: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return 0, fmt.Errorf("can not write risk state %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return 0, fmt.Errorf("can not write risk state %s: %w", f.path, err)
+	}
+	return 0, nil
+}
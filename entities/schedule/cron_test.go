@@ -0,0 +1,129 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+func jst(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, candle.JST)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want []string
+	}{
+		{
+			name: "Weekdays",
+			spec: "0 9 * * 1-5",
+			// 2024-06-07 is a Friday.
+			from: "2024-06-07 08:59",
+			want: []string{"2024-06-07 09:00", "2024-06-10 09:00", "2024-06-11 09:00"},
+		},
+		{
+			name: "Steps And Lists",
+			spec: "*/20 9,21 * * *",
+			from: "2024-06-07 09:00",
+			want: []string{"2024-06-07 09:20", "2024-06-07 09:40", "2024-06-07 21:00"},
+		},
+		{
+			name: "Names",
+			spec: "30 12 * jan,jul SUN",
+			from: "2024-06-07 00:00",
+			want: []string{"2024-07-07 12:30", "2024-07-14 12:30"},
+		},
+		{
+			name: "Sunday As 7",
+			spec: "0 0 * * 7",
+			from: "2024-06-07 00:00",
+			want: []string{"2024-06-09 00:00"},
+		},
+		{
+			name: "Day Of Month Or Week",
+			spec: "0 0 1 * MON",
+			from: "2024-06-25 00:00",
+			want: []string{"2024-07-01 00:00", "2024-07-08 00:00"},
+		},
+		{
+			name: "Step From A Value",
+			spec: "0 0 25/3 * *",
+			from: "2024-06-25 00:00",
+			want: []string{"2024-06-28 00:00", "2024-07-25 00:00"},
+		},
+		{
+			name: "Leap Day",
+			spec: "0 0 29 2 *",
+			from: "2024-03-01 00:00",
+			want: []string{"2028-02-29 00:00"},
+		},
+		{
+			name: "Macro",
+			spec: "@monthly",
+			from: "2024-06-07 00:00",
+			want: []string{"2024-07-01 00:00"},
+		},
+		{
+			name: "Never",
+			spec: "0 0 30 2 *",
+			from: "2024-06-07 00:00",
+			want: []string{"0001-01-01 00:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			got := jst(tt.from)
+			for _, want := range tt.want {
+				got = c.Next(got)
+				if want == "0001-01-01 00:00" {
+					if !got.IsZero() {
+						t.Errorf("Cron.Next() = %v, want zero", got)
+					}
+					return
+				}
+				if !got.Equal(jst(want)) {
+					t.Errorf("Cron.Next() = %v, want %v", got, want)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "0 9 * * 1-5"},
+		{spec: "@daily"},
+		{spec: "0 9 * *", wantErr: true},
+		{spec: "60 9 * * *", wantErr: true},
+		{spec: "0 9 0 * *", wantErr: true},
+		{spec: "0 9 * 13 *", wantErr: true},
+		{spec: "0 9 * * 8", wantErr: true},
+		{spec: "0 9 * * 5-1", wantErr: true},
+		{spec: "*/0 9 * * *", wantErr: true},
+		{spec: "0 9 * * FOO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+// session is a trading session of the Tokyo Stock Exchange, in minutes from midnight JST.
+type session struct {
+	open, close int
+}
+
+// tseSessions are the morning and afternoon sessions, the afternoon one closing at 15:30 since
+// November 2024.
+var tseSessions = []session{{9 * 60, 11*60 + 30}, {12*60 + 30, 15*60 + 30}}
+
+// Holiday returns the name of the Japanese national holiday on the date of t in JST, including
+// substitute holidays (振替休日) and citizens' holidays (国民の休日). The rules are the ones in
+// force since 2020, with the Olympic moves of 2020 and 2021.
+func Holiday(t time.Time) (string, bool) {
+	t = t.In(candle.JST)
+	name, ok := holidays(t.Year())[date{t.Month(), t.Day()}]
+	return name, ok
+}
+
+// TradingDay reports whether the Tokyo Stock Exchange trades on the date of t in JST: weekdays
+// other than national holidays and the year-end holidays of December 31 to January 3.
+func TradingDay(t time.Time) bool {
+	t = t.In(candle.JST)
+	switch {
+	case t.Weekday() == time.Saturday || t.Weekday() == time.Sunday:
+		return false
+	case t.Month() == time.December && t.Day() == 31:
+		return false
+	case t.Month() == time.January && t.Day() <= 3:
+		return false
+	}
+	_, holiday := Holiday(t)
+	return !holiday
+}
+
+// MarketOpen reports whether the Tokyo Stock Exchange is in a session at t.
+func MarketOpen(t time.Time) bool {
+	t = t.In(candle.JST)
+	if !TradingDay(t) {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, v := range tseSessions {
+		if minute >= v.open && minute < v.close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t when the Tokyo Stock Exchange is in a session, and the opening of the next
+// session otherwise.
+func NextOpen(t time.Time) time.Time {
+	if MarketOpen(t) {
+		return t
+	}
+	t = t.In(candle.JST)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, candle.JST)
+	for i := 0; i < 30; i++ {
+		if TradingDay(day) {
+			for _, v := range tseSessions {
+				open := day.Add(time.Duration(v.open) * time.Minute)
+				if open.After(t) {
+					return open
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+type date struct {
+	month time.Month
+	day   int
+}
+
+var holidayCache sync.Map
+
+// holidays returns the national holidays of year.
+func holidays(year int) map[date]string {
+	if v, ok := holidayCache.Load(year); ok {
+		return v.(map[date]string)
+	}
+
+	days := map[date]string{
+		{time.January, 1}:   "元日",
+		{time.February, 11}: "建国記念の日",
+		{time.February, 23}: "天皇誕生日",
+		{time.April, 29}:    "昭和の日",
+		{time.May, 3}:       "憲法記念日",
+		{time.May, 4}:       "みどりの日",
+		{time.May, 5}:       "こどもの日",
+		{time.November, 3}:  "文化の日",
+		{time.November, 23}: "勤労感謝の日",
+	}
+	days[date{time.January, nthMonday(year, time.January, 2)}] = "成人の日"
+	days[date{time.March, vernalEquinox(year)}] = "春分の日"
+	days[date{time.September, nthMonday(year, time.September, 3)}] = "敬老の日"
+	days[date{time.September, autumnalEquinox(year)}] = "秋分の日"
+	// The holidays around the Tokyo Olympics were moved in 2020 and 2021.
+	switch year {
+	case 2020:
+		days[date{time.July, 23}] = "海の日"
+		days[date{time.July, 24}] = "スポーツの日"
+		days[date{time.August, 10}] = "山の日"
+	case 2021:
+		days[date{time.July, 22}] = "海の日"
+		days[date{time.July, 23}] = "スポーツの日"
+		days[date{time.August, 8}] = "山の日"
+	default:
+		days[date{time.July, nthMonday(year, time.July, 3)}] = "海の日"
+		days[date{time.August, 11}] = "山の日"
+		days[date{time.October, nthMonday(year, time.October, 2)}] = "スポーツの日"
+	}
+
+	// A day between two holidays is a holiday, and a holiday on a Sunday moves to the next day
+	// that is not one.
+	base := map[date]bool{}
+	for k := range days {
+		base[k] = true
+	}
+	for day := time.Date(year, time.January, 2, 0, 0, 0, 0, candle.JST); day.Year() == year; day = day.AddDate(0, 0, 1) {
+		before, after := day.AddDate(0, 0, -1), day.AddDate(0, 0, 1)
+		d := date{day.Month(), day.Day()}
+		if !base[d] && day.Weekday() != time.Sunday && base[date{before.Month(), before.Day()}] && base[date{after.Month(), after.Day()}] {
+			days[d] = "国民の休日"
+		}
+	}
+	for k := range base {
+		day := time.Date(year, k.month, k.day, 0, 0, 0, 0, candle.JST)
+		if day.Weekday() != time.Sunday {
+			continue
+		}
+		for {
+			day = day.AddDate(0, 0, 1)
+			d := date{day.Month(), day.Day()}
+			if _, ok := days[d]; !ok {
+				days[d] = "振替休日"
+				break
+			}
+		}
+	}
+
+	holidayCache.Store(year, days)
+	return days
+}
+
+// nthMonday returns the day of the nth Monday of month.
+func nthMonday(year int, month time.Month, n int) int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, candle.JST).Weekday()
+	return 1 + (int(time.Monday)-int(first)+7)%7 + (n-1)*7
+}
+
+// vernalEquinox and autumnalEquinox approximate the days of the equinoxes, which are accurate
+// for 1980 to 2099.
+func vernalEquinox(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+func autumnalEquinox(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"testing"
+)
+
+func TestHoliday(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{date: "2024-01-08 00:00", want: "成人の日"},
+		{date: "2024-02-12 00:00", want: "振替休日"},
+		{date: "2024-03-20 00:00", want: "春分の日"},
+		{date: "2024-05-06 00:00", want: "振替休日"},
+		{date: "2024-09-22 00:00", want: "秋分の日"},
+		{date: "2024-09-23 00:00", want: "振替休日"},
+		{date: "2024-10-14 00:00", want: "スポーツの日"},
+		{date: "2026-09-21 00:00", want: "敬老の日"},
+		{date: "2026-09-22 00:00", want: "国民の休日"},
+		{date: "2026-09-23 00:00", want: "秋分の日"},
+		{date: "2021-07-23 00:00", want: "スポーツの日"},
+		{date: "2021-10-11 00:00", want: ""},
+		{date: "2024-06-07 00:00", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, ok := Holiday(jst(tt.date))
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("Holiday() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		t    string
+		want string
+	}{
+		{name: "Open", t: "2024-06-07 10:00", want: "2024-06-07 10:00"},
+		{name: "Before The Open", t: "2024-06-07 08:00", want: "2024-06-07 09:00"},
+		{name: "Lunch Break", t: "2024-06-07 11:30", want: "2024-06-07 12:30"},
+		{name: "After The Close", t: "2024-06-07 15:30", want: "2024-06-10 09:00"},
+		{name: "Weekend", t: "2024-06-08 10:00", want: "2024-06-10 09:00"},
+		{name: "Holiday", t: "2024-09-20 16:00", want: "2024-09-24 09:00"},
+		{name: "Year End", t: "2024-12-30 15:30", want: "2025-01-06 09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextOpen(jst(tt.t)); !got.Equal(jst(tt.want)) {
+				t.Errorf("NextOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package schedule runs recurring orders, such as buying 10,000 JPY of BTC every Monday
+// (dollar-cost averaging).
+//
+// A schedule is due at each time matching its cron expression, in JST. Schedules of stocks of
+// kabu STATION are due at the next session of the Tokyo Stock Exchange instead when the market
+// is closed then. Each run is recorded before its order is sent, so that a slot is never run
+// twice, even across restarts.
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/journal"
+)
+
+type OrderType string
+
+const (
+	// Market sends market orders.
+	Market OrderType = "market"
+	// LimitAtBest sends limit orders at the best price of the own side of the board: the best bid
+	// for buys and the best ask for sells, so that they wait in the book instead of crossing it.
+	LimitAtBest OrderType = "limit-at-best"
+)
+
+// OrderTypes are the supported order types.
+var OrderTypes = []OrderType{Market, LimitAtBest}
+
+// Schedule is a recurring order run by `schedule run`.
+type Schedule struct {
+	ID int `json:"id"`
+	// Cron is the cron expression of the times of the orders, in JST.
+	Cron string `json:"cron"`
+	// ProductCode is a product of bitFlyer, such as BTC_JPY, or a symbol of kabu STATION, such as
+	// 1306@1.
+	ProductCode string `json:"product_code"`
+	// Side is "BUY" or "SELL".
+	Side string `json:"side"`
+	// Amount is the JPY of each order, whose size is the amount at the price of the order.
+	// Size is a fixed size instead. One of them is set.
+	Amount    float64   `json:"amount,omitempty"`
+	Size      float64   `json:"size,omitempty"`
+	OrderType OrderType `json:"order_type"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate reports whether the schedule can be run.
+func (s Schedule) Validate() error {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	if c.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron expression %q never matches", s.Cron)
+	}
+	if s.ProductCode == "" {
+		return fmt.Errorf("product code is empty")
+	}
+	if s.Side != "BUY" && s.Side != "SELL" {
+		return fmt.Errorf("side must be BUY or SELL, got %q", s.Side)
+	}
+	if (s.Amount > 0) == (s.Size > 0) {
+		return fmt.Errorf("set either a positive amount in JPY or a positive size")
+	}
+	if s.Amount < 0 || s.Size < 0 || math.IsInf(s.Amount+s.Size, 0) || math.IsNaN(s.Amount+s.Size) {
+		return fmt.Errorf("amount and size must not be negative")
+	}
+	if s.Broker() == journal.Kabucom && s.Size > 0 && s.Size != math.Trunc(s.Size) {
+		return fmt.Errorf("size of stocks must be a whole number of shares, got %v", s.Size)
+	}
+	switch s.OrderType {
+	case Market, LimitAtBest:
+	default:
+		return fmt.Errorf("unsupported order type %q (supported: %s)", s.OrderType, orderTypeNames())
+	}
+	return nil
+}
+
+func orderTypeNames() string {
+	names := make([]string, len(OrderTypes))
+	for i, v := range OrderTypes {
+		names[i] = string(v)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Broker returns the broker of the product: kabu STATION for symbols such as 1306@1, and
+// bitFlyer otherwise.
+func (s Schedule) Broker() string {
+	if strings.Contains(s.ProductCode, "@") {
+		return journal.Kabucom
+	}
+	return journal.BitFlyer
+}
+
+func (s Schedule) String() string {
+	quantity := fmt.Sprintf("%v", s.Size)
+	if s.Amount > 0 {
+		quantity = fmt.Sprintf("%v JPY of", s.Amount)
+	}
+	return fmt.Sprintf("%s %s %s at %s, %q", s.Side, quantity, s.ProductCode, s.OrderType, s.Cron)
+}
+
+// Slot is a time a schedule is due at.
+type Slot struct {
+	// Time is the time matching the cron expression.
+	Time time.Time
+	// At is when the slot is run: Time, or the next session of the market for stocks.
+	At time.Time
+	// Missed is the number of earlier slots after the last run that are not run, because a later
+	// one is due.
+	Missed int
+}
+
+// Due returns the latest slot of s after since which is due at now, if any. since is the time of
+// the last slot run, or the creation of the schedule. Only the latest slot is run after a
+// downtime: the orders of the missed ones are not sent at once.
+func (s Schedule) Due(since time.Time, now time.Time) (Slot, bool) {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return Slot{}, false
+	}
+
+	var due Slot
+	found := false
+	for t := c.Next(since); !t.IsZero() && !t.After(now); t = c.Next(t) {
+		at := t
+		if s.Broker() == journal.Kabucom {
+			at = NextOpen(t)
+		}
+		if at.IsZero() || at.After(now) {
+			break
+		}
+		if found {
+			due.Missed++
+		}
+		due.Time, due.At, found = t, at, true
+	}
+	return due, found
+}
+
+// Next returns the first slot of s after since which is not due at now yet: the next order of
+// the schedule.
+func (s Schedule) Next(since time.Time, now time.Time) (Slot, bool) {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return Slot{}, false
+	}
+	for t := c.Next(since); !t.IsZero(); t = c.Next(t) {
+		at := t
+		if s.Broker() == journal.Kabucom {
+			at = NextOpen(t)
+		}
+		if at.After(now) {
+			return Slot{Time: t, At: at}, true
+		}
+	}
+	return Slot{}, false
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"testing"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+	valid := Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: Market}
+	tests := []struct {
+		name    string
+		edit    func(s *Schedule)
+		wantErr bool
+	}{
+		{name: "Amount", edit: func(s *Schedule) {}},
+		{name: "Size", edit: func(s *Schedule) { s.Amount, s.Size = 0, 0.01 }},
+		{name: "Stock", edit: func(s *Schedule) { s.ProductCode, s.OrderType = "1306@1", LimitAtBest }},
+		{name: "Invalid Cron", edit: func(s *Schedule) { s.Cron = "0 9 * *" }, wantErr: true},
+		{name: "Never", edit: func(s *Schedule) { s.Cron = "0 9 31 2 *" }, wantErr: true},
+		{name: "No Product", edit: func(s *Schedule) { s.ProductCode = "" }, wantErr: true},
+		{name: "Invalid Side", edit: func(s *Schedule) { s.Side = "buy" }, wantErr: true},
+		{name: "Amount And Size", edit: func(s *Schedule) { s.Size = 0.01 }, wantErr: true},
+		{name: "Neither", edit: func(s *Schedule) { s.Amount = 0 }, wantErr: true},
+		{name: "Fractional Shares", edit: func(s *Schedule) { s.ProductCode, s.Amount, s.Size = "1306@1", 0, 1.5 }, wantErr: true},
+		{name: "Invalid Order Type", edit: func(s *Schedule) { s.OrderType = "stop" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.edit(&s)
+			if err := s.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Schedule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedule_Due(t *testing.T) {
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		since      string
+		now        string
+		want       bool
+		wantSlot   string
+		wantAt     string
+		wantMissed int
+	}{
+		{
+			name:     "Due",
+			schedule: Schedule{Cron: "0 9 * * *", ProductCode: "BTC_JPY"},
+			since:    "2024-06-06 09:00",
+			now:      "2024-06-07 09:00",
+			want:     true,
+			wantSlot: "2024-06-07 09:00",
+			wantAt:   "2024-06-07 09:00",
+		},
+		{
+			name:     "Not Yet",
+			schedule: Schedule{Cron: "0 9 * * *", ProductCode: "BTC_JPY"},
+			since:    "2024-06-07 09:00",
+			now:      "2024-06-08 08:59",
+		},
+		{
+			name:       "Latest Of Missed Slots",
+			schedule:   Schedule{Cron: "0 9 * * *", ProductCode: "BTC_JPY"},
+			since:      "2024-06-04 09:00",
+			now:        "2024-06-07 10:00",
+			want:       true,
+			wantSlot:   "2024-06-07 09:00",
+			wantAt:     "2024-06-07 09:00",
+			wantMissed: 2,
+		},
+		{
+			// 2024-06-08 is a Saturday.
+			name:     "Stock Waits For The Market",
+			schedule: Schedule{Cron: "0 10 * * *", ProductCode: "1306@1"},
+			since:    "2024-06-07 10:00",
+			now:      "2024-06-08 10:00",
+		},
+		{
+			name:       "Stock At The Next Open",
+			schedule:   Schedule{Cron: "0 10 * * *", ProductCode: "1306@1"},
+			since:      "2024-06-07 10:00",
+			now:        "2024-06-10 09:00",
+			want:       true,
+			wantSlot:   "2024-06-09 10:00",
+			wantAt:     "2024-06-10 09:00",
+			wantMissed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.schedule.Due(jst(tt.since), jst(tt.now))
+			if ok != tt.want {
+				t.Fatalf("Schedule.Due() = %+v, %v, want %v", got, ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if !got.Time.Equal(jst(tt.wantSlot)) || !got.At.Equal(jst(tt.wantAt)) || got.Missed != tt.wantMissed {
+				t.Errorf("Schedule.Due() = %+v, want %s at %s, %d missed", got, tt.wantSlot, tt.wantAt, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	s := Schedule{Cron: "0 10 * * *", ProductCode: "1306@1"}
+	// The Saturday slot waits for Monday, and is next until then.
+	got, ok := s.Next(jst("2024-06-07 10:00"), jst("2024-06-08 12:00"))
+	if !ok || !got.Time.Equal(jst("2024-06-08 10:00")) || !got.At.Equal(jst("2024-06-10 09:00")) {
+		t.Errorf("Schedule.Next() = %+v, %v", got, ok)
+	}
+}
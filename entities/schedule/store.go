@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type RunStatus string
+
+const (
+	// Sending is recorded before the order of a run is sent. A run left sending was interrupted,
+	// and its order may or may not have been sent: check the orders of the broker.
+	Sending RunStatus = "sending"
+	Sent    RunStatus = "sent"
+	Failed  RunStatus = "failed"
+	// Missed is a slot found too late to be run, such as after a long downtime.
+	Missed RunStatus = "missed"
+)
+
+// Run is the order of a slot of a schedule.
+type Run struct {
+	ScheduleID int `json:"schedule_id"`
+	// Slot is the time of the cron expression the run is for.
+	Slot   time.Time `json:"slot"`
+	Time   time.Time `json:"time"`
+	Status RunStatus `json:"status"`
+	// OrderID is the order ID of the broker.
+	OrderID string  `json:"order_id,omitempty"`
+	Size    float64 `json:"size,omitempty"`
+	// Price is the limit price of the order, zero for market orders.
+	Price float64 `json:"price,omitempty"`
+	Error string  `json:"error,omitempty"`
+}
+
+// maxRuns is the number of runs kept for each schedule.
+const maxRuns = 100
+
+// file is the content of the schedules file.
+type file struct {
+	Schedules []Schedule `json:"schedules"`
+	Runs      []Run      `json:"runs"`
+}
+
+// FileStore keeps schedules and their runs in a JSON file, so that `schedule add` and
+// `schedule remove` change the schedules of a running `schedule run`, and a restarted one knows
+// the slots already run.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore backed by the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultPath returns the default path of the schedules file.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".capital-go", "schedules.json")
+}
+
+// Load returns the schedules ordered by id. A missing file has no schedules.
+func (f *FileStore) Load() ([]Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return content.Schedules, nil
+}
+
+// Add stores s with the next id, and returns it.
+func (f *FileStore) Add(s Schedule) (Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.load()
+	if err != nil {
+		return Schedule{}, err
+	}
+	s.ID = 1
+	for _, v := range content.Schedules {
+		if v.ID >= s.ID {
+			s.ID = v.ID + 1
+		}
+	}
+	// The ids of removed schedules are not reused, so that their runs are not taken for the ones
+	// of a new schedule.
+	for _, v := range content.Runs {
+		if v.ScheduleID >= s.ID {
+			s.ID = v.ScheduleID + 1
+		}
+	}
+	content.Schedules = append(content.Schedules, s)
+	if err := f.save(content); err != nil {
+		return Schedule{}, err
+	}
+	return s, nil
+}
+
+// Remove deletes the schedule id. Its runs are kept. It fails with ErrResourceNotFound when
+// there is none.
+func (f *FileStore) Remove(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.load()
+	if err != nil {
+		return err
+	}
+	for i, v := range content.Schedules {
+		if v.ID == id {
+			content.Schedules = append(content.Schedules[:i], content.Schedules[i+1:]...)
+			return f.save(content)
+		}
+	}
+	return fmt.Errorf("%w: schedule %d", cerror.ErrResourceNotFound, id)
+}
+
+// Runs returns the runs of the schedule id, oldest first, or of every schedule when id is zero.
+func (f *FileStore) Runs(id int) ([]Run, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	runs := []Run{}
+	for _, v := range content.Runs {
+		if id == 0 || v.ScheduleID == id {
+			runs = append(runs, v)
+		}
+	}
+	return runs, nil
+}
+
+// Record stores run, replacing the run of the same schedule and slot. Unless replace is set, it
+// fails with ErrBadRequest when the slot already has a run, so that a slot is claimed only once.
+func (f *FileStore) Record(run Run, replace bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.load()
+	if err != nil {
+		return err
+	}
+	for i, v := range content.Runs {
+		if v.ScheduleID == run.ScheduleID && v.Slot.Equal(run.Slot) {
+			if !replace {
+				return fmt.Errorf("%w: slot %s of schedule %d has already run", cerror.ErrBadRequest, run.Slot, run.ScheduleID)
+			}
+			content.Runs[i] = run
+			return f.save(content)
+		}
+	}
+	content.Runs = append(content.Runs, run)
+	return f.save(content)
+}
+
+func (f *FileStore) load() (*file, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &file{Schedules: []Schedule{}, Runs: []Run{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not read schedules %s: %w", f.path, err)
+	}
+	content := &file{}
+	if err := json.Unmarshal(raw, content); err != nil {
+		return nil, fmt.Errorf("can not parse schedules %s: %w", f.path, err)
+	}
+	if content.Schedules == nil {
+		content.Schedules = []Schedule{}
+	}
+	if content.Runs == nil {
+		content.Runs = []Run{}
+	}
+	sort.Slice(content.Schedules, func(i, j int) bool { return content.Schedules[i].ID < content.Schedules[j].ID })
+	return content, nil
+}
+
+func (f *FileStore) save(content *file) error {
+	// Only the latest runs of each schedule are kept; the latest one is the slot a restarted
+	// `schedule run` resumes from.
+	sort.SliceStable(content.Runs, func(i, j int) bool { return content.Runs[i].Slot.Before(content.Runs[j].Slot) })
+	counts := map[int]int{}
+	for i := len(content.Runs) - 1; i >= 0; i-- {
+		id := content.Runs[i].ScheduleID
+		counts[id]++
+		if counts[id] > maxRuns {
+			content.Runs = append(content.Runs[:i], content.Runs[i+1:]...)
+		}
+	}
+
+	raw, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0775); err != nil {
+		return fmt.Errorf("can not create schedules directory: %w", err)
+	}
+	// Replace the file at once, so that a running `schedule run` never reads it half written.
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0664); err != nil {
+		return fmt.Errorf("can not write schedules %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("can not write schedules %s: %w", f.path, err)
+	}
+	return nil
+}
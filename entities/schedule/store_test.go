@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+func TestFileStore(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "dir", "schedules.json"))
+
+	schedules, err := f.Load()
+	if err != nil || len(schedules) != 0 {
+		t.Fatalf("FileStore.Load() = %v, %v, want no schedules", schedules, err)
+	}
+
+	for _, v := range []Schedule{
+		{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: Market},
+		{Cron: "0 10 * * 1", ProductCode: "1306@1", Side: "BUY", Size: 10, OrderType: Market},
+	} {
+		if _, err := f.Add(v); err != nil {
+			t.Fatalf("FileStore.Add() error = %v", err)
+		}
+	}
+
+	run := Run{ScheduleID: 2, Slot: jst("2024-06-10 10:00"), Time: jst("2024-06-10 10:00"), Status: Sending}
+	if err := f.Record(run, false); err != nil {
+		t.Fatalf("FileStore.Record() error = %v", err)
+	}
+	// A slot is claimed once.
+	if err := f.Record(run, false); !errors.Is(err, cerror.ErrBadRequest) {
+		t.Errorf("FileStore.Record() error = %v, expectedError %v", err, cerror.ErrBadRequest)
+	}
+	run.Status, run.OrderID = Sent, "order-1"
+	if err := f.Record(run, true); err != nil {
+		t.Fatalf("FileStore.Record() error = %v", err)
+	}
+	runs, err := f.Runs(2)
+	if err != nil || len(runs) != 1 || runs[0].Status != Sent || runs[0].OrderID != "order-1" {
+		t.Errorf("FileStore.Runs() = %+v, %v", runs, err)
+	}
+
+	if err := f.Remove(2); err != nil {
+		t.Fatalf("FileStore.Remove() error = %v", err)
+	}
+	if err := f.Remove(2); !errors.Is(err, cerror.ErrResourceNotFound) {
+		t.Errorf("FileStore.Remove() error = %v, expectedError %v", err, cerror.ErrResourceNotFound)
+	}
+
+	// The id of a removed schedule with runs is not reused.
+	added, err := f.Add(Schedule{Cron: "@daily", ProductCode: "ETH_JPY", Side: "BUY", Size: 0.01, OrderType: Market})
+	if err != nil || added.ID != 3 {
+		t.Errorf("FileStore.Add() = %v, %v, want id 3", added, err)
+	}
+	runs, err = f.Runs(0)
+	if err != nil || len(runs) != 1 {
+		t.Errorf("FileStore.Runs() = %+v, %v, want the run of the removed schedule", runs, err)
+	}
+}
@@ -0,0 +1,88 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// smaCross trades crosses of two simple moving averages of closes.
+type smaCross struct {
+	fast   int
+	slow   int
+	size   float64
+	closes []float64
+	// above reports whether the fast average was above the slow one on the previous bar.
+	above *bool
+}
+
+func newSMACross(p Params) (Strategy, error) {
+	fast, slow := int(p["fast"]), int(p["slow"])
+	if fast < 1 || slow <= fast {
+		return nil, fmt.Errorf("sma-cross needs 1 <= fast < slow, got fast=%v slow=%v", p["fast"], p["slow"])
+	}
+	if p["size"] <= 0 {
+		return nil, fmt.Errorf("sma-cross needs a positive size, got %v", p["size"])
+	}
+	return &smaCross{fast: fast, slow: slow, size: p["size"]}, nil
+}
+
+func (s *smaCross) OnTrade(broker Broker, trade candle.Trade) {}
+
+func (s *smaCross) OnBar(broker Broker, bar candle.Candle) {
+	s.closes = append(s.closes, bar.Close)
+	if len(s.closes) > s.slow {
+		s.closes = s.closes[1:]
+	}
+	if len(s.closes) < s.slow {
+		return
+	}
+
+	above := average(s.closes[s.slow-s.fast:]) > average(s.closes)
+	crossed := s.above != nil && *s.above != above
+	s.above = &above
+	if !crossed {
+		return
+	}
+
+	position := broker.Position()
+	if above && position == 0 {
+		broker.Send(Order{Side: bitflyer.SideBuy, Size: s.size})
+	}
+	if !above && position > 0 {
+		broker.Send(Order{Side: bitflyer.SideSell, Size: position})
+	}
+}
+
+func average(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// buyAndHold buys once, as a benchmark for other strategies.
+type buyAndHold struct {
+	size   float64
+	bought bool
+}
+
+func newBuyAndHold(p Params) (Strategy, error) {
+	if p["size"] <= 0 {
+		return nil, fmt.Errorf("buy-and-hold needs a positive size, got %v", p["size"])
+	}
+	return &buyAndHold{size: p["size"]}, nil
+}
+
+func (s *buyAndHold) OnTrade(broker Broker, trade candle.Trade) {}
+
+func (s *buyAndHold) OnBar(broker Broker, bar candle.Candle) {
+	if s.bought {
+		return
+	}
+	if _, err := broker.Send(Order{Side: bitflyer.SideBuy, Size: s.size}); err == nil {
+		s.bought = true
+	}
+}
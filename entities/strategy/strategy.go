@@ -0,0 +1,130 @@
+// Package strategy defines the interface of trading strategies and the built-in ones.
+//
+// The same Strategy runs in backtests over stored executions and live against a broker,
+// so strategies only see trades, closed candles and a Broker to place orders with.
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// Order is an order placed by a strategy on its product.
+type Order struct {
+	Side bitflyer.ChildOrderSide
+	// Price is the limit price. Zero is a market order.
+	Price float64
+	Size  float64
+}
+
+// Broker places orders of a strategy.
+type Broker interface {
+	// Send places an order and returns its id.
+	Send(order Order) (string, error)
+	// Cancel cancels a resting limit order.
+	Cancel(id string) error
+	// Position returns the base currency held by the strategy.
+	Position() float64
+}
+
+// Strategy reacts to market data by placing orders.
+type Strategy interface {
+	// OnTrade is called for each execution of the product.
+	OnTrade(broker Broker, trade candle.Trade)
+	// OnBar is called when a candle closes. Candles without trades repeat the previous close.
+	OnBar(broker Broker, bar candle.Candle)
+}
+
+// Params are numeric parameters of a strategy by name.
+type Params map[string]float64
+
+// ParseParams parses values such as "fast=5".
+func ParseParams(values []string) (Params, error) {
+	params := Params{}
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid parameter %q (expected name=value)", v)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter %q: %w", v, err)
+		}
+		params[key] = f
+	}
+	return params, nil
+}
+
+// definition is a built-in strategy.
+type definition struct {
+	description string
+	defaults    Params
+	new         func(p Params) (Strategy, error)
+}
+
+var builtins = map[string]definition{
+	"sma-cross": {
+		description: "buy when the fast moving average of closes crosses above the slow one, sell all when it crosses below",
+		defaults:    Params{"fast": 5, "slow": 20, "size": 0.01},
+		new:         newSMACross,
+	},
+	"buy-and-hold": {
+		description: "buy once on the first candle and hold",
+		defaults:    Params{"size": 0.01},
+		new:         newBuyAndHold,
+	},
+}
+
+// Names returns the names of the built-in strategies.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for k := range builtins {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Describe returns the description and the default parameters of a built-in strategy.
+func Describe(name string) (string, Params, bool) {
+	d, ok := builtins[name]
+	if !ok {
+		return "", nil, false
+	}
+	return d.description, d.defaults, true
+}
+
+// New returns the built-in strategy name. params override its defaults.
+func New(name string, params Params) (Strategy, error) {
+	merged, err := Resolve(name, params)
+	if err != nil {
+		return nil, err
+	}
+	return builtins[name].new(merged)
+}
+
+// Resolve returns the parameters the built-in strategy name runs with: its defaults overridden
+// by params.
+func Resolve(name string, params Params) (Params, error) {
+	d, ok := builtins[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	merged := Params{}
+	for k, v := range d.defaults {
+		merged[k] = v
+	}
+	for k, v := range params {
+		if _, ok := d.defaults[k]; !ok {
+			return nil, fmt.Errorf("strategy %s has no parameter %q", name, k)
+		}
+		merged[k] = v
+	}
+	return merged, nil
+}
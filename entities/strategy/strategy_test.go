@@ -0,0 +1,124 @@
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// fakeBroker fills every order at once.
+type fakeBroker struct {
+	position float64
+	orders   []Order
+}
+
+func (b *fakeBroker) Send(order Order) (string, error) {
+	b.orders = append(b.orders, order)
+	if order.Side == bitflyer.SideBuy {
+		b.position += order.Size
+	} else {
+		b.position -= order.Size
+	}
+	return "", nil
+}
+
+func (b *fakeBroker) Cancel(id string) error { return nil }
+
+func (b *fakeBroker) Position() float64 { return b.position }
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    Params
+		wantErr bool
+	}{
+		{name: "Valid", values: []string{"fast=3", "size=0.5"}, want: Params{"fast": 3, "size": 0.5}},
+		{name: "No Value", values: []string{"fast"}, wantErr: true},
+		{name: "Not A Number", values: []string{"fast=x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseParams(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseParams() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		params   Params
+		wantErr  bool
+	}{
+		{name: "Defaults", strategy: "sma-cross"},
+		{name: "Override", strategy: "sma-cross", params: Params{"fast": 2, "slow": 3}},
+		{name: "Unknown Strategy", strategy: "martingale", wantErr: true},
+		{name: "Unknown Parameter", strategy: "buy-and-hold", params: Params{"fast": 2}, wantErr: true},
+		{name: "Invalid Parameter", strategy: "sma-cross", params: Params{"fast": 20, "slow": 5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.strategy, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		params   Params
+		want     Params
+		wantErr  bool
+	}{
+		{name: "Defaults", strategy: "buy-and-hold", want: Params{"size": 0.01}},
+		{name: "Override", strategy: "sma-cross", params: Params{"size": 0.1}, want: Params{"fast": 5, "slow": 20, "size": 0.1}},
+		{name: "Unknown Strategy", strategy: "martingale", wantErr: true},
+		{name: "Unknown Parameter", strategy: "buy-and-hold", params: Params{"fast": 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.strategy, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMACross(t *testing.T) {
+	s, err := New("sma-cross", Params{"fast": 1, "slow": 2, "size": 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker := &fakeBroker{}
+	for i, v := range []float64{100, 99, 98, 101, 102, 100, 99} {
+		s.OnBar(broker, candle.Candle{Time: time.Unix(int64(i*60), 0), Close: v})
+	}
+
+	want := []Order{
+		{Side: bitflyer.SideBuy, Size: 0.1},
+		{Side: bitflyer.SideSell, Size: 0.1},
+	}
+	if !reflect.DeepEqual(broker.orders, want) {
+		t.Errorf("orders = %v, want %v", broker.orders, want)
+	}
+}
@@ -0,0 +1,291 @@
+// Package tax computes the figures of Japanese tax returns from the trading history: the
+// miscellaneous income of crypto assets, as the calculation sheets of the National Tax Agency
+// (暗号資産の計算書) compute it, and the capital gains of stocks.
+//
+// Years are calendar years in JST. Amounts are in JPY.
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/pnl"
+)
+
+// Trade is a fill of a crypto asset against JPY.
+type Trade struct {
+	Time     time.Time
+	Currency string
+	// Side is "BUY" or "SELL".
+	Side  string
+	Price float64
+	Size  float64
+	// Commission is charged in the asset, as bitFlyer does: buys receive Size less the commission
+	// and sells deliver Size plus the commission.
+	Commission float64
+}
+
+// Transfer is a deposit or a withdrawal of a crypto asset between wallets of the same owner.
+type Transfer struct {
+	Time     time.Time
+	Currency string
+	// Amount is positive for deposits and negative for withdrawals.
+	Amount float64
+	// Fee is charged in the asset, on top of Amount.
+	Fee float64
+}
+
+// Kinds of entries.
+const (
+	EntryBuy        = "buy"
+	EntrySell       = "sell"
+	EntryDeposit    = "deposit"
+	EntryWithdrawal = "withdrawal"
+)
+
+// Holding is a quantity of an asset and its cost.
+type Holding struct {
+	Quantity float64 `json:"quantity"`
+	Amount   float64 `json:"amount"`
+}
+
+// UnitCost returns the cost of a unit, zero without quantity.
+func (h Holding) UnitCost() float64 {
+	if h.Quantity <= execution.SizeEpsilon {
+		return 0
+	}
+	return h.Amount / h.Quantity
+}
+
+// Entry is a line of the ledger of an asset.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Quantity is the quantity bought, sold or transferred, without the fee.
+	Quantity float64 `json:"quantity"`
+	// Price is the price of trades and the unit cost of transfers.
+	Price float64 `json:"price"`
+	// Amount is paid by buys, received by sells, and the cost moved by transfers.
+	Amount float64 `json:"amount"`
+	// Fee is the quantity charged as the commission of the trade or the fee of the transfer.
+	Fee float64 `json:"fee"`
+	// Balance is the holding after the entry.
+	Balance Holding `json:"balance"`
+}
+
+// CurrencyReport is the calculation of an asset for a year.
+type CurrencyReport struct {
+	Currency string `json:"currency"`
+	// Opening is the holding at the beginning of the year and Closing at its end.
+	Opening Holding `json:"opening"`
+	// Bought is the quantity received and the amount paid by buys.
+	Bought Holding `json:"bought"`
+	// Sold is the quantity delivered and the amount received by sells (売却価額).
+	Sold    Holding `json:"sold"`
+	Closing Holding `json:"closing"`
+	// AverageCost is the average of the year (総平均単価) with the total-average method, and the
+	// unit cost at the end of the year with the moving-average method.
+	AverageCost float64 `json:"average_cost"`
+	// Cost is the cost of the quantity sold (売却原価).
+	Cost float64 `json:"cost"`
+	// Expenses (必要経費) are the cost of commissions of sells and fees of withdrawals.
+	Expenses float64 `json:"expenses"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Income returns the income of the asset (所得金額).
+func (c CurrencyReport) Income() float64 {
+	return c.Sold.Amount - c.Cost - c.Expenses
+}
+
+// CryptoReport is the miscellaneous income of crypto assets for a year.
+type CryptoReport struct {
+	Year   int        `json:"year"`
+	Method pnl.Method `json:"method"`
+	// Currencies are the assets held or traded in the year, sorted by name.
+	Currencies []CurrencyReport `json:"currencies"`
+}
+
+// Income returns the income of every asset.
+func (r *CryptoReport) Income() float64 {
+	income := 0.0
+	for _, v := range r.Currencies {
+		income += v.Income()
+	}
+	return income
+}
+
+// event is a trade or a transfer.
+type event struct {
+	time     time.Time
+	trade    *Trade
+	transfer *Transfer
+}
+
+// Crypto returns the calculation of year with method, pnl.MovingAverage (移動平均法) or
+// pnl.TotalAverage (総平均法). Trades and transfers of the previous years make the opening holdings.
+//
+// Withdrawals move the quantity out at its cost, and deposits in at the cost of the holding, as
+// transfers between wallets of the same owner. Coins acquired elsewhere are not valued: record their
+// purchase instead. Selling or withdrawing more than held fails, as the history is then incomplete.
+func Crypto(year int, method pnl.Method, trades []Trade, transfers []Transfer) (*CryptoReport, error) {
+	if method != pnl.MovingAverage && method != pnl.TotalAverage {
+		return nil, fmt.Errorf("unsupported method %q (supported: %s, %s)", method, pnl.MovingAverage, pnl.TotalAverage)
+	}
+
+	events := map[string][]event{}
+	for i := range trades {
+		v := &trades[i]
+		if v.Side != "BUY" && v.Side != "SELL" {
+			return nil, fmt.Errorf("unsupported side %q of a trade of %s", v.Side, v.Currency)
+		}
+		events[v.Currency] = append(events[v.Currency], event{time: v.Time, trade: v})
+	}
+	for i := range transfers {
+		v := &transfers[i]
+		events[v.Currency] = append(events[v.Currency], event{time: v.Time, transfer: v})
+	}
+
+	r := &CryptoReport{Year: year, Method: method, Currencies: []CurrencyReport{}}
+	for currency, e := range events {
+		sort.SliceStable(e, func(a, b int) bool { return e[a].time.Before(e[b].time) })
+		c, err := calculate(currency, year, method, e)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			r.Currencies = append(r.Currencies, *c)
+		}
+	}
+	sort.Slice(r.Currencies, func(a, b int) bool { return r.Currencies[a].Currency < r.Currencies[b].Currency })
+	return r, nil
+}
+
+// calculate returns the calculation of currency for year, or nil when it is neither held nor traded.
+func calculate(currency string, year int, method pnl.Method, events []event) (*CurrencyReport, error) {
+	holding := Holding{}
+	for start := 0; start < len(events); {
+		y := events[start].time.In(candle.JST).Year()
+		if y > year {
+			break
+		}
+		end := start
+		for end < len(events) && events[end].time.In(candle.JST).Year() == y {
+			end++
+		}
+
+		c := &CurrencyReport{Currency: currency, Opening: holding, Entries: []Entry{}}
+		average := 0.0
+		if method == pnl.TotalAverage {
+			bought := holding
+			for _, v := range events[start:end] {
+				if v.trade != nil && v.trade.Side == "BUY" {
+					bought.Quantity += v.trade.Size - v.trade.Commission
+					bought.Amount += v.trade.Price * v.trade.Size
+				}
+			}
+			average = bought.UnitCost()
+		}
+		for _, v := range events[start:end] {
+			unit := holding.UnitCost()
+			if method == pnl.TotalAverage {
+				unit = average
+			}
+			entry, err := c.apply(&holding, unit, v)
+			if err != nil {
+				return nil, err
+			}
+			if method == pnl.TotalAverage {
+				holding.Amount = holding.Quantity * average
+			}
+			entry.Balance = holding
+			c.Entries = append(c.Entries, entry)
+		}
+		c.Closing = holding
+		c.AverageCost = average
+		if method == pnl.MovingAverage {
+			c.AverageCost = holding.UnitCost()
+		}
+
+		if y == year {
+			return c, nil
+		}
+		start = end
+	}
+
+	if holding.Quantity == 0 {
+		return nil, nil
+	}
+	// Held through the year without any event.
+	return &CurrencyReport{
+		Currency:    currency,
+		Opening:     holding,
+		Closing:     holding,
+		AverageCost: holding.UnitCost(),
+		Entries:     []Entry{},
+	}, nil
+}
+
+// apply applies v to holding, valuing the quantity taken out at unit, and returns its entry.
+func (c *CurrencyReport) apply(holding *Holding, unit float64, v event) (Entry, error) {
+	if t := v.trade; t != nil {
+		entry := Entry{Time: t.Time, Quantity: t.Size, Price: t.Price, Amount: t.Price * t.Size, Fee: t.Commission}
+		if t.Side == "BUY" {
+			entry.Kind = EntryBuy
+			holding.Quantity += t.Size - t.Commission
+			holding.Amount += entry.Amount
+			c.Bought.Quantity += t.Size - t.Commission
+			c.Bought.Amount += entry.Amount
+			return entry, nil
+		}
+
+		entry.Kind = EntrySell
+		if err := take(holding, unit, t.Size+t.Commission, c.Currency, t.Time); err != nil {
+			return Entry{}, err
+		}
+		c.Sold.Quantity += t.Size
+		c.Sold.Amount += entry.Amount
+		c.Cost += unit * t.Size
+		c.Expenses += unit * t.Commission
+		return entry, nil
+	}
+
+	t := v.transfer
+	entry := Entry{Time: t.Time, Quantity: math.Abs(t.Amount), Price: unit, Amount: unit * math.Abs(t.Amount), Fee: t.Fee}
+	if t.Amount >= 0 {
+		entry.Kind = EntryDeposit
+		holding.Quantity += t.Amount
+		holding.Amount += entry.Amount
+		// A fee of a deposit is charged on the deposited quantity.
+		if err := take(holding, unit, t.Fee, c.Currency, t.Time); err != nil {
+			return Entry{}, err
+		}
+		c.Expenses += unit * t.Fee
+		return entry, nil
+	}
+
+	entry.Kind = EntryWithdrawal
+	if err := take(holding, unit, -t.Amount+t.Fee, c.Currency, t.Time); err != nil {
+		return Entry{}, err
+	}
+	c.Expenses += unit * t.Fee
+	return entry, nil
+}
+
+// take takes quantity out of holding at unit.
+func take(holding *Holding, unit float64, quantity float64, currency string, at time.Time) error {
+	if quantity > holding.Quantity+execution.SizeEpsilon {
+		return fmt.Errorf("%v %s taken out on %s is more than the %v held: the trades or deposits before it are missing",
+			quantity, currency, at.In(candle.JST).Format(time.RFC3339), holding.Quantity)
+	}
+	holding.Quantity -= quantity
+	holding.Amount -= unit * quantity
+	if holding.Quantity <= execution.SizeEpsilon {
+		*holding = Holding{}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package tax
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/pnl"
+)
+
+func jst(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, candle.JST)
+}
+
+func testHistory() ([]Trade, []Transfer) {
+	trades := []Trade{
+		{Time: jst(2025, 3, 1), Currency: "BTC", Side: "BUY", Price: 1000000, Size: 1},
+		{Time: jst(2025, 3, 1), Currency: "ETH", Side: "BUY", Price: 200000, Size: 2},
+		{Time: jst(2026, 1, 10), Currency: "BTC", Side: "SELL", Price: 3000000, Size: 0.5},
+		// 1 BTC is received for 2,500,000.
+		{Time: jst(2026, 2, 1), Currency: "BTC", Side: "BUY", Price: 2000000, Size: 1.25, Commission: 0.25},
+		{Time: jst(2026, 4, 1), Currency: "BTC", Side: "SELL", Price: 3000000, Size: 1, Commission: 0.01},
+		{Time: jst(2027, 1, 1), Currency: "BTC", Side: "SELL", Price: 9000000, Size: 9},
+	}
+	transfers := []Transfer{
+		{Time: jst(2026, 3, 1), Currency: "BTC", Amount: 0.5},
+		{Time: jst(2026, 5, 1), Currency: "BTC", Amount: -0.2, Fee: 0.01},
+	}
+	return trades, transfers
+}
+
+func TestCrypto(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   pnl.Method
+		expected CurrencyReport
+		income   float64
+	}{
+		{
+			name:   "moving average",
+			method: pnl.MovingAverage,
+			expected: CurrencyReport{
+				Opening:     Holding{Quantity: 1, Amount: 1000000},
+				Bought:      Holding{Quantity: 1, Amount: 2500000},
+				Sold:        Holding{Quantity: 1.5, Amount: 4500000},
+				Closing:     Holding{Quantity: 0.78, Amount: 1560000},
+				AverageCost: 2000000,
+				// 0.5 at 1,000,000 then 1 at 2,000,000.
+				Cost: 2500000,
+				// The commission of the sell and the fee of the withdrawal, 0.01 each at 2,000,000.
+				Expenses: 40000,
+			},
+			income: 1960000,
+		},
+		{
+			name:   "total average",
+			method: pnl.TotalAverage,
+			expected: CurrencyReport{
+				Opening: Holding{Quantity: 1, Amount: 1000000},
+				Bought:  Holding{Quantity: 1, Amount: 2500000},
+				Sold:    Holding{Quantity: 1.5, Amount: 4500000},
+				Closing: Holding{Quantity: 0.78, Amount: 1365000},
+				// (1,000,000 + 2,500,000) / 2, the sell before the buy included.
+				AverageCost: 1750000,
+				Cost:        2625000,
+				Expenses:    35000,
+			},
+			income: 1840000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades, transfers := testHistory()
+			got, err := Crypto(2026, tt.method, trades, transfers)
+			if err != nil {
+				t.Fatalf("Crypto() error = %v", err)
+			}
+			if len(got.Currencies) != 2 || got.Currencies[0].Currency != "BTC" || got.Currencies[1].Currency != "ETH" {
+				t.Fatalf("Crypto() = %+v, expected BTC and ETH", got.Currencies)
+			}
+
+			c := got.Currencies[0]
+			e := tt.expected
+			if !nearHolding(c.Opening, e.Opening) || !nearHolding(c.Bought, e.Bought) || !nearHolding(c.Sold, e.Sold) || !nearHolding(c.Closing, e.Closing) ||
+				!near(c.AverageCost, e.AverageCost) || !near(c.Cost, e.Cost) || !near(c.Expenses, e.Expenses) {
+				t.Errorf("Crypto() BTC = %+v, expected %+v", c, e)
+			}
+			if !near(got.Income(), tt.income) {
+				t.Errorf("Crypto() income = %v, expected %v", got.Income(), tt.income)
+			}
+
+			kinds := []string{EntrySell, EntryBuy, EntryDeposit, EntrySell, EntryWithdrawal}
+			if len(c.Entries) != len(kinds) {
+				t.Fatalf("Crypto() entries = %+v", c.Entries)
+			}
+			for i, v := range c.Entries {
+				if v.Kind != kinds[i] {
+					t.Errorf("Crypto() entry %d = %+v, expected %s", i, v, kinds[i])
+				}
+			}
+			if !nearHolding(c.Entries[len(c.Entries)-1].Balance, e.Closing) {
+				t.Errorf("Crypto() last balance = %+v, expected %+v", c.Entries[len(c.Entries)-1].Balance, e.Closing)
+			}
+
+			// ETH is held through the year.
+			eth := got.Currencies[1]
+			if eth.Opening != (Holding{Quantity: 2, Amount: 400000}) || eth.Closing != eth.Opening || eth.Income() != 0 {
+				t.Errorf("Crypto() ETH = %+v", eth)
+			}
+		})
+	}
+}
+
+func TestCrypto_errors(t *testing.T) {
+	trades, transfers := testHistory()
+	if _, err := Crypto(2026, pnl.FIFO, trades, transfers); err == nil {
+		t.Errorf("Crypto() error = nil, expected one for fifo")
+	}
+	transfers = append(transfers, Transfer{Time: jst(2026, 6, 1), Currency: "BTC", Amount: -1})
+	if _, err := Crypto(2026, pnl.MovingAverage, trades, transfers); err == nil {
+		t.Errorf("Crypto() error = nil, expected one for a withdrawal beyond the holding")
+	}
+	// The history after the year does not matter.
+	if _, err := Crypto(2025, pnl.MovingAverage, trades, transfers); err != nil {
+		t.Errorf("Crypto() error = %v, expected none", err)
+	}
+}
+
+func near(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func nearHolding(a Holding, b Holding) bool {
+	return near(a.Quantity, b.Quantity) && near(a.Amount, b.Amount)
+}
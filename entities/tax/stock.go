@@ -0,0 +1,110 @@
+package tax
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+)
+
+// StockTrade is a fill of a stock.
+type StockTrade struct {
+	Time   time.Time
+	Symbol string
+	// Side is "BUY" or "SELL".
+	Side  string
+	Price float64
+	Qty   float64
+	// Commission is charged in JPY, including the consumption tax.
+	Commission float64
+}
+
+// SymbolReport is the capital gains of a stock for a year.
+type SymbolReport struct {
+	Symbol string `json:"symbol"`
+	// Qty is the quantity sold, and Proceeds the amount received (譲渡収入金額).
+	Qty      float64 `json:"qty"`
+	Proceeds float64 `json:"proceeds"`
+	// Cost is the acquisition cost of the quantity sold (取得費), commissions of buys included.
+	Cost float64 `json:"cost"`
+	// Commission is the commissions of sells (譲渡費用).
+	Commission float64 `json:"commission"`
+	// Holding is the quantity held at the end of the year.
+	Holding float64 `json:"holding"`
+}
+
+// Gain returns the capital gain of the stock.
+func (s SymbolReport) Gain() float64 {
+	return s.Proceeds - s.Cost - s.Commission
+}
+
+// StockReport is the capital gains of stocks for a year, sold symbols only.
+type StockReport struct {
+	Year    int            `json:"year"`
+	Symbols []SymbolReport `json:"symbols"`
+}
+
+// Gain returns the capital gain of every stock.
+func (r *StockReport) Gain() float64 {
+	gain := 0.0
+	for _, v := range r.Symbols {
+		gain += v.Gain()
+	}
+	return gain
+}
+
+// Stocks returns the capital gains of year. The acquisition cost is the moving average of the buys
+// commissions included (総平均法に準ずる方法), carried over from the previous years. Selling more
+// than held fails, as the history is then incomplete; margin trades are not supported.
+func Stocks(year int, trades []StockTrade) (*StockReport, error) {
+	sorted := append([]StockTrade{}, trades...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Time.Before(sorted[b].Time) })
+
+	holdings := map[string]*Holding{}
+	symbols := map[string]*SymbolReport{}
+	for _, v := range sorted {
+		y := v.Time.In(candle.JST).Year()
+		if y > year {
+			break
+		}
+		h, ok := holdings[v.Symbol]
+		if !ok {
+			h = &Holding{}
+			holdings[v.Symbol] = h
+		}
+
+		switch v.Side {
+		case "BUY":
+			h.Quantity += v.Qty
+			h.Amount += v.Price*v.Qty + v.Commission
+		case "SELL":
+			unit := h.UnitCost()
+			if err := take(h, unit, v.Qty, v.Symbol, v.Time); err != nil {
+				return nil, err
+			}
+			if y != year {
+				continue
+			}
+			s, ok := symbols[v.Symbol]
+			if !ok {
+				s = &SymbolReport{Symbol: v.Symbol}
+				symbols[v.Symbol] = s
+			}
+			s.Qty += v.Qty
+			s.Proceeds += v.Price * v.Qty
+			s.Cost += unit * v.Qty
+			s.Commission += v.Commission
+		default:
+			return nil, fmt.Errorf("unsupported side %q of a trade of %s", v.Side, v.Symbol)
+		}
+	}
+
+	r := &StockReport{Year: year, Symbols: []SymbolReport{}}
+	for k, v := range symbols {
+		v.Holding = holdings[k].Quantity
+		r.Symbols = append(r.Symbols, *v)
+	}
+	sort.Slice(r.Symbols, func(a, b int) bool { return r.Symbols[a].Symbol < r.Symbols[b].Symbol })
+	return r, nil
+}
@@ -0,0 +1,40 @@
+package tax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStocks(t *testing.T) {
+	trades := []StockTrade{
+		{Time: jst(2025, 6, 1), Symbol: "9433@1", Side: "BUY", Price: 4000, Qty: 100, Commission: 1000},
+		{Time: jst(2026, 1, 5), Symbol: "9433@1", Side: "BUY", Price: 5000, Qty: 100, Commission: 1000},
+		{Time: jst(2026, 2, 1), Symbol: "9433@1", Side: "SELL", Price: 6000, Qty: 150, Commission: 500},
+		{Time: jst(2026, 3, 1), Symbol: "1306@1", Side: "BUY", Price: 2000, Qty: 10},
+		{Time: jst(2025, 3, 1), Symbol: "7203@1", Side: "BUY", Price: 2000, Qty: 100},
+		{Time: jst(2025, 4, 1), Symbol: "7203@1", Side: "SELL", Price: 2500, Qty: 100},
+	}
+
+	got, err := Stocks(2026, trades)
+	if err != nil {
+		t.Fatalf("Stocks() error = %v", err)
+	}
+	// The average cost is (401,000 + 501,000) / 200 = 4,510 with the commissions of buys.
+	expected := &StockReport{
+		Year: 2026,
+		Symbols: []SymbolReport{
+			{Symbol: "9433@1", Qty: 150, Proceeds: 900000, Cost: 676500, Commission: 500, Holding: 50},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Stocks() = %+v, expected %+v", got, expected)
+	}
+	if got.Gain() != 223000 {
+		t.Errorf("Stocks() gain = %v, expected 223000", got.Gain())
+	}
+
+	trades = append(trades, StockTrade{Time: jst(2026, 4, 1), Symbol: "1306@1", Side: "SELL", Price: 2000, Qty: 11})
+	if _, err := Stocks(2026, trades); err == nil {
+		t.Errorf("Stocks() error = nil, expected one for a sell beyond the holding")
+	}
+}
@@ -0,0 +1,185 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/alert"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/notify"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// AlertMarket provides the market data evaluated by alerts.
+type AlertMarket interface {
+	GetTicker(ctx context.Context, productCode string) (*bitflyer.TickerResponse, error)
+	GetCollateral(ctx context.Context) (*bitflyer.CollateralResponse, error)
+}
+
+var _ AlertMarket = &bitflyer.BitFlyer{}
+
+// AlertStore persists alerts.
+type AlertStore interface {
+	Load() ([]alert.Alert, error)
+	Add(a alert.Alert) (alert.Alert, error)
+	Remove(id int) error
+}
+
+var _ AlertStore = &alert.FileStore{}
+
+// Notifier delivers fired alerts.
+type Notifier interface {
+	Notify(ctx context.Context, n notify.Notification) error
+}
+
+// AlertEvent reports a failure of `Run`, such as a failed request. Alerts keep running.
+// Fired alerts are reported by the notifiers.
+type AlertEvent struct {
+	Time time.Time
+	Err  error
+}
+
+type AlertUseCase struct {
+	market    AlertMarket
+	store     AlertStore
+	notifiers []Notifier
+	now       func() time.Time
+	// OnEvent is called for each failure, to report them.
+	OnEvent func(AlertEvent)
+}
+
+func NewAlertUseCase(market AlertMarket, store AlertStore, notifiers []Notifier) AlertUseCase {
+	return AlertUseCase{
+		market:    market,
+		store:     store,
+		notifiers: notifiers,
+		now:       time.Now,
+	}
+}
+
+// Add validates and stores a, and returns it with its id.
+func (u *AlertUseCase) Add(a alert.Alert) (alert.Alert, error) {
+	if err := a.Validate(); err != nil {
+		return alert.Alert{}, fmt.Errorf("%w: %s", cerror.ErrBadRequest, err)
+	}
+	a.CreatedAt = u.now()
+	return u.store.Add(a)
+}
+
+func (u *AlertUseCase) List() ([]alert.Alert, error) {
+	return u.store.Load()
+}
+
+func (u *AlertUseCase) Remove(id int) error {
+	return u.store.Remove(id)
+}
+
+// Run evaluates the stored alerts every interval until ctx is cancelled, and notifies the fired ones.
+// The alerts are reloaded each time, so alerts added or removed meanwhile take effect.
+func (u *AlertUseCase) Run(ctx context.Context, interval time.Duration) error {
+	evaluator := alert.NewEvaluator()
+	for {
+		u.check(ctx, evaluator)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// check evaluates every alert once.
+func (u *AlertUseCase) check(ctx context.Context, evaluator *alert.Evaluator) {
+	alerts, err := u.store.Load()
+	if err != nil {
+		u.event(err)
+		return
+	}
+	ids := map[int]bool{}
+	for _, v := range alerts {
+		ids[v.ID] = true
+	}
+	evaluator.Forget(ids)
+
+	// Each product and the collateral are fetched once, however many alerts use them.
+	samples := map[string]*alert.Sample{}
+	var account *alert.Sample
+	for _, a := range alerts {
+		if a.Condition == alert.KeepRateBelow {
+			if account == nil {
+				account = u.account(ctx)
+			}
+			if account != nil {
+				u.fire(ctx, evaluator, a, *account)
+			}
+			continue
+		}
+
+		sample, ok := samples[a.ProductCode]
+		if !ok {
+			sample = u.sample(ctx, a.ProductCode)
+			samples[a.ProductCode] = sample
+		}
+		if sample != nil {
+			u.fire(ctx, evaluator, a, *sample)
+		}
+	}
+}
+
+// sample returns the market data of productCode, or nil when it can not be fetched.
+func (u *AlertUseCase) sample(ctx context.Context, productCode string) *alert.Sample {
+	ticker, err := u.market.GetTicker(ctx, productCode)
+	if err != nil {
+		u.event(fmt.Errorf("failed to fetch ticker of %s: %w", productCode, err))
+		return nil
+	}
+	return &alert.Sample{
+		Time:        u.now(),
+		ProductCode: productCode,
+		Price:       ticker.Ltp,
+		BestBid:     ticker.BestBid,
+		BestAsk:     ticker.BestAsk,
+	}
+}
+
+// account returns the keep rate of the account, or nil when it can not be fetched.
+func (u *AlertUseCase) account(ctx context.Context) *alert.Sample {
+	collateral, err := u.market.GetCollateral(ctx)
+	if err != nil {
+		u.event(fmt.Errorf("failed to fetch collateral: %w", err))
+		return nil
+	}
+	// bitFlyer reports 1.5 for a keep rate of 150%.
+	return &alert.Sample{Time: u.now(), KeepRate: collateral.KeepRate * 100}
+}
+
+func (u *AlertUseCase) fire(ctx context.Context, evaluator *alert.Evaluator, a alert.Alert, s alert.Sample) {
+	e, ok := evaluator.Check(a, s)
+	if !ok {
+		return
+	}
+
+	n := notify.Notification{
+		Time:  e.Time.In(candle.JST),
+		Title: fmt.Sprintf("alert %d: %s", a.ID, a),
+		Text:  e.Message,
+		Data:  map[string]any{"alert": a, "value": e.Value},
+	}
+	if a.Note != "" {
+		n.Text += "\n" + a.Note
+	}
+	for _, v := range u.notifiers {
+		if err := v.Notify(ctx, n); err != nil {
+			u.event(fmt.Errorf("failed to notify alert %d: %w", a.ID, err))
+		}
+	}
+}
+
+func (u *AlertUseCase) event(err error) {
+	if u.OnEvent != nil {
+		u.OnEvent(AlertEvent{Time: u.now(), Err: err})
+	}
+}
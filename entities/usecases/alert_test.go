@@ -0,0 +1,181 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/alert"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/notify"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedAlertMarket struct {
+	tickers    map[string]*bitflyer.TickerResponse
+	collateral *bitflyer.CollateralResponse
+	calls      []string
+}
+
+func (m *mockedAlertMarket) GetTicker(ctx context.Context, productCode string) (*bitflyer.TickerResponse, error) {
+	m.calls = append(m.calls, productCode)
+	if v, ok := m.tickers[productCode]; ok {
+		return v, nil
+	}
+	return nil, cerror.ErrBadRequest
+}
+
+func (m *mockedAlertMarket) GetCollateral(ctx context.Context) (*bitflyer.CollateralResponse, error) {
+	m.calls = append(m.calls, "collateral")
+	if m.collateral == nil {
+		return nil, cerror.ErrUnAuthorized
+	}
+	return m.collateral, nil
+}
+
+type mockedAlertStore struct {
+	alerts []alert.Alert
+}
+
+func (m *mockedAlertStore) Load() ([]alert.Alert, error) {
+	return m.alerts, nil
+}
+
+func (m *mockedAlertStore) Add(a alert.Alert) (alert.Alert, error) {
+	a.ID = len(m.alerts) + 1
+	m.alerts = append(m.alerts, a)
+	return a, nil
+}
+
+func (m *mockedAlertStore) Remove(id int) error {
+	return cerror.ErrResourceNotFound
+}
+
+type mockedNotifier struct {
+	notifications []notify.Notification
+	err           error
+}
+
+func (m *mockedNotifier) Notify(ctx context.Context, n notify.Notification) error {
+	m.notifications = append(m.notifications, n)
+	return m.err
+}
+
+func TestAlertUseCase_Add(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		alert       alert.Alert
+		want        alert.Alert
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name:  "Success",
+			alert: alert.Alert{ProductCode: "BTC_JPY", Condition: alert.PriceAbove, Threshold: 5000000},
+			want:  alert.Alert{ID: 1, ProductCode: "BTC_JPY", Condition: alert.PriceAbove, Threshold: 5000000, CreatedAt: now},
+		},
+		{
+			name:        "invalid",
+			alert:       alert.Alert{ProductCode: "BTC_JPY", Condition: alert.Move, Threshold: 3},
+			wantErr:     true,
+			expectedErr: cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewAlertUseCase(&mockedAlertMarket{}, &mockedAlertStore{}, nil)
+			u.now = func() time.Time { return now }
+			got, err := u.Add(tt.alert)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AlertUseCase.Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (err != nil) && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("AlertUseCase.Add() error = %v, expectedErr %v", err, tt.expectedErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AlertUseCase.Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertUseCase_check(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		alerts        []alert.Alert
+		market        *mockedAlertMarket
+		notifyErr     error
+		expectedCalls []string
+		expected      []string
+		events        int
+	}{
+		{
+			name: "fired alerts are notified",
+			alerts: []alert.Alert{
+				{ID: 1, ProductCode: "BTC_JPY", Condition: alert.PriceAbove, Threshold: 5000000, Note: "take profit"},
+				{ID: 2, ProductCode: "BTC_JPY", Condition: alert.SpreadAbove, Threshold: 5000},
+				{ID: 3, Condition: alert.KeepRateBelow, Threshold: 150},
+			},
+			market: &mockedAlertMarket{
+				tickers:    map[string]*bitflyer.TickerResponse{"BTC_JPY": {Ltp: 5100000, BestBid: 5099000, BestAsk: 5101000}},
+				collateral: &bitflyer.CollateralResponse{KeepRate: 1.2},
+			},
+			expectedCalls: []string{"BTC_JPY", "collateral"},
+			expected: []string{
+				"alert 1: BTC_JPY price-above 5000000|BTC_JPY price 5100000 rose to 5000000 or above\ntake profit",
+				"alert 3: keep-rate-below 150%|keep rate 120% fell below 150%",
+			},
+		},
+		{
+			name: "failed requests are reported",
+			alerts: []alert.Alert{
+				{ID: 1, ProductCode: "ETH_JPY", Condition: alert.PriceBelow, Threshold: 100},
+				{ID: 2, ProductCode: "ETH_JPY", Condition: alert.PriceAbove, Threshold: 100},
+				{ID: 3, Condition: alert.KeepRateBelow, Threshold: 150},
+			},
+			market:        &mockedAlertMarket{},
+			expectedCalls: []string{"ETH_JPY", "collateral"},
+			events:        2,
+		},
+		{
+			name:          "failed notifications are reported",
+			alerts:        []alert.Alert{{ID: 1, ProductCode: "BTC_JPY", Condition: alert.PriceBelow, Threshold: 6000000}},
+			market:        &mockedAlertMarket{tickers: map[string]*bitflyer.TickerResponse{"BTC_JPY": {Ltp: 5100000}}},
+			notifyErr:     errors.New("connection refused"),
+			expectedCalls: []string{"BTC_JPY"},
+			expected:      []string{"alert 1: BTC_JPY price-below 6000000|BTC_JPY price 5100000 fell to 6000000 or below"},
+			events:        1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &mockedNotifier{err: tt.notifyErr}
+			u := NewAlertUseCase(tt.market, &mockedAlertStore{alerts: tt.alerts}, []Notifier{notifier})
+			u.now = func() time.Time { return now }
+			var events []AlertEvent
+			u.OnEvent = func(e AlertEvent) { events = append(events, e) }
+
+			u.check(context.Background(), alert.NewEvaluator())
+
+			if !reflect.DeepEqual(tt.market.calls, tt.expectedCalls) {
+				t.Errorf("AlertUseCase.check() calls = %v, expected %v", tt.market.calls, tt.expectedCalls)
+			}
+			var got []string
+			for _, v := range notifier.notifications {
+				got = append(got, v.Title+"|"+v.Text)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("AlertUseCase.check() notifications = %q, expected %q", got, tt.expected)
+			}
+			if len(events) != tt.events {
+				t.Errorf("AlertUseCase.check() events = %v, expected %d", events, tt.events)
+			}
+		})
+	}
+}
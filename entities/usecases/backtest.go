@@ -0,0 +1,100 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/backtest"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/history"
+	"github.com/sn1w/capital-go/entities/strategy"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type BacktestQuery struct {
+	ProductCode string
+	Strategy    string
+	Params      strategy.Params
+	From        time.Time
+	To          time.Time
+	Config      backtest.Config
+}
+
+type BacktestUseCase struct {
+	store ExecutionStore
+}
+
+func NewBacktestUseCase(store ExecutionStore) BacktestUseCase {
+	return BacktestUseCase{
+		store: store,
+	}
+}
+
+// ExecutionStore provides executions downloaded by `data fetch`.
+type ExecutionStore interface {
+	Days(productCode string) ([]time.Time, error)
+	Executions(productCode string, day time.Time) (bitflyer.GetExecutionsResponse, error)
+}
+
+var _ ExecutionStore = &history.Store{}
+
+// Run replays the stored executions between From and To through the strategy.
+func (b *BacktestUseCase) Run(ctx context.Context, query BacktestQuery) (*backtest.Report, error) {
+	s, err := strategy.New(query.Strategy, query.Params)
+	if err != nil {
+		return nil, err
+	}
+	engine := backtest.New(query.Config, s)
+
+	days, err := b.store.Days(query.ProductCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored executions: %w", err)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	replayed := 0
+	for _, day := range days {
+		if !day.Add(24*time.Hour).After(query.From) || !day.Before(query.To) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		executions, err := b.store.Executions(query.ProductCode, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stored executions: %w", err)
+		}
+		trades := make([]candle.Trade, 0, len(executions))
+		for _, v := range executions {
+			execDate, err := bitflyer.ParseTime(v.ExecDate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse exec_date of execution %d: %w", v.Id, err)
+			}
+			if execDate.Before(query.From) || !execDate.Before(query.To) {
+				continue
+			}
+			trades = append(trades, candle.Trade{ID: v.Id, Time: execDate, Price: v.Price, Size: v.Size})
+		}
+		sort.Slice(trades, func(i, j int) bool {
+			if trades[i].Time.Equal(trades[j].Time) {
+				return trades[i].ID < trades[j].ID
+			}
+			return trades[i].Time.Before(trades[j].Time)
+		})
+
+		for _, v := range trades {
+			engine.Add(v)
+		}
+		replayed += len(trades)
+	}
+
+	if replayed == 0 {
+		return nil, fmt.Errorf("%w: no stored executions of %s between %s and %s. download them with `data fetch` first",
+			cerror.ErrResourceNotFound, query.ProductCode, query.From.Format(time.RFC3339), query.To.Format(time.RFC3339))
+	}
+	return engine.Report(), nil
+}
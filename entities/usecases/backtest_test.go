@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/backtest"
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// mockedExecutionStore keeps executions per JST day.
+type mockedExecutionStore map[time.Time]bitflyer.GetExecutionsResponse
+
+func (m mockedExecutionStore) Days(productCode string) ([]time.Time, error) {
+	days := []time.Time{}
+	for k := range m {
+		days = append(days, k)
+	}
+	return days, nil
+}
+
+func (m mockedExecutionStore) Executions(productCode string, day time.Time) (bitflyer.GetExecutionsResponse, error) {
+	return m[day], nil
+}
+
+func TestBacktestUseCase_Run(t *testing.T) {
+	day := time.Date(2023, 4, 1, 0, 0, 0, 0, candle.JST)
+	execution := func(id int64, t time.Time, price float64) bitflyer.ExecutionResponse {
+		return bitflyer.ExecutionResponse{Id: id, Price: price, Size: 1, ExecDate: t.UTC().Format("2006-01-02T15:04:05.000")}
+	}
+	store := mockedExecutionStore{
+		day: {
+			execution(1, day.Add(10*time.Hour), 100),
+			execution(2, day.Add(23*time.Hour), 110),
+		},
+		day.AddDate(0, 0, 1): {
+			execution(4, day.Add(25*time.Hour), 130),
+			execution(3, day.Add(24*time.Hour), 120),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		from        time.Time
+		to          time.Time
+		wantFrom    time.Time
+		wantTo      time.Time
+		expectedErr error
+	}{
+		{
+			name:     "Across Days",
+			from:     day.Add(12 * time.Hour),
+			to:       day.Add(48 * time.Hour),
+			wantFrom: day.Add(23 * time.Hour),
+			wantTo:   day.Add(25 * time.Hour),
+		},
+		{
+			name:     "To Is Exclusive",
+			from:     day,
+			to:       day.Add(24 * time.Hour),
+			wantFrom: day.Add(10 * time.Hour),
+			wantTo:   day.Add(23 * time.Hour),
+		},
+		{
+			name:        "Nothing Stored",
+			from:        day.AddDate(0, 0, 5),
+			to:          day.AddDate(0, 0, 6),
+			expectedErr: cerror.ErrResourceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBacktestUseCase(store)
+			got, err := b.Run(context.Background(), BacktestQuery{
+				ProductCode: "BTC_JPY",
+				Strategy:    "buy-and-hold",
+				From:        tt.from,
+				To:          tt.to,
+				Config:      backtest.DefaultConfig(),
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("BacktestUseCase.Run() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if !got.From.Equal(tt.wantFrom) || !got.To.Equal(tt.wantTo) {
+				t.Errorf("BacktestUseCase.Run() replayed %v to %v, want %v to %v", got.From, got.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
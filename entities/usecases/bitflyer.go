@@ -1,9 +1,15 @@
 package usecases
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/sn1w/capital-go/entities/candle"
 	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	cerror "github.com/sn1w/capital-go/error"
 )
 
 type AvaiableMarkets = []AvaiableMarket
@@ -40,12 +46,52 @@ type OrderCreate struct {
 	Price       float64
 	Size        float64
 	Buy         bool
+	// Market sends a market order, which takes no price. Limit orders need a positive price.
+	Market bool
 }
 
 type OrderInformation struct {
 	OrderAcceeptanceId string
 }
 
+// OrderPreview summarizes an order before it is sent.
+type OrderPreview struct {
+	ProductCode string
+	Side        string
+	Type        string
+	// Price is zero for market orders.
+	Price float64
+	Size  float64
+	// Notional is price * size, with market orders estimated at the best opposite quote.
+	Notional float64
+	MidPrice float64
+	// Distance is the deviation of the (estimated) price from the mid price, such as 0.01 for 1% above.
+	Distance float64
+	// Body is the JSON request body that would be signed and sent.
+	Body string
+}
+
+// OrderChecker is implemented by clients checking orders before sending them, such as RiskGuard.
+type OrderChecker interface {
+	CheckOrder(ctx context.Context, req bitflyer.SendOrderRequest) error
+}
+
+type OrderExecution struct {
+	Price      float64
+	Size       float64
+	Commission float64
+}
+
+type CandleQuery struct {
+	ProductCode string
+	Interval    time.Duration
+	From        time.Time
+	To          time.Time
+}
+
+// executionsPageSize is the count of executions fetched per request.
+const executionsPageSize = 500
+
 type BitFlyerUseCase struct {
 	client BitFlyerClient
 }
@@ -57,16 +103,24 @@ func NewBitFlyerUseCase(client BitFlyerClient) BitFlyerUseCase {
 }
 
 type BitFlyerClient interface {
-	GetAvaiableMarkets() (bitflyer.GetMarketsResponse, error)
-	GetBoard(productCode string) (*bitflyer.BoardResponse, error)
-	GetBalance() (bitflyer.GetBalancesResponse, error)
-	SendOrder(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error)
+	GetAvaiableMarkets(ctx context.Context) (bitflyer.GetMarketsResponse, error)
+	GetBoard(ctx context.Context, productCode string) (*bitflyer.BoardResponse, error)
+	GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error)
+	GetBalance(ctx context.Context) (bitflyer.GetBalancesResponse, error)
+	SendOrder(ctx context.Context, req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error)
+	CancelOrder(ctx context.Context, req bitflyer.CancelOrderRequest) error
+	GetMyExecutions(ctx context.Context, productCode string, childOrderAcceptanceId string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error)
+	GetChildOrders(ctx context.Context, productCode string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error)
+	GetCoinIns(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error)
+	GetCoinOuts(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error)
+	GetPositions(ctx context.Context, productCode string) (bitflyer.GetPositionsResponse, error)
 }
 
 var _ BitFlyerClient = &bitflyer.BitFlyer{}
+var _ BitFlyerClient = &paper.BitFlyer{}
 
-func (b *BitFlyerUseCase) ShowAvaiableMarkets() (AvaiableMarkets, error) {
-	result, err := b.client.GetAvaiableMarkets()
+func (b *BitFlyerUseCase) ShowAvaiableMarkets(ctx context.Context) (AvaiableMarkets, error) {
+	result, err := b.client.GetAvaiableMarkets(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch markets: %w", err)
 	}
@@ -84,8 +138,8 @@ func (b *BitFlyerUseCase) ShowAvaiableMarkets() (AvaiableMarkets, error) {
 	return responses, nil
 }
 
-func (b *BitFlyerUseCase) GetBoard(productCode string) (BoardInformation, error) {
-	result, err := b.client.GetBoard(productCode)
+func (b *BitFlyerUseCase) GetBoard(ctx context.Context, productCode string) (BoardInformation, error) {
+	result, err := b.client.GetBoard(ctx, productCode)
 
 	response := BoardInformation{}
 
@@ -112,13 +166,17 @@ func (b *BitFlyerUseCase) GetBoard(productCode string) (BoardInformation, error)
 	return response, nil
 }
 
-func (b *BitFlyerUseCase) GetBalance() (Balances, error) {
-	result, err := b.client.GetBalance()
+func (b *BitFlyerUseCase) GetBalance(ctx context.Context) (Balances, error) {
+	result, err := b.client.GetBalance(ctx)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch balance: %w", err)
 	}
 
+	return toBalances(result), nil
+}
+
+func toBalances(result bitflyer.GetBalancesResponse) Balances {
 	response := Balances{}
 
 	for _, v := range result {
@@ -129,24 +187,48 @@ func (b *BitFlyerUseCase) GetBalance() (Balances, error) {
 		})
 	}
 
-	return response, nil
+	return response
 }
 
-func (b *BitFlyerUseCase) CreateOrder(req OrderCreate) (*OrderInformation, error) {
+// orderRequest validates req and builds the request sent for it.
+func orderRequest(req OrderCreate) (bitflyer.SendOrderRequest, error) {
+	switch {
+	case req.ProductCode == "":
+		return bitflyer.SendOrderRequest{}, fmt.Errorf("%w: product code is empty", cerror.ErrBadRequest)
+	case req.Size <= 0:
+		return bitflyer.SendOrderRequest{}, fmt.Errorf("%w: size must be positive, got %v", cerror.ErrBadRequest, req.Size)
+	case req.Market && req.Price != 0:
+		return bitflyer.SendOrderRequest{}, fmt.Errorf("%w: market orders take no price, got %v", cerror.ErrBadRequest, req.Price)
+	case !req.Market && req.Price <= 0:
+		return bitflyer.SendOrderRequest{}, fmt.Errorf("%w: limit orders need a positive price, got %v", cerror.ErrBadRequest, req.Price)
+	}
+
 	orderMethod := bitflyer.SideBuy
 	if !req.Buy {
 		orderMethod = bitflyer.SideSell
 	}
+	orderType := bitflyer.ChildOrderTypeLimit
+	if req.Market {
+		orderType = bitflyer.ChildOrderTypeMarket
+	}
 
-	result, err := b.client.SendOrder(bitflyer.SendOrderRequest{
+	return bitflyer.SendOrderRequest{
 		ProductCode:    req.ProductCode,
 		Size:           req.Size,
 		Price:          req.Price,
 		Side:           orderMethod,
-		ChildOrderType: bitflyer.ChildOrderTypeLimit,
+		ChildOrderType: orderType,
 		TimeInForce:    bitflyer.TimeInForceGTC,
-	})
+	}, nil
+}
+
+func (b *BitFlyerUseCase) CreateOrder(ctx context.Context, req OrderCreate) (*OrderInformation, error) {
+	orderReq, err := orderRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
+	result, err := b.client.SendOrder(ctx, orderReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send order: %w", err)
 	}
@@ -155,3 +237,140 @@ func (b *BitFlyerUseCase) CreateOrder(req OrderCreate) (*OrderInformation, error
 		OrderAcceeptanceId: result.ChildOrderAcceptanceId,
 	}, nil
 }
+
+// PreviewOrder validates req and summarizes it against the current board without sending it.
+func (b *BitFlyerUseCase) PreviewOrder(ctx context.Context, req OrderCreate) (*OrderPreview, error) {
+	orderReq, err := orderRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(orderReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshall request body: %w", err)
+	}
+
+	board, err := b.client.GetBoard(ctx, req.ProductCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board: %w", err)
+	}
+	bid, ask := bestQuotes(board)
+
+	preview := &OrderPreview{
+		ProductCode: orderReq.ProductCode,
+		Side:        string(orderReq.Side),
+		Type:        string(orderReq.ChildOrderType),
+		Price:       orderReq.Price,
+		Size:        orderReq.Size,
+		MidPrice:    board.MidPrice,
+		Body:        string(body),
+	}
+
+	// Market orders are estimated at the best opposite quote.
+	price := orderReq.Price
+	if orderReq.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+		price = ask
+		if orderReq.Side == bitflyer.SideSell {
+			price = bid
+		}
+	}
+	preview.Notional = price * orderReq.Size
+	if board.MidPrice > 0 && price > 0 {
+		preview.Distance = (price - board.MidPrice) / board.MidPrice
+	}
+	return preview, nil
+}
+
+// CheckOrder runs the pre-trade checks of the client on req, if it has any.
+func (b *BitFlyerUseCase) CheckOrder(ctx context.Context, req OrderCreate) error {
+	orderReq, err := orderRequest(req)
+	if err != nil {
+		return err
+	}
+	checker, ok := b.client.(OrderChecker)
+	if !ok {
+		return nil
+	}
+	return checker.CheckOrder(ctx, orderReq)
+}
+
+// bestQuotes returns the highest bid and the lowest ask of board, zero when a side is empty.
+func bestQuotes(board *bitflyer.BoardResponse) (bid float64, ask float64) {
+	for _, v := range board.Bids {
+		if v.Price > bid {
+			bid = v.Price
+		}
+	}
+	for _, v := range board.Asks {
+		if ask == 0 || v.Price < ask {
+			ask = v.Price
+		}
+	}
+	return bid, ask
+}
+
+func (b *BitFlyerUseCase) CancelOrder(ctx context.Context, productCode string, orderAcceptanceId string) error {
+	err := b.client.CancelOrder(ctx, bitflyer.CancelOrderRequest{
+		ProductCode:            productCode,
+		ChildOrderAcceptanceId: orderAcceptanceId,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BitFlyerUseCase) GetOrderExecutions(ctx context.Context, productCode string, orderAcceptanceId string) ([]OrderExecution, error) {
+	result, err := b.client.GetMyExecutions(ctx, productCode, orderAcceptanceId, bitflyer.Pagination{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch executions of order: %w", err)
+	}
+
+	response := []OrderExecution{}
+	for _, v := range result {
+		response = append(response, OrderExecution{
+			Price:      v.Price,
+			Size:       v.Size,
+			Commission: v.Commission,
+		})
+	}
+
+	return response, nil
+}
+
+// GetCandles pages back through the execution history until From, and aggregates it into candles.
+func (b *BitFlyerUseCase) GetCandles(ctx context.Context, query CandleQuery) ([]candle.Candle, error) {
+	builder := candle.NewBuilder(query.Interval)
+	page := bitflyer.Pagination{Count: executionsPageSize}
+
+	for {
+		before := page.Before
+		result, err := b.client.GetExecutions(ctx, query.ProductCode, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch executions: %w", err)
+		}
+
+		reached := len(result) == 0
+		for _, v := range result {
+			execDate, err := bitflyer.ParseTime(v.ExecDate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse exec_date of execution %d: %w", v.Id, err)
+			}
+			if execDate.Before(query.From) {
+				reached = true
+				continue
+			}
+			if execDate.Before(query.To) {
+				builder.Add(candle.Trade{ID: v.Id, Time: execDate, Price: v.Price, Size: v.Size})
+			}
+			if page.Before == 0 || v.Id < page.Before {
+				page.Before = v.Id
+			}
+		}
+		if reached || page.Before == before {
+			break
+		}
+	}
+
+	return builder.Candles(query.From, query.To), nil
+}
@@ -1,34 +1,66 @@
 package usecases
 
 import (
+	"context"
 	"errors"
+	"math"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/sn1w/capital-go/entities/candle"
 	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
 	cerror "github.com/sn1w/capital-go/error"
 )
 
 type mockedBitFlyerClient struct {
 	bitflyer.BitFlyer
-	getMarket  func() (bitflyer.GetMarketsResponse, error)
-	getBoard   func(pc string) (*bitflyer.BoardResponse, error)
-	getBalance func() (bitflyer.GetBalancesResponse, error)
-	sendOrder  func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error)
+	getMarket     func() (bitflyer.GetMarketsResponse, error)
+	getBoard      func(pc string) (*bitflyer.BoardResponse, error)
+	getExecutions func(pc string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error)
+	getBalance    func() (bitflyer.GetBalancesResponse, error)
+	sendOrder     func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error)
+	cancelOrder   func(req bitflyer.CancelOrderRequest) error
+	getMyExecs    func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error)
+	getOrders     func(pc string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error)
+	getCoinIns    func(page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error)
+	getCoinOuts   func(page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error)
+	getPositions  func(pc string) (bitflyer.GetPositionsResponse, error)
 }
 
-func (m mockedBitFlyerClient) GetAvaiableMarkets() (bitflyer.GetMarketsResponse, error) {
+func (m mockedBitFlyerClient) GetAvaiableMarkets(ctx context.Context) (bitflyer.GetMarketsResponse, error) {
 	return m.getMarket()
 }
-func (m mockedBitFlyerClient) GetBoard(productCode string) (*bitflyer.BoardResponse, error) {
+func (m mockedBitFlyerClient) GetBoard(ctx context.Context, productCode string) (*bitflyer.BoardResponse, error) {
 	return m.getBoard(productCode)
 }
-func (m mockedBitFlyerClient) GetBalance() (bitflyer.GetBalancesResponse, error) {
+func (m mockedBitFlyerClient) GetExecutions(ctx context.Context, productCode string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+	return m.getExecutions(productCode, page)
+}
+func (m mockedBitFlyerClient) GetBalance(ctx context.Context) (bitflyer.GetBalancesResponse, error) {
 	return m.getBalance()
 }
-func (m mockedBitFlyerClient) SendOrder(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+func (m mockedBitFlyerClient) SendOrder(ctx context.Context, req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
 	return m.sendOrder(req)
 }
+func (m mockedBitFlyerClient) CancelOrder(ctx context.Context, req bitflyer.CancelOrderRequest) error {
+	return m.cancelOrder(req)
+}
+func (m mockedBitFlyerClient) GetMyExecutions(ctx context.Context, productCode string, childOrderAcceptanceId string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+	return m.getMyExecs(productCode, childOrderAcceptanceId, page)
+}
+func (m mockedBitFlyerClient) GetChildOrders(ctx context.Context, productCode string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error) {
+	return m.getOrders(productCode, state)
+}
+func (m mockedBitFlyerClient) GetCoinIns(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error) {
+	return m.getCoinIns(page)
+}
+func (m mockedBitFlyerClient) GetCoinOuts(ctx context.Context, page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error) {
+	return m.getCoinOuts(page)
+}
+func (m mockedBitFlyerClient) GetPositions(ctx context.Context, productCode string) (bitflyer.GetPositionsResponse, error) {
+	return m.getPositions(productCode)
+}
 
 func TestBitFlyerUseCase_ShowAvaiableMarkets(t *testing.T) {
 	type fields struct {
@@ -78,7 +110,7 @@ func TestBitFlyerUseCase_ShowAvaiableMarkets(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBitFlyerUseCase(tt.fields.Client)
-			got, err := b.ShowAvaiableMarkets()
+			got, err := b.ShowAvaiableMarkets(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUseCase.ShowAvaiableMarkets() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -144,7 +176,7 @@ func TestBitFlyerUseCase_GetBoard(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBitFlyerUseCase(tt.fields.Client)
-			got, err := b.GetBoard(tt.args.productCode)
+			got, err := b.GetBoard(context.Background(), tt.args.productCode)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUseCase.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -208,7 +240,7 @@ func TestBitFlyerUseCase_GetBalance(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBitFlyerUseCase(tt.fields.Client)
-			got, err := b.GetBalance()
+			got, err := b.GetBalance(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUseCase.GetBalance() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -262,6 +294,27 @@ func TestBitFlyerUseCase_CreateOrder(t *testing.T) {
 			},
 			want: &OrderInformation{OrderAcceeptanceId: "test_id"},
 		},
+		{
+			name: "Market Order",
+			fields: fields{
+				Client: mockedBitFlyerClient{
+					sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+						if req.ChildOrderType != bitflyer.ChildOrderTypeMarket {
+							return nil, cerror.ErrBadRequest
+						}
+						return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "test_id"}, nil
+					},
+				},
+			},
+			args: args{
+				req: OrderCreate{
+					Size:        0.01,
+					ProductCode: "BTC_JPY",
+					Market:      true,
+				},
+			},
+			want: &OrderInformation{OrderAcceeptanceId: "test_id"},
+		},
 		{
 			name: "error",
 			fields: fields{
@@ -271,14 +324,66 @@ func TestBitFlyerUseCase_CreateOrder(t *testing.T) {
 					},
 				},
 			},
+			args: args{
+				req: OrderCreate{
+					Size:        0.01,
+					ProductCode: "BTC_JPY",
+					Market:      true,
+				},
+			},
 			wantErr:     true,
 			expectedErr: cerror.ErrUnknown,
 		},
+		{
+			name: "Limit Order Without Price",
+			fields: fields{
+				Client: mockedBitFlyerClient{},
+			},
+			args: args{
+				// A zero price is not a market order.
+				req: OrderCreate{
+					Size:        0.01,
+					ProductCode: "BTC_JPY",
+				},
+			},
+			wantErr:     true,
+			expectedErr: cerror.ErrBadRequest,
+		},
+		{
+			name: "Market Order With Price",
+			fields: fields{
+				Client: mockedBitFlyerClient{},
+			},
+			args: args{
+				req: OrderCreate{
+					Price:       5000000,
+					Size:        0.01,
+					ProductCode: "BTC_JPY",
+					Market:      true,
+				},
+			},
+			wantErr:     true,
+			expectedErr: cerror.ErrBadRequest,
+		},
+		{
+			name: "Size Missing",
+			fields: fields{
+				Client: mockedBitFlyerClient{},
+			},
+			args: args{
+				req: OrderCreate{
+					Price:       10242,
+					ProductCode: "BTC_JPY",
+				},
+			},
+			wantErr:     true,
+			expectedErr: cerror.ErrBadRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBitFlyerUseCase(tt.fields.Client)
-			got, err := b.CreateOrder(tt.args.req)
+			got, err := b.CreateOrder(context.Background(), tt.args.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUseCase.CreateOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -293,3 +398,184 @@ func TestBitFlyerUseCase_CreateOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestBitFlyerUseCase_PreviewOrder(t *testing.T) {
+	client := mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			return &bitflyer.BoardResponse{
+				MidPrice: 5000000,
+				Bids:     bitflyer.PriceResponses{{Price: 4990000, Size: 1}, {Price: 4995000, Size: 1}},
+				Asks:     bitflyer.PriceResponses{{Price: 5010000, Size: 1}, {Price: 5005000, Size: 1}},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name        string
+		req         OrderCreate
+		want        *OrderPreview
+		expectedErr error
+	}{
+		{
+			name: "Limit Order",
+			req:  OrderCreate{ProductCode: "BTC_JPY", Price: 5050000, Size: 0.01, Buy: true},
+			want: &OrderPreview{
+				ProductCode: "BTC_JPY", Side: "BUY", Type: "LIMIT", Price: 5050000, Size: 0.01,
+				Notional: 50500, MidPrice: 5000000, Distance: 0.01,
+				Body: `{"product_code":"BTC_JPY","child_order_type":"LIMIT","side":"BUY","price":5050000,"size":0.01,"minute_to_expire":0,"time_in_force":"GTC"}`,
+			},
+		},
+		{
+			name: "Market Sell At Best Bid",
+			req:  OrderCreate{ProductCode: "BTC_JPY", Size: 0.1, Market: true},
+			want: &OrderPreview{
+				ProductCode: "BTC_JPY", Side: "SELL", Type: "MARKET", Size: 0.1,
+				Notional: 499500, MidPrice: 5000000, Distance: -0.001,
+				Body: `{"product_code":"BTC_JPY","child_order_type":"MARKET","side":"SELL","price":0,"size":0.1,"minute_to_expire":0,"time_in_force":"GTC"}`,
+			},
+		},
+		{
+			name:        "Negative Price",
+			req:         OrderCreate{ProductCode: "BTC_JPY", Price: -1, Size: 0.1},
+			expectedErr: cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBitFlyerUseCase(client)
+			got, err := b.PreviewOrder(context.Background(), tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("BitFlyerUseCase.PreviewOrder() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
+			if got != nil {
+				// Round away floating point residue.
+				got.Notional = math.Round(got.Notional*1e6) / 1e6
+				got.Distance = math.Round(got.Distance*1e6) / 1e6
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUseCase.PreviewOrder() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mockedOrderChecker rejects every order.
+type mockedOrderChecker struct {
+	mockedBitFlyerClient
+}
+
+func (m mockedOrderChecker) CheckOrder(ctx context.Context, req bitflyer.SendOrderRequest) error {
+	return cerror.ErrRiskRejected
+}
+
+func TestBitFlyerUseCase_CheckOrder(t *testing.T) {
+	req := OrderCreate{ProductCode: "BTC_JPY", Price: 5000000, Size: 0.01, Buy: true}
+	tests := []struct {
+		name        string
+		client      BitFlyerClient
+		req         OrderCreate
+		expectedErr error
+	}{
+		{name: "Without Checks", client: mockedBitFlyerClient{}, req: req},
+		{name: "Rejected", client: mockedOrderChecker{}, req: req, expectedErr: cerror.ErrRiskRejected},
+		{name: "Invalid", client: mockedBitFlyerClient{}, req: OrderCreate{Size: 0.01}, expectedErr: cerror.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBitFlyerUseCase(tt.client)
+			if err := b.CheckOrder(context.Background(), tt.req); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("BitFlyerUseCase.CheckOrder() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUseCase_GetCandles(t *testing.T) {
+	// One execution per minute from 10:00 JST, ids 1 to 7, newest first.
+	history := bitflyer.GetExecutionsResponse{}
+	for i := int64(7); i >= 1; i-- {
+		history = append(history, bitflyer.ExecutionResponse{
+			Id:       i,
+			Price:    float64(100 + i),
+			Size:     0.1,
+			ExecDate: time.Date(2023, 4, 1, 1, int(i-1), 30, 0, time.UTC).Format("2006-01-02T15:04:05.000"),
+		})
+	}
+	paged := func(calls *int) func(pc string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+		return func(pc string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+			*calls++
+			res := bitflyer.GetExecutionsResponse{}
+			for _, v := range history {
+				if (page.Before == 0 || v.Id < page.Before) && len(res) < 2 {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		}
+	}
+	at := func(min int) time.Time { return time.Date(2023, 4, 1, 10, min, 0, 0, candle.JST) }
+
+	type args struct {
+		query CandleQuery
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []candle.Candle
+		wantCalls   int
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			args: args{query: CandleQuery{ProductCode: "BTC_JPY", Interval: time.Minute, From: at(2), To: at(5)}},
+			want: []candle.Candle{
+				{Time: at(2), Open: 103, High: 103, Low: 103, Close: 103, Volume: 0.1, Trades: 1},
+				{Time: at(3), Open: 104, High: 104, Low: 104, Close: 104, Volume: 0.1, Trades: 1},
+				{Time: at(4), Open: 105, High: 105, Low: 105, Close: 105, Volume: 0.1, Trades: 1},
+			},
+			// Pages of ids 7-6, 5-4 and 3-2, where the execution 2 is older than From.
+			wantCalls: 3,
+		},
+		{
+			name: "History Exhausted",
+			args: args{query: CandleQuery{ProductCode: "BTC_JPY", Interval: 2 * time.Minute, From: at(-10), To: at(2)}},
+			want: []candle.Candle{
+				{Time: at(0), Open: 101, High: 102, Low: 101, Close: 102, Volume: 0.2, Trades: 2},
+			},
+			wantCalls: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			b := NewBitFlyerUseCase(mockedBitFlyerClient{getExecutions: paged(&calls)})
+			got, err := b.GetCandles(context.Background(), tt.args.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUseCase.GetCandles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (err != nil) && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("BitFlyerUseCase.GetCandles() error = %v, expectedErr %v", err, tt.expectedErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUseCase.GetCandles() = %v, want %v", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("BitFlyerUseCase.GetCandles() requested %v pages, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		b := NewBitFlyerUseCase(mockedBitFlyerClient{
+			getExecutions: func(pc string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+				return nil, cerror.ErrUnknown
+			},
+		})
+		if _, err := b.GetCandles(context.Background(), CandleQuery{Interval: time.Minute}); !errors.Is(err, cerror.ErrUnknown) {
+			t.Errorf("BitFlyerUseCase.GetCandles() error = %v, expectedErr %v", err, cerror.ErrUnknown)
+		}
+	})
+}
@@ -0,0 +1,495 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+type ExecutionQuery struct {
+	Order execution.Order
+	// PollInterval is the interval of polling the fills of the child orders and the board.
+	PollInterval time.Duration
+}
+
+// ExecutionProgress is how much of the parent order is filled.
+type ExecutionProgress struct {
+	Size   float64
+	Filled float64
+	// AveragePrice is the average price of the fills.
+	AveragePrice float64
+	// Orders is the number of child orders sent.
+	Orders int
+}
+
+// ExecutionEvent reports a child order, a fill or a failure of a running execution.
+type ExecutionEvent struct {
+	Time     time.Time
+	Message  string
+	Progress ExecutionProgress
+	// Err is set when the event is a failure. The execution keeps running.
+	Err error
+}
+
+type ExecutionResult struct {
+	ExecutionProgress
+	// Reason is why the execution stopped before the size was filled, empty when it was filled.
+	Reason string
+}
+
+type ExecutionUseCase struct {
+	client  BitFlyerClient
+	useCase BitFlyerUseCase
+	now     func() time.Time
+	// OnEvent is called for each child order, fill and failure, to report them.
+	OnEvent func(ExecutionEvent)
+}
+
+func NewExecutionUseCase(client BitFlyerClient) ExecutionUseCase {
+	return ExecutionUseCase{
+		client:  client,
+		useCase: NewBitFlyerUseCase(client),
+		now:     time.Now,
+	}
+}
+
+// Run executes the parent order of query with child orders sent through BitFlyerUseCase, until it
+// is filled, the algorithm stops or ctx is cancelled. Child orders left open are then cancelled,
+// and their fills until the cancel are counted.
+//
+// A child order is done when it is filled, or when it has left the active orders of the account:
+// cancelled, expired or rejected.
+func (u *ExecutionUseCase) Run(ctx context.Context, query ExecutionQuery) (*ExecutionResult, error) {
+	order := query.Order
+	minSize, err := validateExecution(query)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &executor{ctx: ctx, runner: u, order: order, minSize: minSize, poll: query.PollInterval}
+	var reason string
+	switch order.Algorithm {
+	case execution.TWAP:
+		reason, err = e.twap()
+	case execution.Iceberg:
+		reason, err = e.iceberg()
+	case execution.Chase:
+		reason, err = e.chase()
+	}
+	if ctx.Err() != nil {
+		reason, err = "interrupted", nil
+	}
+
+	// Cancel what is left with a context of its own, as ctx may be cancelled.
+	stopCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	e.ctx = stopCtx
+	for _, v := range e.open() {
+		e.cancel(v)
+	}
+	if !e.settle() && err == nil {
+		err = errors.New("child orders are still open after cancelling them: check the active orders")
+	}
+
+	res := &ExecutionResult{ExecutionProgress: e.progress(), Reason: reason}
+	if res.Filled < order.Size-execution.SizeEpsilon && res.Reason == "" {
+		res.Reason = "not filled"
+	}
+	return res, err
+}
+
+// FirstOrder validates the parent order of query, and returns the first child order Run would send
+// with the current board, without sending it.
+func (u *ExecutionUseCase) FirstOrder(ctx context.Context, query ExecutionQuery) (OrderCreate, error) {
+	if _, err := validateExecution(query); err != nil {
+		return OrderCreate{}, err
+	}
+
+	o := query.Order
+	first := OrderCreate{ProductCode: o.ProductCode, Buy: o.Buy}
+	switch o.Algorithm {
+	case execution.TWAP:
+		first.Size = execution.RoundSize(execution.Slices(o.Size, o.Slices)[0])
+		first.Market = true
+	case execution.Iceberg:
+		first.Size = execution.RoundSize(math.Min(o.Display, o.Size))
+		first.Price = o.Price
+	case execution.Chase:
+		board, err := u.client.GetBoard(ctx, o.ProductCode)
+		if err != nil {
+			return OrderCreate{}, fmt.Errorf("failed to fetch board: %w", err)
+		}
+		bid, ask := bestQuotes(board)
+		price, ok := execution.ChasePrice(o.Buy, bid, ask)
+		if !ok {
+			return OrderCreate{}, fmt.Errorf("no quote to rest at: bid %s, ask %s", plain(bid), plain(ask))
+		}
+		first.Size = execution.RoundSize(o.Size)
+		first.Price = price
+	}
+	return first, nil
+}
+
+// DryRun runs the validation and the risk checks of the first child order of query, and previews
+// it without sending it.
+func (u *ExecutionUseCase) DryRun(ctx context.Context, query ExecutionQuery) (*OrderPreview, error) {
+	first, err := u.FirstOrder(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := u.useCase.PreviewOrder(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.useCase.CheckOrder(ctx, first); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// validateExecution validates query, and returns the minimum order size of its product.
+func validateExecution(query ExecutionQuery) (float64, error) {
+	order := query.Order
+	minSize, ok := bitflyer.MinimumOrderSizes[order.ProductCode]
+	if !ok {
+		return 0, fmt.Errorf("the minimum order size of %s is unknown", order.ProductCode)
+	}
+	if err := order.Validate(minSize); err != nil {
+		return 0, err
+	}
+	if query.PollInterval <= 0 {
+		return 0, fmt.Errorf("poll interval must be positive, got %s", query.PollInterval)
+	}
+	return minSize, nil
+}
+
+// childOrder is an order sent by an execution.
+type childOrder struct {
+	id string
+	// price is zero for market orders.
+	price  float64
+	size   float64
+	filled float64
+	cost   float64
+	// missing counts the polls the order was not in the active orders. New orders may take a
+	// moment to show up there.
+	missing   int
+	cancelled bool
+	done      bool
+}
+
+// executor runs the algorithm of an order.
+type executor struct {
+	ctx      context.Context
+	runner   *ExecutionUseCase
+	order    execution.Order
+	minSize  float64
+	poll     time.Duration
+	children []*childOrder
+}
+
+// twap sends a market slice at each interval of the duration. A slice too small to be sent, or
+// whose best quote is beyond the limit price, is carried over to the next one.
+func (e *executor) twap() (string, error) {
+	o := e.order
+	start := e.runner.now()
+	slices := execution.Slices(o.Size, o.Slices)
+	interval := o.Duration / time.Duration(o.Slices)
+
+	target := 0.0
+	for i, v := range slices {
+		if !e.waitUntil(start.Add(interval * time.Duration(i))) {
+			return "interrupted", nil
+		}
+		target += v
+		size := execution.RoundSize(target - e.committed())
+		if size < e.minSize {
+			continue
+		}
+		if o.Price > 0 {
+			bid, ask, err := e.quotes()
+			if err != nil {
+				e.event("", err)
+				continue
+			}
+			// Market orders take the best quote of the other side.
+			quote := bid
+			if o.Buy {
+				quote = ask
+			}
+			if !execution.Within(o.Buy, quote, o.Price) {
+				e.event(fmt.Sprintf("slice %d/%d carried over: best quote %s is beyond the limit %s", i+1, len(slices), plain(quote), plain(o.Price)), nil)
+				continue
+			}
+		}
+		if _, err := e.send(0, size); err != nil {
+			return "", err
+		}
+	}
+	if !e.settle() {
+		return "interrupted", nil
+	}
+	if e.progress().Filled < o.Size-e.minSize {
+		return "the last slices were not sent", nil
+	}
+	return "", nil
+}
+
+// iceberg rests a limit order of the display size, and the next one once it is done.
+func (e *executor) iceberg() (string, error) {
+	o := e.order
+	for {
+		e.refresh()
+		remaining := execution.RoundSize(o.Size - e.progress().Filled)
+		if remaining <= execution.SizeEpsilon {
+			return "", nil
+		}
+		if len(e.open()) == 0 {
+			if remaining < e.minSize {
+				return fmt.Sprintf("the rest %s is smaller than the minimum order size", plain(remaining)), nil
+			}
+			if _, err := e.send(o.Price, math.Min(o.Display, remaining)); err != nil {
+				return "", err
+			}
+		}
+		if !e.sleep(e.poll) {
+			return "interrupted", nil
+		}
+	}
+}
+
+// chase rests a limit order at the best price of its side, and replaces it at the new best price
+// when the market moves, until it is filled or the best price is beyond the maximum slippage.
+func (e *executor) chase() (string, error) {
+	o := e.order
+	bid, ask, err := e.quotes()
+	if err != nil {
+		return "", err
+	}
+	arrival, ok := execution.ChasePrice(o.Buy, bid, ask)
+	if !ok {
+		return "", fmt.Errorf("no quote to rest at: bid %s, ask %s", plain(bid), plain(ask))
+	}
+	limit := execution.SlippageLimit(o.Buy, arrival, o.MaxSlippage)
+
+	for {
+		e.refresh()
+		remaining := execution.RoundSize(o.Size - e.progress().Filled)
+		if remaining <= execution.SizeEpsilon {
+			return "", nil
+		}
+
+		bid, ask, err := e.quotes()
+		price, ok := execution.ChasePrice(o.Buy, bid, ask)
+		switch {
+		case err != nil:
+			e.event("", err)
+		case !ok:
+		case !execution.Within(o.Buy, price, limit):
+			return fmt.Sprintf("the best price %s is beyond the max slippage limit %s", plain(price), plain(limit)), nil
+		default:
+			open := e.open()
+			if len(open) == 0 {
+				if remaining < e.minSize {
+					return fmt.Sprintf("the rest %s is smaller than the minimum order size", plain(remaining)), nil
+				}
+				if _, err := e.send(price, remaining); err != nil {
+					return "", err
+				}
+			} else if v := open[0]; v.price != price && !v.cancelled {
+				// The rest is sent at the new price once the cancel is done, so that it is never
+				// filled twice.
+				e.cancel(v)
+			}
+		}
+		if !e.sleep(e.poll) {
+			return "interrupted", nil
+		}
+	}
+}
+
+func (e *executor) quotes() (float64, float64, error) {
+	board, err := e.runner.client.GetBoard(e.ctx, e.order.ProductCode)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch board: %w", err)
+	}
+	bid, ask := bestQuotes(board)
+	return bid, ask, nil
+}
+
+func (e *executor) send(price float64, size float64) (*childOrder, error) {
+	size = execution.RoundSize(size)
+	res, err := e.runner.useCase.CreateOrder(e.ctx, OrderCreate{
+		ProductCode: e.order.ProductCode,
+		Price:       price,
+		Size:        size,
+		Buy:         e.order.Buy,
+		Market:      price == 0,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s %v: %w", side(e.order.Buy), size, err)
+	}
+
+	c := &childOrder{id: res.OrderAcceeptanceId, price: price, size: size}
+	e.children = append(e.children, c)
+	at := "market"
+	if price > 0 {
+		at = plain(price)
+	}
+	e.event(fmt.Sprintf("sent %s %v at %s: %s", side(e.order.Buy), size, at, c.id), nil)
+	return c, nil
+}
+
+func (e *executor) cancel(c *childOrder) {
+	c.cancelled = true
+	if err := e.runner.useCase.CancelOrder(e.ctx, e.order.ProductCode, c.id); err != nil {
+		// It may have been filled meanwhile: it is done once it leaves the active orders.
+		e.event("", fmt.Errorf("cancel %s: %w", c.id, err))
+		return
+	}
+	e.event(fmt.Sprintf("cancelled %s", c.id), nil)
+}
+
+// refresh applies the fills of the open child orders, and marks the done ones.
+func (e *executor) refresh() {
+	open := e.open()
+	if len(open) == 0 {
+		return
+	}
+	orders, err := e.runner.client.GetChildOrders(e.ctx, e.order.ProductCode, bitflyer.ChildOrderStateActive)
+	if err != nil {
+		e.event("", fmt.Errorf("failed to fetch active orders: %w", err))
+		return
+	}
+	active := map[string]bool{}
+	for _, v := range orders {
+		active[v.ChildOrderAcceptanceId] = true
+	}
+
+	before := e.progress().Filled
+	for _, v := range open {
+		executions, err := e.runner.useCase.GetOrderExecutions(e.ctx, e.order.ProductCode, v.id)
+		if err != nil {
+			e.event("", fmt.Errorf("order %s: %w", v.id, err))
+			continue
+		}
+		v.filled, v.cost = 0, 0
+		for _, x := range executions {
+			v.filled += x.Size
+			v.cost += x.Size * x.Price
+		}
+
+		switch {
+		case v.filled >= v.size-execution.SizeEpsilon:
+			v.done = true
+		case active[v.id]:
+			v.missing = 0
+		default:
+			v.missing++
+			v.done = v.cancelled || v.missing >= 2
+		}
+	}
+	if p := e.progress(); p.Filled > before+execution.SizeEpsilon {
+		e.event(fmt.Sprintf("filled %s/%s (%.1f%%) at an average of %s", plain(p.Filled), plain(p.Size), p.Filled/p.Size*100, plain(p.AveragePrice)), nil)
+	}
+}
+
+func (e *executor) open() []*childOrder {
+	res := []*childOrder{}
+	for _, v := range e.children {
+		if !v.done {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// committed returns the size filled and resting in open child orders.
+func (e *executor) committed() float64 {
+	size := 0.0
+	for _, v := range e.children {
+		if v.done {
+			size += v.filled
+		} else {
+			size += v.size
+		}
+	}
+	return size
+}
+
+// settle polls the open child orders until they are done. It is false when ctx is done first.
+func (e *executor) settle() bool {
+	for {
+		e.refresh()
+		if len(e.open()) == 0 {
+			return true
+		}
+		if !e.sleep(e.poll) {
+			return false
+		}
+	}
+}
+
+// waitUntil polls the open child orders until t. It is false when ctx is done first.
+func (e *executor) waitUntil(t time.Time) bool {
+	for {
+		e.refresh()
+		d := t.Sub(e.runner.now())
+		if d <= 0 {
+			return e.ctx.Err() == nil
+		}
+		if d > e.poll {
+			d = e.poll
+		}
+		if !e.sleep(d) {
+			return false
+		}
+	}
+}
+
+// sleep waits for d. It is false when ctx is done first.
+func (e *executor) sleep(d time.Duration) bool {
+	select {
+	case <-e.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (e *executor) progress() ExecutionProgress {
+	p := ExecutionProgress{Size: e.order.Size, Orders: len(e.children)}
+	cost := 0.0
+	for _, v := range e.children {
+		p.Filled += v.filled
+		cost += v.cost
+	}
+	if p.Filled > 0 {
+		p.AveragePrice = cost / p.Filled
+	}
+	return p
+}
+
+func (e *executor) event(message string, err error) {
+	if e.runner.OnEvent != nil {
+		e.runner.OnEvent(ExecutionEvent{Time: e.runner.now(), Message: message, Progress: e.progress(), Err: err})
+	}
+}
+
+// plain formats v without an exponent, rounded to the order size step.
+func plain(v float64) string {
+	return strconv.FormatFloat(execution.Round(v), 'f', -1, 64)
+}
+
+func side(buy bool) string {
+	if buy {
+		return string(bitflyer.SideBuy)
+	}
+	return string(bitflyer.SideSell)
+}
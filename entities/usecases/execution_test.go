@@ -0,0 +1,312 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// fakeExchange keeps the orders sent through a mocked client. Orders fill at once when fill returns
+// true for them, and otherwise rest until cancelled.
+type fakeExchange struct {
+	mu        sync.Mutex
+	sent      []bitflyer.SendOrderRequest
+	cancelled []string
+	active    map[string]bool
+	filled    map[string]bitflyer.SendOrderRequest
+	boards    int
+	board     func(n int) (float64, float64)
+	fill      func(req bitflyer.SendOrderRequest) bool
+	onSend    func()
+}
+
+func (f *fakeExchange) client() mockedBitFlyerClient {
+	f.active, f.filled = map[string]bool{}, map[string]bitflyer.SendOrderRequest{}
+	return mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.boards++
+			bid, ask := f.board(f.boards)
+			return &bitflyer.BoardResponse{Bids: bitflyer.PriceResponses{{Price: bid, Size: 1}}, Asks: bitflyer.PriceResponses{{Price: ask, Size: 1}}}, nil
+		},
+		sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+			f.mu.Lock()
+			f.sent = append(f.sent, req)
+			id := fmt.Sprintf("id%d", len(f.sent))
+			if f.fill(req) {
+				f.filled[id] = req
+			} else {
+				f.active[id] = true
+			}
+			f.mu.Unlock()
+			if f.onSend != nil {
+				f.onSend()
+			}
+			return &bitflyer.OrderResponse{ChildOrderAcceptanceId: id}, nil
+		},
+		cancelOrder: func(req bitflyer.CancelOrderRequest) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.cancelled = append(f.cancelled, req.ChildOrderAcceptanceId)
+			delete(f.active, req.ChildOrderAcceptanceId)
+			return nil
+		},
+		getMyExecs: func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			req, ok := f.filled[id]
+			if !ok {
+				return bitflyer.GetPrivateExecutionsResponse{}, nil
+			}
+			price := req.Price
+			if price == 0 {
+				price = 100
+			}
+			return bitflyer.GetPrivateExecutionsResponse{{Price: price, Size: req.Size, ChildOrderAcceptanceId: id}}, nil
+		},
+		getOrders: func(pc string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			res := bitflyer.GetChildOrdersResponse{}
+			for id := range f.active {
+				res = append(res, bitflyer.ChildOrderResponse{ChildOrderAcceptanceId: id})
+			}
+			return res, nil
+		},
+	}
+}
+
+func TestExecutionUseCase_Run(t *testing.T) {
+	market := func(size float64) bitflyer.SendOrderRequest {
+		return bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Size: size, ChildOrderType: bitflyer.ChildOrderTypeMarket, TimeInForce: bitflyer.TimeInForceGTC}
+	}
+	limit := func(price float64, size float64) bitflyer.SendOrderRequest {
+		return bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Price: price, Size: size, ChildOrderType: bitflyer.ChildOrderTypeLimit, TimeInForce: bitflyer.TimeInForceGTC}
+	}
+	spread := func(n int) (float64, float64) { return 100, 101 }
+
+	tests := []struct {
+		name          string
+		order         execution.Order
+		board         func(n int) (float64, float64)
+		fill          func(req bitflyer.SendOrderRequest) bool
+		interrupt     bool
+		want          ExecutionResult
+		wantSent      []bitflyer.SendOrderRequest
+		wantCancelled []string
+		wantErr       bool
+	}{
+		{
+			name:  "TWAP",
+			order: execution.Order{Algorithm: execution.TWAP, ProductCode: "BTC_JPY", Buy: true, Size: 0.03, Duration: 3 * time.Millisecond, Slices: 3},
+			board: spread,
+			fill:  func(req bitflyer.SendOrderRequest) bool { return true },
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.03, Filled: 0.03, AveragePrice: 100, Orders: 3},
+			},
+			wantSent:      []bitflyer.SendOrderRequest{market(0.01), market(0.01), market(0.01)},
+			wantCancelled: []string{},
+		},
+		{
+			name:  "TWAP Carried Over Beyond The Limit",
+			order: execution.Order{Algorithm: execution.TWAP, ProductCode: "BTC_JPY", Buy: true, Size: 0.02, Duration: 2 * time.Millisecond, Slices: 2, Price: 100},
+			board: func(n int) (float64, float64) {
+				if n == 1 {
+					return 100, 101
+				}
+				return 99, 100
+			},
+			fill: func(req bitflyer.SendOrderRequest) bool { return true },
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.02, Filled: 0.02, AveragePrice: 100, Orders: 1},
+			},
+			wantSent:      []bitflyer.SendOrderRequest{market(0.02)},
+			wantCancelled: []string{},
+		},
+		{
+			name:  "Iceberg",
+			order: execution.Order{Algorithm: execution.Iceberg, ProductCode: "BTC_JPY", Buy: true, Size: 0.025, Display: 0.01, Price: 99},
+			board: spread,
+			fill:  func(req bitflyer.SendOrderRequest) bool { return true },
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.025, Filled: 0.025, AveragePrice: 99, Orders: 3},
+			},
+			wantSent:      []bitflyer.SendOrderRequest{limit(99, 0.01), limit(99, 0.01), limit(99, 0.005)},
+			wantCancelled: []string{},
+		},
+		{
+			name:  "Chase Up To The Max Slippage",
+			order: execution.Order{Algorithm: execution.Chase, ProductCode: "BTC_JPY", Buy: true, Size: 0.01, MaxSlippage: 5},
+			board: func(n int) (float64, float64) {
+				switch {
+				case n <= 2:
+					return 100, 101
+				case n <= 4:
+					return 101, 102
+				}
+				return 110, 111
+			},
+			fill: func(req bitflyer.SendOrderRequest) bool { return false },
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.01, Orders: 2},
+				Reason:            "the best price 110 is beyond the max slippage limit 105",
+			},
+			wantSent:      []bitflyer.SendOrderRequest{limit(100, 0.01), limit(101, 0.01)},
+			wantCancelled: []string{"id1", "id2"},
+		},
+		{
+			name:  "Chase Filled",
+			order: execution.Order{Algorithm: execution.Chase, ProductCode: "BTC_JPY", Buy: true, Size: 0.01, MaxSlippage: 1},
+			board: spread,
+			fill:  func(req bitflyer.SendOrderRequest) bool { return true },
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.01, Filled: 0.01, AveragePrice: 100, Orders: 1},
+			},
+			wantSent:      []bitflyer.SendOrderRequest{limit(100, 0.01)},
+			wantCancelled: []string{},
+		},
+		{
+			name:      "Interrupted",
+			order:     execution.Order{Algorithm: execution.Iceberg, ProductCode: "BTC_JPY", Buy: true, Size: 0.03, Display: 0.01, Price: 99},
+			board:     spread,
+			fill:      func(req bitflyer.SendOrderRequest) bool { return false },
+			interrupt: true,
+			want: ExecutionResult{
+				ExecutionProgress: ExecutionProgress{Size: 0.03, Orders: 1},
+				Reason:            "interrupted",
+			},
+			wantSent:      []bitflyer.SendOrderRequest{limit(99, 0.01)},
+			wantCancelled: []string{"id1"},
+		},
+		{
+			name:    "Below The Minimum Order Size",
+			order:   execution.Order{Algorithm: execution.Chase, ProductCode: "BTC_JPY", Buy: true, Size: 0.0001},
+			board:   spread,
+			fill:    func(req bitflyer.SendOrderRequest) bool { return true },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			f := &fakeExchange{board: tt.board, fill: tt.fill}
+			if tt.interrupt {
+				f.onSend = cancel
+			}
+			u := NewExecutionUseCase(f.client())
+			u.OnEvent = func(e ExecutionEvent) {
+				if e.Err != nil {
+					t.Errorf("ExecutionUseCase.Run() reported %v", e.Err)
+				}
+			}
+
+			got, err := u.Run(ctx, ExecutionQuery{Order: tt.order, PollInterval: time.Millisecond})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecutionUseCase.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ExecutionUseCase.Run() = %+v, want %+v", *got, tt.want)
+			}
+			if !reflect.DeepEqual(f.sent, tt.wantSent) {
+				t.Errorf("ExecutionUseCase.Run() sent %v, want %v", f.sent, tt.wantSent)
+			}
+			if f.cancelled == nil {
+				f.cancelled = []string{}
+			}
+			if !reflect.DeepEqual(f.cancelled, tt.wantCancelled) {
+				t.Errorf("ExecutionUseCase.Run() cancelled %v, want %v", f.cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+func TestExecutionUseCase_FirstOrder(t *testing.T) {
+	spread := func(n int) (float64, float64) { return 100, 101 }
+	tests := []struct {
+		name    string
+		order   execution.Order
+		board   func(n int) (float64, float64)
+		want    OrderCreate
+		wantErr bool
+	}{
+		{
+			name:  "TWAP",
+			order: execution.Order{Algorithm: execution.TWAP, ProductCode: "BTC_JPY", Buy: true, Size: 0.1, Duration: time.Hour, Slices: 3},
+			want:  OrderCreate{ProductCode: "BTC_JPY", Buy: true, Size: 0.03333333, Market: true},
+		},
+		{
+			name:  "Iceberg",
+			order: execution.Order{Algorithm: execution.Iceberg, ProductCode: "BTC_JPY", Size: 1, Display: 0.05, Price: 100},
+			want:  OrderCreate{ProductCode: "BTC_JPY", Size: 0.05, Price: 100},
+		},
+		{
+			name:  "Chase Sell",
+			order: execution.Order{Algorithm: execution.Chase, ProductCode: "BTC_JPY", Size: 0.2, MaxSlippage: 1},
+			board: spread,
+			want:  OrderCreate{ProductCode: "BTC_JPY", Size: 0.2, Price: 101},
+		},
+		{
+			name:    "Chase Without Quotes",
+			order:   execution.Order{Algorithm: execution.Chase, ProductCode: "BTC_JPY", Buy: true, Size: 0.2, MaxSlippage: 1},
+			board:   func(n int) (float64, float64) { return 0, 0 },
+			wantErr: true,
+		},
+		{
+			name:    "Invalid",
+			order:   execution.Order{Algorithm: execution.TWAP, ProductCode: "BTC_JPY", Buy: true, Size: 0.01, Duration: time.Hour, Slices: 100},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeExchange{board: tt.board}
+			u := NewExecutionUseCase(f.client())
+			got, err := u.FirstOrder(context.Background(), ExecutionQuery{Order: tt.order, PollInterval: time.Second})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecutionUseCase.FirstOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExecutionUseCase.FirstOrder() = %+v, want %+v", got, tt.want)
+			}
+			if len(f.sent) > 0 {
+				t.Errorf("ExecutionUseCase.FirstOrder() sent %v", f.sent)
+			}
+		})
+	}
+}
+
+func TestExecutionUseCase_DryRun(t *testing.T) {
+	order := execution.Order{Algorithm: execution.TWAP, ProductCode: "BTC_JPY", Buy: true, Size: 0.1, Duration: time.Hour, Slices: 2}
+	f := &fakeExchange{board: func(n int) (float64, float64) { return 100, 101 }}
+
+	u := NewExecutionUseCase(f.client())
+	got, err := u.DryRun(context.Background(), ExecutionQuery{Order: order, PollInterval: time.Second})
+	if err != nil {
+		t.Fatalf("ExecutionUseCase.DryRun() error = %v", err)
+	}
+	if got.Type != string(bitflyer.ChildOrderTypeMarket) || got.Size != 0.05 {
+		t.Errorf("ExecutionUseCase.DryRun() = %+v, want a market order of 0.05", got)
+	}
+
+	u = NewExecutionUseCase(mockedOrderChecker{mockedBitFlyerClient: f.client()})
+	if _, err := u.DryRun(context.Background(), ExecutionQuery{Order: order, PollInterval: time.Second}); !errors.Is(err, cerror.ErrRiskRejected) {
+		t.Errorf("ExecutionUseCase.DryRun() error = %v, want %v", err, cerror.ErrRiskRejected)
+	}
+	if len(f.sent) > 0 {
+		t.Errorf("ExecutionUseCase.DryRun() sent %v", f.sent)
+	}
+}
@@ -0,0 +1,502 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/infrastructures/tradecsv"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/internal/logging"
+)
+
+// JournalStore persists the journal.
+type JournalStore interface {
+	Load() (*journal.Journal, error)
+	AddOrder(o journal.Order) (journal.Order, error)
+	AddFills(fills []journal.Fill) (int, error)
+	AddTransfers(transfers []journal.Transfer) (int, error)
+	AddSnapshot(s journal.Snapshot) error
+}
+
+var _ JournalStore = &journal.FileStore{}
+
+// OrderJournal is a BitFlyerClient recording every order accepted by the broker in a journal.
+// Other calls go to the wrapped client unchanged.
+type OrderJournal struct {
+	BitFlyerClient
+	store  JournalStore
+	broker string
+	now    func() time.Time
+}
+
+// NewOrderJournal returns an OrderJournal of client. broker names the broker in the journal,
+// such as journal.Paper for the paper broker.
+func NewOrderJournal(client BitFlyerClient, store JournalStore, broker string) *OrderJournal {
+	return &OrderJournal{
+		BitFlyerClient: client,
+		store:          store,
+		broker:         broker,
+		now:            time.Now,
+	}
+}
+
+var _ OrderChecker = &OrderJournal{}
+
+// SendOrder sends the order and records it once accepted. When it can not be recorded, the
+// failure is logged and the order is still returned as sent, so that callers keep its acceptance id.
+func (j *OrderJournal) SendOrder(ctx context.Context, req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+	res, err := j.BitFlyerClient.SendOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	o := journal.Order{
+		Broker:       j.broker,
+		ProductCode:  req.ProductCode,
+		AcceptanceID: res.ChildOrderAcceptanceId,
+		Side:         string(req.Side),
+		Type:         string(req.ChildOrderType),
+		Price:        req.Price,
+		Size:         req.Size,
+		SentAt:       j.now(),
+	}
+	if req.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+		o.Price = 0
+	}
+	if _, err := j.store.AddOrder(o); err != nil {
+		logging.Default().Error("order was sent but not journaled", "acceptance_id", res.ChildOrderAcceptanceId, "error", err)
+	}
+	return res, nil
+}
+
+// CheckOrder runs the checks of the wrapped client, if it has any.
+func (j *OrderJournal) CheckOrder(ctx context.Context, req bitflyer.SendOrderRequest) error {
+	if checker, ok := j.BitFlyerClient.(OrderChecker); ok {
+		return checker.CheckOrder(ctx, req)
+	}
+	return nil
+}
+
+// KabucomOrderJournal is a KabucomOrderClient recording every order accepted by kabu STATION in a
+// journal, like OrderJournal. Other calls go to the wrapped client unchanged.
+type KabucomOrderJournal struct {
+	KabucomOrderClient
+	store  JournalStore
+	broker string
+	now    func() time.Time
+}
+
+// NewKabucomOrderJournal returns a KabucomOrderJournal of client. broker names the broker in the
+// journal.
+func NewKabucomOrderJournal(client KabucomOrderClient, store JournalStore, broker string) *KabucomOrderJournal {
+	return &KabucomOrderJournal{
+		KabucomOrderClient: client,
+		store:              store,
+		broker:             broker,
+		now:                time.Now,
+	}
+}
+
+var _ KabucomOrderChecker = &KabucomOrderJournal{}
+
+// SendOrder sends the order and records it once accepted. When it can not be recorded, the
+// failure is logged and the order is still returned as sent, as OrderJournal does.
+func (j *KabucomOrderJournal) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	id, err := j.KabucomOrderClient.SendOrder(ctx, token, order)
+	if err != nil {
+		return "", err
+	}
+
+	o := journal.Order{
+		Broker:       j.broker,
+		ProductCode:  order.Symbol,
+		AcceptanceID: id,
+		Side:         order.Side,
+		Type:         string(bitflyer.ChildOrderTypeLimit),
+		Price:        order.Price,
+		Size:         order.Qty,
+		SentAt:       j.now(),
+	}
+	if order.Price == 0 {
+		o.Type = string(bitflyer.ChildOrderTypeMarket)
+	}
+	if _, err := j.store.AddOrder(o); err != nil {
+		logging.Default().Error("order was sent but not journaled", "order_id", id, "error", err)
+	}
+	return id, nil
+}
+
+// CheckOrder runs the checks of the wrapped client, if it has any.
+func (j *KabucomOrderJournal) CheckOrder(ctx context.Context, token string, order kabucom.OrderRequest) error {
+	if checker, ok := j.KabucomOrderClient.(KabucomOrderChecker); ok {
+		return checker.CheckOrder(ctx, token, order)
+	}
+	return nil
+}
+
+// JournalKabucom provides the fills and balances of a kabu STATION account.
+type JournalKabucom interface {
+	GetExecutions(ctx context.Context, token string) ([]kabucom.Execution, error)
+	GetCashWallet(ctx context.Context, token string) (float64, error)
+}
+
+var _ JournalKabucom = &kabucom.KabucomClient{}
+var _ JournalKabucom = &paper.Kabucom{}
+
+type JournalSyncQuery struct {
+	// ProductCodes are the bitFlyer products whose fills are pulled, in addition to the
+	// products of the journaled orders.
+	ProductCodes []string
+	// KabucomToken is the kabu STATION token. The kabu STATION account is skipped without it.
+	KabucomToken string
+}
+
+type JournalSyncResult struct {
+	// Fills is the number of fills added, by broker.
+	Fills map[string]int
+	// Transfers is the number of crypto deposits and withdrawals added.
+	Transfers int
+	Snapshots int
+}
+
+// historyPageSize is the count of fills and transfers fetched per request by Sync.
+const historyPageSize = 100
+
+type JournalUseCase struct {
+	store    JournalStore
+	bitflyer BitFlyerClient
+	// broker names the account of bitflyer in the journal.
+	broker  string
+	kabucom JournalKabucom
+	// kabucomBroker names the account of kabucom in the journal.
+	kabucomBroker string
+	now           func() time.Time
+	pageSize      int
+}
+
+func NewJournalUseCase(store JournalStore, bitflyer BitFlyerClient, broker string, kabucom JournalKabucom, kabucomBroker string) JournalUseCase {
+	return JournalUseCase{
+		store:         store,
+		bitflyer:      bitflyer,
+		broker:        broker,
+		kabucom:       kabucom,
+		kabucomBroker: kabucomBroker,
+		now:           time.Now,
+		pageSize:      historyPageSize,
+	}
+}
+
+// Sync pulls the fills of the execution history and the transfers of crypto assets, and snapshots
+// the balances of the accounts.
+// The history is paged back from the latest until a page holds only records of the journal, so the
+// first sync pulls it all. Records already in the journal are skipped, so it can run any number of
+// times.
+func (u *JournalUseCase) Sync(ctx context.Context, query JournalSyncQuery) (*JournalSyncResult, error) {
+	j, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	result := &JournalSyncResult{Fills: map[string]int{}}
+
+	codes := append([]string{}, query.ProductCodes...)
+	for _, v := range j.ProductCodes(u.broker) {
+		if !contains(codes, v) {
+			codes = append(codes, v)
+		}
+	}
+	known := map[string]bool{}
+	for _, v := range j.Fills {
+		if v.Broker == u.broker {
+			known[v.ID] = true
+		}
+	}
+	for _, code := range codes {
+		fills, err := u.bitflyerFills(ctx, code, known)
+		if err != nil {
+			return nil, err
+		}
+		added, err := u.store.AddFills(fills)
+		if err != nil {
+			return nil, err
+		}
+		result.Fills[u.broker] += added
+	}
+
+	known = map[string]bool{}
+	for _, v := range j.Transfers {
+		if v.Broker == u.broker {
+			known[v.ID] = true
+		}
+	}
+	transfers, err := u.bitflyerTransfers(ctx, known)
+	if err != nil {
+		return nil, err
+	}
+	if result.Transfers, err = u.store.AddTransfers(transfers); err != nil {
+		return nil, err
+	}
+
+	balances, err := u.bitflyer.GetBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balances: %w", err)
+	}
+	snapshot := journal.Snapshot{Broker: u.broker, Time: u.now(), Balances: []journal.Balance{}}
+	for _, v := range balances {
+		snapshot.Balances = append(snapshot.Balances, journal.Balance{Currency: v.CurrencyCode, Amount: v.Amount, Available: v.Available})
+	}
+	if err := u.store.AddSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	result.Snapshots++
+
+	if query.KabucomToken == "" {
+		return result, nil
+	}
+
+	fills, err := u.kabucomFills(ctx, query.KabucomToken)
+	if err != nil {
+		return nil, err
+	}
+	added, err := u.store.AddFills(fills)
+	if err != nil {
+		return nil, err
+	}
+	result.Fills[u.kabucomBroker] += added
+
+	cash, err := u.kabucom.GetCashWallet(ctx, query.KabucomToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch kabu STATION wallet: %w", err)
+	}
+	snapshot = journal.Snapshot{
+		Broker:   u.kabucomBroker,
+		Time:     u.now(),
+		Balances: []journal.Balance{{Currency: "JPY", Amount: cash, Available: cash}},
+	}
+	if err := u.store.AddSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	result.Snapshots++
+
+	return result, nil
+}
+
+// bitflyerFills returns the fills of productCode which are not known, paging back from the latest
+// until a page holds only known fills.
+func (u *JournalUseCase) bitflyerFills(ctx context.Context, productCode string, known map[string]bool) ([]journal.Fill, error) {
+	fills := []journal.Fill{}
+	page := bitflyer.Pagination{Count: u.pageSize}
+	for {
+		executions, err := u.bitflyer.GetMyExecutions(ctx, productCode, "", page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch executions of %s: %w", productCode, err)
+		}
+
+		before, fresh := page.Before, false
+		for _, v := range executions {
+			if page.Before == 0 || v.Id < page.Before {
+				page.Before = v.Id
+			}
+			id := strconv.FormatInt(v.Id, 10)
+			if known[id] {
+				continue
+			}
+			fresh = true
+
+			at, err := bitflyer.ParseTime(v.ExecDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exec_date of execution %d: %w", v.Id, err)
+			}
+			fills = append(fills, journal.Fill{
+				Broker:      u.broker,
+				ID:          id,
+				ProductCode: productCode,
+				OrderID:     v.ChildOrderAcceptanceId,
+				Side:        string(v.Side),
+				Price:       v.Price,
+				Size:        v.Size,
+				Commission:  v.Commission,
+				Time:        at,
+			})
+		}
+		if !fresh || page.Before == before || len(executions) < page.Count {
+			return fills, nil
+		}
+	}
+}
+
+// bitflyerTransfers returns the completed deposits and withdrawals of crypto assets which are not
+// known, paging back like bitflyerFills. Pending ones are pulled once completed, as their amount and
+// fee may still change.
+func (u *JournalUseCase) bitflyerTransfers(ctx context.Context, known map[string]bool) ([]journal.Transfer, error) {
+	transfers := []journal.Transfer{}
+
+	page := bitflyer.Pagination{Count: u.pageSize}
+	for {
+		ins, err := u.bitflyer.GetCoinIns(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch deposits: %w", err)
+		}
+
+		before, fresh := page.Before, false
+		for _, v := range ins {
+			if page.Before == 0 || v.Id < page.Before {
+				page.Before = v.Id
+			}
+			id := fmt.Sprintf("in-%d", v.Id)
+			if known[id] {
+				continue
+			}
+			// Pending ones are not journaled, and keep the paging going until they are.
+			fresh = true
+			if v.Status != "COMPLETED" {
+				continue
+			}
+
+			at, err := bitflyer.ParseTime(v.EventDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid event_date of deposit %d: %w", v.Id, err)
+			}
+			transfers = append(transfers, journal.Transfer{
+				Broker:   u.broker,
+				ID:       id,
+				Currency: v.CurrencyCode,
+				Amount:   v.Amount,
+				Time:     at,
+			})
+		}
+		if !fresh || page.Before == before || len(ins) < page.Count {
+			break
+		}
+	}
+
+	page = bitflyer.Pagination{Count: u.pageSize}
+	for {
+		outs, err := u.bitflyer.GetCoinOuts(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch withdrawals: %w", err)
+		}
+
+		before, fresh := page.Before, false
+		for _, v := range outs {
+			if page.Before == 0 || v.Id < page.Before {
+				page.Before = v.Id
+			}
+			id := fmt.Sprintf("out-%d", v.Id)
+			if known[id] {
+				continue
+			}
+			fresh = true
+			if v.Status != "COMPLETED" {
+				continue
+			}
+
+			at, err := bitflyer.ParseTime(v.EventDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid event_date of withdrawal %d: %w", v.Id, err)
+			}
+			transfers = append(transfers, journal.Transfer{
+				Broker:   u.broker,
+				ID:       id,
+				Currency: v.CurrencyCode,
+				Amount:   -v.Amount,
+				Fee:      v.Fee + v.AdditionalFee,
+				Time:     at,
+			})
+		}
+		if !fresh || page.Before == before || len(outs) < page.Count {
+			return transfers, nil
+		}
+	}
+}
+
+func (u *JournalUseCase) kabucomFills(ctx context.Context, token string) ([]journal.Fill, error) {
+	executions, err := u.kabucom.GetExecutions(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch kabu STATION executions: %w", err)
+	}
+
+	fills := []journal.Fill{}
+	for _, v := range executions {
+		fills = append(fills, journal.Fill{
+			Broker:      u.kabucomBroker,
+			ID:          v.ID,
+			ProductCode: v.Symbol,
+			OrderID:     v.OrderID,
+			Side:        v.Side,
+			Price:       v.Price,
+			Size:        v.Qty,
+			Commission:  v.Commission,
+			Time:        v.Time,
+		})
+	}
+	return fills, nil
+}
+
+type JournalImportResult struct {
+	Fills     int
+	Transfers int
+	// Duplicates is the number of records already in the journal, such as fills pulled by Sync.
+	Duplicates int
+	// Skipped is the number of rows of the file which are neither fills nor transfers.
+	Skipped int
+}
+
+// Import adds the records of a file to the journal. Records already imported have the same id and
+// are skipped by the store. Records pulled from the API have other ids, and are matched by the store
+// within the time precision of the file.
+func (u *JournalUseCase) Import(records *tradecsv.Records) (*JournalImportResult, error) {
+	result := &JournalImportResult{Skipped: records.Skipped}
+
+	fills := []journal.Fill{}
+	for _, v := range records.Fills {
+		v.Precision = records.Precision
+		fills = append(fills, v)
+	}
+	transfers := []journal.Transfer{}
+	for _, v := range records.Transfers {
+		v.Precision = records.Precision
+		transfers = append(transfers, v)
+	}
+
+	var err error
+	if result.Fills, err = u.store.AddFills(fills); err != nil {
+		return nil, err
+	}
+	if result.Transfers, err = u.store.AddTransfers(transfers); err != nil {
+		return nil, err
+	}
+	result.Duplicates = len(fills) - result.Fills + len(transfers) - result.Transfers
+	return result, nil
+}
+
+// List returns the records selected by filter.
+func (u *JournalUseCase) List(filter journal.Filter) (*journal.Journal, error) {
+	j, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return j.Filter(filter), nil
+}
+
+// Show returns the order id and its fills.
+func (u *JournalUseCase) Show(id int) (journal.Order, []journal.Fill, error) {
+	j, err := u.store.Load()
+	if err != nil {
+		return journal.Order{}, nil, err
+	}
+	return j.Order(id)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,529 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer/bitflyertest"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/tradecsv"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/risk"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedJournalKabucom struct {
+	executions []kabucom.Execution
+	cash       float64
+}
+
+func (m mockedJournalKabucom) GetExecutions(ctx context.Context, token string) ([]kabucom.Execution, error) {
+	if token != "token" {
+		return nil, cerror.ErrUnAuthorized
+	}
+	return m.executions, nil
+}
+
+func (m mockedJournalKabucom) GetCashWallet(ctx context.Context, token string) (float64, error) {
+	return m.cash, nil
+}
+
+func TestOrderJournal_SendOrder(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		req         bitflyer.SendOrderRequest
+		sendErr     error
+		expected    []journal.Order
+		expectedErr error
+	}{
+		{
+			name: "limit",
+			req:  bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01},
+			expected: []journal.Order{
+				{ID: 1, Broker: journal.Paper, ProductCode: "BTC_JPY", AcceptanceID: "JRF1", Side: "BUY", Type: "LIMIT", Price: 5000000, Size: 0.01, SentAt: now},
+			},
+		},
+		{
+			name: "market",
+			req:  bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideSell, Price: 100, Size: 1},
+			expected: []journal.Order{
+				{ID: 1, Broker: journal.Paper, ProductCode: "BTC_JPY", AcceptanceID: "JRF1", Side: "SELL", Type: "MARKET", Size: 1, SentAt: now},
+			},
+		},
+		{
+			name:        "rejected orders are not recorded",
+			req:         bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeLimit, Side: bitflyer.SideBuy, Price: 1, Size: 1},
+			sendErr:     cerror.ErrInsufficientFunds,
+			expected:    []journal.Order{},
+			expectedErr: cerror.ErrInsufficientFunds,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+			client := mockedBitFlyerClient{
+				sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+					if tt.sendErr != nil {
+						return nil, tt.sendErr
+					}
+					return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "JRF1"}, nil
+				},
+			}
+			j := NewOrderJournal(client, store, journal.Paper)
+			j.now = func() time.Time { return now }
+
+			_, err := j.SendOrder(context.Background(), tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("OrderJournal.SendOrder() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			got, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Orders, tt.expected) {
+				t.Errorf("OrderJournal.SendOrder() journaled %+v, expected %+v", got.Orders, tt.expected)
+			}
+		})
+	}
+}
+
+// brokenJournalStore fails to record orders.
+type brokenJournalStore struct {
+	JournalStore
+}
+
+func (s brokenJournalStore) AddOrder(o journal.Order) (journal.Order, error) {
+	return journal.Order{}, errors.New("disk full")
+}
+
+func TestOrderJournal_SendOrderNotJournaled(t *testing.T) {
+	client := mockedBitFlyerClient{
+		sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+			return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "JRF1"}, nil
+		},
+	}
+	j := NewOrderJournal(client, brokenJournalStore{}, journal.Paper)
+
+	// The order is live, so its acceptance id is returned without an error.
+	res, err := j.SendOrder(context.Background(), bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideBuy, Size: 0.01})
+	if err != nil || res == nil || res.ChildOrderAcceptanceId != "JRF1" {
+		t.Errorf("OrderJournal.SendOrder() = %v, %v, want JRF1 without an error", res, err)
+	}
+}
+
+func TestKabucomOrderJournal_SendOrder(t *testing.T) {
+	now := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	sent := 0
+	// Orders over 1000000 JPY are rejected before they are sent.
+	guard := NewKabucomRiskGuard(mockedRiskKabucom{sent: &sent}, risk.Limits{MaxOrderNotional: 1000000}, &mockedDailyState{}, journal.Kabucom)
+	j := NewKabucomOrderJournal(guard, store, journal.Kabucom)
+	j.now = func() time.Time { return now }
+
+	if _, err := j.SendOrder(context.Background(), "token", kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10000}); !errors.Is(err, cerror.ErrRiskRejected) {
+		t.Errorf("KabucomOrderJournal.SendOrder() error = %v, expectedError %v", err, cerror.ErrRiskRejected)
+	}
+	if err := j.CheckOrder(context.Background(), "token", kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10000}); !errors.Is(err, cerror.ErrRiskRejected) {
+		t.Errorf("KabucomOrderJournal.CheckOrder() error = %v, expectedError %v", err, cerror.ErrRiskRejected)
+	}
+	id, err := j.SendOrder(context.Background(), "token", kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 100, Price: 2000})
+	if err != nil {
+		t.Fatalf("KabucomOrderJournal.SendOrder() error = %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []journal.Order{
+		{ID: 1, Broker: journal.Kabucom, ProductCode: "1306@1", AcceptanceID: id, Side: "BUY", Type: "LIMIT", Price: 2000, Size: 100, SentAt: now},
+	}
+	if sent != 1 || !reflect.DeepEqual(got.Orders, expected) {
+		t.Errorf("KabucomOrderJournal.SendOrder() sent %d orders and journaled %+v, expected 1 and %+v", sent, got.Orders, expected)
+	}
+}
+
+func TestOrderJournal_CheckOrder(t *testing.T) {
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	if err := NewOrderJournal(mockedOrderChecker{}, store, journal.BitFlyer).CheckOrder(context.Background(), bitflyer.SendOrderRequest{}); !errors.Is(err, cerror.ErrRiskRejected) {
+		t.Errorf("OrderJournal.CheckOrder() error = %v, expectedError %v", err, cerror.ErrRiskRejected)
+	}
+	if err := NewOrderJournal(mockedBitFlyerClient{}, store, journal.BitFlyer).CheckOrder(context.Background(), bitflyer.SendOrderRequest{}); err != nil {
+		t.Errorf("OrderJournal.CheckOrder() error = %v, expected none", err)
+	}
+}
+
+func TestJournalUseCase_Sync(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	var requested []string
+	client := mockedBitFlyerClient{
+		getMyExecs: func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+			requested = append(requested, pc)
+			if pc != "BTC_JPY" {
+				return bitflyer.GetPrivateExecutionsResponse{}, nil
+			}
+			return bitflyer.GetPrivateExecutionsResponse{
+				{Id: 2, Side: bitflyer.SideSell, Price: 5100000, Size: 0.01, Commission: 0.00001, ExecDate: "2023-04-01T00:00:02.000", ChildOrderAcceptanceId: "JRF2"},
+				{Id: 1, Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01, ExecDate: "2023-04-01T00:00:01.000", ChildOrderAcceptanceId: "JRF1"},
+			}, nil
+		},
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{{CurrencyCode: "JPY", Amount: 1000, Available: 900}}, nil
+		},
+		getCoinIns: func(page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error) {
+			return bitflyer.GetCoinInsResponse{
+				{Id: 2, CurrencyCode: "BTC", Amount: 0.2, Status: "PENDING", EventDate: "2023-04-01T00:00:04.000"},
+				{Id: 1, CurrencyCode: "BTC", Amount: 0.1, Status: "COMPLETED", EventDate: "2023-04-01T00:00:03.000"},
+			}, nil
+		},
+		getCoinOuts: func(page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error) {
+			return bitflyer.GetCoinOutsResponse{
+				{Id: 1, CurrencyCode: "BTC", Amount: 0.05, Fee: 0.0004, AdditionalFee: 0.0001, Status: "COMPLETED", EventDate: "2023-04-01T00:00:05.000"},
+			}, nil
+		},
+	}
+	market := mockedJournalKabucom{
+		executions: []kabucom.Execution{{ID: "E1", OrderID: "K1", Symbol: "9433@1", Side: "BUY", Price: 4000, Qty: 100, Time: now}},
+		cash:       500000,
+	}
+
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	if _, err := store.AddOrder(journal.Order{Broker: journal.BitFlyer, ProductCode: "ETH_JPY"}); err != nil {
+		t.Fatal(err)
+	}
+	u := NewJournalUseCase(store, client, journal.BitFlyer, market, journal.Kabucom)
+	u.now = func() time.Time { return now }
+
+	tests := []struct {
+		name        string
+		query       JournalSyncQuery
+		expected    JournalSyncResult
+		expectedErr error
+	}{
+		{
+			name:     "first",
+			query:    JournalSyncQuery{ProductCodes: []string{"BTC_JPY"}, KabucomToken: "token"},
+			expected: JournalSyncResult{Fills: map[string]int{journal.BitFlyer: 2, journal.Kabucom: 1}, Transfers: 2, Snapshots: 2},
+		},
+		{
+			name:     "again adds no fills",
+			query:    JournalSyncQuery{ProductCodes: []string{"BTC_JPY"}, KabucomToken: "token"},
+			expected: JournalSyncResult{Fills: map[string]int{journal.BitFlyer: 0, journal.Kabucom: 0}, Snapshots: 2},
+		},
+		{
+			name:     "without kabucom",
+			query:    JournalSyncQuery{ProductCodes: []string{"BTC_JPY"}},
+			expected: JournalSyncResult{Fills: map[string]int{journal.BitFlyer: 0}, Snapshots: 1},
+		},
+		{
+			name:        "kabucom error",
+			query:       JournalSyncQuery{KabucomToken: "stale"},
+			expectedErr: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			got, err := u.Sync(context.Background(), tt.query)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("JournalUseCase.Sync() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.expected) {
+				t.Errorf("JournalUseCase.Sync() = %+v, expected %+v", *got, tt.expected)
+			}
+			// The products of journaled orders are pulled too.
+			if !reflect.DeepEqual(requested, []string{"BTC_JPY", "ETH_JPY"}) {
+				t.Errorf("JournalUseCase.Sync() requested %v", requested)
+			}
+		})
+	}
+
+	j, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []journal.Fill{
+		{Broker: journal.Kabucom, ID: "E1", ProductCode: "9433@1", OrderID: "K1", Side: "BUY", Price: 4000, Size: 100, Time: now},
+		{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", OrderID: "JRF1", Side: "BUY", Price: 5000000, Size: 0.01, Time: now.Add(time.Second)},
+		{Broker: journal.BitFlyer, ID: "2", ProductCode: "BTC_JPY", OrderID: "JRF2", Side: "SELL", Price: 5100000, Size: 0.01, Commission: 0.00001, Time: now.Add(2 * time.Second)},
+	}
+	for i := range j.Fills {
+		j.Fills[i].Time = j.Fills[i].Time.UTC()
+	}
+	if !reflect.DeepEqual(j.Fills, expected) {
+		t.Errorf("JournalUseCase.Sync() journaled %+v, expected %+v", j.Fills, expected)
+	}
+	transfers := []journal.Transfer{
+		{Broker: journal.BitFlyer, ID: "in-1", Currency: "BTC", Amount: 0.1, Time: now.Add(3 * time.Second)},
+		{Broker: journal.BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.05, Fee: 0.0005, Time: now.Add(5 * time.Second)},
+	}
+	for i := range j.Transfers {
+		j.Transfers[i].Time = j.Transfers[i].Time.UTC()
+	}
+	if !reflect.DeepEqual(j.Transfers, transfers) {
+		t.Errorf("JournalUseCase.Sync() journaled %+v, expected %+v", j.Transfers, transfers)
+	}
+	// The failed sync keeps the bitFlyer snapshot taken before the failure.
+	if len(j.Snapshots) != 6 || j.Snapshots[0].Balances[0] != (journal.Balance{Currency: "JPY", Amount: 1000, Available: 900}) {
+		t.Errorf("JournalUseCase.Sync() snapshots = %+v", j.Snapshots)
+	}
+}
+
+func TestJournalUseCase_SyncPages(t *testing.T) {
+	s, ts := bitflyertest.NewTestServer(bitflyertest.DefaultConfig())
+	defer ts.Close()
+	client := s.NewClient(ts.URL)
+	ctx := context.Background()
+
+	buy := func(n int) {
+		for i := 0; i < n; i++ {
+			req := bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Size: 0.001, ChildOrderType: bitflyer.ChildOrderTypeMarket}
+			if _, err := client.SendOrder(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	u := NewJournalUseCase(store, client, journal.BitFlyer, mockedJournalKabucom{}, journal.Kabucom)
+	u.pageSize = 2
+
+	// More fills than a page, then more than a page since the last sync.
+	for _, n := range []int{5, 3} {
+		buy(n)
+		got, err := u.Sync(ctx, JournalSyncQuery{ProductCodes: []string{"BTC_JPY"}})
+		if err != nil {
+			t.Fatalf("JournalUseCase.Sync() error = %v", err)
+		}
+		if got.Fills[journal.BitFlyer] != n {
+			t.Errorf("JournalUseCase.Sync() added %d fills, expected %d", got.Fills[journal.BitFlyer], n)
+		}
+	}
+
+	j, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, v := range j.Fills {
+		ids[v.ID] = true
+	}
+	if len(j.Fills) != 8 || len(ids) != 8 {
+		t.Errorf("JournalUseCase.Sync() journaled %+v, expected 8 distinct fills", j.Fills)
+	}
+}
+
+func TestJournalUseCase_SyncTransferPages(t *testing.T) {
+	ins := bitflyer.GetCoinInsResponse{}
+	for i := int64(5); i >= 1; i-- {
+		ins = append(ins, bitflyer.CoinInResponse{Id: i, CurrencyCode: "BTC", Amount: 0.1, Status: "COMPLETED", EventDate: "2023-04-01T00:00:00.000"})
+	}
+	outs := bitflyer.GetCoinOutsResponse{}
+	for i := int64(3); i >= 1; i-- {
+		outs = append(outs, bitflyer.CoinOutResponse{Id: i, CurrencyCode: "BTC", Amount: 0.01, Status: "COMPLETED", EventDate: "2023-04-01T00:00:00.000"})
+	}
+	inPage := func(id int64, page bitflyer.Pagination, n int) bool {
+		return (page.Before == 0 || id < page.Before) && id > page.After && n < page.Count
+	}
+	pages := 0
+	client := mockedBitFlyerClient{
+		getMyExecs: func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+			return bitflyer.GetPrivateExecutionsResponse{}, nil
+		},
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{}, nil
+		},
+		getCoinIns: func(page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error) {
+			pages++
+			res := bitflyer.GetCoinInsResponse{}
+			for _, v := range ins {
+				if inPage(v.Id, page, len(res)) {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+		getCoinOuts: func(page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error) {
+			pages++
+			res := bitflyer.GetCoinOutsResponse{}
+			for _, v := range outs {
+				if inPage(v.Id, page, len(res)) {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+	}
+
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	u := NewJournalUseCase(store, client, journal.BitFlyer, mockedJournalKabucom{}, journal.Kabucom)
+	u.pageSize = 2
+
+	got, err := u.Sync(context.Background(), JournalSyncQuery{})
+	if err != nil {
+		t.Fatalf("JournalUseCase.Sync() error = %v", err)
+	}
+	if got.Transfers != 8 {
+		t.Errorf("JournalUseCase.Sync() added %d transfers, expected 8", got.Transfers)
+	}
+
+	// A new deposit is pulled from the latest page only.
+	ins = append(bitflyer.GetCoinInsResponse{{Id: 6, CurrencyCode: "BTC", Amount: 0.1, Status: "COMPLETED", EventDate: "2023-04-02T00:00:00.000"}}, ins...)
+	pages = 0
+	got, err = u.Sync(context.Background(), JournalSyncQuery{})
+	if err != nil {
+		t.Fatalf("JournalUseCase.Sync() error = %v", err)
+	}
+	if got.Transfers != 1 || pages != 3 {
+		t.Errorf("JournalUseCase.Sync() added %d transfers in %d pages, expected 1 in 3", got.Transfers, pages)
+	}
+}
+
+func TestJournalUseCase_Import(t *testing.T) {
+	at := time.Date(2023, 4, 1, 9, 0, 1, 0, time.UTC)
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	// Pulled from the API, with a fraction of a second.
+	if _, err := store.AddFills([]journal.Fill{
+		{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: at.Add(300 * time.Millisecond)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddTransfers([]journal.Transfer{
+		{Broker: journal.BitFlyer, ID: "in-1", Currency: "BTC", Amount: 0.1, Time: at.Add(500 * time.Millisecond)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	u := NewJournalUseCase(store, mockedBitFlyerClient{}, journal.BitFlyer, mockedJournalKabucom{}, journal.Kabucom)
+
+	records := &tradecsv.Records{
+		Fills: []journal.Fill{
+			// The fill pulled from the API and a second one of the same content.
+			{Broker: journal.BitFlyer, ID: "csv-a", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: at},
+			{Broker: journal.BitFlyer, ID: "csv-b", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: at},
+			// The next second is another fill.
+			{Broker: journal.BitFlyer, ID: "csv-c", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: at.Add(time.Second)},
+		},
+		Transfers: []journal.Transfer{
+			{Broker: journal.BitFlyer, ID: "csv-d", Currency: "BTC", Amount: 0.1, Time: at},
+			{Broker: journal.BitFlyer, ID: "csv-e", Currency: "BTC", Amount: -0.05, Fee: 0.0004, Time: at},
+		},
+		Precision: time.Second,
+		Skipped:   3,
+	}
+
+	tests := []struct {
+		name     string
+		expected JournalImportResult
+	}{
+		{
+			name:     "first",
+			expected: JournalImportResult{Fills: 2, Transfers: 1, Duplicates: 2, Skipped: 3},
+		},
+		{
+			name:     "again adds nothing",
+			expected: JournalImportResult{Duplicates: 5, Skipped: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.Import(records)
+			if err != nil {
+				t.Fatalf("JournalUseCase.Import() error = %v", err)
+			}
+			if *got != tt.expected {
+				t.Errorf("JournalUseCase.Import() = %+v, expected %+v", *got, tt.expected)
+			}
+		})
+	}
+
+	j, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, v := range j.Fills {
+		ids = append(ids, v.ID)
+	}
+	for _, v := range j.Transfers {
+		ids = append(ids, v.ID)
+	}
+	if expected := []string{"csv-b", "1", "csv-c", "csv-e", "in-1"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("JournalUseCase.Import() journaled %v, expected %v", ids, expected)
+	}
+}
+
+func TestJournalUseCase_ImportThenSync(t *testing.T) {
+	at := time.Date(2023, 4, 1, 9, 0, 1, 0, time.UTC)
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	client := mockedBitFlyerClient{
+		getMyExecs: func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+			return bitflyer.GetPrivateExecutionsResponse{
+				{Id: 2, Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01, ExecDate: "2023-04-01T09:00:02.100"},
+				{Id: 1, Side: bitflyer.SideBuy, Price: 5000000, Size: 0.01, ExecDate: "2023-04-01T09:00:01.300"},
+			}, nil
+		},
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{}, nil
+		},
+		getCoinIns: func(page bitflyer.Pagination) (bitflyer.GetCoinInsResponse, error) {
+			return bitflyer.GetCoinInsResponse{
+				{Id: 1, CurrencyCode: "BTC", Amount: 0.1, Status: "COMPLETED", EventDate: "2023-04-01T09:00:01.500"},
+			}, nil
+		},
+		getCoinOuts: func(page bitflyer.Pagination) (bitflyer.GetCoinOutsResponse, error) {
+			return bitflyer.GetCoinOutsResponse{}, nil
+		},
+	}
+	u := NewJournalUseCase(store, client, journal.BitFlyer, mockedJournalKabucom{}, journal.Kabucom)
+
+	// The history of the file ends with the first fill and the deposit.
+	records := &tradecsv.Records{
+		Fills: []journal.Fill{
+			{Broker: journal.BitFlyer, ID: "csv-a", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.01, Time: at},
+		},
+		Transfers: []journal.Transfer{
+			{Broker: journal.BitFlyer, ID: "csv-b", Currency: "BTC", Amount: 0.1, Time: at},
+		},
+		Precision: time.Second,
+	}
+	if _, err := u.Import(records); err != nil {
+		t.Fatalf("JournalUseCase.Import() error = %v", err)
+	}
+
+	for _, expected := range []JournalSyncResult{
+		{Fills: map[string]int{journal.BitFlyer: 1}, Snapshots: 1},
+		{Fills: map[string]int{journal.BitFlyer: 0}, Snapshots: 1},
+	} {
+		got, err := u.Sync(context.Background(), JournalSyncQuery{ProductCodes: []string{"BTC_JPY"}})
+		if err != nil {
+			t.Fatalf("JournalUseCase.Sync() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, expected) {
+			t.Errorf("JournalUseCase.Sync() = %+v, expected %+v", *got, expected)
+		}
+	}
+
+	j, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, v := range j.Fills {
+		ids = append(ids, v.ID)
+	}
+	for _, v := range j.Transfers {
+		ids = append(ids, v.ID)
+	}
+	if expected := []string{"1", "2", "in-1"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("JournalUseCase.Sync() journaled %v, expected %v", ids, expected)
+	}
+}
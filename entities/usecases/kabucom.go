@@ -1,9 +1,11 @@
 package usecases
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
 )
 
 type KabucomUseCase struct {
@@ -11,7 +13,8 @@ type KabucomUseCase struct {
 }
 
 type KabucomClient interface {
-	GetToken(pwd string) (string, error)
+	GetToken(ctx context.Context, pwd string) (string, error)
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
 }
 
 func NewKabucomUseCase(client KabucomClient) KabucomUseCase {
@@ -21,9 +24,10 @@ func NewKabucomUseCase(client KabucomClient) KabucomUseCase {
 }
 
 var _ KabucomClient = &kabucom.KabucomClient{}
+var _ KabucomClient = &paper.Kabucom{}
 
-func (k *KabucomUseCase) DoAuthorize(pwd string) (string, error) {
-	result, err := k.client.GetToken(pwd)
+func (k *KabucomUseCase) DoAuthorize(ctx context.Context, pwd string) (string, error) {
+	result, err := k.client.GetToken(ctx, pwd)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to authorize: %w", err)
@@ -31,3 +35,30 @@ func (k *KabucomUseCase) DoAuthorize(pwd string) (string, error) {
 
 	return result, nil
 }
+
+func (k *KabucomUseCase) GetBoard(ctx context.Context, token string, symbol string) (BoardInformation, error) {
+	result, err := k.client.GetBoard(ctx, token, symbol)
+
+	response := BoardInformation{}
+
+	if err != nil {
+		return response, fmt.Errorf("failed to fetch board: %w", err)
+	}
+
+	// kabu STATION reports no mid price; it is left to be computed from the best quotes.
+	for _, v := range result.Asks {
+		response.Asks = append(response.Asks, BoardPrice{
+			Price: v.Price,
+			Size:  v.Qty,
+		})
+	}
+
+	for _, v := range result.Bids {
+		response.Bids = append(response.Bids, BoardPrice{
+			Price: v.Price,
+			Size:  v.Qty,
+		})
+	}
+
+	return response, nil
+}
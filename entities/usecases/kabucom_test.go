@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedKabucomClient struct {
+	getToken func(pwd string) (string, error)
+	getBoard func(token string, symbol string) (*kabucom.BoardResponse, error)
+}
+
+func (m mockedKabucomClient) GetToken(ctx context.Context, pwd string) (string, error) {
+	return m.getToken(pwd)
+}
+func (m mockedKabucomClient) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	return m.getBoard(token, symbol)
+}
+
+func TestKabucomUseCase_GetBoard(t *testing.T) {
+	type fields struct {
+		Client KabucomClient
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		want        BoardInformation
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			fields: fields{
+				Client: mockedKabucomClient{
+					getBoard: func(token string, symbol string) (*kabucom.BoardResponse, error) {
+						return &kabucom.BoardResponse{
+							Symbol:       "9433",
+							CurrentPrice: 4000,
+							Bids:         []kabucom.BoardLevel{{Price: 3999, Qty: 100}},
+							Asks:         []kabucom.BoardLevel{{Price: 4001, Qty: 200}},
+						}, nil
+					},
+				},
+			},
+			want: BoardInformation{
+				Asks: []BoardPrice{{Price: 4001, Size: 200}},
+				Bids: []BoardPrice{{Price: 3999, Size: 100}},
+			},
+		},
+		{
+			name: "error",
+			fields: fields{
+				Client: mockedKabucomClient{
+					getBoard: func(token string, symbol string) (*kabucom.BoardResponse, error) {
+						return nil, cerror.ErrUnAuthorized
+					},
+				},
+			},
+			wantErr:     true,
+			expectedErr: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKabucomUseCase(tt.fields.Client)
+			got, err := k.GetBoard(context.Background(), "token", "9433@1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("KabucomUseCase.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (err != nil) && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("KabucomUseCase.GetBoard() error = %v, expectedErr %v", err, tt.expectedErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("KabucomUseCase.GetBoard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
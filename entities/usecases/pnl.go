@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/pnl"
+)
+
+// PnLKabucom provides the quotes of kabu STATION.
+type PnLKabucom interface {
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
+}
+
+var _ PnLKabucom = &kabucom.KabucomClient{}
+var _ PnLKabucom = &paper.Kabucom{}
+
+type PnLQuery struct {
+	Method pnl.Method
+	// From and To bound the period, ignored when zero. Fills before From still make the cost
+	// of the positions.
+	From time.Time
+	To   time.Time
+	// Broker and ProductCode select the fills, every one when empty.
+	Broker      string
+	ProductCode string
+	// KabucomToken is the kabu STATION token. kabu STATION positions are not marked without it.
+	KabucomToken string
+}
+
+type PnLUseCase struct {
+	store    JournalStore
+	bitflyer BitFlyerClient
+	// broker names the account of bitflyer in the journal.
+	broker  string
+	kabucom PnLKabucom
+	// kabucomBroker names the account of kabucom in the journal.
+	kabucomBroker string
+}
+
+func NewPnLUseCase(store JournalStore, bitflyer BitFlyerClient, broker string, kabucom PnLKabucom, kabucomBroker string) PnLUseCase {
+	return PnLUseCase{
+		store:         store,
+		bitflyer:      bitflyer,
+		broker:        broker,
+		kabucom:       kabucom,
+		kabucomBroker: kabucomBroker,
+	}
+}
+
+// Report computes the profit and loss of the journaled fills. Open positions are marked at
+// current quotes: the mid price of bitFlyer boards and the current price of kabu STATION.
+func (u *PnLUseCase) Report(ctx context.Context, query PnLQuery) (*pnl.Report, error) {
+	j, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	fills := []pnl.Fill{}
+	for _, v := range j.Filter(journal.Filter{Broker: query.Broker, ProductCode: query.ProductCode, To: query.To}).Fills {
+		// The other accounts, live or paper, are not traded in this mode.
+		if v.Broker != u.broker && v.Broker != u.kabucomBroker {
+			continue
+		}
+		fee := v.Commission
+		if v.Broker == journal.BitFlyer {
+			// bitFlyer charges the commission in the currency bought or sold.
+			fee = v.Commission * v.Price
+		}
+		fills = append(fills, pnl.Fill{
+			Key:   pnl.Key{Broker: v.Broker, ProductCode: v.ProductCode},
+			Time:  v.Time,
+			Side:  v.Side,
+			Price: v.Price,
+			Size:  v.Size,
+			Fee:   fee,
+		})
+	}
+
+	book, err := pnl.Calculate(fills, query.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := pnl.Quotes{}
+	for _, v := range book.Positions {
+		switch v.Broker {
+		case u.kabucomBroker:
+			if query.KabucomToken == "" {
+				continue
+			}
+			board, err := u.kabucom.GetBoard(ctx, query.KabucomToken, v.ProductCode)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the quote of %s: %w", v.ProductCode, err)
+			}
+			quotes[v.Key] = board.CurrentPrice
+		default:
+			board, err := u.bitflyer.GetBoard(ctx, v.ProductCode)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the quote of %s: %w", v.ProductCode, err)
+			}
+			quotes[v.Key] = board.MidPrice
+		}
+	}
+
+	return pnl.NewReport(book, query.Method, query.From, query.To, quotes), nil
+}
@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/pnl"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedPnLKabucom struct{}
+
+func (m mockedPnLKabucom) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	if token != "token" {
+		return nil, cerror.ErrUnAuthorized
+	}
+	return &kabucom.BoardResponse{Symbol: symbol, CurrentPrice: 4200}, nil
+}
+
+func TestPnLUseCase_Report(t *testing.T) {
+	at := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	_, err := store.AddFills([]journal.Fill{
+		{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.02, Commission: 0.0000625, Time: at},
+		{Broker: journal.BitFlyer, ID: "2", ProductCode: "BTC_JPY", Side: "SELL", Price: 5100000, Size: 0.01, Time: at.Add(time.Hour)},
+		{Broker: journal.Paper, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 1, Size: 1, Time: at},
+		{Broker: journal.Kabucom, ID: "E1", ProductCode: "9433@1", Side: "BUY", Price: 4000, Size: 100, Commission: 55, Time: at},
+		{Broker: journal.PaperKabucom, ID: "E1", ProductCode: "9433@1", Side: "BUY", Price: 4100, Size: 100, Time: at},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			return &bitflyer.BoardResponse{MidPrice: 5200000}, nil
+		},
+	}
+	u := NewPnLUseCase(store, client, journal.BitFlyer, mockedPnLKabucom{}, journal.Kabucom)
+
+	btc := pnl.Key{Broker: journal.BitFlyer, ProductCode: "BTC_JPY"}
+	au := pnl.Key{Broker: journal.Kabucom, ProductCode: "9433@1"}
+	tests := []struct {
+		name        string
+		query       PnLQuery
+		expected    []pnl.ProductPnL
+		expectedErr error
+	}{
+		{
+			name:  "every broker",
+			query: PnLQuery{Method: pnl.FIFO, KabucomToken: "token"},
+			expected: []pnl.ProductPnL{
+				// The commission of bitFlyer is in BTC.
+				{Key: btc, Realized: 1000, Fees: 312.5, Trades: 2, Position: 0.01, AverageCost: 5000000, Price: 5200000, Unrealized: 2000},
+				{Key: au, Fees: 55, Trades: 1, Position: 100, AverageCost: 4000, Price: 4200, Unrealized: 20000},
+			},
+		},
+		{
+			name:  "kabucom without a token",
+			query: PnLQuery{Method: pnl.MovingAverage, Broker: journal.Kabucom},
+			expected: []pnl.ProductPnL{
+				{Key: au, Fees: 55, Trades: 1, Position: 100, AverageCost: 4000},
+			},
+		},
+		{
+			name:  "fills after To are ignored",
+			query: PnLQuery{Method: pnl.FIFO, ProductCode: "BTC_JPY", To: at.Add(time.Hour)},
+			expected: []pnl.ProductPnL{
+				{Key: btc, Fees: 312.5, Trades: 1, Position: 0.02, AverageCost: 5000000, Price: 5200000, Unrealized: 4000},
+			},
+		},
+		{
+			name:        "stale token",
+			query:       PnLQuery{Method: pnl.FIFO, KabucomToken: "stale"},
+			expectedErr: cerror.ErrUnAuthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.Report(context.Background(), tt.query)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("PnLUseCase.Report() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Products, tt.expected) {
+				t.Errorf("PnLUseCase.Report() = %+v, expected %+v", got.Products, tt.expected)
+			}
+		})
+	}
+
+	t.Run("paper accounts", func(t *testing.T) {
+		paper := NewPnLUseCase(store, client, journal.Paper, mockedPnLKabucom{}, journal.PaperKabucom)
+		got, err := paper.Report(context.Background(), PnLQuery{Method: pnl.FIFO, KabucomToken: "token"})
+		if err != nil {
+			t.Fatalf("PnLUseCase.Report() error = %v", err)
+		}
+		expected := []pnl.ProductPnL{
+			{Key: pnl.Key{Broker: journal.Paper, ProductCode: "BTC_JPY"}, Trades: 1, Position: 1, AverageCost: 1, Price: 5200000, Unrealized: 5199999},
+			{Key: pnl.Key{Broker: journal.PaperKabucom, ProductCode: "9433@1"}, Trades: 1, Position: 100, AverageCost: 4100, Price: 4200, Unrealized: 10000},
+		}
+		if !reflect.DeepEqual(got.Products, expected) {
+			t.Errorf("PnLUseCase.Report() = %+v, expected %+v", got.Products, expected)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/rebalance"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// RebalanceKabucom provides the holdings, quotes and lot sizes of a kabu STATION account, and sends
+// its orders.
+type RebalanceKabucom interface {
+	GetPositions(ctx context.Context, token string) ([]kabucom.Position, error)
+	GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error)
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
+	GetCashWallet(ctx context.Context, token string) (float64, error)
+	SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error)
+}
+
+var _ RebalanceKabucom = &kabucom.KabucomClient{}
+var _ RebalanceKabucom = &paper.Kabucom{}
+
+// bitflyerStep is the increment of order sizes of bitFlyer.
+const bitflyerStep = 0.00000001
+
+type RebalanceQuery struct {
+	Target *rebalance.Target
+	// KabucomToken is the kabu STATION token. The account is skipped without it, which fails when
+	// the target has stocks.
+	KabucomToken string
+	// KabucomOrderPassword is needed to send the orders of stocks.
+	KabucomOrderPassword string
+}
+
+type RebalanceUseCase struct {
+	bitflyer BitFlyerClient
+	kabucom  RebalanceKabucom
+}
+
+func NewRebalanceUseCase(bitflyer BitFlyerClient, kabucom RebalanceKabucom) RebalanceUseCase {
+	return RebalanceUseCase{
+		bitflyer: bitflyer,
+		kabucom:  kabucom,
+	}
+}
+
+// Plan reads the holdings of the assets of the target from the accounts, and plans the orders
+// bringing them to the target. Crypto assets are valued at the mid price of their JPY pair, and
+// stocks at their current price.
+func (u *RebalanceUseCase) Plan(ctx context.Context, query RebalanceQuery) (*rebalance.Plan, error) {
+	target := query.Target
+	holdings := []rebalance.Holding{}
+
+	balances, err := u.bitflyer.GetBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balances: %w", err)
+	}
+	amounts := map[string]float64{}
+	for _, v := range balances {
+		amounts[v.CurrencyCode] = v.Amount
+	}
+	holdings = append(holdings, rebalance.Holding{Asset: rebalance.Cash, Broker: journal.BitFlyer, Quantity: amounts["JPY"], Price: 1})
+
+	stocks := []string{}
+	for _, asset := range target.Assets() {
+		broker, code, err := rebalance.Market(asset)
+		if err != nil {
+			return nil, err
+		}
+		switch broker {
+		case journal.Kabucom:
+			stocks = append(stocks, asset)
+		case journal.BitFlyer:
+			board, err := u.bitflyer.GetBoard(ctx, code)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch board of %s: %w", code, err)
+			}
+			h := rebalance.Holding{
+				Asset:    asset,
+				Broker:   journal.BitFlyer,
+				Quantity: amounts[asset],
+				Price:    board.MidPrice,
+				MinSize:  bitflyer.MinimumOrderSizes[code],
+				Step:     bitflyerStep,
+			}
+			if lot, ok := target.Lots[asset]; ok {
+				h.MinSize, h.Step = lot, lot
+			}
+			if h.MinSize == 0 {
+				return nil, fmt.Errorf("the minimum order size of %s is unknown. set it in the lots of the target", code)
+			}
+			holdings = append(holdings, h)
+		}
+	}
+
+	if query.KabucomToken == "" {
+		if len(stocks) > 0 {
+			return nil, errors.New("a kabu STATION token is required for the stocks of the target")
+		}
+		return rebalance.NewPlan(target, holdings)
+	}
+
+	cash, err := u.kabucom.GetCashWallet(ctx, query.KabucomToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch kabu STATION wallet: %w", err)
+	}
+	holdings = append(holdings, rebalance.Holding{Asset: rebalance.Cash, Broker: journal.Kabucom, Quantity: cash, Price: 1})
+
+	positions, err := u.kabucom.GetPositions(ctx, query.KabucomToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch kabu STATION positions: %w", err)
+	}
+	for _, asset := range stocks {
+		h := rebalance.Holding{Asset: asset, Broker: journal.Kabucom}
+		for _, v := range positions {
+			if v.Symbol == asset {
+				h.Quantity += v.Qty
+				h.Price = v.CurrentPrice
+			}
+		}
+		if h.Price == 0 {
+			board, err := u.kabucom.GetBoard(ctx, query.KabucomToken, asset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch board of %s: %w", asset, err)
+			}
+			h.Price = board.CurrentPrice
+		}
+
+		lot, ok := target.Lots[asset]
+		if !ok {
+			symbol, err := u.kabucom.GetSymbol(ctx, query.KabucomToken, asset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch symbol %s: %w", asset, err)
+			}
+			lot = symbol.TradingUnit
+		}
+		h.MinSize, h.Step = lot, lot
+		holdings = append(holdings, h)
+	}
+	return rebalance.NewPlan(target, holdings)
+}
+
+// CheckOrder runs the risk checks of the clients on order of a plan, if they have any.
+func (u *RebalanceUseCase) CheckOrder(ctx context.Context, order rebalance.Order, query RebalanceQuery) error {
+	if order.Broker == journal.Kabucom {
+		if checker, ok := u.kabucom.(KabucomOrderChecker); ok {
+			return checker.CheckOrder(ctx, query.KabucomToken, kabucomRebalanceRequest(order, query))
+		}
+		return nil
+	}
+
+	req, err := orderRequest(bitflyerRebalanceOrder(order))
+	if err != nil {
+		return err
+	}
+	if checker, ok := u.bitflyer.(OrderChecker); ok {
+		return checker.CheckOrder(ctx, req)
+	}
+	return nil
+}
+
+// SendOrder sends order of a plan as a market order through the risk checks and the journal of the
+// clients, and returns the id of the order.
+func (u *RebalanceUseCase) SendOrder(ctx context.Context, order rebalance.Order, query RebalanceQuery) (string, error) {
+	if order.Broker == journal.Kabucom {
+		if query.KabucomToken == "" || query.KabucomOrderPassword == "" {
+			return "", fmt.Errorf("%w: a kabu STATION token and order password are required for %s", cerror.ErrBadRequest, order.ProductCode)
+		}
+		return u.kabucom.SendOrder(ctx, query.KabucomToken, kabucomRebalanceRequest(order, query))
+	}
+
+	req, err := orderRequest(bitflyerRebalanceOrder(order))
+	if err != nil {
+		return "", err
+	}
+	res, err := u.bitflyer.SendOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return res.ChildOrderAcceptanceId, nil
+}
+
+func kabucomRebalanceRequest(order rebalance.Order, query RebalanceQuery) kabucom.OrderRequest {
+	return kabucom.OrderRequest{Symbol: order.ProductCode, Side: order.Side, Qty: order.Size, Password: query.KabucomOrderPassword}
+}
+
+func bitflyerRebalanceOrder(order rebalance.Order) OrderCreate {
+	return OrderCreate{ProductCode: order.ProductCode, Size: order.Size, Buy: order.Side == "BUY", Market: true}
+}
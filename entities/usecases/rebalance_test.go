@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/rebalance"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedRebalanceKabucom struct {
+	positions []kabucom.Position
+	sent      *[]kabucom.OrderRequest
+}
+
+func (m mockedRebalanceKabucom) GetPositions(ctx context.Context, token string) ([]kabucom.Position, error) {
+	if token != "token" {
+		return nil, cerror.ErrUnAuthorized
+	}
+	return m.positions, nil
+}
+
+func (m mockedRebalanceKabucom) GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error) {
+	return &kabucom.Symbol{Symbol: symbol, TradingUnit: 100}, nil
+}
+
+func (m mockedRebalanceKabucom) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	return &kabucom.BoardResponse{Symbol: symbol, CurrentPrice: 4000}, nil
+}
+
+func (m mockedRebalanceKabucom) GetCashWallet(ctx context.Context, token string) (float64, error) {
+	return 300000, nil
+}
+
+func (m mockedRebalanceKabucom) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	*m.sent = append(*m.sent, order)
+	return "kabu-1", nil
+}
+
+func TestRebalanceUseCase_Plan(t *testing.T) {
+	client := mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			return &bitflyer.BoardResponse{MidPrice: map[string]float64{"BTC_JPY": 5000000, "XRP_JPY": 100}[pc]}, nil
+		},
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{{CurrencyCode: "JPY", Amount: 100000}, {CurrencyCode: "BTC", Amount: 0.02}}, nil
+		},
+	}
+	market := mockedRebalanceKabucom{
+		positions: []kabucom.Position{{Symbol: "1306@1", Qty: 200, CurrentPrice: 2000}},
+	}
+	u := NewRebalanceUseCase(client, market)
+
+	tests := []struct {
+		name          string
+		query         RebalanceQuery
+		expected      []rebalance.Order
+		expectedError string
+	}{
+		{
+			name: "both brokers",
+			query: RebalanceQuery{
+				Target: &rebalance.Target{
+					Tolerance: 0.05,
+					Weights:   map[string]float64{"1306@1": 60, "9433@1": 0, "BTC": 20, "JPY": 20},
+					Lots:      map[string]float64{"1306@1": 10},
+				},
+				KabucomToken: "token",
+			},
+			expected: []rebalance.Order{
+				{Asset: "1306@1", Broker: journal.Kabucom, ProductCode: "1306@1", Side: "BUY", Size: 70, Price: 2000, Notional: 140000},
+				{Asset: "BTC", Broker: journal.BitFlyer, ProductCode: "BTC_JPY", Side: "BUY", Size: 0.007, Price: 5000000, Notional: 35000},
+			},
+		},
+		{
+			name: "bitFlyer only",
+			query: RebalanceQuery{
+				Target: &rebalance.Target{Weights: map[string]float64{"BTC": 3, "JPY": 1}},
+			},
+			expected: []rebalance.Order{
+				{Asset: "BTC", Broker: journal.BitFlyer, ProductCode: "BTC_JPY", Side: "BUY", Size: 0.01, Price: 5000000, Notional: 50000},
+			},
+		},
+		{
+			name: "stocks without a token",
+			query: RebalanceQuery{
+				Target: &rebalance.Target{Weights: map[string]float64{"1306@1": 1}},
+			},
+			expectedError: "a kabu STATION token is required for the stocks of the target",
+		},
+		{
+			name: "unknown minimum size",
+			query: RebalanceQuery{
+				Target: &rebalance.Target{Weights: map[string]float64{"XRP": 1}},
+			},
+			expectedError: "the minimum order size of XRP_JPY is unknown. set it in the lots of the target",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.Plan(context.Background(), tt.query)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("RebalanceUseCase.Plan() error = %v, expectedError %v", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RebalanceUseCase.Plan() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Orders, tt.expected) {
+				t.Errorf("RebalanceUseCase.Plan() = %+v, expected %+v", got.Orders, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRebalanceUseCase_PlanKabucomError(t *testing.T) {
+	client := mockedBitFlyerClient{
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{{CurrencyCode: "JPY", Amount: 100000}}, nil
+		},
+	}
+	u := NewRebalanceUseCase(client, mockedRebalanceKabucom{})
+	query := RebalanceQuery{Target: &rebalance.Target{Weights: map[string]float64{"1306@1": 1}}, KabucomToken: "stale"}
+	if _, err := u.Plan(context.Background(), query); !errors.Is(err, cerror.ErrUnAuthorized) {
+		t.Errorf("RebalanceUseCase.Plan() error = %v, expectedError %v", err, cerror.ErrUnAuthorized)
+	}
+}
+
+func TestRebalanceUseCase_SendOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		order        rebalance.Order
+		password     string
+		wantBitFlyer []bitflyer.SendOrderRequest
+		wantKabucom  []kabucom.OrderRequest
+		expectedErr  error
+	}{
+		{
+			name:         "bitFlyer",
+			order:        rebalance.Order{Asset: "BTC", Broker: journal.BitFlyer, ProductCode: "BTC_JPY", Side: "SELL", Size: 0.01, Price: 5000000},
+			wantBitFlyer: []bitflyer.SendOrderRequest{{ProductCode: "BTC_JPY", ChildOrderType: bitflyer.ChildOrderTypeMarket, Side: bitflyer.SideSell, Size: 0.01, TimeInForce: bitflyer.TimeInForceGTC}},
+			wantKabucom:  []kabucom.OrderRequest{},
+		},
+		{
+			name:         "kabu STATION",
+			order:        rebalance.Order{Asset: "1306@1", Broker: journal.Kabucom, ProductCode: "1306@1", Side: "BUY", Size: 100, Price: 4000},
+			password:     "password",
+			wantBitFlyer: []bitflyer.SendOrderRequest{},
+			wantKabucom:  []kabucom.OrderRequest{{Symbol: "1306@1", Side: "BUY", Qty: 100, Password: "password"}},
+		},
+		{
+			name:         "kabu STATION Without Password",
+			order:        rebalance.Order{Asset: "1306@1", Broker: journal.Kabucom, ProductCode: "1306@1", Side: "BUY", Size: 100, Price: 4000},
+			wantBitFlyer: []bitflyer.SendOrderRequest{},
+			wantKabucom:  []kabucom.OrderRequest{},
+			expectedErr:  cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := []bitflyer.SendOrderRequest{}
+			client := mockedBitFlyerClient{
+				sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+					sent = append(sent, req)
+					return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "JRF1"}, nil
+				},
+			}
+			stocks := []kabucom.OrderRequest{}
+			u := NewRebalanceUseCase(client, mockedRebalanceKabucom{sent: &stocks})
+
+			_, err := u.SendOrder(context.Background(), tt.order, RebalanceQuery{KabucomToken: "token", KabucomOrderPassword: tt.password})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("RebalanceUseCase.SendOrder() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if !reflect.DeepEqual(sent, tt.wantBitFlyer) || !reflect.DeepEqual(stocks, tt.wantKabucom) {
+				t.Errorf("RebalanceUseCase.SendOrder() sent %+v and %+v, expected %+v and %+v", sent, stocks, tt.wantBitFlyer, tt.wantKabucom)
+			}
+		})
+	}
+}
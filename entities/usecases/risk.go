@@ -0,0 +1,269 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/risk"
+)
+
+// DailyState measures the loss of an account since the start of the day.
+type DailyState interface {
+	DailyLoss(account string, today string, equity float64) (float64, error)
+}
+
+var _ DailyState = &risk.FileState{}
+
+// RiskGuard is a BitFlyerClient checking every order against risk limits before sending it.
+// Other calls go to the wrapped client unchanged.
+type RiskGuard struct {
+	BitFlyerClient
+	limits  risk.Limits
+	state   DailyState
+	account string
+	now     func() time.Time
+}
+
+// NewRiskGuard returns a RiskGuard of client. account names the account in state,
+// so that live and paper trading measure their daily losses separately.
+func NewRiskGuard(client BitFlyerClient, limits risk.Limits, state DailyState, account string) *RiskGuard {
+	return &RiskGuard{
+		BitFlyerClient: client,
+		limits:         limits,
+		state:          state,
+		account:        account,
+		now:            time.Now,
+	}
+}
+
+var _ OrderChecker = &RiskGuard{}
+
+// SendOrder sends the order if it passes the limits, and returns a *risk.Rejection otherwise.
+func (g *RiskGuard) SendOrder(ctx context.Context, req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+	if err := g.CheckOrder(ctx, req); err != nil {
+		return nil, err
+	}
+	return g.BitFlyerClient.SendOrder(ctx, req)
+}
+
+// CheckOrder returns a *risk.Rejection when the order breaks the limits.
+func (g *RiskGuard) CheckOrder(ctx context.Context, req bitflyer.SendOrderRequest) error {
+	order := risk.Order{ProductCode: req.ProductCode, Side: req.Side, Price: req.Price, Size: req.Size}
+	if req.ChildOrderType == bitflyer.ChildOrderTypeMarket {
+		order.Price = 0
+	}
+
+	// Reject products outside the allow-list before querying their market.
+	if err := g.limits.CheckProduct(req.ProductCode); err != nil {
+		return err
+	}
+
+	market, err := g.market(ctx, req.ProductCode)
+	if err != nil {
+		return fmt.Errorf("failed to check the order: %w", err)
+	}
+	return g.limits.Check(order, market)
+}
+
+// market gathers what the limits need.
+func (g *RiskGuard) market(ctx context.Context, productCode string) (risk.Market, error) {
+	market := risk.Market{}
+
+	board, err := g.BitFlyerClient.GetBoard(ctx, productCode)
+	if err != nil {
+		return market, err
+	}
+	market.Mid = board.MidPrice
+	market.BestBid, market.BestAsk = bestQuotes(board)
+
+	if _, ok := g.limits.MaxPosition[productCode]; !ok && g.limits.DailyLossLimit <= 0 {
+		return market, nil
+	}
+
+	balances, err := g.BitFlyerClient.GetBalance(ctx)
+	if err != nil {
+		return market, err
+	}
+	if strings.HasPrefix(productCode, "FX_") {
+		// FX products are margin trades: their position is not a balance.
+		positions, err := g.BitFlyerClient.GetPositions(ctx, productCode)
+		if err != nil {
+			return market, err
+		}
+		for _, v := range positions {
+			if v.Side == bitflyer.SideSell {
+				market.Position -= v.Size
+			} else {
+				market.Position += v.Size
+			}
+		}
+	} else {
+		base := strings.Split(productCode, "_")[0]
+		for _, v := range balances {
+			if v.CurrencyCode == base {
+				market.Position = v.Amount
+			}
+		}
+	}
+
+	if g.limits.DailyLossLimit > 0 {
+		equity, err := g.equity(ctx, balances)
+		if err != nil {
+			return market, err
+		}
+		if market.DailyLoss, err = dailyLoss(g.state, g.account, g.now(), equity); err != nil {
+			return market, err
+		}
+	}
+	return market, nil
+}
+
+// dailyLoss returns the loss of account since the start of the JST day of now.
+func dailyLoss(state DailyState, account string, now time.Time, equity float64) (float64, error) {
+	return state.DailyLoss(account, now.In(candle.JST).Format("2006-01-02"), equity)
+}
+
+// equity values balances in JPY at the mid prices of their JPY markets.
+// Currencies without such a market are not counted.
+func (g *RiskGuard) equity(ctx context.Context, balances bitflyer.GetBalancesResponse) (float64, error) {
+	equity := 0.0
+	for _, v := range balances {
+		if v.CurrencyCode == "JPY" {
+			equity += v.Amount
+			continue
+		}
+		if v.Amount == 0 {
+			continue
+		}
+		board, err := g.BitFlyerClient.GetBoard(ctx, v.CurrencyCode+"_JPY")
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+		equity += v.Amount * board.MidPrice
+	}
+	return equity, nil
+}
+
+// KabucomOrderClient sends kabu STATION orders, and provides what they are checked against.
+type KabucomOrderClient interface {
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
+	GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error)
+	GetPositions(ctx context.Context, token string) ([]kabucom.Position, error)
+	GetCashWallet(ctx context.Context, token string) (float64, error)
+	SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error)
+}
+
+var _ KabucomOrderClient = &kabucom.KabucomClient{}
+var _ KabucomOrderClient = &paper.Kabucom{}
+
+// KabucomOrderChecker is implemented by kabu STATION clients checking orders before sending them,
+// such as KabucomRiskGuard.
+type KabucomOrderChecker interface {
+	CheckOrder(ctx context.Context, token string, order kabucom.OrderRequest) error
+}
+
+// KabucomRiskGuard is a KabucomOrderClient checking every order against risk limits before sending
+// it, like RiskGuard. Limits name stocks by their symbol without the exchange, such as "1306".
+type KabucomRiskGuard struct {
+	KabucomOrderClient
+	limits  risk.Limits
+	state   DailyState
+	account string
+	now     func() time.Time
+}
+
+// NewKabucomRiskGuard returns a KabucomRiskGuard of client. account names the account in state.
+func NewKabucomRiskGuard(client KabucomOrderClient, limits risk.Limits, state DailyState, account string) *KabucomRiskGuard {
+	return &KabucomRiskGuard{
+		KabucomOrderClient: client,
+		limits:             limits,
+		state:              state,
+		account:            account,
+		now:                time.Now,
+	}
+}
+
+var _ KabucomOrderChecker = &KabucomRiskGuard{}
+
+// SendOrder sends the order if it passes the limits, and returns a *risk.Rejection otherwise.
+func (g *KabucomRiskGuard) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	if err := g.CheckOrder(ctx, token, order); err != nil {
+		return "", err
+	}
+	return g.KabucomOrderClient.SendOrder(ctx, token, order)
+}
+
+// CheckOrder returns a *risk.Rejection when the order breaks the limits.
+func (g *KabucomRiskGuard) CheckOrder(ctx context.Context, token string, order kabucom.OrderRequest) error {
+	code := stockCode(order.Symbol)
+	if err := g.limits.CheckProduct(code); err != nil {
+		return err
+	}
+
+	market, err := g.market(ctx, token, order.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check the order: %w", err)
+	}
+	return g.limits.Check(risk.Order{ProductCode: code, Side: bitflyer.ChildOrderSide(order.Side), Price: order.Price, Size: order.Qty}, market)
+}
+
+// market gathers what the limits need.
+func (g *KabucomRiskGuard) market(ctx context.Context, token string, symbol string) (risk.Market, error) {
+	market := risk.Market{}
+
+	board, err := g.KabucomOrderClient.GetBoard(ctx, token, symbol)
+	if err != nil {
+		return market, err
+	}
+	market.Mid = board.CurrentPrice
+	if len(board.Bids) > 0 {
+		market.BestBid = board.Bids[0].Price
+	}
+	if len(board.Asks) > 0 {
+		market.BestAsk = board.Asks[0].Price
+	}
+	if market.BestBid > 0 && market.BestAsk > 0 {
+		market.Mid = (market.BestBid + market.BestAsk) / 2
+	}
+
+	if _, ok := g.limits.MaxPosition[stockCode(symbol)]; !ok && g.limits.DailyLossLimit <= 0 {
+		return market, nil
+	}
+
+	positions, err := g.KabucomOrderClient.GetPositions(ctx, token)
+	if err != nil {
+		return market, err
+	}
+	equity := 0.0
+	for _, v := range positions {
+		if stockCode(v.Symbol) == stockCode(symbol) {
+			market.Position += v.Qty
+		}
+		equity += v.Qty * v.CurrentPrice
+	}
+
+	if g.limits.DailyLossLimit > 0 {
+		cash, err := g.KabucomOrderClient.GetCashWallet(ctx, token)
+		if err != nil {
+			return market, err
+		}
+		if market.DailyLoss, err = dailyLoss(g.state, g.account, g.now(), equity+cash); err != nil {
+			return market, err
+		}
+	}
+	return market, nil
+}
+
+// stockCode returns the symbol without the exchange, such as "1306" of "1306@1".
+func stockCode(symbol string) string {
+	return strings.Split(symbol, "@")[0]
+}
@@ -0,0 +1,215 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/risk"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// mockedDailyState starts every day at startEquity.
+type mockedDailyState struct {
+	startEquity float64
+	day         string
+}
+
+func (m *mockedDailyState) DailyLoss(account string, today string, equity float64) (float64, error) {
+	m.day = today
+	return m.startEquity - equity, nil
+}
+
+func TestRiskGuard_SendOrder(t *testing.T) {
+	boards := map[string]*bitflyer.BoardResponse{
+		"BTC_JPY": {
+			MidPrice: 5000000,
+			Bids:     bitflyer.PriceResponses{{Price: 4999000, Size: 1}},
+			Asks:     bitflyer.PriceResponses{{Price: 5001000, Size: 1}},
+		},
+		"FX_BTC_JPY": {
+			MidPrice: 5000000,
+			Bids:     bitflyer.PriceResponses{{Price: 4999000, Size: 1}},
+			Asks:     bitflyer.PriceResponses{{Price: 5001000, Size: 1}},
+		},
+		// Nobody sells.
+		"ETH_JPY": {
+			MidPrice: 300000,
+			Bids:     bitflyer.PriceResponses{{Price: 300000, Size: 1}},
+		},
+	}
+	client := func(sent *int) mockedBitFlyerClient {
+		return mockedBitFlyerClient{
+			getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+				if board, ok := boards[pc]; ok {
+					return board, nil
+				}
+				return nil, cerror.ErrBadRequest
+			},
+			getBalance: func() (bitflyer.GetBalancesResponse, error) {
+				return bitflyer.GetBalancesResponse{
+					{CurrencyCode: "JPY", Amount: 900000},
+					{CurrencyCode: "BTC", Amount: 0.1},
+					// Without a JPY market, it is not counted in the equity.
+					{CurrencyCode: "XYZ", Amount: 10},
+				}, nil
+			},
+			getPositions: func(pc string) (bitflyer.GetPositionsResponse, error) {
+				return bitflyer.GetPositionsResponse{
+					{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideBuy, Size: 0.2},
+					{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideSell, Size: 0.05},
+				}, nil
+			},
+			sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+				*sent++
+				return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "test_id"}, nil
+			},
+		}
+	}
+
+	limits := risk.Limits{MaxOrderNotional: 1000000, PriceBand: 0.05, MaxPosition: map[string]float64{"BTC_JPY": 0.2, "FX_BTC_JPY": 0.2}, DailyLossLimit: 50000}
+	tests := []struct {
+		name        string
+		req         bitflyer.SendOrderRequest
+		startEquity float64
+		expectedErr error
+	}{
+		{
+			name:        "Accepted",
+			req:         bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeLimit, Price: 5000000, Size: 0.01},
+			startEquity: 1400000,
+		},
+		{
+			name:        "Over Max Position",
+			req:         bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeMarket, Size: 0.15},
+			startEquity: 1400000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			// The FX position is 0.15, not the BTC balance of 0.1.
+			name:        "Over Max FX Position",
+			req:         bitflyer.SendOrderRequest{ProductCode: "FX_BTC_JPY", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeLimit, Price: 5000000, Size: 0.1},
+			startEquity: 1400000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			name:        "Market Order Without Quote",
+			req:         bitflyer.SendOrderRequest{ProductCode: "ETH_JPY", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeMarket, Size: 10},
+			startEquity: 1400000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			// The equity is 900000 JPY + 0.1 BTC at 5000000 = 1400000.
+			name:        "Daily Loss Reached",
+			req:         bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeLimit, Price: 5000000, Size: 0.01},
+			startEquity: 1450000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := 0
+			state := &mockedDailyState{startEquity: tt.startEquity}
+			g := NewRiskGuard(client(&sent), limits, state, "bitflyer")
+			g.now = func() time.Time { return time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC) }
+
+			_, err := g.SendOrder(context.Background(), tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("RiskGuard.SendOrder() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if wantSent := map[bool]int{true: 0, false: 1}[tt.expectedErr != nil]; sent != wantSent {
+				t.Errorf("RiskGuard.SendOrder() sent %v orders, want %v", sent, wantSent)
+			}
+			// 15:30 UTC is the next day in JST.
+			if state.day != "2023-04-02" {
+				t.Errorf("RiskGuard.SendOrder() measured the loss of %q, want 2023-04-02", state.day)
+			}
+		})
+	}
+}
+
+// mockedRiskKabucom holds 100 shares of 1306 at 2000 JPY and 300000 JPY of cash.
+type mockedRiskKabucom struct {
+	sent *int
+}
+
+func (m mockedRiskKabucom) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	return &kabucom.BoardResponse{
+		Symbol:       "1306",
+		CurrentPrice: 2000,
+		Bids:         []kabucom.BoardLevel{{Price: 1999, Qty: 100}},
+		Asks:         []kabucom.BoardLevel{{Price: 2001, Qty: 100}},
+	}, nil
+}
+func (m mockedRiskKabucom) GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error) {
+	return &kabucom.Symbol{}, nil
+}
+func (m mockedRiskKabucom) GetPositions(ctx context.Context, token string) ([]kabucom.Position, error) {
+	return []kabucom.Position{{Symbol: "1306@1", Qty: 100, Price: 1900, CurrentPrice: 2000}}, nil
+}
+func (m mockedRiskKabucom) GetCashWallet(ctx context.Context, token string) (float64, error) {
+	return 300000, nil
+}
+func (m mockedRiskKabucom) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	*m.sent++
+	return "20230401A01N00000001", nil
+}
+
+func TestKabucomRiskGuard_SendOrder(t *testing.T) {
+	limits := risk.Limits{MaxOrderNotional: 1000000, PriceBand: 0.05, MaxPosition: map[string]float64{"1306": 300}, DailyLossLimit: 50000}
+	tests := []struct {
+		name        string
+		order       kabucom.OrderRequest
+		startEquity float64
+		expectedErr error
+	}{
+		{
+			name:        "Accepted",
+			order:       kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 100},
+			startEquity: 500000,
+		},
+		{
+			name:        "Typo In Quantity",
+			order:       kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 10000},
+			startEquity: 500000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			name:        "Over Max Position",
+			order:       kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Price: 2000, Qty: 300},
+			startEquity: 500000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			name:        "Typo In Price",
+			order:       kabucom.OrderRequest{Symbol: "1306@1", Side: "SELL", Price: 200, Qty: 100},
+			startEquity: 500000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			// The equity is 300000 JPY + 100 shares at 2000 = 500000.
+			name:        "Daily Loss Reached",
+			order:       kabucom.OrderRequest{Symbol: "1306@1", Side: "BUY", Qty: 100},
+			startEquity: 550000,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := 0
+			state := &mockedDailyState{startEquity: tt.startEquity}
+			g := NewKabucomRiskGuard(mockedRiskKabucom{sent: &sent}, limits, state, "kabucom")
+
+			_, err := g.SendOrder(context.Background(), "token", tt.order)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("KabucomRiskGuard.SendOrder() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if wantSent := map[bool]int{true: 0, false: 1}[tt.expectedErr != nil]; sent != wantSent {
+				t.Errorf("KabucomRiskGuard.SendOrder() sent %v orders, want %v", sent, wantSent)
+			}
+		})
+	}
+}
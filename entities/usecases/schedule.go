@@ -0,0 +1,394 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/infrastructures/paper"
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/schedule"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+// ScheduleKabucom provides the quotes and lot sizes of kabu STATION, and sends its orders.
+type ScheduleKabucom interface {
+	GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error)
+	GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error)
+	SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error)
+}
+
+var _ ScheduleKabucom = &kabucom.KabucomClient{}
+var _ ScheduleKabucom = &paper.Kabucom{}
+
+// ScheduleStore persists schedules and their runs.
+type ScheduleStore interface {
+	Load() ([]schedule.Schedule, error)
+	Add(s schedule.Schedule) (schedule.Schedule, error)
+	Remove(id int) error
+	Runs(id int) ([]schedule.Run, error)
+	Record(run schedule.Run, replace bool) error
+}
+
+var _ ScheduleStore = &schedule.FileStore{}
+
+// DefaultScheduleGrace is how late a slot may be run by default.
+const DefaultScheduleGrace = time.Hour
+
+// ScheduleRunOptions are the settings of `Run`.
+type ScheduleRunOptions struct {
+	// KabucomToken and KabucomOrderPassword are needed by schedules of stocks.
+	KabucomToken         string
+	KabucomOrderPassword string
+	// Grace is how late after its time a slot is still run. Later slots are recorded as missed.
+	Grace time.Duration
+}
+
+// ScheduleEvent reports a run of `Run`, or a failure, such as a failed request.
+type ScheduleEvent struct {
+	Time     time.Time
+	Schedule schedule.Schedule
+	// Run is the run recorded, if any.
+	Run *schedule.Run
+	// Missed is the number of slots passed over for Run, after a downtime.
+	Missed int
+	Err    error
+}
+
+// ScheduleStatus is a schedule with its last and next runs.
+type ScheduleStatus struct {
+	Schedule schedule.Schedule
+	// Last is the latest run, if any.
+	Last *schedule.Run
+	// Next is the next slot, if the cron expression has one.
+	Next *schedule.Slot
+}
+
+type ScheduleUseCase struct {
+	bitflyer BitFlyerClient
+	kabucom  ScheduleKabucom
+	store    ScheduleStore
+	now      func() time.Time
+	// OnEvent is called for each run and failure, to report them.
+	OnEvent func(ScheduleEvent)
+}
+
+func NewScheduleUseCase(bitflyer BitFlyerClient, kabucom ScheduleKabucom, store ScheduleStore) ScheduleUseCase {
+	return ScheduleUseCase{
+		bitflyer: bitflyer,
+		kabucom:  kabucom,
+		store:    store,
+		now:      time.Now,
+	}
+}
+
+// Add validates and stores s, and returns it with its id. Its first slot is the first one after now.
+func (u *ScheduleUseCase) Add(s schedule.Schedule) (schedule.Schedule, error) {
+	if err := s.Validate(); err != nil {
+		return schedule.Schedule{}, fmt.Errorf("%w: %s", cerror.ErrBadRequest, err)
+	}
+	s.CreatedAt = u.now()
+	return u.store.Add(s)
+}
+
+// ScheduleOrder is the order a schedule sends at the current board.
+type ScheduleOrder struct {
+	Size float64
+	// Price is the limit price, zero for market orders.
+	Price float64
+	// Next is the first slot of the schedule, if the cron expression has one.
+	Next *schedule.Slot
+}
+
+// Preview validates s and returns the order it would send now, once it passes the risk checks.
+// Nothing is stored or sent.
+func (u *ScheduleUseCase) Preview(ctx context.Context, s schedule.Schedule, opts ScheduleRunOptions) (*ScheduleOrder, error) {
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", cerror.ErrBadRequest, err)
+	}
+	order, err := u.prepare(ctx, s, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.check(ctx, s, order, opts); err != nil {
+		return nil, err
+	}
+
+	res := &ScheduleOrder{Size: order.size, Price: order.price}
+	now := u.now()
+	if slot, ok := s.Next(now, now); ok {
+		res.Next = &slot
+	}
+	return res, nil
+}
+
+// List returns the schedules with their last and next runs.
+func (u *ScheduleUseCase) List() ([]ScheduleStatus, error) {
+	schedules, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	runs, err := u.store.Runs(0)
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.now()
+	statuses := make([]ScheduleStatus, 0, len(schedules))
+	for _, v := range schedules {
+		status := ScheduleStatus{Schedule: v}
+		since := v.CreatedAt
+		for i := range runs {
+			if runs[i].ScheduleID == v.ID && !runs[i].Slot.Before(since) {
+				status.Last, since = &runs[i], runs[i].Slot
+			}
+		}
+		if slot, ok := v.Due(since, now); ok {
+			status.Next = &slot
+		} else if slot, ok := v.Next(since, now); ok {
+			status.Next = &slot
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (u *ScheduleUseCase) Remove(id int) error {
+	return u.store.Remove(id)
+}
+
+// Runs returns the recorded runs of the schedule id, or of every schedule when id is zero.
+func (u *ScheduleUseCase) Runs(id int) ([]schedule.Run, error) {
+	return u.store.Runs(id)
+}
+
+// Run sends the orders of the due slots every interval until ctx is cancelled. The schedules are
+// reloaded each time, so schedules added or removed meanwhile take effect.
+func (u *ScheduleUseCase) Run(ctx context.Context, opts ScheduleRunOptions, interval time.Duration) error {
+	for {
+		u.RunDue(ctx, opts)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RunDue sends the orders of the slots due now, once.
+//
+// A slot is recorded as sending before its order is sent, so that it is never sent twice, and is
+// then updated with the order or its error. An order failing before it is sent, such as when the
+// board can not be fetched, is retried on the next call until the slot is older than the grace.
+func (u *ScheduleUseCase) RunDue(ctx context.Context, opts ScheduleRunOptions) {
+	schedules, err := u.store.Load()
+	if err != nil {
+		u.event(ScheduleEvent{Err: err})
+		return
+	}
+	runs, err := u.store.Runs(0)
+	if err != nil {
+		u.event(ScheduleEvent{Err: err})
+		return
+	}
+	grace := opts.Grace
+	if grace <= 0 {
+		grace = DefaultScheduleGrace
+	}
+
+	for _, s := range schedules {
+		since := s.CreatedAt
+		for _, v := range runs {
+			if v.ScheduleID == s.ID && v.Slot.After(since) {
+				since = v.Slot
+			}
+		}
+		now := u.now()
+		slot, ok := s.Due(since, now)
+		if !ok {
+			continue
+		}
+
+		run := schedule.Run{ScheduleID: s.ID, Slot: slot.Time, Time: now}
+		if now.Sub(slot.At) > grace {
+			run.Status = schedule.Missed
+			u.record(s, run, slot.Missed, false)
+			continue
+		}
+
+		order, err := u.prepare(ctx, s, opts)
+		if err != nil {
+			if !errors.Is(err, cerror.ErrBadRequest) {
+				// Retried on the next call.
+				u.event(ScheduleEvent{Schedule: s, Err: err})
+				continue
+			}
+			run.Status, run.Error = schedule.Failed, err.Error()
+			u.record(s, run, slot.Missed, false)
+			continue
+		}
+
+		run.Status, run.Size, run.Price = schedule.Sending, order.size, order.price
+		if !u.record(s, run, slot.Missed, true) {
+			continue
+		}
+		run.OrderID, err = u.send(ctx, s, order, opts)
+		run.Status = schedule.Sent
+		if err != nil {
+			run.Status, run.Error = schedule.Failed, err.Error()
+		}
+		u.record(s, run, slot.Missed, false)
+	}
+}
+
+// scheduledOrder is the order of a slot.
+type scheduledOrder struct {
+	size float64
+	// price is the limit price, zero for market orders.
+	price float64
+}
+
+// prepare returns the order of s at the current board. Errors of the schedule itself, such as an
+// amount below the lot size, wrap ErrBadRequest.
+func (u *ScheduleUseCase) prepare(ctx context.Context, s schedule.Schedule, opts ScheduleRunOptions) (scheduledOrder, error) {
+	buy := s.Side == "BUY"
+	var bid, ask, lot, minSize float64
+	switch s.Broker() {
+	case journal.Kabucom:
+		if opts.KabucomToken == "" || opts.KabucomOrderPassword == "" {
+			return scheduledOrder{}, fmt.Errorf("%w: a kabu STATION token and order password are required for %s", cerror.ErrBadRequest, s.ProductCode)
+		}
+		board, err := u.kabucom.GetBoard(ctx, opts.KabucomToken, s.ProductCode)
+		if err != nil {
+			return scheduledOrder{}, fmt.Errorf("failed to fetch board of %s: %w", s.ProductCode, err)
+		}
+		if len(board.Bids) > 0 {
+			bid = board.Bids[0].Price
+		}
+		if len(board.Asks) > 0 {
+			ask = board.Asks[0].Price
+		}
+		if bid == 0 {
+			bid = board.CurrentPrice
+		}
+		if ask == 0 {
+			ask = board.CurrentPrice
+		}
+		symbol, err := u.kabucom.GetSymbol(ctx, opts.KabucomToken, s.ProductCode)
+		if err != nil {
+			return scheduledOrder{}, fmt.Errorf("failed to fetch symbol %s: %w", s.ProductCode, err)
+		}
+		lot, minSize = symbol.TradingUnit, symbol.TradingUnit
+	default:
+		board, err := u.bitflyer.GetBoard(ctx, s.ProductCode)
+		if err != nil {
+			return scheduledOrder{}, fmt.Errorf("failed to fetch board of %s: %w", s.ProductCode, err)
+		}
+		bid, ask = bestQuotes(board)
+		lot, minSize = bitflyerStep, bitflyer.MinimumOrderSizes[s.ProductCode]
+	}
+
+	// Limit orders rest at the best price of their side; market orders are sized at the best
+	// price of the other side, which they take.
+	order := scheduledOrder{}
+	sizing := ask
+	if s.OrderType == schedule.LimitAtBest {
+		order.price, sizing = bid, bid
+		if !buy {
+			order.price, sizing = ask, ask
+		}
+	} else if !buy {
+		sizing = bid
+	}
+	if sizing <= 0 {
+		return scheduledOrder{}, fmt.Errorf("no quote of %s", s.ProductCode)
+	}
+
+	order.size = s.Size
+	if s.Amount > 0 {
+		order.size = execution.RoundLot(s.Amount/sizing, lot)
+	}
+	if order.size <= 0 || order.size < minSize {
+		return scheduledOrder{}, fmt.Errorf("%w: size %v of %s is below the minimum order size %v", cerror.ErrBadRequest, order.size, s.ProductCode, minSize)
+	}
+	if s.Broker() == journal.Kabucom && math.Mod(order.size, lot) != 0 {
+		return scheduledOrder{}, fmt.Errorf("%w: size %v of %s is not a multiple of the lot size %v", cerror.ErrBadRequest, order.size, s.ProductCode, lot)
+	}
+	return order, nil
+}
+
+// send sends order through the risk checks and the journal of the clients.
+func (u *ScheduleUseCase) send(ctx context.Context, s schedule.Schedule, order scheduledOrder, opts ScheduleRunOptions) (string, error) {
+	if s.Broker() == journal.Kabucom {
+		return u.kabucom.SendOrder(ctx, opts.KabucomToken, kabucomScheduleRequest(s, order, opts))
+	}
+
+	req, err := orderRequest(bitflyerScheduleOrder(s, order))
+	if err != nil {
+		return "", err
+	}
+	res, err := u.bitflyer.SendOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return res.ChildOrderAcceptanceId, nil
+}
+
+// check runs the risk checks of the clients on order, if they have any.
+func (u *ScheduleUseCase) check(ctx context.Context, s schedule.Schedule, order scheduledOrder, opts ScheduleRunOptions) error {
+	if s.Broker() == journal.Kabucom {
+		if checker, ok := u.kabucom.(KabucomOrderChecker); ok {
+			return checker.CheckOrder(ctx, opts.KabucomToken, kabucomScheduleRequest(s, order, opts))
+		}
+		return nil
+	}
+
+	req, err := orderRequest(bitflyerScheduleOrder(s, order))
+	if err != nil {
+		return err
+	}
+	if checker, ok := u.bitflyer.(OrderChecker); ok {
+		return checker.CheckOrder(ctx, req)
+	}
+	return nil
+}
+
+func kabucomScheduleRequest(s schedule.Schedule, order scheduledOrder, opts ScheduleRunOptions) kabucom.OrderRequest {
+	return kabucom.OrderRequest{
+		Symbol:   s.ProductCode,
+		Side:     s.Side,
+		Qty:      order.size,
+		Price:    order.price,
+		Password: opts.KabucomOrderPassword,
+	}
+}
+
+func bitflyerScheduleOrder(s schedule.Schedule, order scheduledOrder) OrderCreate {
+	return OrderCreate{ProductCode: s.ProductCode, Size: order.size, Price: order.price, Buy: s.Side == "BUY", Market: s.OrderType == schedule.Market}
+}
+
+// record stores run and reports it. A claim fails when the slot already has a run, such as one
+// recorded by another `schedule run`. It reports whether run was stored.
+func (u *ScheduleUseCase) record(s schedule.Schedule, run schedule.Run, missed int, claim bool) bool {
+	if err := u.store.Record(run, !claim); err != nil {
+		u.event(ScheduleEvent{Schedule: s, Err: err})
+		return false
+	}
+	if run.Status != schedule.Sending {
+		u.event(ScheduleEvent{Schedule: s, Run: &run, Missed: missed})
+	}
+	return true
+}
+
+func (u *ScheduleUseCase) event(e ScheduleEvent) {
+	e.Time = u.now()
+	if u.OnEvent != nil {
+		u.OnEvent(e)
+	}
+}
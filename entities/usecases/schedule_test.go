@@ -0,0 +1,309 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom"
+	"github.com/sn1w/capital-go/entities/schedule"
+	cerror "github.com/sn1w/capital-go/error"
+)
+
+type mockedScheduleKabucom struct {
+	orders *[]kabucom.OrderRequest
+}
+
+func (m mockedScheduleKabucom) GetBoard(ctx context.Context, token string, symbol string) (*kabucom.BoardResponse, error) {
+	return &kabucom.BoardResponse{
+		Symbol:       symbol,
+		CurrentPrice: 2010,
+		Bids:         []kabucom.BoardLevel{{Price: 2000, Qty: 100}},
+		Asks:         []kabucom.BoardLevel{{Price: 2020, Qty: 100}},
+	}, nil
+}
+
+func (m mockedScheduleKabucom) GetSymbol(ctx context.Context, token string, symbol string) (*kabucom.Symbol, error) {
+	return &kabucom.Symbol{Symbol: "1306", TradingUnit: 10}, nil
+}
+
+func (m mockedScheduleKabucom) SendOrder(ctx context.Context, token string, order kabucom.OrderRequest) (string, error) {
+	*m.orders = append(*m.orders, order)
+	return "kabu-1", nil
+}
+
+// checkedScheduleKabucom rejects every order with err.
+type checkedScheduleKabucom struct {
+	mockedScheduleKabucom
+	err error
+}
+
+func (m checkedScheduleKabucom) CheckOrder(ctx context.Context, token string, order kabucom.OrderRequest) error {
+	return m.err
+}
+
+func TestScheduleUseCase_RunDue(t *testing.T) {
+	// 2024-06-10 is a Monday.
+	created := time.Date(2024, 6, 9, 7, 0, 0, 0, candle.JST)
+	slot := time.Date(2024, 6, 10, 9, 0, 0, 0, candle.JST)
+	opts := ScheduleRunOptions{KabucomToken: "token", KabucomOrderPassword: "password"}
+
+	tests := []struct {
+		name      string
+		schedule  schedule.Schedule
+		runs      []schedule.Run
+		now       time.Time
+		boardErr  error
+		wantOrder bool
+		wantSize  float64
+		wantPrice float64
+		want      schedule.RunStatus
+	}{
+		{
+			name:      "Amount At Market",
+			schedule:  schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			now:       slot.Add(time.Minute),
+			wantOrder: true,
+			wantSize:  0.002,
+			want:      schedule.Sent,
+		},
+		{
+			name:      "Size At Best",
+			schedule:  schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "SELL", Size: 0.01, OrderType: schedule.LimitAtBest},
+			now:       slot,
+			wantOrder: true,
+			wantSize:  0.01,
+			wantPrice: 5000000,
+			want:      schedule.Sent,
+		},
+		{
+			name:     "Not Due",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			now:      slot.Add(-time.Minute),
+		},
+		{
+			name:     "Interrupted Run Is Not Resent",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			runs:     []schedule.Run{{ScheduleID: 1, Slot: slot, Time: slot, Status: schedule.Sending}},
+			now:      slot.Add(time.Minute),
+			want:     schedule.Sending,
+		},
+		{
+			name:     "Too Late",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			now:      slot.Add(2 * time.Hour),
+			want:     schedule.Missed,
+		},
+		{
+			name:     "Below The Minimum Size",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 1000, OrderType: schedule.Market},
+			now:      slot,
+			want:     schedule.Failed,
+		},
+		{
+			name:     "Failed Board Is Retried",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			now:      slot,
+			boardErr: cerror.ErrRateLimited,
+		},
+		{
+			name:      "Stock In Lots At Best",
+			schedule:  schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "1306@1", Side: "BUY", Amount: 50000, OrderType: schedule.LimitAtBest},
+			now:       slot,
+			wantOrder: true,
+			wantSize:  20,
+			wantPrice: 2000,
+			want:      schedule.Sent,
+		},
+		{
+			// The Sunday slot of a stock waits for the open on Monday.
+			name:     "Stock Before The Open",
+			schedule: schedule.Schedule{Cron: "0 8 * * 0", ProductCode: "1306@1", Side: "BUY", Size: 10, OrderType: schedule.Market},
+			now:      slot.Add(-time.Minute),
+		},
+		{
+			name:      "Stock At The Open",
+			schedule:  schedule.Schedule{Cron: "0 8 * * 0", ProductCode: "1306@1", Side: "BUY", Size: 10, OrderType: schedule.Market},
+			now:       slot,
+			wantOrder: true,
+			wantSize:  10,
+			want:      schedule.Sent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := schedule.NewFileStore(filepath.Join(t.TempDir(), "schedules.json"))
+			tt.schedule.CreatedAt = created
+			if _, err := store.Add(tt.schedule); err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range tt.runs {
+				if err := store.Record(v, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sent := []bitflyer.SendOrderRequest{}
+			client := mockedBitFlyerClient{
+				getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+					if tt.boardErr != nil {
+						return nil, tt.boardErr
+					}
+					return &bitflyer.BoardResponse{
+						MidPrice: 4999000,
+						Bids:     bitflyer.PriceResponses{{Price: 4998000, Size: 1}},
+						Asks:     bitflyer.PriceResponses{{Price: 5000000, Size: 1}},
+					}, nil
+				},
+				sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+					sent = append(sent, req)
+					return &bitflyer.OrderResponse{ChildOrderAcceptanceId: "JRF-1"}, nil
+				},
+			}
+			stocks := []kabucom.OrderRequest{}
+			u := NewScheduleUseCase(client, mockedScheduleKabucom{orders: &stocks}, store)
+			u.now = func() time.Time { return tt.now }
+			events := []ScheduleEvent{}
+			u.OnEvent = func(e ScheduleEvent) { events = append(events, e) }
+
+			// A second pass sends nothing more.
+			u.RunDue(context.Background(), opts)
+			u.RunDue(context.Background(), opts)
+
+			orders := len(sent) + len(stocks)
+			if orders != map[bool]int{true: 1}[tt.wantOrder] {
+				t.Fatalf("ScheduleUseCase.RunDue() sent %d orders (%+v, %+v), want order %v", orders, sent, stocks, tt.wantOrder)
+			}
+			if tt.wantOrder {
+				size, price := 0.0, 0.0
+				if len(sent) > 0 {
+					size, price = sent[0].Size, sent[0].Price
+				} else {
+					size, price = stocks[0].Qty, stocks[0].Price
+					if stocks[0].Password != "password" {
+						t.Errorf("ScheduleUseCase.RunDue() order password = %q", stocks[0].Password)
+					}
+				}
+				if size != tt.wantSize || price != tt.wantPrice {
+					t.Errorf("ScheduleUseCase.RunDue() order = %v @ %v, want %v @ %v", size, price, tt.wantSize, tt.wantPrice)
+				}
+			}
+
+			runs, err := store.Runs(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if len(runs) != 0 {
+					t.Errorf("ScheduleUseCase.RunDue() runs = %+v, want none", runs)
+				}
+				if tt.boardErr != nil && (len(events) != 2 || !errors.Is(events[0].Err, tt.boardErr)) {
+					t.Errorf("ScheduleUseCase.RunDue() events = %+v, want 2 errors", events)
+				}
+				return
+			}
+			if len(runs) != 1 || runs[0].Status != tt.want {
+				t.Errorf("ScheduleUseCase.RunDue() runs = %+v, want one %s", runs, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleUseCase_List(t *testing.T) {
+	store := schedule.NewFileStore(filepath.Join(t.TempDir(), "schedules.json"))
+	u := NewScheduleUseCase(mockedBitFlyerClient{}, mockedScheduleKabucom{}, store)
+	u.now = func() time.Time { return time.Date(2024, 6, 10, 9, 30, 0, 0, candle.JST) }
+
+	if _, err := u.Add(schedule.Schedule{Cron: "0 9 * * *", ProductCode: "BTC_JPY", Side: "BUY", OrderType: schedule.Market}); !errors.Is(err, cerror.ErrBadRequest) {
+		t.Errorf("ScheduleUseCase.Add() error = %v, expectedError %v", err, cerror.ErrBadRequest)
+	}
+	if _, err := u.Add(schedule.Schedule{Cron: "0 9 * * *", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Record(schedule.Run{ScheduleID: 1, Slot: time.Date(2024, 6, 11, 9, 0, 0, 0, candle.JST), Status: schedule.Sent}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := u.List()
+	if err != nil {
+		t.Fatalf("ScheduleUseCase.List() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Last == nil || got[0].Next == nil || !got[0].Next.Time.Equal(time.Date(2024, 6, 12, 9, 0, 0, 0, candle.JST)) {
+		t.Errorf("ScheduleUseCase.List() = %+v", got)
+	}
+}
+
+func TestScheduleUseCase_Preview(t *testing.T) {
+	// 2024-06-09 is a Sunday.
+	now := time.Date(2024, 6, 9, 7, 0, 0, 0, candle.JST)
+	opts := ScheduleRunOptions{KabucomToken: "token", KabucomOrderPassword: "password"}
+	client := mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			return &bitflyer.BoardResponse{
+				MidPrice: 4999000,
+				Bids:     bitflyer.PriceResponses{{Price: 4998000, Size: 1}},
+				Asks:     bitflyer.PriceResponses{{Price: 5000000, Size: 1}},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name        string
+		schedule    schedule.Schedule
+		checkErr    error
+		want        ScheduleOrder
+		expectedErr error
+	}{
+		{
+			name:     "bitFlyer",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			want:     ScheduleOrder{Size: 0.002, Next: &schedule.Slot{Time: time.Date(2024, 6, 10, 9, 0, 0, 0, candle.JST), At: time.Date(2024, 6, 10, 9, 0, 0, 0, candle.JST)}},
+		},
+		{
+			name:     "kabu STATION",
+			schedule: schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "1306@1", Side: "BUY", Amount: 50000, OrderType: schedule.LimitAtBest},
+			want:     ScheduleOrder{Size: 20, Price: 2000, Next: &schedule.Slot{Time: time.Date(2024, 6, 10, 9, 0, 0, 0, candle.JST), At: time.Date(2024, 6, 10, 9, 0, 0, 0, candle.JST)}},
+		},
+		{
+			name:        "Rejected By The Risk Checks",
+			schedule:    schedule.Schedule{Cron: "0 9 * * 1", ProductCode: "1306@1", Side: "BUY", Amount: 50000000, OrderType: schedule.Market},
+			checkErr:    cerror.ErrRiskRejected,
+			expectedErr: cerror.ErrRiskRejected,
+		},
+		{
+			name:        "Invalid",
+			schedule:    schedule.Schedule{Cron: "0 9 * *", ProductCode: "BTC_JPY", Side: "BUY", Amount: 10000, OrderType: schedule.Market},
+			expectedErr: cerror.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stocks := []kabucom.OrderRequest{}
+			store := schedule.NewFileStore(filepath.Join(t.TempDir(), "schedules.json"))
+			u := NewScheduleUseCase(client, checkedScheduleKabucom{mockedScheduleKabucom{orders: &stocks}, tt.checkErr}, store)
+			u.now = func() time.Time { return now }
+
+			got, err := u.Preview(context.Background(), tt.schedule, opts)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("ScheduleUseCase.Preview() error = %v, expectedError %v", err, tt.expectedErr)
+			}
+			if len(stocks) != 0 {
+				t.Errorf("ScheduleUseCase.Preview() sent %+v", stocks)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+			if got.Size != tt.want.Size || got.Price != tt.want.Price || got.Next == nil || !got.Next.At.Equal(tt.want.Next.At) {
+				t.Errorf("ScheduleUseCase.Preview() = %+v, want %+v", got, tt.want)
+			}
+			schedules, err := store.Load()
+			if err != nil || len(schedules) != 0 {
+				t.Errorf("ScheduleUseCase.Preview() stored %+v, %v", schedules, err)
+			}
+		})
+	}
+}
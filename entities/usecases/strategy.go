@@ -0,0 +1,419 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/candle"
+	"github.com/sn1w/capital-go/entities/execution"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/strategy"
+)
+
+// candleDelay is how long a candle stays open after its end, as executions of the realtime API
+// arrive shortly after they happen.
+const candleDelay = 2 * time.Second
+
+// stopTimeout bounds cancelling orders and flattening the position on stop.
+const stopTimeout = 30 * time.Second
+
+type StrategyRunQuery struct {
+	ProductCode string
+	Strategy    string
+	Params      strategy.Params
+	Interval    time.Duration
+	// PollInterval is the interval of polling order fills and closing candles.
+	PollInterval time.Duration
+	// Flatten sells the position of the strategy when it stops.
+	Flatten bool
+}
+
+// StrategyEvent reports an order or a failure of a running strategy.
+type StrategyEvent struct {
+	Time    time.Time
+	Message string
+	// Err is set when the event is a failure. The strategy keeps running.
+	Err error
+}
+
+type StrategyResult struct {
+	Orders   int
+	Position float64
+}
+
+type StrategyUseCase struct {
+	client   BitFlyerClient
+	realtime RealtimeClient
+	useCase  BitFlyerUseCase
+	now      func() time.Time
+	// reconnectDelay is the first delay before reconnecting, doubled on each failure.
+	reconnectDelay time.Duration
+	// OnEvent is called for each order and failure, to report them.
+	OnEvent func(StrategyEvent)
+}
+
+func NewStrategyUseCase(client BitFlyerClient, realtime RealtimeClient) StrategyUseCase {
+	return StrategyUseCase{
+		client:         client,
+		realtime:       realtime,
+		useCase:        NewBitFlyerUseCase(client),
+		now:            time.Now,
+		reconnectDelay: time.Second,
+	}
+}
+
+// StrategyPreview is a strategy about to run, to confirm before it starts.
+type StrategyPreview struct {
+	Strategy    string
+	ProductCode string
+	// Params are the parameters the strategy runs with, defaults included.
+	Params strategy.Params
+	// MidPrice is the current mid price of ProductCode.
+	MidPrice float64
+}
+
+// Preview builds the strategy of query and fetches the board of its product, without starting it
+// or sending anything.
+func (s *StrategyUseCase) Preview(ctx context.Context, query StrategyRunQuery) (*StrategyPreview, error) {
+	if _, err := strategy.New(query.Strategy, query.Params); err != nil {
+		return nil, err
+	}
+	params, err := strategy.Resolve(query.Strategy, query.Params)
+	if err != nil {
+		return nil, err
+	}
+	board, err := s.client.GetBoard(ctx, query.ProductCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the board of %s: %w", query.ProductCode, err)
+	}
+	return &StrategyPreview{
+		Strategy:    query.Strategy,
+		ProductCode: query.ProductCode,
+		Params:      params,
+		MidPrice:    board.MidPrice,
+	}, nil
+}
+
+// executionBatch is a message of the executions channel, or the failure of a connection.
+type executionBatch struct {
+	executions bitflyer.GetExecutionsResponse
+	// first is set on the first message of a connection. Executions before it may have been
+	// missed, such as while reconnecting.
+	first bool
+	err   error
+}
+
+// Run feeds executions after the start to the strategy, and sends its orders through
+// BitFlyerUseCase until ctx is cancelled. On stop, resting orders are cancelled and the
+// position is sold when Flatten is set.
+//
+// Executions are streamed from the executions channel of the realtime API. On each connection,
+// the executions missed since the start or the last connection are fetched from
+// `GET /v1/executions` first. The position of the strategy is the sum of the fills of its own
+// orders, which are polled.
+func (s *StrategyUseCase) Run(ctx context.Context, query StrategyRunQuery) (*StrategyResult, error) {
+	st, err := strategy.New(query.Strategy, query.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.client.GetExecutions(ctx, query.ProductCode, bitflyer.Pagination{Count: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch executions: %w", err)
+	}
+	var lastID int64
+	if len(latest) > 0 {
+		lastID = latest[0].Id
+	}
+
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	batches := make(chan executionBatch)
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		s.stream(streamCtx, query.ProductCode, batches)
+	}()
+	defer func() {
+		cancelStream()
+		<-streamDone
+	}()
+
+	broker := &liveBroker{ctx: ctx, runner: s, productCode: query.ProductCode}
+	candles := candle.NewStream(query.Interval)
+	// feed passes trades after lastID to the strategy, oldest first.
+	feed := func(trades []candle.Trade) {
+		for _, trade := range trades {
+			if trade.ID <= lastID {
+				continue
+			}
+			lastID = trade.ID
+			for _, v := range candles.Add(trade) {
+				st.OnBar(broker, v)
+			}
+			st.OnTrade(broker, trade)
+		}
+	}
+	ticker := time.NewTicker(query.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.stop(broker, query)
+		case b := <-batches:
+			if b.err != nil {
+				s.event("", b.err)
+				continue
+			}
+			trades, err := toTrades(b.executions)
+			if err != nil {
+				s.event("", err)
+				continue
+			}
+			if b.first {
+				missed, err := s.history(ctx, query.ProductCode, lastID)
+				if err != nil {
+					if ctx.Err() != nil {
+						return s.stop(broker, query)
+					}
+					s.event("", fmt.Errorf("executions before the connection are missing: %w", err))
+				}
+				feed(missed)
+			}
+			feed(trades)
+		case <-ticker.C:
+			broker.sync()
+			for _, v := range candles.CloseUntil(s.now().Add(-candleDelay)) {
+				st.OnBar(broker, v)
+			}
+		}
+	}
+}
+
+// stream sends the messages of the executions channel of productCode to batches until ctx is
+// cancelled, reconnecting on failures.
+func (s *StrategyUseCase) stream(ctx context.Context, productCode string, batches chan<- executionBatch) {
+	send := func(b executionBatch) {
+		select {
+		case batches <- b:
+		case <-ctx.Done():
+		}
+	}
+
+	channels := []string{bitflyer.ExecutionsChannel(productCode)}
+	delay := s.reconnectDelay
+	for ctx.Err() == nil {
+		first := true
+		err := s.realtime.Subscribe(ctx, channels, func(m bitflyer.ChannelMessage) {
+			var executions bitflyer.GetExecutionsResponse
+			if err := json.Unmarshal(m.Message, &executions); err != nil {
+				send(executionBatch{err: fmt.Errorf("failed to parse executions: %w", err)})
+				return
+			}
+			send(executionBatch{executions: executions, first: first})
+			first = false
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if !first {
+			delay = s.reconnectDelay
+		}
+		send(executionBatch{err: fmt.Errorf("realtime connection lost, reconnecting in %s: %w", delay, err)})
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// history returns the executions after lastID from `GET /v1/executions`, oldest first.
+func (s *StrategyUseCase) history(ctx context.Context, productCode string, lastID int64) ([]candle.Trade, error) {
+	page := bitflyer.Pagination{Count: executionsPageSize, After: lastID}
+	executions := bitflyer.GetExecutionsResponse{}
+	for {
+		result, err := s.client.GetExecutions(ctx, productCode, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch executions: %w", err)
+		}
+		executions = append(executions, result...)
+		if len(result) < executionsPageSize {
+			break
+		}
+		page.Before = result[len(result)-1].Id
+	}
+	return toTrades(executions)
+}
+
+// toTrades converts executions into trades, oldest first.
+func toTrades(executions bitflyer.GetExecutionsResponse) ([]candle.Trade, error) {
+	trades := []candle.Trade{}
+	for _, v := range executions {
+		execDate, err := bitflyer.ParseTime(v.ExecDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse exec_date of execution %d: %w", v.Id, err)
+		}
+		trades = append(trades, candle.Trade{ID: v.Id, Time: execDate, Price: v.Price, Size: v.Size})
+	}
+	sort.Slice(trades, func(i, j int) bool { return trades[i].ID < trades[j].ID })
+	return trades, nil
+}
+
+// stop cancels resting orders and flattens the position if requested.
+func (s *StrategyUseCase) stop(broker *liveBroker, query StrategyRunQuery) (*StrategyResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	broker.ctx = ctx
+
+	for _, v := range broker.open() {
+		broker.Cancel(v.id)
+	}
+	broker.sync()
+
+	if query.Flatten && broker.position > execution.SizeEpsilon {
+		id, err := broker.Send(strategy.Order{Side: bitflyer.SideSell, Size: broker.position})
+		if err != nil {
+			return broker.result(), fmt.Errorf("failed to flatten the position: %w", err)
+		}
+		for broker.pending(id) {
+			select {
+			case <-ctx.Done():
+				return broker.result(), fmt.Errorf("failed to confirm the flattening order %s: %w", id, ctx.Err())
+			case <-time.After(query.PollInterval):
+			}
+			broker.sync()
+		}
+	}
+	return broker.result(), nil
+}
+
+func (s *StrategyUseCase) event(message string, err error) {
+	if s.OnEvent != nil {
+		s.OnEvent(StrategyEvent{Time: s.now(), Message: message, Err: err})
+	}
+}
+
+// liveOrder is an order sent by a running strategy.
+type liveOrder struct {
+	id     string
+	side   bitflyer.ChildOrderSide
+	size   float64
+	filled float64
+	done   bool
+}
+
+// liveBroker is the strategy.Broker of a running strategy.
+type liveBroker struct {
+	ctx         context.Context
+	runner      *StrategyUseCase
+	productCode string
+	position    float64
+	orders      []*liveOrder
+}
+
+var _ strategy.Broker = &liveBroker{}
+
+func (b *liveBroker) Send(o strategy.Order) (string, error) {
+	res, err := b.runner.useCase.CreateOrder(b.ctx, OrderCreate{
+		ProductCode: b.productCode,
+		Price:       o.Price,
+		Size:        o.Size,
+		Buy:         o.Side == bitflyer.SideBuy,
+		// Strategies send market orders with a zero price.
+		Market: o.Price == 0,
+	})
+	if err != nil {
+		b.runner.event("", fmt.Errorf("%s %v: %w", o.Side, o.Size, err))
+		return "", err
+	}
+
+	b.orders = append(b.orders, &liveOrder{id: res.OrderAcceeptanceId, side: o.Side, size: o.Size})
+	price := "market"
+	if o.Price > 0 {
+		price = fmt.Sprint(o.Price)
+	}
+	b.runner.event(fmt.Sprintf("sent %s %v at %s: %s", o.Side, o.Size, price, res.OrderAcceeptanceId), nil)
+	return res.OrderAcceeptanceId, nil
+}
+
+func (b *liveBroker) Cancel(id string) error {
+	for _, v := range b.open() {
+		if v.id != id {
+			continue
+		}
+		if err := b.runner.useCase.CancelOrder(b.ctx, b.productCode, id); err != nil {
+			b.runner.event("", fmt.Errorf("cancel %s: %w", id, err))
+			return err
+		}
+		// Count what was filled before the cancel.
+		b.update(v)
+		v.done = true
+		b.runner.event(fmt.Sprintf("cancelled %s", id), nil)
+		return nil
+	}
+	return fmt.Errorf("order %s is not open", id)
+}
+
+func (b *liveBroker) Position() float64 {
+	return b.position
+}
+
+func (b *liveBroker) open() []*liveOrder {
+	res := []*liveOrder{}
+	for _, v := range b.orders {
+		if !v.done {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (b *liveBroker) pending(id string) bool {
+	for _, v := range b.open() {
+		if v.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sync applies new fills of open orders to the position.
+func (b *liveBroker) sync() {
+	for _, v := range b.open() {
+		b.update(v)
+		if v.filled >= v.size-execution.SizeEpsilon {
+			v.done = true
+			b.runner.event(fmt.Sprintf("filled %s %v: %s", v.side, v.size, v.id), nil)
+		}
+	}
+}
+
+func (b *liveBroker) update(o *liveOrder) {
+	executions, err := b.runner.useCase.GetOrderExecutions(b.ctx, b.productCode, o.id)
+	if err != nil {
+		b.runner.event("", fmt.Errorf("order %s: %w", o.id, err))
+		return
+	}
+
+	filled := 0.0
+	for _, v := range executions {
+		filled += v.Size
+	}
+	if o.side == bitflyer.SideBuy {
+		b.position += filled - o.filled
+	} else {
+		b.position -= filled - o.filled
+	}
+	o.filled = filled
+}
+
+func (b *liveBroker) result() *StrategyResult {
+	return &StrategyResult{Orders: len(b.orders), Position: b.position}
+}
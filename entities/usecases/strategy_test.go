@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/strategy"
+)
+
+func TestStrategyUseCase_Run(t *testing.T) {
+	start := time.Date(2023, 4, 1, 1, 0, 0, 0, time.UTC)
+	execution := func(id int64, t time.Time) bitflyer.ExecutionResponse {
+		return bitflyer.ExecutionResponse{Id: id, Price: 100, Size: 1, ExecDate: t.Format("2006-01-02T15:04:05.000")}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first connection repeats an execution of the backfill and fails. Executions missed while
+	// reconnecting are fetched again.
+	realtime := &mockedRealtime{sessions: [][]bitflyer.ChannelMessage{
+		{message(t, "lightning_executions_BTC_JPY", bitflyer.GetExecutionsResponse{execution(11, start.Add(10*time.Second))})},
+		{message(t, "lightning_executions_BTC_JPY", bitflyer.GetExecutionsResponse{execution(12, start.Add(20*time.Second)), execution(13, start.Add(30*time.Second))})},
+	}}
+
+	var mu sync.Mutex
+	sent := []bitflyer.SendOrderRequest{}
+	backfills := []int64{}
+	reconnected := false
+	client := mockedBitFlyerClient{
+		getExecutions: func(pc string, page bitflyer.Pagination) (bitflyer.GetExecutionsResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch page.After {
+			case 0:
+				// The newest execution before the start is not replayed.
+				return bitflyer.GetExecutionsResponse{execution(10, start.Add(-time.Second))}, nil
+			case 10:
+				backfills = append(backfills, page.After)
+				return bitflyer.GetExecutionsResponse{execution(11, start.Add(10*time.Second))}, nil
+			case 11:
+				backfills = append(backfills, page.After)
+				reconnected = true
+				return bitflyer.GetExecutionsResponse{execution(12, start.Add(20*time.Second))}, nil
+			}
+			t.Errorf("StrategyUseCase.Run() fetched executions after %d", page.After)
+			return bitflyer.GetExecutionsResponse{}, nil
+		},
+		sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, req)
+			return &bitflyer.OrderResponse{ChildOrderAcceptanceId: string(req.Side)}, nil
+		},
+		getMyExecs: func(pc string, id string, page bitflyer.Pagination) (bitflyer.GetPrivateExecutionsResponse, error) {
+			// Market orders fill at once. The strategy is stopped once the buy is seen filled.
+			if id == string(bitflyer.SideBuy) {
+				cancel()
+			}
+			return bitflyer.GetPrivateExecutionsResponse{{Price: 100, Size: 0.01, ChildOrderAcceptanceId: id}}, nil
+		},
+	}
+
+	s := NewStrategyUseCase(client, realtime)
+	s.reconnectDelay = time.Millisecond
+	// The first candle closes once the clock passes its end, after the reconnection.
+	s.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		if !reconnected {
+			return start
+		}
+		return start.Add(2 * time.Minute)
+	}
+	messages := []string{}
+	failures := 0
+	s.OnEvent = func(e StrategyEvent) {
+		if e.Err != nil {
+			failures++
+			return
+		}
+		messages = append(messages, e.Message)
+	}
+
+	got, err := s.Run(ctx, StrategyRunQuery{
+		ProductCode:  "BTC_JPY",
+		Strategy:     "buy-and-hold",
+		Interval:     time.Minute,
+		PollInterval: time.Millisecond,
+		Flatten:      true,
+	})
+	if err != nil {
+		t.Fatalf("StrategyUseCase.Run() error = %v", err)
+	}
+
+	want := []bitflyer.SendOrderRequest{
+		{ProductCode: "BTC_JPY", Side: bitflyer.SideBuy, Size: 0.01, ChildOrderType: bitflyer.ChildOrderTypeMarket, TimeInForce: bitflyer.TimeInForceGTC},
+		{ProductCode: "BTC_JPY", Side: bitflyer.SideSell, Size: 0.01, ChildOrderType: bitflyer.ChildOrderTypeMarket, TimeInForce: bitflyer.TimeInForceGTC},
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("StrategyUseCase.Run() sent %v, want %v", sent, want)
+	}
+	if got.Orders != 2 || got.Position != 0 {
+		t.Errorf("StrategyUseCase.Run() = %+v, want 2 orders and no position", got)
+	}
+	wantMessages := []string{
+		"sent BUY 0.01 at market: BUY", "filled BUY 0.01: BUY",
+		"sent SELL 0.01 at market: SELL", "filled SELL 0.01: SELL",
+	}
+	if !reflect.DeepEqual(backfills, []int64{10, 11}) {
+		t.Errorf("StrategyUseCase.Run() backfilled after %v, want after [10 11]", backfills)
+	}
+	if failures != 1 {
+		t.Errorf("StrategyUseCase.Run() reported %d failures, want the lost connection", failures)
+	}
+	if !reflect.DeepEqual(messages, wantMessages) {
+		t.Errorf("StrategyUseCase.OnEvent received %v, want %v", messages, wantMessages)
+	}
+}
+
+func TestStrategyUseCase_Preview(t *testing.T) {
+	client := mockedBitFlyerClient{
+		getBoard: func(pc string) (*bitflyer.BoardResponse, error) {
+			return &bitflyer.BoardResponse{MidPrice: 5000000}, nil
+		},
+		sendOrder: func(req bitflyer.SendOrderRequest) (*bitflyer.OrderResponse, error) {
+			t.Errorf("StrategyUseCase.Preview() sent %v", req)
+			return nil, nil
+		},
+	}
+	s := NewStrategyUseCase(client, &mockedRealtime{})
+
+	tests := []struct {
+		name     string
+		query    StrategyRunQuery
+		expected *StrategyPreview
+		wantErr  bool
+	}{
+		{
+			name:  "defaults are included",
+			query: StrategyRunQuery{ProductCode: "BTC_JPY", Strategy: "sma-cross", Params: strategy.Params{"size": 0.1}},
+			expected: &StrategyPreview{
+				Strategy: "sma-cross", ProductCode: "BTC_JPY", MidPrice: 5000000,
+				Params: strategy.Params{"fast": 5, "slow": 20, "size": 0.1},
+			},
+		},
+		{
+			name:    "invalid parameters",
+			query:   StrategyRunQuery{ProductCode: "BTC_JPY", Strategy: "sma-cross", Params: strategy.Params{"fast": 20, "slow": 5}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown strategy",
+			query:   StrategyRunQuery{ProductCode: "BTC_JPY", Strategy: "martingale"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Preview(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StrategyUseCase.Preview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("StrategyUseCase.Preview() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/pnl"
+	"github.com/sn1w/capital-go/entities/tax"
+)
+
+type TaxQuery struct {
+	Year int
+	// Method is pnl.MovingAverage or pnl.TotalAverage, as filed to the tax office.
+	Method pnl.Method
+}
+
+type TaxReport struct {
+	Crypto *tax.CryptoReport
+	Stocks *tax.StockReport
+	// Skipped are the bitFlyer products traded but not computed: derivatives such as FX_BTC_JPY
+	// and pairs not against JPY.
+	Skipped []string
+}
+
+type TaxUseCase struct {
+	store JournalStore
+}
+
+func NewTaxUseCase(store JournalStore) TaxUseCase {
+	return TaxUseCase{store: store}
+}
+
+// Report computes the crypto income from the bitFlyer fills and transfers of the journal, and the
+// capital gains of stocks from the kabu STATION fills. The paper broker is not reported.
+func (u *TaxUseCase) Report(query TaxQuery) (*TaxReport, error) {
+	j, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	trades := []tax.Trade{}
+	stocks := []tax.StockTrade{}
+	skipped := []string{}
+	for _, v := range j.Fills {
+		switch v.Broker {
+		case journal.BitFlyer:
+			parts := strings.Split(v.ProductCode, "_")
+			if len(parts) != 2 || parts[1] != "JPY" {
+				if !contains(skipped, v.ProductCode) {
+					skipped = append(skipped, v.ProductCode)
+				}
+				continue
+			}
+			trades = append(trades, tax.Trade{
+				Time:       v.Time,
+				Currency:   parts[0],
+				Side:       v.Side,
+				Price:      v.Price,
+				Size:       v.Size,
+				Commission: v.Commission,
+			})
+		case journal.Kabucom:
+			stocks = append(stocks, tax.StockTrade{
+				Time:       v.Time,
+				Symbol:     v.ProductCode,
+				Side:       v.Side,
+				Price:      v.Price,
+				Qty:        v.Size,
+				Commission: v.Commission,
+			})
+		}
+	}
+	sort.Strings(skipped)
+
+	transfers := []tax.Transfer{}
+	for _, v := range j.Transfers {
+		if v.Broker != journal.BitFlyer {
+			continue
+		}
+		transfers = append(transfers, tax.Transfer{Time: v.Time, Currency: v.Currency, Amount: v.Amount, Fee: v.Fee})
+	}
+
+	crypto, err := tax.Crypto(query.Year, query.Method, trades, transfers)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := tax.Stocks(query.Year, stocks)
+	if err != nil {
+		return nil, err
+	}
+	return &TaxReport{Crypto: crypto, Stocks: stock, Skipped: skipped}, nil
+}
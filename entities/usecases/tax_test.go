@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/journal"
+	"github.com/sn1w/capital-go/entities/pnl"
+)
+
+func TestTaxUseCase_Report(t *testing.T) {
+	at := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	store := journal.NewFileStore(filepath.Join(t.TempDir(), "journal.json"))
+	_, err := store.AddFills([]journal.Fill{
+		{Broker: journal.BitFlyer, ID: "1", ProductCode: "BTC_JPY", Side: "BUY", Price: 5000000, Size: 0.02, Time: at},
+		{Broker: journal.BitFlyer, ID: "2", ProductCode: "BTC_JPY", Side: "SELL", Price: 6000000, Size: 0.01, Time: at.Add(time.Hour)},
+		{Broker: journal.BitFlyer, ID: "3", ProductCode: "FX_BTC_JPY", Side: "SELL", Price: 6000000, Size: 1, Time: at},
+		{Broker: journal.BitFlyer, ID: "4", ProductCode: "ETH_BTC", Side: "BUY", Price: 0.05, Size: 1, Time: at},
+		{Broker: journal.Paper, ID: "1", ProductCode: "BTC_JPY", Side: "SELL", Price: 1, Size: 1, Time: at},
+		{Broker: journal.Kabucom, ID: "E1", ProductCode: "9433@1", Side: "BUY", Price: 4000, Size: 100, Time: at},
+		{Broker: journal.Kabucom, ID: "E2", ProductCode: "9433@1", Side: "SELL", Price: 4100, Size: 100, Commission: 100, Time: at.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.AddTransfers([]journal.Transfer{
+		{Broker: journal.BitFlyer, ID: "out-1", Currency: "BTC", Amount: -0.005, Time: at.Add(2 * time.Hour)},
+		{Broker: journal.Paper, ID: "in-1", Currency: "BTC", Amount: 9, Time: at},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewTaxUseCase(store)
+
+	got, err := u.Report(TaxQuery{Year: 2026, Method: pnl.MovingAverage})
+	if err != nil {
+		t.Fatalf("TaxUseCase.Report() error = %v", err)
+	}
+	if len(got.Crypto.Currencies) != 1 || got.Crypto.Income() != 10000 || got.Crypto.Currencies[0].Closing.Quantity != 0.005 {
+		t.Errorf("TaxUseCase.Report() crypto = %+v", got.Crypto)
+	}
+	if len(got.Stocks.Symbols) != 1 || got.Stocks.Gain() != 9900 {
+		t.Errorf("TaxUseCase.Report() stocks = %+v", got.Stocks)
+	}
+	if !reflect.DeepEqual(got.Skipped, []string{"ETH_BTC", "FX_BTC_JPY"}) {
+		t.Errorf("TaxUseCase.Report() skipped = %v", got.Skipped)
+	}
+
+	if _, err := u.Report(TaxQuery{Year: 2026, Method: pnl.FIFO}); err == nil {
+		t.Errorf("TaxUseCase.Report() error = nil, expected one for fifo")
+	}
+}
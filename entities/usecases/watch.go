@@ -0,0 +1,333 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// RealtimeClient streams channels of the bitFlyer Realtime API.
+type RealtimeClient interface {
+	Subscribe(ctx context.Context, channels []string, handler func(bitflyer.ChannelMessage)) error
+}
+
+var _ RealtimeClient = &bitflyer.Realtime{}
+
+type Ticker struct {
+	BestBid float64
+	BestAsk float64
+	Ltp     float64
+	Volume  float64
+	Time    time.Time
+}
+
+type MarketTrade struct {
+	Time  time.Time
+	Side  string
+	Price float64
+	Size  float64
+}
+
+type OpenOrder struct {
+	OrderAcceptanceId string
+	Side              string
+	Type              string
+	Price             float64
+	Size              float64
+	Outstanding       float64
+	Time              time.Time
+}
+
+// MarketState is the live state of a product.
+type MarketState struct {
+	ProductCode string
+	MidPrice    float64
+	// Bids and Asks are sorted from the best price.
+	Bids   BoardPrices
+	Asks   BoardPrices
+	Ticker Ticker
+	// Trades are the latest executions, newest first.
+	Trades   []MarketTrade
+	Orders   []OpenOrder
+	Balances Balances
+	// Connected is false until the first message and while reconnecting.
+	Connected bool
+	// Err is the last error of the market data, such as a lost connection. It is cleared by the next message.
+	Err error
+	// AccountErr is the last error loading the orders and balances. It is cleared by the next load.
+	AccountErr error
+	UpdatedAt  time.Time
+}
+
+type MarketWatchQuery struct {
+	ProductCode string
+	// Account loads the open orders and balances, which need authorization.
+	Account bool
+	// OrderEvents reloads the account on child order events of the realtime API.
+	// Paper trading has no such events and relies on RefreshInterval instead.
+	OrderEvents bool
+	// RefreshInterval reloads the account periodically. Zero disables it.
+	RefreshInterval time.Duration
+}
+
+const (
+	// maxLevels is the number of price levels per side kept in MarketState.
+	maxLevels = 100
+	// maxTrades is the number of trades kept in MarketState.
+	maxTrades = 100
+	// maxReconnectDelay bounds the backoff between reconnections.
+	maxReconnectDelay = 30 * time.Second
+)
+
+// MarketWatchUseCase keeps a MarketState up to date from the realtime API.
+type MarketWatchUseCase struct {
+	client   BitFlyerClient
+	realtime RealtimeClient
+	now      func() time.Time
+	// reconnectDelay is the first delay before reconnecting, doubled on each failure.
+	reconnectDelay time.Duration
+	refresh        chan struct{}
+}
+
+func NewMarketWatchUseCase(client BitFlyerClient, realtime RealtimeClient) *MarketWatchUseCase {
+	return &MarketWatchUseCase{
+		client:         client,
+		realtime:       realtime,
+		now:            time.Now,
+		reconnectDelay: time.Second,
+		refresh:        make(chan struct{}, 1),
+	}
+}
+
+// Refresh reloads the account soon, such as after sending an order.
+func (u *MarketWatchUseCase) Refresh() {
+	select {
+	case u.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// marketWatch is the state shared by the goroutines of Watch.
+type marketWatch struct {
+	mu       sync.Mutex
+	state    MarketState
+	bids     map[float64]float64
+	asks     map[float64]float64
+	onChange func(MarketState)
+}
+
+// Watch streams the state of query.ProductCode to onChange until ctx is cancelled, reconnecting on failures.
+// onChange is called one at a time and must not block.
+func (u *MarketWatchUseCase) Watch(ctx context.Context, query MarketWatchQuery, onChange func(MarketState)) error {
+	w := &marketWatch{
+		state:    MarketState{ProductCode: query.ProductCode},
+		bids:     map[float64]float64{},
+		asks:     map[float64]float64{},
+		onChange: onChange,
+	}
+
+	var wg sync.WaitGroup
+	if query.Account {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u.watchAccount(ctx, query, w)
+		}()
+	}
+
+	channels := []string{
+		bitflyer.BoardSnapshotChannel(query.ProductCode),
+		bitflyer.BoardChannel(query.ProductCode),
+		bitflyer.TickerChannel(query.ProductCode),
+		bitflyer.ExecutionsChannel(query.ProductCode),
+	}
+	if query.Account && query.OrderEvents {
+		channels = append(channels, bitflyer.ChildOrderEventsChannel)
+	}
+
+	delay := u.reconnectDelay
+	for ctx.Err() == nil {
+		received := false
+		err := u.realtime.Subscribe(ctx, channels, func(m bitflyer.ChannelMessage) {
+			received = true
+			if m.Channel == bitflyer.ChildOrderEventsChannel {
+				u.Refresh()
+				return
+			}
+			w.update(func(s *MarketState) error {
+				s.Connected = true
+				return w.apply(s, m, u.now())
+			})
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if received {
+			delay = u.reconnectDelay
+		}
+		w.update(func(s *MarketState) error {
+			s.Connected = false
+			return fmt.Errorf("realtime connection lost, reconnecting in %s: %w", delay, err)
+		})
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// update changes the state with fn and notifies it. The error of fn becomes MarketState.Err.
+func (w *marketWatch) update(fn func(s *MarketState) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.Err = fn(&w.state)
+	w.onChange(w.state.copy())
+}
+
+func (s MarketState) copy() MarketState {
+	s.Bids = append(BoardPrices{}, s.Bids...)
+	s.Asks = append(BoardPrices{}, s.Asks...)
+	s.Trades = append([]MarketTrade{}, s.Trades...)
+	s.Orders = append([]OpenOrder{}, s.Orders...)
+	s.Balances = append(Balances{}, s.Balances...)
+	return s
+}
+
+// apply applies a public channel message to s, with w.mu held.
+func (w *marketWatch) apply(s *MarketState, m bitflyer.ChannelMessage, now time.Time) error {
+	s.UpdatedAt = now
+	switch m.Channel {
+	case bitflyer.BoardSnapshotChannel(s.ProductCode), bitflyer.BoardChannel(s.ProductCode):
+		var board bitflyer.BoardResponse
+		if err := json.Unmarshal(m.Message, &board); err != nil {
+			return fmt.Errorf("failed to parse board: %w", err)
+		}
+		if m.Channel == bitflyer.BoardSnapshotChannel(s.ProductCode) {
+			w.bids = map[float64]float64{}
+			w.asks = map[float64]float64{}
+		}
+		// A zero size removes the price.
+		for _, v := range board.Bids {
+			if w.bids[v.Price] = v.Size; v.Size == 0 {
+				delete(w.bids, v.Price)
+			}
+		}
+		for _, v := range board.Asks {
+			if w.asks[v.Price] = v.Size; v.Size == 0 {
+				delete(w.asks, v.Price)
+			}
+		}
+		if board.MidPrice > 0 {
+			s.MidPrice = board.MidPrice
+		}
+		s.Bids = levels(w.bids, true)
+		s.Asks = levels(w.asks, false)
+	case bitflyer.TickerChannel(s.ProductCode):
+		var ticker bitflyer.TickerResponse
+		if err := json.Unmarshal(m.Message, &ticker); err != nil {
+			return fmt.Errorf("failed to parse ticker: %w", err)
+		}
+		s.Ticker = Ticker{BestBid: ticker.BestBid, BestAsk: ticker.BestAsk, Ltp: ticker.Ltp, Volume: ticker.Volume}
+		s.Ticker.Time, _ = bitflyer.ParseTime(ticker.Timestamp)
+	case bitflyer.ExecutionsChannel(s.ProductCode):
+		var executions bitflyer.GetExecutionsResponse
+		if err := json.Unmarshal(m.Message, &executions); err != nil {
+			return fmt.Errorf("failed to parse executions: %w", err)
+		}
+		// Messages are oldest first.
+		for _, v := range executions {
+			t, _ := bitflyer.ParseTime(v.ExecDate)
+			s.Trades = append([]MarketTrade{{Time: t, Side: string(v.Side), Price: v.Price, Size: v.Size}}, s.Trades...)
+		}
+		if len(s.Trades) > maxTrades {
+			s.Trades = s.Trades[:maxTrades]
+		}
+	}
+	return nil
+}
+
+// levels sorts the price levels of a side from the best price.
+func levels(side map[float64]float64, descending bool) BoardPrices {
+	res := make(BoardPrices, 0, len(side))
+	for k, v := range side {
+		res = append(res, BoardPrice{Price: k, Size: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if descending {
+			return res[i].Price > res[j].Price
+		}
+		return res[i].Price < res[j].Price
+	})
+	if len(res) > maxLevels {
+		res = res[:maxLevels]
+	}
+	return res
+}
+
+// watchAccount reloads the open orders and balances on refresh requests, until ctx is cancelled.
+func (u *MarketWatchUseCase) watchAccount(ctx context.Context, query MarketWatchQuery, w *marketWatch) {
+	var tick <-chan time.Time
+	if query.RefreshInterval > 0 {
+		ticker := time.NewTicker(query.RefreshInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		orders, balances, err := u.loadAccount(ctx, query.ProductCode)
+		if ctx.Err() != nil {
+			return
+		}
+		w.update(func(s *MarketState) error {
+			if s.AccountErr = err; err == nil {
+				s.Orders, s.Balances = orders, balances
+			}
+			return s.Err
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.refresh:
+		case <-tick:
+		}
+	}
+}
+
+func (u *MarketWatchUseCase) loadAccount(ctx context.Context, productCode string) ([]OpenOrder, Balances, error) {
+	res, err := u.client.GetChildOrders(ctx, productCode, bitflyer.ChildOrderStateActive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch open orders: %w", err)
+	}
+	orders := make([]OpenOrder, 0, len(res))
+	for _, v := range res {
+		t, _ := bitflyer.ParseTime(v.ChildOrderDate)
+		orders = append(orders, OpenOrder{
+			OrderAcceptanceId: v.ChildOrderAcceptanceId,
+			Side:              string(v.Side),
+			Type:              string(v.ChildOrderType),
+			Price:             v.Price,
+			Size:              v.Size,
+			Outstanding:       v.OutstandingSize,
+			Time:              t,
+		})
+	}
+
+	balances, err := u.client.GetBalance(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+	return orders, toBalances(balances), nil
+}
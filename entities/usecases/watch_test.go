@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+)
+
+// mockedRealtime replays one session of messages per call of Subscribe, then fails.
+// The last session stays open until ctx is cancelled.
+type mockedRealtime struct {
+	mu       sync.Mutex
+	sessions [][]bitflyer.ChannelMessage
+	channels []string
+}
+
+func (m *mockedRealtime) Subscribe(ctx context.Context, channels []string, handler func(bitflyer.ChannelMessage)) error {
+	m.mu.Lock()
+	m.channels = channels
+	session := m.sessions[0]
+	m.sessions = m.sessions[1:]
+	last := len(m.sessions) == 0
+	m.mu.Unlock()
+
+	for _, v := range session {
+		handler(v)
+	}
+	if last {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return errors.New("connection reset")
+}
+
+func message(t *testing.T, channel string, v any) bitflyer.ChannelMessage {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bitflyer.ChannelMessage{Channel: channel, Message: raw}
+}
+
+func TestMarketWatchUseCase_Watch(t *testing.T) {
+	realtime := &mockedRealtime{sessions: [][]bitflyer.ChannelMessage{
+		{
+			message(t, "lightning_board_snapshot_BTC_JPY", bitflyer.BoardResponse{
+				MidPrice: 100,
+				Bids:     bitflyer.PriceResponses{{Price: 98, Size: 1}, {Price: 99, Size: 2}},
+				Asks:     bitflyer.PriceResponses{{Price: 102, Size: 1}, {Price: 101, Size: 3}},
+			}),
+			// A zero size removes the price.
+			message(t, "lightning_board_BTC_JPY", bitflyer.BoardResponse{
+				MidPrice: 100.5,
+				Bids:     bitflyer.PriceResponses{{Price: 99, Size: 0}, {Price: 100, Size: 0.5}},
+			}),
+		},
+		{
+			message(t, "lightning_ticker_BTC_JPY", bitflyer.TickerResponse{BestBid: 100, BestAsk: 101, Ltp: 100, Timestamp: "2023-04-01T00:00:00.5"}),
+			message(t, "lightning_executions_BTC_JPY", bitflyer.GetExecutionsResponse{
+				{Id: 1, Side: bitflyer.SideBuy, Price: 101, Size: 0.1, ExecDate: "2023-04-01T00:00:00.1"},
+				{Id: 2, Side: bitflyer.SideSell, Price: 100, Size: 0.2, ExecDate: "2023-04-01T00:00:00.2"},
+			}),
+			message(t, "child_order_events", []bitflyer.ChildOrderEvent{{EventType: bitflyer.ChildOrderEventOrder}}),
+		},
+	}}
+
+	var loads int32
+	client := mockedBitFlyerClient{
+		getOrders: func(pc string, state bitflyer.ChildOrderState) (bitflyer.GetChildOrdersResponse, error) {
+			atomic.AddInt32(&loads, 1)
+			return bitflyer.GetChildOrdersResponse{{
+				ChildOrderAcceptanceId: "JRF1", Side: bitflyer.SideBuy, ChildOrderType: bitflyer.ChildOrderTypeLimit,
+				Price: 99, Size: 1, OutstandingSize: 1, ChildOrderDate: "2023-04-01T00:00:00",
+			}}, nil
+		},
+		getBalance: func() (bitflyer.GetBalancesResponse, error) {
+			return bitflyer.GetBalancesResponse{{CurrencyCode: "JPY", Amount: 1000, Available: 901}}, nil
+		},
+	}
+
+	u := NewMarketWatchUseCase(client, realtime)
+	u.reconnectDelay = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var states []MarketState
+	err := u.Watch(ctx, MarketWatchQuery{ProductCode: "BTC_JPY", Account: true, OrderEvents: true}, func(s MarketState) {
+		states = append(states, s)
+		// The order event reloads the account a second time.
+		if len(s.Trades) == 2 && atomic.LoadInt32(&loads) >= 2 && s.Err == nil && len(s.Orders) == 1 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("MarketWatchUseCase.Watch() error = %v", err)
+	}
+
+	last := states[len(states)-1]
+	wantBids := BoardPrices{{Price: 100, Size: 0.5}, {Price: 98, Size: 1}}
+	wantAsks := BoardPrices{{Price: 101, Size: 3}, {Price: 102, Size: 1}}
+	if !reflect.DeepEqual(last.Bids, wantBids) || !reflect.DeepEqual(last.Asks, wantAsks) || last.MidPrice != 100.5 {
+		t.Errorf("board = %v %v %v, want %v %v 100.5", last.MidPrice, last.Bids, last.Asks, wantBids, wantAsks)
+	}
+	if last.Trades[0].Price != 100 || last.Trades[1].Price != 101 {
+		t.Errorf("trades = %v, want newest first", last.Trades)
+	}
+	if last.Ticker.Ltp != 100 || last.Ticker.Time.Nanosecond() != 500000000 {
+		t.Errorf("ticker = %v", last.Ticker)
+	}
+	if last.Orders[0].OrderAcceptanceId != "JRF1" || last.Balances[0].Available != 901 {
+		t.Errorf("account = %v %v", last.Orders, last.Balances)
+	}
+	if realtime.channels[len(realtime.channels)-1] != bitflyer.ChildOrderEventsChannel {
+		t.Errorf("subscribed %v, want child order events", realtime.channels)
+	}
+
+	// The lost connection was reported while reconnecting.
+	reported := false
+	for _, v := range states {
+		if v.Err != nil && !v.Connected {
+			reported = true
+		}
+	}
+	if !reported {
+		t.Errorf("MarketWatchUseCase.Watch() did not report the lost connection")
+	}
+}
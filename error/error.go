@@ -8,4 +8,9 @@ var (
 	ErrUnAuthorized          = errors.New("unauthorized")
 	ErrResourceNotFound      = errors.New("resource not found")
 	ErrUnknownResponseFormat = errors.New("unknown resposne format")
+	ErrInsufficientFunds     = errors.New("insufficient funds")
+	ErrMarketHalted          = errors.New("market halted")
+	ErrOrderNotFound         = errors.New("order not found")
+	ErrRateLimited           = errors.New("rate limited")
+	ErrRiskRejected          = errors.New("rejected by risk check")
 )
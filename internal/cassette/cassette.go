@@ -0,0 +1,281 @@
+// Package cassette records HTTP interactions into a file and replays them.
+//
+// A Recorder captures every round trip with secrets redacted. A Replayer serves the
+// recorded responses instead of the network, so reported failures can be reproduced
+// from the CLI with --replay or from Go tests.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/internal/transport"
+)
+
+// FileName is the name of the cassette file in a record or replay directory.
+const FileName = "cassette.json"
+
+// Redacted replaces secrets in recorded headers and bodies.
+const Redacted = "REDACTED"
+
+// SensitiveFields are the JSON body fields whose values are never recorded.
+var SensitiveFields = []string{"APIPassword", "Password", "Token"}
+
+// ErrNotRecorded is returned by a Replayer for a request missing in the cassette.
+var ErrNotRecorded = errors.New("request is not recorded in the cassette")
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  Request       `json:"request"`
+	Response Response      `json:"response"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette file.
+func Load(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read cassette %s: %w", path, err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("can not parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes c to path atomically.
+func (c *Cassette) Save(path string) error {
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Recorder is a RoundTripper saving every interaction of next into a cassette file.
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	next     http.RoundTripper
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder writing a new cassette to path.
+// The file is rewritten after each interaction, so it is complete even if the process exits.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{path: path, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+
+	resBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: transport.Redact(req.Header),
+			Body:   RedactBody(reqBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     transport.Redact(resp.Header),
+			Body:       RedactBody(resBody),
+		},
+		Latency: latency,
+	})
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, fmt.Errorf("can not save cassette %s: %w", r.path, err)
+	}
+	return resp, nil
+}
+
+// Replayer is a RoundTripper serving responses from a cassette.
+//
+// Requests are matched by method, path, query and body, ignoring the host and headers.
+// Identical requests are served the recorded responses in order, and the last one is
+// repeated once they are exhausted.
+type Replayer struct {
+	mu     sync.Mutex
+	served map[string]int
+	byKey  map[string][]Interaction
+}
+
+// NewReplayer returns a Replayer of c.
+func NewReplayer(c *Cassette) *Replayer {
+	r := &Replayer{served: map[string]int{}, byKey: map[string][]Interaction{}}
+	for _, v := range c.Interactions {
+		k, err := key(v.Request.Method, v.Request.URL, v.Request.Body)
+		if err != nil {
+			continue
+		}
+		r.byKey[k] = append(r.byKey[k], v)
+	}
+	return r
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	k, err := key(req.Method, req.URL.String(), RedactBody(body))
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	interactions := r.byKey[k]
+	if len(interactions) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.RequestURI())
+	}
+	i := r.served[k]
+	if i >= len(interactions) {
+		i = len(interactions) - 1
+	}
+	r.served[k]++
+	r.mu.Unlock()
+
+	recorded := interactions[i].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// key identifies a request regardless of its host.
+func key(method string, rawURL string, body string) (string, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	return method + " " + req.URL.RequestURI() + "\n" + body, nil
+}
+
+// readBody reads *body and replaces it with a reader of the same content.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+// RedactBody replaces the values of SensitiveFields in a JSON body.
+// Bodies without them, or which are not JSON, are returned as they are.
+func RedactBody(body []byte) string {
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil || !redact(v) {
+		return string(body)
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(raw)
+}
+
+// redact replaces SensitiveFields in v recursively and reports whether any was found.
+func redact(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if isSensitive(k) {
+				v[k] = Redacted
+				found = true
+				continue
+			}
+			found = redact(child) || found
+		}
+	case []any:
+		for _, child := range v {
+			found = redact(child) || found
+		}
+	}
+	return found
+}
+
+func isSensitive(field string) bool {
+	for _, v := range SensitiveFields {
+		if v == field {
+			return true
+		}
+	}
+	return false
+}
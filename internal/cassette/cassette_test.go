@@ -0,0 +1,127 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sn1w/capital-go/config"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer"
+	"github.com/sn1w/capital-go/entities/infrastructures/bitflyer/bitflyertest"
+	"github.com/sn1w/capital-go/entities/infrastructures/kabucom/kabucomtest"
+	"github.com/sn1w/capital-go/internal/cassette"
+	"github.com/sn1w/capital-go/internal/transport"
+)
+
+func TestRecorderAndReplayer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cassette.FileName)
+	ctx := context.Background()
+
+	s, ts := bitflyertest.NewTestServer(bitflyertest.DefaultConfig())
+	recorder := cassette.NewRecorder(path, http.DefaultTransport)
+	live := s.NewClient(ts.URL, bitflyer.WithHTTPClient(&http.Client{Transport: recorder}))
+
+	order := bitflyer.SendOrderRequest{ProductCode: "BTC_JPY", ChildOrderType: "MARKET", Side: "BUY", Size: 0.01}
+	wantBoard, err := live.GetBoard(ctx, "BTC_JPY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOrder, err := live.SendOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBalance, err := live.GetBalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "fake-key") {
+		t.Errorf("cassette contains the API key: %s", raw)
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 3 {
+		t.Fatalf("len(Interactions) = %v, want 3", len(c.Interactions))
+	}
+
+	// The replaying client uses another endpoint and no credentials.
+	replay := bitflyer.NewBitFlyer(config.Config{BitFlyerApiEndpoint: "http://replay.invalid"},
+		bitflyer.WithHTTPClient(&http.Client{Transport: cassette.NewReplayer(c)}))
+
+	for i := 0; i < 2; i++ {
+		board, err := replay.GetBoard(ctx, "BTC_JPY")
+		if err != nil || !reflect.DeepEqual(board, wantBoard) {
+			t.Errorf("GetBoard() = %v, %v, want %v", board, err, wantBoard)
+		}
+	}
+	if got, err := replay.SendOrder(ctx, order); err != nil || !reflect.DeepEqual(got, wantOrder) {
+		t.Errorf("SendOrder() = %v, %v, want %v", got, err, wantOrder)
+	}
+	if got, err := replay.GetBalance(ctx); err != nil || !reflect.DeepEqual(got, wantBalance) {
+		t.Errorf("GetBalance() = %v, %v, want %v", got, err, wantBalance)
+	}
+
+	order.Size = 0.02
+	if _, err := replay.SendOrder(ctx, order); !errors.Is(err, cassette.ErrNotRecorded) {
+		t.Errorf("SendOrder(not recorded) error = %v, expectedError %v", err, cassette.ErrNotRecorded)
+	}
+}
+
+func TestRecorder_redactsKabucomSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cassette.FileName)
+	cfg := kabucomtest.DefaultConfig()
+	s, ts := kabucomtest.NewTestServer(cfg)
+	defer ts.Close()
+
+	defer func(original http.RoundTripper) { transport.DefaultTransport = original }(transport.DefaultTransport)
+	transport.DefaultTransport = cassette.NewRecorder(path, http.DefaultTransport)
+	client := s.NewClient(ts.URL)
+
+	if _, err := client.GetToken(context.Background(), cfg.APIPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{cfg.APIPassword, s.Token()} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette contains %q: %s", secret, raw)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "Sensitive Fields", body: `{"APIPassword":"p","Qty":100}`, want: `{"APIPassword":"REDACTED","Qty":100}`},
+		{name: "Nested", body: `[{"Order":{"Password":"p"}}]`, want: `[{"Order":{"Password":"REDACTED"}}]`},
+		{name: "Untouched", body: `{"price": 5000000.123456789}`, want: `{"price": 5000000.123456789}`},
+		{name: "Not JSON", body: `Password=p`, want: `Password=p`},
+		{name: "Empty", body: ``, want: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cassette.RedactBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("RedactBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
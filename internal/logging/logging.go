@@ -0,0 +1,186 @@
+// Package logging provides a small leveled, structured logger in the style of log/slog.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sn1w/capital-go/internal/print"
+)
+
+// Level is the importance of a log record. The values follow log/slog.
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return strconv.Itoa(int(l))
+}
+
+// Format is the output format of a Logger.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown log format %q (expected %q or %q)", s, FormatText, FormatJSON)
+}
+
+// Logger writes records at or above its level as key=value text or JSON lines.
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	level  Level
+	format Format
+	now    func() time.Time
+}
+
+// New returns a Logger writing to w.
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{w: w, level: level, format: format, now: time.Now}
+}
+
+var defaultLogger = New(print.Stderr, LevelWarn, FormatText)
+
+// Default returns the logger used by the application.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// SetDefault replaces the logger returned by Default.
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// Enabled reports whether records at level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level >= l.level
+}
+
+// Debug logs msg at LevelDebug with key-value pairs args.
+func (l *Logger) Debug(msg string, args ...any) { l.Log(LevelDebug, msg, args...) }
+
+// Info logs msg at LevelInfo with key-value pairs args.
+func (l *Logger) Info(msg string, args ...any) { l.Log(LevelInfo, msg, args...) }
+
+// Warn logs msg at LevelWarn with key-value pairs args.
+func (l *Logger) Warn(msg string, args ...any) { l.Log(LevelWarn, msg, args...) }
+
+// Error logs msg at LevelError with key-value pairs args.
+func (l *Logger) Error(msg string, args ...any) { l.Log(LevelError, msg, args...) }
+
+// Log writes a record. args are alternating keys and values; a key without value is logged as "!BADKEY".
+func (l *Logger) Log(level Level, msg string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	attrs := make([][2]any, 0, len(args)/2+3)
+	attrs = append(attrs,
+		[2]any{"time", l.now().Format(time.RFC3339Nano)},
+		[2]any{"level", level.String()},
+		[2]any{"msg", msg},
+	)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			attrs = append(attrs, [2]any{"!BADKEY", args[i]})
+			break
+		}
+		attrs = append(attrs, [2]any{fmt.Sprint(args[i]), args[i+1]})
+	}
+
+	var line string
+	if l.format == FormatJSON {
+		line = formatJSON(attrs)
+	} else {
+		line = formatText(attrs)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.w, line)
+}
+
+func formatText(attrs [][2]any) string {
+	parts := make([]string, 0, len(attrs))
+	for _, v := range attrs {
+		parts = append(parts, fmt.Sprintf("%s=%s", v[0], quote(textValue(v[1]))))
+	}
+	return strings.Join(parts, " ")
+}
+
+func textValue(v any) string {
+	switch t := v.(type) {
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func formatJSON(attrs [][2]any) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range attrs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(v[0])
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(jsonValue(v[1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func jsonValue(v any) []byte {
+	switch t := v.(type) {
+	case error:
+		v = t.Error()
+	case time.Duration:
+		v = t.String()
+	}
+
+	res, err := json.Marshal(v)
+	if err != nil {
+		res, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return res
+}
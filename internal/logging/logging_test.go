@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogger_Log(t *testing.T) {
+	type args struct {
+		level Level
+		msg   string
+		args  []any
+	}
+	tests := []struct {
+		name   string
+		level  Level
+		format Format
+		args   args
+		want   string
+	}{
+		{
+			name:   "text",
+			level:  LevelDebug,
+			format: FormatText,
+			args:   args{LevelInfo, "http request", []any{"method", "GET", "latency", 1500 * time.Millisecond}},
+			want:   `time=2026-01-02T03:04:05Z level=INFO msg="http request" method=GET latency=1.5s` + "\n",
+		},
+		{
+			name:   "json",
+			level:  LevelDebug,
+			format: FormatJSON,
+			args:   args{LevelWarn, "failed", []any{"status", 500, "error", errors.New("boom")}},
+			want:   `{"time":"2026-01-02T03:04:05Z","level":"WARN","msg":"failed","status":500,"error":"boom"}` + "\n",
+		},
+		{
+			name:   "below level",
+			level:  LevelWarn,
+			format: FormatText,
+			args:   args{LevelDebug, "hidden", nil},
+			want:   "",
+		},
+		{
+			name:   "odd arguments",
+			level:  LevelDebug,
+			format: FormatText,
+			args:   args{LevelError, "odd", []any{"key"}},
+			want:   `time=2026-01-02T03:04:05Z level=ERROR msg=odd !BADKEY=key` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			l := New(&buf, tt.level, tt.format)
+			l.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			l.Log(tt.args.level, tt.args.msg, tt.args.args...)
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("value is mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if got, err := ParseFormat("JSON"); err != nil || got != FormatJSON {
+		t.Errorf("ParseFormat(JSON) = %v, %v", got, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("ParseFormat(xml) should fail")
+	}
+}
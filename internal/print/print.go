@@ -59,7 +59,7 @@ func Question(ask string) bool {
 		if strings.Contains(err.Error(), "expected newline") {
 			return Question(ask)
 		}
-		fmt.Fprint(os.Stderr, err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		return false
 	}
 
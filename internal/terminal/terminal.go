@@ -0,0 +1,7 @@
+// Package terminal switches a terminal to raw mode and reports its size, for full-screen commands.
+package terminal
+
+import "errors"
+
+// ErrUnsupported is returned on platforms without raw terminal support.
+var ErrUnsupported = errors.New("full-screen terminal is not supported on this platform")
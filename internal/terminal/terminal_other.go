@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package terminal
+
+import "os"
+
+// IsTerminal reports whether fd is a terminal.
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw returns ErrUnsupported on this platform.
+func MakeRaw(fd int) (func() error, error) {
+	return nil, ErrUnsupported
+}
+
+// Size returns ErrUnsupported on this platform.
+func Size(fd int) (int, int, error) {
+	return 0, 0, ErrUnsupported
+}
+
+// NotifyResize does nothing on this platform.
+func NotifyResize(ch chan<- os.Signal) {}
@@ -0,0 +1,114 @@
+// Package transport provides the http.RoundTripper middleware chain shared by API clients.
+package transport
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sn1w/capital-go/internal/logging"
+)
+
+// Middleware decorates a RoundTripper.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use a function as a RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// DefaultUserAgent is the User-Agent sent by clients built with New.
+var DefaultUserAgent = "capital-go"
+
+// DefaultTransport is the RoundTripper below the middlewares of clients built with NewClient.
+// It is replaced to record or replay traffic.
+var DefaultTransport http.RoundTripper = http.DefaultTransport
+
+// SensitiveHeaders are the headers whose values are never logged.
+var SensitiveHeaders = []string{"ACCESS-KEY", "ACCESS-SIGN", "X-API-KEY", "Authorization"}
+
+// Chain wraps base with middlewares. The first middleware is the outermost one.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// New returns base wrapped with the default middlewares: user-agent and logging.
+func New(base http.RoundTripper) http.RoundTripper {
+	return Chain(base, UserAgent(DefaultUserAgent), Logging(logging.Default()))
+}
+
+// NewClient returns an http.Client using the default middlewares over DefaultTransport.
+func NewClient() *http.Client {
+	return &http.Client{Transport: New(DefaultTransport)}
+}
+
+// UserAgent sets the User-Agent header unless the request already has one.
+func UserAgent(ua string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("User-Agent") != "" {
+				return next.RoundTrip(req)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", ua)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Logging logs each request and its response status and latency at debug level,
+// and failed round trips at warn level. Sensitive headers are redacted.
+func Logging(logger *logging.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if logger.Enabled(logging.LevelDebug) {
+				logger.Debug("http request",
+					"method", req.Method,
+					"url", req.URL.String(),
+					"header", Redact(req.Header),
+				)
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			latency := time.Since(start)
+
+			if err != nil {
+				logger.Warn("http request failed",
+					"method", req.Method,
+					"url", req.URL.String(),
+					"latency", latency,
+					"error", err,
+				)
+				return resp, err
+			}
+
+			logger.Debug("http response",
+				"method", req.Method,
+				"url", req.URL.String(),
+				"status", resp.StatusCode,
+				"latency", latency,
+				"header", Redact(resp.Header),
+			)
+			return resp, nil
+		})
+	}
+}
+
+// Redact returns a copy of h with the values of SensitiveHeaders replaced.
+func Redact(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, v := range SensitiveHeaders {
+		if redacted.Get(v) != "" {
+			redacted.Set(v, "REDACTED")
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sn1w/capital-go/internal/logging"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	middleware := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{StatusCode: 200, Header: http.Header{}}, nil
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	if _, err := Chain(base, middleware("first"), middleware("second")).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(order, ","); got != "first,second,base" {
+		t.Errorf("Chain() order = %s, want first,second,base", got)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Get("User-Agent")
+		return &http.Response{StatusCode: 200, Header: http.Header{}}, nil
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost", nil)
+	if _, err := UserAgent("capital-go/test")(base).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if got != "capital-go/test" {
+		t.Errorf("User-Agent = %s, want capital-go/test", got)
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Errorf("UserAgent() must not modify the original request")
+	}
+}
+
+func TestLogging(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := logging.New(&buf, logging.LevelDebug, logging.FormatText)
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Header: http.Header{"X-Api-Key": []string{"token"}}}, nil
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost/v1/me/getbalance", nil)
+	req.Header.Add("ACCESS-KEY", "my-key")
+	req.Header.Add("ACCESS-SIGN", "my-sign")
+	req.Header.Add("ACCESS-TIMESTAMP", "1000")
+
+	if _, err := Logging(logger)(base).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"my-key", "my-sign", "token"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains secret %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{`msg="http request"`, `msg="http response"`, "status=200", "latency=", "1000"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log does not contain %q:\n%s", want, out)
+		}
+	}
+}
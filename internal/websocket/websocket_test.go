@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer echoes every message, then closes after "bye".
+func echoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg) == "bye" {
+				return
+			}
+			if err := conn.WriteMessage(msg); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestConn_Echo(t *testing.T) {
+	server := echoServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/json-rpc", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	messages := [][]byte{
+		[]byte(`{"method":"subscribe"}`),
+		// Lengths with 16 and 64 bit extended headers.
+		bytes.Repeat([]byte("a"), 200),
+		bytes.Repeat([]byte("b"), 70000),
+	}
+	for _, want := range messages {
+		if err := conn.WriteMessage(want); err != nil {
+			t.Fatalf("Conn.WriteMessage() error = %v", err)
+		}
+		if err := conn.Ping(); err != nil {
+			t.Fatalf("Conn.Ping() error = %v", err)
+		}
+		got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Conn.ReadMessage() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Conn.ReadMessage() = %d bytes, want %d bytes", len(got), len(want))
+		}
+	}
+
+	if err := conn.WriteMessage([]byte("bye")); err != nil {
+		t.Fatalf("Conn.WriteMessage() error = %v", err)
+	}
+	if _, err := conn.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Conn.ReadMessage() error = %v, expectedError %v", err, ErrClosed)
+	}
+}
+
+func TestConn_Fragmented(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// "hello" in two fragments with a ping in between.
+		conn.conn.Write([]byte{opText, 3, 'h', 'e', 'l'})
+		conn.conn.Write([]byte{0x80 | opPing, 0})
+		conn.conn.Write([]byte{0x80 | opContinuation, 2, 'l', 'o'})
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	got, err := conn.ReadMessage()
+	if err != nil || string(got) != "hello" {
+		t.Errorf("Conn.ReadMessage() = %q, %v, want hello", got, err)
+	}
+}
+
+func TestDial_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil); err == nil {
+		t.Errorf("Dial() to a server without websocket succeeded")
+	}
+	if _, err := Dial(context.Background(), server.URL, nil); err == nil {
+		t.Errorf("Dial() of an http url succeeded")
+	}
+}